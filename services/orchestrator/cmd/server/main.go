@@ -12,13 +12,25 @@ import (
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/clients"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/config"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/database"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/dedup"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/frequency"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/handlers"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/i18n"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/maintenance"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/metrics"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/middleware"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/ratelimit"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/repository"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/scheduler"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/services"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/throttle"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/webhook"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/kafka"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/retry"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
@@ -37,25 +49,45 @@ func main() {
 		zap.Bool("mock_services", cfg.Services.UseMockServices),
 	)
 
-	// Initialize Kafka Manager
+	// Initialize Kafka Manager. In-memory mode delivers to subscribers in
+	// this process instead of a broker.
+	var bus *kafka.InMemoryBus
+	if cfg.Kafka.InMemory {
+		bus = kafka.NewInMemoryBus(logger.Log)
+	}
 	kafkaManager, err := kafka.NewManager(kafka.ManagerConfig{
-		Brokers:    cfg.Kafka.Brokers,
-		EmailTopic: cfg.Kafka.EmailTopic,
-		PushTopic:  cfg.Kafka.PushTopic,
-		Logger:     logger.Log,
-		Username:   cfg.Kafka.Username,
-		Password:   cfg.Kafka.Password,
-		UseTLS:     cfg.Kafka.UseTLS,
+		Brokers:     cfg.Kafka.Brokers,
+		EmailTopic:  cfg.Kafka.EmailTopic,
+		PushTopic:   cfg.Kafka.PushTopic,
+		Logger:      logger.Log,
+		Username:    cfg.Kafka.Username,
+		Password:    cfg.Kafka.Password,
+		UseTLS:      cfg.Kafka.UseTLS,
+		Compression: cfg.Kafka.Compression,
+
+		SASLMechanism: cfg.Kafka.SASLMechanism,
+		Balancer:      kafka.Balancer(cfg.Kafka.Balancer),
+		DryRun:        cfg.Kafka.DryRun,
+		Bus:           bus,
+		LogValues:     cfg.Kafka.LogValues,
+
+		BatchSize:    cfg.Kafka.BatchSize,
+		BatchBytes:   int64(cfg.Kafka.BatchBytes),
+		BatchTimeout: cfg.Kafka.BatchTimeout,
+
+		EmailPriorityTopic: cfg.Kafka.EmailPriorityTopic,
+		PushPriorityTopic:  cfg.Kafka.PushPriorityTopic,
 	})
 	if err != nil {
 		logger.Log.Fatal("Failed to initialize Kafka manager", zap.Error(err))
 	}
-	defer kafkaManager.Close()
 
 	logger.Log.Info("Kafka manager initialized",
 		zap.Strings("brokers", cfg.Kafka.Brokers),
 		zap.String("email_topic", cfg.Kafka.EmailTopic),
 		zap.String("push_topic", cfg.Kafka.PushTopic),
+		zap.Bool("dry_run", cfg.Kafka.DryRun),
+		zap.Bool("in_memory", cfg.Kafka.InMemory),
 	)
 
 	// Initialize PostgreSQL database
@@ -102,6 +134,118 @@ func main() {
 		kafkaManager,
 		notificationRepo,
 	)
+	orchestrationService.SetIdempotencyStore(idempotencyService)
+
+	orchestratorMetrics := metrics.NewPrometheus()
+	if err := orchestratorMetrics.Register(prometheus.DefaultRegisterer); err != nil {
+		logger.Log.Warn("Failed to register orchestrator metrics", zap.Error(err))
+	}
+	orchestrationService.SetMetrics(orchestratorMetrics)
+
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.NewLimiter(ratelimit.Config{
+			Rate:       cfg.RateLimit.Rate,
+			Burst:      cfg.RateLimit.Burst,
+			PerChannel: cfg.RateLimit.PerChannel,
+		})
+		if err := limiter.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+			logger.Log.Warn("Failed to register rate limiter metrics", zap.Error(err))
+		}
+		orchestrationService.SetRateLimiter(limiter)
+
+		logger.Log.Info("Per-user rate limiting enabled",
+			zap.Float64("rate", cfg.RateLimit.Rate),
+			zap.Int("burst", cfg.RateLimit.Burst),
+		)
+	}
+
+	if cfg.Throttle.EmailRate > 0 || cfg.Throttle.PushRate > 0 {
+		orchestrationService.SetThrottle(throttle.New(throttle.Config{
+			Rates: map[string]float64{
+				string(models.NotificationEmail): cfg.Throttle.EmailRate,
+				string(models.NotificationPush):  cfg.Throttle.PushRate,
+			},
+			MaxWait: cfg.Throttle.MaxWait,
+		}))
+
+		logger.Log.Info("Channel throttling enabled",
+			zap.Float64("email_rate", cfg.Throttle.EmailRate),
+			zap.Float64("push_rate", cfg.Throttle.PushRate),
+		)
+	}
+
+	if cfg.Dedup.Enabled {
+		orchestrationService.SetDeduplicator(dedup.NewMemoryStore(nil), cfg.Dedup.Window)
+		logger.Log.Info("Notification deduplication enabled", zap.Duration("window", cfg.Dedup.Window))
+	}
+	if cfg.Frequency.Enabled {
+		orchestrationService.SetFrequencyLimiter(frequency.New(nil, nil))
+		logger.Log.Info("Channel frequency limits enabled")
+	}
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if cfg.Scheduler.Enabled {
+		sched := scheduler.New(scheduler.Config{
+			Publisher:    kafkaManager,
+			PollInterval: cfg.Scheduler.PollInterval,
+			OnRelease:    orchestrationService.RecordRelease,
+		})
+		go sched.Run(schedulerCtx)
+		orchestrationService.SetScheduler(sched)
+
+		logger.Log.Info("Notification scheduler started", zap.Duration("poll_interval", cfg.Scheduler.PollInterval))
+	}
+
+	if cfg.I18n.CatalogDir != "" {
+		bundle := i18n.NewBundle()
+		if err := bundle.LoadDir(cfg.I18n.CatalogDir); err != nil {
+			logger.Log.Fatal("Failed to load message catalogs", zap.Error(err))
+		}
+		orchestrationService.SetTranslator(bundle)
+
+		logger.Log.Info("Message catalogs loaded", zap.String("dir", cfg.I18n.CatalogDir))
+	}
+
+	if cfg.Delivery.TrackingEnabled {
+		orchestrationService.SetStatusStore(repository.NewMemoryStatusStore(nil))
+		logger.Log.Info("Delivery status tracking enabled")
+	}
+
+	if cfg.Delivery.RetryAttempts > 1 || cfg.Delivery.Fallback {
+		policy := retry.DefaultPolicy()
+		policy.MaxAttempts = cfg.Delivery.RetryAttempts
+		policy.InitialDelay = cfg.Delivery.RetryBackoff
+		orchestrationService.SetDeliveryRetry(policy, cfg.Delivery.Fallback)
+
+		logger.Log.Info("Delivery retries enabled",
+			zap.Int("max_attempts", policy.MaxAttempts),
+			zap.Bool("fallback", cfg.Delivery.Fallback),
+		)
+	}
+
+	if cfg.Webhook.Enabled {
+		orchestrationService.SetWebhookSender(webhook.NewSender(&http.Client{Timeout: cfg.Webhook.Timeout}, nil))
+		logger.Log.Info("Webhook delivery enabled", zap.Duration("timeout", cfg.Webhook.Timeout))
+	}
+
+	pruneCtx, stopPruner := context.WithCancel(context.Background())
+	defer stopPruner()
+	if cfg.Devices.PruneEnabled {
+		pruner := maintenance.NewDevicePruner(maintenance.DevicePrunerConfig{
+			Users:    userClient,
+			Lister:   userClient,
+			MaxAge:   cfg.Devices.MaxAge,
+			Interval: cfg.Devices.PruneInterval,
+			Metrics:  orchestratorMetrics,
+		})
+		go pruner.Run(pruneCtx)
+
+		logger.Log.Info("Stale device pruning enabled",
+			zap.Duration("interval", cfg.Devices.PruneInterval),
+			zap.Duration("max_age", cfg.Devices.MaxAge),
+		)
+	}
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(db)
@@ -171,8 +315,18 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Keep going when the server cannot shut down in time, so the producers
+	// below are still flushed and closed
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Log.Fatal("Server forced to shutdown", zap.Error(err))
+		logger.Log.Error("Server forced to shutdown", zap.Error(err))
+	}
+
+	// Drain notifications still being processed, then flush and close the
+	// producers
+	stopScheduler()
+	stopPruner()
+	if err := orchestrationService.Shutdown(ctx); err != nil {
+		logger.Log.Error("Orchestrator did not shut down cleanly", zap.Error(err))
 	}
 
 	logger.Log.Info("Server exited")
@@ -0,0 +1,219 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	circuitbreaker "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/circuit-breaker"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// PreferencesCache supplies last known preferences while the user service is
+// unavailable. CachingUserService implements it.
+type PreferencesCache interface {
+	Cached(userID string) (*models.UserPreferences, bool)
+}
+
+// BreakerUserService stops calling another UserService after repeated 5xx or
+// network failures. While open it fails fast with
+// models.ErrUserServiceUnavailable, or serves preferences from Fallback when
+// it has them. Not-found and other client errors do not count as failures.
+type BreakerUserService struct {
+	upstream UserService
+	breaker  *circuitbreaker.CircuitBreaker
+	fallback PreferencesCache
+}
+
+type BreakerUserServiceConfig struct {
+	FailureThreshold uint32
+	Cooldown         time.Duration
+	// HalfOpenMax is how many trial calls are let through after the
+	// cooldown, defaulting to FailureThreshold so the breaker can close
+	HalfOpenMax uint32
+	Fallback    PreferencesCache
+	Now         func() time.Time
+}
+
+func NewBreakerUserService(upstream UserService, cfg BreakerUserServiceConfig) *BreakerUserService {
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.HalfOpenMax == 0 {
+		cfg.HalfOpenMax = cfg.FailureThreshold
+	}
+
+	return &BreakerUserService{
+		upstream: upstream,
+		breaker: circuitbreaker.New(circuitbreaker.Config{
+			Name:        "user-service",
+			MaxFailures: cfg.FailureThreshold,
+			Timeout:     cfg.Cooldown,
+			HalfOpenMax: cfg.HalfOpenMax,
+			Now:         cfg.Now,
+		}),
+		fallback: cfg.Fallback,
+	}
+}
+
+// State returns the breaker state for metrics
+func (b *BreakerUserService) State() circuitbreaker.State {
+	return b.breaker.State()
+}
+
+func (b *BreakerUserService) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	var prefs *models.UserPreferences
+	err := b.execute(func() error {
+		var err error
+		prefs, err = b.upstream.GetPreferences(ctx, userID)
+		return err
+	})
+	if errors.Is(err, models.ErrUserServiceUnavailable) && b.fallback != nil {
+		if cached, ok := b.fallback.Cached(userID); ok {
+			logger.Log.Warn("Serving cached preferences while user service is unavailable",
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+			return cached, nil
+		}
+	}
+	return prefs, err
+}
+
+func (b *BreakerUserService) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	var status *models.OptOutStatus
+	err := b.execute(func() error {
+		var err error
+		status, err = b.upstream.GetOptOutStatus(ctx, userID)
+		return err
+	})
+	return status, err
+}
+
+func (b *BreakerUserService) UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error {
+	return b.execute(func() error {
+		return b.upstream.UpdatePreferences(ctx, prefs)
+	})
+}
+
+func (b *BreakerUserService) RegisterDevice(ctx context.Context, userID string, d models.Device) error {
+	return b.execute(func() error {
+		return b.upstream.RegisterDevice(ctx, userID, d)
+	})
+}
+
+func (b *BreakerUserService) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	return b.execute(func() error {
+		return b.upstream.RemoveDevice(ctx, userID, deviceID)
+	})
+}
+
+func (b *BreakerUserService) ExportUserData(ctx context.Context, userID string) ([]byte, error) {
+	var data []byte
+	err := b.execute(func() error {
+		var err error
+		data, err = b.upstream.ExportUserData(ctx, userID)
+		return err
+	})
+	return data, err
+}
+
+func (b *BreakerUserService) DeleteUserData(ctx context.Context, userID string) error {
+	return b.execute(func() error {
+		return b.upstream.DeleteUserData(ctx, userID)
+	})
+}
+
+func (b *BreakerUserService) ListUserIDs(ctx context.Context) ([]string, error) {
+	var userIDs []string
+	err := b.execute(func() error {
+		var err error
+		userIDs, err = b.upstream.ListUserIDs(ctx)
+		return err
+	})
+	return userIDs, err
+}
+
+// GetPreferencesBulk counts as a single call. It is a failure when no user
+// could be fetched and every error was a 5xx or network failure.
+func (b *BreakerUserService) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	var (
+		results map[string]*models.UserPreferences
+		callErr error
+	)
+	err := b.breaker.Execute(func() error {
+		results, callErr = b.upstream.GetPreferencesBulk(ctx, userIDs)
+		if len(results) == 0 && isBulkOutage(callErr) {
+			return callErr
+		}
+		return nil
+	})
+	if err == nil || !isBreakerRejection(err) {
+		return results, callErr
+	}
+
+	results = make(map[string]*models.UserPreferences)
+	errs := make(map[string]error)
+	for _, userID := range uniqueUserIDs(userIDs) {
+		if b.fallback != nil {
+			if cached, ok := b.fallback.Cached(userID); ok {
+				results[userID] = cached
+				continue
+			}
+		}
+		errs[userID] = unavailable(err)
+	}
+	if len(errs) > 0 {
+		return results, &models.BulkError{Errors: errs}
+	}
+	return results, nil
+}
+
+// execute runs call through the breaker, counting only 5xx and network
+// failures against it
+func (b *BreakerUserService) execute(call func() error) error {
+	var callErr error
+	err := b.breaker.Execute(func() error {
+		callErr = call()
+		if isRetryableUserServiceError(callErr) {
+			return callErr
+		}
+		return nil
+	})
+	if isBreakerRejection(err) {
+		return unavailable(err)
+	}
+	return callErr
+}
+
+func isBreakerRejection(err error) bool {
+	return errors.Is(err, circuitbreaker.ErrCircuitOpen) || errors.Is(err, circuitbreaker.ErrTooManyRequests)
+}
+
+func unavailable(err error) error {
+	return fmt.Errorf("%w: %v", models.ErrUserServiceUnavailable, err)
+}
+
+// isBulkOutage reports whether a bulk call failed only with 5xx or network
+// errors
+func isBulkOutage(err error) bool {
+	if err == nil {
+		return false
+	}
+	var bulkErr *models.BulkError
+	if !errors.As(err, &bulkErr) {
+		return isRetryableUserServiceError(err)
+	}
+	for _, userErr := range bulkErr.Errors {
+		if !isRetryableUserServiceError(userErr) {
+			return false
+		}
+	}
+	return len(bulkErr.Errors) > 0
+}
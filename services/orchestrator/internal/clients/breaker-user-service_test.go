@@ -0,0 +1,138 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	circuitbreaker "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/circuit-breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errServerDown = &models.UserServiceError{StatusCode: http.StatusServiceUnavailable}
+
+// newTestBreaker returns a breaker with a threshold of 2 whose clock is
+// advanced by moving *now
+func newTestBreaker(upstream UserService, fallback PreferencesCache) (*BreakerUserService, *time.Time) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	breaker := NewBreakerUserService(upstream, BreakerUserServiceConfig{
+		FailureThreshold: 2,
+		Cooldown:         30 * time.Second,
+		Fallback:         fallback,
+		Now:              func() time.Time { return now },
+	})
+	return breaker, &now
+}
+
+func TestBreakerUserService_Transitions(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	breaker, now := newTestBreaker(upstream, nil)
+	assert.Equal(t, circuitbreaker.StateClosed, breaker.State())
+
+	// closed -> open after two consecutive failures
+	upstream.err = errServerDown
+	for i := 0; i < 2; i++ {
+		_, err := breaker.GetPreferences(ctx, "user-1")
+		assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, breaker.State())
+
+	// open fails fast without calling upstream
+	_, err := breaker.GetPreferences(ctx, "user-1")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Equal(t, 2, upstream.calls("user-1"))
+
+	// open -> half-open once the cooldown passes
+	upstream.err = nil
+	*now = now.Add(31 * time.Second)
+	_, err = breaker.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, circuitbreaker.StateHalfOpen, breaker.State())
+
+	// half-open -> closed after enough successful trial calls
+	_, err = breaker.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, circuitbreaker.StateClosed, breaker.State())
+	assert.Equal(t, 4, upstream.calls("user-1"))
+}
+
+func TestBreakerUserService_HalfOpenFailureReopens(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	upstream.err = errServerDown
+	breaker, now := newTestBreaker(upstream, nil)
+
+	for i := 0; i < 2; i++ {
+		breaker.GetPreferences(ctx, "user-1")
+	}
+	*now = now.Add(31 * time.Second)
+	_, err := breaker.GetPreferences(ctx, "user-1")
+
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Equal(t, circuitbreaker.StateOpen, breaker.State())
+	assert.Equal(t, 3, upstream.calls("user-1"))
+}
+
+func TestBreakerUserService_ClientErrorsDoNotTrip(t *testing.T) {
+	upstream := newCountingUserService()
+	upstream.err = &models.UserServiceError{StatusCode: http.StatusNotFound}
+	breaker, _ := newTestBreaker(upstream, nil)
+
+	for i := 0; i < 5; i++ {
+		_, err := breaker.GetPreferences(context.Background(), "user-1")
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	}
+
+	assert.Equal(t, circuitbreaker.StateClosed, breaker.State())
+	assert.Equal(t, 5, upstream.calls("user-1"))
+}
+
+func TestBreakerUserService_OpenFallsBackToCache(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Minute})
+	_, err := cache.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+
+	upstream.err = errServerDown
+	breaker, _ := newTestBreaker(upstream, cache)
+	for i := 0; i < 2; i++ {
+		breaker.GetPreferences(ctx, "user-2")
+	}
+	require.Equal(t, circuitbreaker.StateOpen, breaker.State())
+
+	prefs, err := breaker.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+	assert.True(t, prefs.Email)
+
+	_, err = breaker.GetPreferences(ctx, "user-3")
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+
+	results, err := breaker.GetPreferencesBulk(ctx, []string{"user-1", "user-3"})
+	require.Error(t, err)
+	assert.Contains(t, results, "user-1")
+	var bulkErr *models.BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.ErrorIs(t, bulkErr.Errors["user-3"], models.ErrUserServiceUnavailable)
+}
+
+func TestBreakerUserService_OptOutFailsFastWhenOpen(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	upstream.err = errServerDown
+	breaker, _ := newTestBreaker(upstream, nil)
+
+	for i := 0; i < 2; i++ {
+		breaker.GetOptOutStatus(ctx, "user-1")
+	}
+	_, err := breaker.GetOptOutStatus(ctx, "user-1")
+
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Equal(t, 2, upstream.optOutCalls)
+}
@@ -0,0 +1,212 @@
+package clients
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// CachingUserService caches preferences from another UserService in a
+// TTL-bounded LRU. Opt-out status is always fetched from upstream so
+// unsubscribes take effect at once. Cached preferences are shared between
+// callers and must not be modified.
+type CachingUserService struct {
+	upstream UserService
+
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type CachingUserServiceConfig struct {
+	TTL        time.Duration
+	MaxEntries int
+}
+
+type cachedPreferences struct {
+	userID    string
+	prefs     *models.UserPreferences
+	fetchedAt time.Time
+}
+
+func NewCachingUserService(upstream UserService, cfg CachingUserServiceConfig) *CachingUserService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+
+	return &CachingUserService{
+		upstream:   upstream,
+		ttl:        cfg.TTL,
+		maxEntries: cfg.MaxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// GetPreferences returns the cached preferences for userID if they were
+// fetched within the TTL, and otherwise fetches and caches them. Errors are
+// not cached.
+func (c *CachingUserService) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	if prefs, ok := c.lookup(userID); ok {
+		return prefs, nil
+	}
+
+	prefs, err := c.upstream.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.store(userID, prefs)
+	return prefs, nil
+}
+
+// GetPreferencesBulk serves cached users from the cache and fetches the rest
+// from upstream in one bulk call
+func (c *CachingUserService) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	results := make(map[string]*models.UserPreferences)
+	var missing []string
+	for _, userID := range uniqueUserIDs(userIDs) {
+		if prefs, ok := c.lookup(userID); ok {
+			results[userID] = prefs
+			continue
+		}
+		missing = append(missing, userID)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	fetched, err := c.upstream.GetPreferencesBulk(ctx, missing)
+	for userID, prefs := range fetched {
+		c.store(userID, prefs)
+		results[userID] = prefs
+	}
+	return results, err
+}
+
+// GetOptOutStatus passes straight through to the upstream service
+func (c *CachingUserService) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	return c.upstream.GetOptOutStatus(ctx, userID)
+}
+
+// ListUserIDs passes straight through to the upstream service
+func (c *CachingUserService) ListUserIDs(ctx context.Context) ([]string, error) {
+	return c.upstream.ListUserIDs(ctx)
+}
+
+// ExportUserData passes straight through so exports never contain stale
+// preferences
+func (c *CachingUserService) ExportUserData(ctx context.Context, userID string) ([]byte, error) {
+	return c.upstream.ExportUserData(ctx, userID)
+}
+
+// DeleteUserData deletes upstream and drops the cached copy so deleted
+// preferences are never served
+func (c *CachingUserService) DeleteUserData(ctx context.Context, userID string) error {
+	if err := c.upstream.DeleteUserData(ctx, userID); err != nil {
+		return err
+	}
+	c.Invalidate(userID)
+	return nil
+}
+
+// UpdatePreferences writes through to upstream and drops the cached copy. A
+// version conflict drops it too, so the caller's refetch sees the newer
+// preferences.
+func (c *CachingUserService) UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error {
+	if err := c.upstream.UpdatePreferences(ctx, prefs); err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			c.Invalidate(prefs.UserID)
+		}
+		return err
+	}
+	c.Invalidate(prefs.UserID)
+	return nil
+}
+
+// RegisterDevice writes through to upstream and drops the cached copy
+func (c *CachingUserService) RegisterDevice(ctx context.Context, userID string, d models.Device) error {
+	defer c.Invalidate(userID)
+	return c.upstream.RegisterDevice(ctx, userID, d)
+}
+
+// RemoveDevice writes through to upstream and drops the cached copy
+func (c *CachingUserService) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	defer c.Invalidate(userID)
+	return c.upstream.RemoveDevice(ctx, userID, deviceID)
+}
+
+// Invalidate drops any cached preferences for userID so the next call
+// fetches them again
+func (c *CachingUserService) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[userID]; ok {
+		c.order.Remove(el)
+		delete(c.entries, userID)
+	}
+}
+
+// Cached returns the last preferences fetched for userID even if they are
+// older than the TTL, for use as a fallback while the user service is down
+func (c *CachingUserService) Cached(userID string) (*models.UserPreferences, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	return el.Value.(*cachedPreferences).prefs, true
+}
+
+// lookup returns cached preferences fetched within the TTL. Expired entries
+// stay until replaced or evicted so Cached can still serve them.
+func (c *CachingUserService) lookup(userID string) (*models.UserPreferences, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cachedPreferences)
+	if c.now().Sub(entry.fetchedAt) >= c.ttl {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.prefs, true
+}
+
+// store caches prefs for userID, evicting the least recently used entry
+// when the cache is full
+func (c *CachingUserService) store(userID string, prefs *models.UserPreferences) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[userID]; ok {
+		entry := el.Value.(*cachedPreferences)
+		entry.prefs = prefs
+		entry.fetchedAt = c.now()
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[userID] = c.order.PushFront(&cachedPreferences{userID: userID, prefs: prefs, fetchedAt: c.now()})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedPreferences).userID)
+	}
+}
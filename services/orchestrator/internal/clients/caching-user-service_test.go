@@ -0,0 +1,273 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingUserService is a UserService that records how often it is called
+type countingUserService struct {
+	mu          sync.Mutex
+	prefCalls   map[string]int
+	optOutCalls int
+	bulkCalls   [][]string
+	updates     []*models.UserPreferences
+	err         error
+}
+
+func newCountingUserService() *countingUserService {
+	return &countingUserService{prefCalls: make(map[string]int)}
+}
+
+func (s *countingUserService) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefCalls[userID]++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.UserPreferences{Email: true, Timezone: userID}, nil
+}
+
+func (s *countingUserService) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	s.mu.Lock()
+	s.bulkCalls = append(s.bulkCalls, userIDs)
+	s.mu.Unlock()
+
+	results := make(map[string]*models.UserPreferences)
+	for _, userID := range userIDs {
+		prefs, err := s.GetPreferences(ctx, userID)
+		if err != nil {
+			return results, &models.BulkError{Errors: map[string]error{userID: err}}
+		}
+		results[userID] = prefs
+	}
+	return results, nil
+}
+
+func (s *countingUserService) UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates = append(s.updates, prefs)
+	return s.err
+}
+
+func (s *countingUserService) RegisterDevice(ctx context.Context, userID string, d models.Device) error {
+	return s.err
+}
+
+func (s *countingUserService) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	return s.err
+}
+
+func (s *countingUserService) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.optOutCalls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.OptOutStatus{UserID: userID}, nil
+}
+
+func (s *countingUserService) ExportUserData(ctx context.Context, userID string) ([]byte, error) {
+	return nil, s.err
+}
+
+func (s *countingUserService) DeleteUserData(ctx context.Context, userID string) error {
+	return s.err
+}
+
+func (s *countingUserService) ListUserIDs(ctx context.Context) ([]string, error) {
+	return nil, s.err
+}
+
+func (s *countingUserService) calls(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prefCalls[userID]
+}
+
+// newTestCache returns a cache whose clock is advanced by moving *now
+func newTestCache(upstream UserService, cfg CachingUserServiceConfig) (*CachingUserService, *time.Time) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	cache := NewCachingUserService(upstream, cfg)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestCachingUserService_HitWithinTTL(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, now := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Minute})
+
+	first, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+	*now = now.Add(59 * time.Second)
+	second, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, upstream.calls("user-1"))
+}
+
+func TestCachingUserService_ExpiredEntryRefetches(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, now := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Minute})
+
+	_, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+	*now = now.Add(time.Minute)
+	_, err = cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, upstream.calls("user-1"))
+}
+
+func TestCachingUserService_Invalidate(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Hour})
+
+	_, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+	cache.Invalidate("user-1")
+	cache.Invalidate("user-unknown")
+	_, err = cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, upstream.calls("user-1"))
+}
+
+func TestCachingUserService_EvictsLeastRecentlyUsed(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Hour, MaxEntries: 2})
+
+	for _, userID := range []string{"user-1", "user-2", "user-1", "user-3"} {
+		_, err := cache.GetPreferences(context.Background(), userID)
+		require.NoError(t, err)
+	}
+
+	// user-2 was the least recently used when user-3 was added
+	_, err := cache.GetPreferences(context.Background(), "user-2")
+	require.NoError(t, err)
+	_, err = cache.GetPreferences(context.Background(), "user-3")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, upstream.calls("user-1"))
+	assert.Equal(t, 2, upstream.calls("user-2"))
+	assert.Equal(t, 1, upstream.calls("user-3"))
+}
+
+func TestCachingUserService_ErrorsNotCached(t *testing.T) {
+	upstream := newCountingUserService()
+	upstream.err = errors.New("user service unavailable")
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{})
+
+	_, err := cache.GetPreferences(context.Background(), "user-1")
+	assert.Error(t, err)
+
+	upstream.err = nil
+	prefs, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.True(t, prefs.Email)
+	assert.Equal(t, 2, upstream.calls("user-1"))
+}
+
+func TestCachingUserService_OptOutNotCached(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{})
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetOptOutStatus(context.Background(), "user-1")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, upstream.optOutCalls)
+}
+
+func TestCachingUserService_BulkFetchesOnlyMissing(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Hour})
+
+	_, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	results, err := cache.GetPreferencesBulk(context.Background(), []string{"user-1", "user-2", "user-2", "user-3"})
+
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	require.Len(t, upstream.bulkCalls, 1)
+	assert.Equal(t, []string{"user-2", "user-3"}, upstream.bulkCalls[0])
+
+	// Users fetched in bulk are cached for single lookups
+	_, err = cache.GetPreferences(context.Background(), "user-3")
+	require.NoError(t, err)
+	assert.Equal(t, 1, upstream.calls("user-3"))
+}
+
+func TestCachingUserService_CachedServesExpiredEntries(t *testing.T) {
+	upstream := newCountingUserService()
+	cache, now := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Minute})
+
+	_, ok := cache.Cached("user-1")
+	assert.False(t, ok)
+
+	_, err := cache.GetPreferences(context.Background(), "user-1")
+	require.NoError(t, err)
+	*now = now.Add(time.Hour)
+
+	prefs, ok := cache.Cached("user-1")
+	assert.True(t, ok)
+	assert.True(t, prefs.Email)
+}
+
+func TestCachingUserService_UpdateInvalidates(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Hour})
+
+	_, err := cache.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, cache.UpdatePreferences(ctx, &models.UserPreferences{UserID: "user-1", Email: false}))
+	_, err = cache.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+
+	assert.Len(t, upstream.updates, 1)
+	assert.Equal(t, 2, upstream.calls("user-1"))
+}
+
+func TestCachingUserService_VersionConflictInvalidates(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Hour})
+
+	_, err := cache.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+	upstream.err = models.ErrVersionConflict
+	err = cache.UpdatePreferences(ctx, &models.UserPreferences{UserID: "user-1", Version: 1})
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+	upstream.err = nil
+	_, err = cache.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, upstream.calls("user-1"))
+}
+
+func TestCachingUserService_DeleteInvalidates(t *testing.T) {
+	ctx := context.Background()
+	upstream := newCountingUserService()
+	cache, _ := newTestCache(upstream, CachingUserServiceConfig{TTL: time.Hour})
+
+	_, err := cache.GetPreferences(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, cache.DeleteUserData(ctx, "user-1"))
+
+	_, ok := cache.Cached("user-1")
+	assert.False(t, ok)
+}
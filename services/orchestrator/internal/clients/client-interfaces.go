@@ -1,6 +1,8 @@
 package clients
 
 import (
+	"context"
+
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
 )
 
@@ -10,5 +12,47 @@ type TemplateClient interface {
 }
 
 type UserClient interface {
-	GetPreferences(userID string) (*models.UserPreferences, error)
+	GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error)
+}
+
+// OptOutChecker is implemented by user clients that can report a user's
+// unsubscribe status
+type OptOutChecker interface {
+	GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error)
+}
+
+// UserService is the full user service API, implemented by HTTPUserService
+// (wrapped by NewUserClient in production) and mocks.UserServiceMock so
+// either can be used by the orchestrator
+type UserService interface {
+	UserClient
+	OptOutChecker
+
+	// GetPreferencesBulk fetches preferences for each distinct user ID. Users
+	// that fail are left out of the map and reported in a *models.BulkError.
+	GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error)
+
+	// UpdatePreferences replaces prefs.UserID's preferences. Invalid
+	// preferences are rejected with a *models.ValidationError, and a
+	// prefs.Version other than the stored one with models.ErrVersionConflict.
+	UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error
+
+	// RegisterDevice adds or refreshes a push device, dropping the least
+	// recently seen devices beyond the configured cap. RemoveDevice returns
+	// models.ErrDeviceNotFound for unknown devices.
+	RegisterDevice(ctx context.Context, userID string, d models.Device) error
+	RemoveDevice(ctx context.Context, userID, deviceID string) error
+
+	// ExportUserData returns the user's preferences and opt-out status as a
+	// models.UserDataExport JSON document, for data access requests
+	ExportUserData(ctx context.Context, userID string) ([]byte, error)
+
+	// DeleteUserData erases the user's preferences, devices and opt-out
+	// records. Later lookups return models.ErrUserNotFound, and deleting a
+	// user that is already gone succeeds.
+	DeleteUserData(ctx context.Context, userID string) error
+
+	// ListUserIDs returns the ID of every user the user service holds, for
+	// background jobs such as maintenance.DevicePruner
+	ListUserIDs(ctx context.Context) ([]string, error)
 }
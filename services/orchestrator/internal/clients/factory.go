@@ -5,22 +5,31 @@ import (
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/mocks"
 )
 
-// NewUserClientFromConfig creates a UserClient based on configuration
+// NewUserClientFromConfig creates a UserService based on configuration
 // If UseMockServices is true, returns a mock; otherwise returns a real client
-func NewUserClientFromConfig(cfg config.ServicesConfig) UserClient {
+func NewUserClientFromConfig(cfg config.ServicesConfig) UserService {
 	if cfg.UseMockServices {
 		return mocks.NewUserServiceMock()
 	}
 
+	var cache *CachingUserServiceConfig
+	if cfg.UserCache.Enabled {
+		cache = &CachingUserServiceConfig{
+			TTL:        cfg.UserCache.TTL,
+			MaxEntries: cfg.UserCache.MaxEntries,
+		}
+	}
+
 	return NewUserClient(UserClientConfig{
 		BaseURL:               cfg.UserService.BaseURL,
 		Timeout:               cfg.UserService.Timeout,
 		RetryMaxAttempts:      cfg.UserService.RetryMaxAttempts,
 		RetryInitialDelay:     cfg.UserService.RetryInitialDelay,
 		RetryMaxDelay:         cfg.UserService.RetryMaxDelay,
-		MaxFailures:           5,  // Default circuit breaker settings
-		CircuitBreakerTimeout: 60, // seconds
+		MaxFailures:           uint32(max(cfg.UserBreaker.FailureThreshold, 0)),
+		CircuitBreakerTimeout: cfg.UserBreaker.Cooldown,
 		HalfOpenMax:           3,
+		Cache:                 cache,
 	})
 }
 
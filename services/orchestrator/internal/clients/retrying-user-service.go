@@ -0,0 +1,236 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RetryingUserService retries calls to another UserService that fail with a
+// 5xx response or a network error, backing off exponentially between
+// attempts. 4xx responses are returned straight away, and waiting stops as
+// soon as the caller's context is done.
+type RetryingUserService struct {
+	upstream   UserService
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+type RetryingUserServiceConfig struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+func NewRetryingUserService(upstream UserService, cfg RetryingUserServiceConfig) *RetryingUserService {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 100 * time.Millisecond
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 5 * time.Second
+	}
+
+	return &RetryingUserService{
+		upstream:   upstream,
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.BaseDelay,
+		maxDelay:   cfg.MaxDelay,
+	}
+}
+
+func (r *RetryingUserService) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	var prefs *models.UserPreferences
+	err := r.do(ctx, func() error {
+		var err error
+		prefs, err = r.upstream.GetPreferences(ctx, userID)
+		return err
+	})
+	return prefs, err
+}
+
+func (r *RetryingUserService) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	var status *models.OptOutStatus
+	err := r.do(ctx, func() error {
+		var err error
+		status, err = r.upstream.GetOptOutStatus(ctx, userID)
+		return err
+	})
+	return status, err
+}
+
+// UpdatePreferences retries like the lookups since a PUT of the full
+// preferences is idempotent
+func (r *RetryingUserService) UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error {
+	return r.do(ctx, func() error {
+		return r.upstream.UpdatePreferences(ctx, prefs)
+	})
+}
+
+// RegisterDevice retries since registering a device ID again replaces it
+func (r *RetryingUserService) RegisterDevice(ctx context.Context, userID string, d models.Device) error {
+	return r.do(ctx, func() error {
+		return r.upstream.RegisterDevice(ctx, userID, d)
+	})
+}
+
+// RemoveDevice is not retried: a repeat of a delete that reached the user
+// service would report the device as not found
+func (r *RetryingUserService) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	return r.upstream.RemoveDevice(ctx, userID, deviceID)
+}
+
+func (r *RetryingUserService) ExportUserData(ctx context.Context, userID string) ([]byte, error) {
+	var data []byte
+	err := r.do(ctx, func() error {
+		var err error
+		data, err = r.upstream.ExportUserData(ctx, userID)
+		return err
+	})
+	return data, err
+}
+
+// DeleteUserData retries since deleting a user that is already gone succeeds
+func (r *RetryingUserService) DeleteUserData(ctx context.Context, userID string) error {
+	return r.do(ctx, func() error {
+		return r.upstream.DeleteUserData(ctx, userID)
+	})
+}
+
+func (r *RetryingUserService) ListUserIDs(ctx context.Context) ([]string, error) {
+	var userIDs []string
+	err := r.do(ctx, func() error {
+		var err error
+		userIDs, err = r.upstream.ListUserIDs(ctx)
+		return err
+	})
+	return userIDs, err
+}
+
+// GetPreferencesBulk re-requests only the users whose lookups failed with a
+// retryable error, keeping results from earlier attempts
+func (r *RetryingUserService) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	results := make(map[string]*models.UserPreferences)
+	errs := make(map[string]error)
+	pending := uniqueUserIDs(userIDs)
+
+	for attempt := 0; ; attempt++ {
+		fetched, err := r.upstream.GetPreferencesBulk(ctx, pending)
+		for userID, prefs := range fetched {
+			results[userID] = prefs
+			delete(errs, userID)
+		}
+
+		var retry []string
+		for _, userID := range pending {
+			if _, ok := fetched[userID]; ok {
+				continue
+			}
+			userErr := bulkFailure(err, userID)
+			errs[userID] = userErr
+			if isRetryableUserServiceError(userErr) {
+				retry = append(retry, userID)
+			}
+		}
+		if len(retry) == 0 || attempt == r.maxRetries {
+			break
+		}
+
+		if waitErr := r.wait(ctx, attempt+1); waitErr != nil {
+			for _, userID := range retry {
+				errs[userID] = waitErr
+			}
+			break
+		}
+		pending = retry
+	}
+
+	if len(errs) > 0 {
+		return results, &models.BulkError{Errors: errs}
+	}
+	return results, nil
+}
+
+// bulkFailure returns the error a bulk call reported for userID, or the
+// call's own error when it failed as a whole
+func bulkFailure(err error, userID string) error {
+	var bulkErr *models.BulkError
+	if errors.As(err, &bulkErr) {
+		if userErr, ok := bulkErr.Errors[userID]; ok {
+			return userErr
+		}
+	}
+	if err == nil {
+		return fmt.Errorf("no result returned")
+	}
+	return err
+}
+
+// do runs operation until it succeeds, fails with a non-retryable error or
+// runs out of retries
+func (r *RetryingUserService) do(ctx context.Context, operation func() error) error {
+	var err error
+	for attempt := 0; attempt <= r.maxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := r.wait(ctx, attempt); waitErr != nil {
+				return fmt.Errorf("%w (last error: %v)", waitErr, err)
+			}
+		}
+
+		err = operation()
+		if err == nil || !isRetryableUserServiceError(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("max retries (%d) exceeded: %w", r.maxRetries, err)
+}
+
+// wait sleeps for the backoff before the given retry attempt
+func (r *RetryingUserService) wait(ctx context.Context, attempt int) error {
+	delay := r.baseDelay << (attempt - 1)
+	if delay > r.maxDelay || delay <= 0 {
+		delay = r.maxDelay
+	}
+
+	logger.Log.Debug("Retrying user service call",
+		zap.Int("attempt", attempt),
+		zap.Duration("delay", delay),
+	)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRetryableUserServiceError reports whether err is a 5xx response or a
+// network failure. Cancellation and deadlines are never retried.
+func isRetryableUserServiceError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var serviceErr *models.UserServiceError
+	if errors.As(err, &serviceErr) {
+		return errors.Is(serviceErr, models.ErrUserServiceUnavailable)
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
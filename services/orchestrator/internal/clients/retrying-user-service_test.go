@@ -0,0 +1,154 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first failures requests with status, then answers
+// with body
+func flakyServer(t *testing.T, failures int32, status int, body string) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestRetryingService(baseURL string, maxRetries int) *RetryingUserService {
+	return NewRetryingUserService(
+		NewHTTPUserService(HTTPUserServiceConfig{BaseURL: baseURL, Timeout: 5 * time.Second}),
+		RetryingUserServiceConfig{MaxRetries: maxRetries, BaseDelay: time.Millisecond},
+	)
+}
+
+func TestRetryingUserService_SucceedsAfterTransientFailures(t *testing.T) {
+	server, requests := flakyServer(t, 2, http.StatusServiceUnavailable, `{"email_enabled": true}`)
+	service := newTestRetryingService(server.URL, 3)
+
+	prefs, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.NoError(t, err)
+	assert.True(t, prefs.Email)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestRetryingUserService_GetOptOutStatus_Retries(t *testing.T) {
+	server, requests := flakyServer(t, 2, http.StatusBadGateway, `{"user_id": "user-123", "global": true}`)
+	service := newTestRetryingService(server.URL, 3)
+
+	status, err := service.GetOptOutStatus(context.Background(), "user-123")
+
+	require.NoError(t, err)
+	assert.True(t, status.Global)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestRetryingUserService_GivesUpAfterMaxRetries(t *testing.T) {
+	server, requests := flakyServer(t, 10, http.StatusInternalServerError, `{}`)
+	service := newTestRetryingService(server.URL, 2)
+
+	_, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Contains(t, err.Error(), "max retries (2) exceeded")
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestRetryingUserService_DoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests} {
+		server, requests := flakyServer(t, 10, status, `{}`)
+		service := newTestRetryingService(server.URL, 3)
+
+		_, err := service.GetPreferences(context.Background(), "user-123")
+
+		require.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load(), "status %d", status)
+	}
+}
+
+func TestRetryingUserService_RetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+
+	upstream := newCountingUserService()
+	upstream.err = errors.New("decode failure")
+	service := newTestRetryingService(baseURL, 2)
+
+	_, err := service.GetPreferences(context.Background(), "user-123")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max retries (2) exceeded")
+
+	// Errors that are neither 5xx nor network failures are not retried
+	plain := NewRetryingUserService(upstream, RetryingUserServiceConfig{MaxRetries: 3, BaseDelay: time.Millisecond})
+	_, err = plain.GetPreferences(context.Background(), "user-123")
+	require.Error(t, err)
+	assert.Equal(t, 1, upstream.calls("user-123"))
+}
+
+func TestRetryingUserService_BackoffBoundedByContext(t *testing.T) {
+	server, requests := flakyServer(t, 10, http.StatusServiceUnavailable, `{}`)
+	service := NewRetryingUserService(
+		NewHTTPUserService(HTTPUserServiceConfig{BaseURL: server.URL}),
+		RetryingUserServiceConfig{MaxRetries: 5, BaseDelay: time.Minute},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := service.GetPreferences(ctx, "user-123")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestRetryingUserService_BulkRetriesOnlyTransientFailures(t *testing.T) {
+	var flakyRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/missing/"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.Contains(r.URL.Path, "/flaky/"):
+			if flakyRequests.Add(1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"push_enabled": true}`))
+		default:
+			w.Write([]byte(`{"email_enabled": true}`))
+		}
+	}))
+	defer server.Close()
+	service := newTestRetryingService(server.URL, 3)
+
+	results, err := service.GetPreferencesBulk(context.Background(), []string{"user-1", "flaky", "missing"})
+
+	require.Error(t, err)
+	assert.Len(t, results, 2)
+	assert.True(t, results["flaky"].Push)
+	assert.Equal(t, int32(3), flakyRequests.Load())
+
+	var bulkErr *models.BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.Len(t, bulkErr.Errors, 1)
+	assert.ErrorIs(t, bulkErr.Errors["missing"], models.ErrUserNotFound)
+}
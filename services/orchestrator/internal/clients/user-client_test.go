@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,9 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/config"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestMain initializes the logger before running tests
@@ -76,7 +79,7 @@ func TestUserClient_GetPreferences_Success(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, prefs)
@@ -101,11 +104,39 @@ func TestUserClient_GetPreferences_NotFound(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
 	assert.Nil(t, prefs)
-	assert.Contains(t, err.Error(), "non-retryable status 404")
+}
+
+func TestUserClient_GetPreferences_NotFoundDoesNotTripBreaker(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cfg := UserClientConfig{
+		BaseURL:               server.URL,
+		Timeout:               5 * time.Second,
+		MaxFailures:           1,
+		CircuitBreakerTimeout: 60 * time.Second,
+		HalfOpenMax:           1,
+		RetryMaxAttempts:      3,
+		RetryInitialDelay:     time.Millisecond,
+	}
+
+	client := NewUserClient(cfg)
+	for i := 0; i < 3; i++ {
+		_, err := client.GetPreferences(context.Background(), "user-123")
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	}
+
+	// Every call reached the server once: 404s are not retried and the
+	// breaker stayed closed
+	assert.Equal(t, 3, calls)
 }
 
 func TestUserClient_GetPreferences_ServerError(t *testing.T) {
@@ -125,11 +156,12 @@ func TestUserClient_GetPreferences_ServerError(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.Error(t, err)
 	assert.Nil(t, prefs)
-	assert.Contains(t, err.Error(), "retryable status 500")
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Contains(t, err.Error(), "status 500")
 }
 
 func TestUserClient_GetPreferences_InvalidJSON(t *testing.T) {
@@ -150,7 +182,7 @@ func TestUserClient_GetPreferences_InvalidJSON(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.Error(t, err)
 	assert.Nil(t, prefs)
@@ -169,7 +201,7 @@ func TestUserClient_GetPreferences_NetworkError(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.Error(t, err)
 	assert.Nil(t, prefs)
@@ -194,7 +226,7 @@ func TestUserClient_GetPreferences_Timeout(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.Error(t, err)
 	assert.Nil(t, prefs)
@@ -217,11 +249,11 @@ func TestUserClient_GetPreferences_TooManyRequests(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.Error(t, err)
 	assert.Nil(t, prefs)
-	assert.Contains(t, err.Error(), "retryable status 429")
+	assert.Contains(t, err.Error(), "status 429")
 }
 
 func TestUserClient_GetPreferences_AllPreferencesEnabled(t *testing.T) {
@@ -247,7 +279,7 @@ func TestUserClient_GetPreferences_AllPreferencesEnabled(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, prefs)
@@ -278,7 +310,7 @@ func TestUserClient_GetPreferences_AllPreferencesDisabled(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, prefs)
@@ -318,7 +350,7 @@ func TestUserClient_GetPreferences_WithRetry(t *testing.T) {
 	}
 
 	client := NewUserClient(cfg)
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, prefs)
@@ -344,13 +376,137 @@ func TestUserClient_GetPreferences_CircuitBreakerOpen(t *testing.T) {
 
 	// Trigger enough failures to open circuit breaker
 	for i := 0; i < 3; i++ {
-		_, _ = client.GetPreferences("user-123")
+		_, _ = client.GetPreferences(context.Background(), "user-123")
 	}
 
 	// Now circuit should be open
-	prefs, err := client.GetPreferences("user-123")
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
 
 	assert.Error(t, err)
 	assert.Nil(t, prefs)
-	assert.Contains(t, err.Error(), "temporarily unavailable")
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Contains(t, err.Error(), "circuit breaker is open")
+}
+
+func TestNewUserClientFromConfig_RealClientChecksOptOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/user-123/opt-out", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.OptOutStatus{UserID: "user-123", Channels: map[string]bool{"email": true}})
+	}))
+	defer server.Close()
+
+	var client UserClient = NewUserClientFromConfig(config.ServicesConfig{
+		UserService: config.ServiceEndpoint{BaseURL: server.URL, Timeout: time.Second},
+	})
+
+	// The orchestrator only enforces opt-outs for clients that report them
+	checker, ok := client.(OptOutChecker)
+	require.True(t, ok)
+	status, err := checker.GetOptOutStatus(context.Background(), "user-123")
+	require.NoError(t, err)
+	assert.True(t, status.Channels["email"])
+}
+
+func TestNewUserClientFromConfig_RetriesOnce(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.UserPreferences{UserID: "user-123", Email: true})
+	}))
+	defer server.Close()
+
+	client := NewUserClientFromConfig(config.ServicesConfig{
+		UserService: config.ServiceEndpoint{
+			BaseURL:           server.URL,
+			Timeout:           time.Second,
+			RetryMaxAttempts:  2,
+			RetryInitialDelay: time.Millisecond,
+		},
+	})
+
+	prefs, err := client.GetPreferences(context.Background(), "user-123")
+	require.NoError(t, err)
+	assert.True(t, prefs.Email)
+	// A single retry layer: two failures and the success
+	assert.Equal(t, 3, calls)
+}
+
+func TestNewUserClientFromConfig_BreakerThreshold(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewUserClientFromConfig(config.ServicesConfig{
+		UserService: config.ServiceEndpoint{
+			BaseURL:           server.URL,
+			Timeout:           time.Second,
+			RetryMaxAttempts:  1,
+			RetryInitialDelay: time.Millisecond,
+		},
+		UserBreaker: config.BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute},
+	})
+
+	_, err := client.GetPreferences(context.Background(), "user-123")
+	require.Error(t, err)
+	assert.Equal(t, 2, calls)
+
+	// The breaker opened after one failed call and now fails fast
+	_, err = client.GetPreferences(context.Background(), "user-123")
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Contains(t, err.Error(), "circuit breaker is open")
+	assert.Equal(t, 2, calls)
+}
+
+func TestUserClient_Cache(t *testing.T) {
+	calls := 0
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.UserPreferences{UserID: "user-123", Email: true})
+	}))
+	defer server.Close()
+
+	client := NewUserClient(UserClientConfig{
+		BaseURL:               server.URL,
+		Timeout:               time.Second,
+		MaxFailures:           1,
+		CircuitBreakerTimeout: time.Minute,
+		RetryMaxAttempts:      1,
+		RetryInitialDelay:     time.Millisecond,
+		Cache:                 &CachingUserServiceConfig{TTL: time.Minute},
+	})
+	cache, ok := client.(*CachingUserService)
+	require.True(t, ok)
+
+	ctx := context.Background()
+	_, err := client.GetPreferences(ctx, "user-123")
+	require.NoError(t, err)
+	_, err = client.GetPreferences(ctx, "user-123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "the second lookup is served from the cache")
+
+	// Once the entry expires and the user service is down, the stale
+	// preferences are served instead, and the open breaker stops calling it
+	cache.now = func() time.Time { return time.Now().Add(time.Hour) }
+	failing = true
+	for i := 0; i < 2; i++ {
+		prefs, err := client.GetPreferences(ctx, "user-123")
+		require.NoError(t, err)
+		assert.True(t, prefs.Email)
+	}
+	assert.Equal(t, 3, calls, "one retried call before the breaker opened")
 }
@@ -0,0 +1,304 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// HTTPUserService calls the user service REST API. Each call is a single
+// request; non-200 responses are returned as *models.UserServiceError.
+type HTTPUserService struct {
+	baseURL         string
+	httpClient      *http.Client
+	bulkConcurrency int
+	maxDevices      int
+}
+
+type HTTPUserServiceConfig struct {
+	BaseURL string
+	Timeout time.Duration
+	// HTTPClient is used as is when set, so Timeout is ignored
+	HTTPClient *http.Client
+	// BulkConcurrency caps the requests GetPreferencesBulk has in flight
+	BulkConcurrency int
+	// MaxDevices caps the push devices per user, see models.DefaultMaxDevices
+	MaxDevices int
+}
+
+func NewHTTPUserService(cfg HTTPUserServiceConfig) *HTTPUserService {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		if cfg.Timeout == 0 {
+			cfg.Timeout = 10 * time.Second
+		}
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+	if cfg.BulkConcurrency <= 0 {
+		cfg.BulkConcurrency = 8
+	}
+
+	return &HTTPUserService{
+		baseURL:         cfg.BaseURL,
+		httpClient:      httpClient,
+		bulkConcurrency: cfg.BulkConcurrency,
+		maxDevices:      cfg.MaxDevices,
+	}
+}
+
+// GetPreferences fetches the user's notification preferences
+func (s *HTTPUserService) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	var prefs models.UserPreferences
+	if err := s.get(ctx, userID, "preferences", &prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// GetPreferencesBulk fetches preferences for each distinct user ID with at
+// most BulkConcurrency requests in flight. Users not yet requested when ctx
+// is cancelled are reported with the context's error.
+func (s *HTTPUserService) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]*models.UserPreferences)
+		errs    = make(map[string]error)
+		slots   = make(chan struct{}, s.bulkConcurrency)
+	)
+
+	for _, userID := range uniqueUserIDs(userIDs) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[userID] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(userID string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			prefs, err := s.GetPreferences(ctx, userID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[userID] = err
+				return
+			}
+			results[userID] = prefs
+		}(userID)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, &models.BulkError{Errors: errs}
+	}
+	return results, nil
+}
+
+// GetOptOutStatus fetches the channels and categories the user unsubscribed from
+func (s *HTTPUserService) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	var status models.OptOutStatus
+	if err := s.get(ctx, userID, "opt-out", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// UpdatePreferences validates prefs and PUTs them to the user service, which
+// answers 409 Conflict when prefs.Version is not the stored version
+func (s *HTTPUserService) UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error {
+	if err := models.ValidateUpdate(prefs); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(prefs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+
+	return s.do(ctx, http.MethodPut, prefs.UserID, "preferences", bytes.NewReader(body), nil)
+}
+
+// RegisterDevice enforces the device cap against the user's current devices,
+// deleting any that are evicted, then POSTs d to the user service
+func (s *HTTPUserService) RegisterDevice(ctx context.Context, userID string, d models.Device) error {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	evicted, err := prefs.Channels.Push.RegisterDevice(d, s.maxDevices)
+	if err != nil {
+		return err
+	}
+	for _, old := range evicted {
+		if err := s.RemoveDevice(ctx, userID, old.DeviceID); err != nil && !errors.Is(err, models.ErrDeviceNotFound) {
+			return fmt.Errorf("failed to evict device %s: %w", old.DeviceID, err)
+		}
+	}
+
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal device: %w", err)
+	}
+	return s.do(ctx, http.MethodPost, userID, "devices", bytes.NewReader(body), nil)
+}
+
+// RemoveDevice deletes one of the user's push devices
+func (s *HTTPUserService) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	err := s.do(ctx, http.MethodDelete, userID, "devices/"+url.PathEscape(deviceID), nil, nil)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return fmt.Errorf("%w: %s", models.ErrDeviceNotFound, deviceID)
+	}
+	return err
+}
+
+// ExportUserData fetches the user's preferences and opt-out status and
+// assembles them into a models.UserDataExport document
+func (s *HTTPUserService) ExportUserData(ctx context.Context, userID string) ([]byte, error) {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.GetOptOutStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return models.MarshalUserDataExport(userID, prefs, status, time.Now())
+}
+
+// DeleteUserData DELETEs everything the user service holds for the user. A
+// 404 means there is nothing left to delete.
+func (s *HTTPUserService) DeleteUserData(ctx context.Context, userID string) error {
+	err := s.do(ctx, http.MethodDelete, userID, "data", nil, nil)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil
+	}
+	return err
+}
+
+// listUsersPageSize is the largest page GET /api/v1/users serves
+const listUsersPageSize = 100
+
+// usersPage is a page of GET /api/v1/users
+type usersPage struct {
+	Data []struct {
+		UserID string `json:"user_id"`
+	} `json:"data"`
+	Meta struct {
+		HasNext bool `json:"has_next"`
+	} `json:"meta"`
+}
+
+// ListUserIDs pages through GET /api/v1/users
+func (s *HTTPUserService) ListUserIDs(ctx context.Context) ([]string, error) {
+	userIDs := []string{}
+	for page := 1; ; page++ {
+		var users usersPage
+		path := fmt.Sprintf("/api/v1/users?page=%d&limit=%d", page, listUsersPageSize)
+		if err := s.send(ctx, http.MethodGet, path, nil, &users); err != nil {
+			return nil, err
+		}
+		for _, user := range users.Data {
+			userIDs = append(userIDs, user.UserID)
+		}
+		if !users.Meta.HasNext || len(users.Data) == 0 {
+			return userIDs, nil
+		}
+	}
+}
+
+// get decodes the JSON body of GET /api/v1/users/{userID}/{resource} into out
+func (s *HTTPUserService) get(ctx context.Context, userID, resource string, out interface{}) error {
+	return s.do(ctx, http.MethodGet, userID, resource, nil, out)
+}
+
+// do sends a request to /api/v1/users/{userID}/{resource}, see send
+func (s *HTTPUserService) do(ctx context.Context, method, userID, resource string, body io.Reader, out interface{}) error {
+	return s.send(ctx, method, fmt.Sprintf("/api/v1/users/%s/%s", url.PathEscape(userID), resource), body, out)
+}
+
+// send sends a request to path on the user service and decodes a 200
+// response into out when it is not nil. Any 2xx status is a success.
+func (s *HTTPUserService) send(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	endpoint := s.baseURL + path
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id := correlation.FromContext(ctx); id != "" {
+		req.Header.Set(correlation.Header, id)
+	}
+
+	logger.Log.Debug("Calling user service",
+		zap.String("method", method),
+		zap.String("url", endpoint),
+	)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("user service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logger.Log.Warn("User service returned non-2xx status",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("method", method),
+			zap.String("path", path),
+		)
+		return &models.UserServiceError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// uniqueUserIDs returns userIDs without repeats, keeping the first occurrence
+func uniqueUserIDs(userIDs []string) []string {
+	seen := make(map[string]bool, len(userIDs))
+	unique := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		unique = append(unique, userID)
+	}
+	return unique
+}
@@ -0,0 +1,776 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/mocks"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Both implementations must be usable wherever a UserService is expected
+var (
+	_ UserService = (*HTTPUserService)(nil)
+	_ UserService = (*mocks.UserServiceMock)(nil)
+)
+
+func newTestUserService(t *testing.T, handler http.HandlerFunc) *HTTPUserService {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewHTTPUserService(HTTPUserServiceConfig{BaseURL: server.URL, Timeout: 5 * time.Second})
+}
+
+func TestHTTPUserService_GetPreferences_Success(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v1/users/user-123/preferences", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"email_enabled": true,
+			"push_enabled": false,
+			"notification_enabled": true,
+			"timezone": "Africa/Nairobi",
+			"channels": {"sms": {"enabled": true, "verified": true, "carrier_opt_out": false}}
+		}`))
+	})
+
+	prefs, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.NoError(t, err)
+	assert.True(t, prefs.Email)
+	assert.False(t, prefs.Push)
+	assert.True(t, prefs.NotificationEnabled)
+	assert.Equal(t, "Africa/Nairobi", prefs.Timezone)
+	assert.True(t, prefs.Channels.SMS.Enabled)
+}
+
+func TestHTTPUserService_ForwardsCorrelationID(t *testing.T) {
+	var received []string
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(correlation.Header))
+		w.Write([]byte(`{}`))
+	})
+
+	_, err := service.GetPreferences(correlation.NewContext(context.Background(), "corr-123"), "user-123")
+	require.NoError(t, err)
+	_, err = service.GetPreferences(context.Background(), "user-123")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"corr-123", ""}, received)
+}
+
+func TestHTTPUserService_GetOptOutStatus_Success(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/user-123/opt-out", r.URL.Path)
+
+		w.Write([]byte(`{"user_id": "user-123", "global": false, "channels": {"sms": true}, "categories": {"marketing": true}}`))
+	})
+
+	status, err := service.GetOptOutStatus(context.Background(), "user-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", status.UserID)
+	assert.True(t, status.Channels["sms"])
+	assert.True(t, status.Categories["marketing"])
+}
+
+func TestHTTPUserService_EscapesUserID(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/a%2Fb/preferences", r.URL.EscapedPath())
+		w.Write([]byte(`{}`))
+	})
+
+	_, err := service.GetPreferences(context.Background(), "a/b")
+
+	assert.NoError(t, err)
+}
+
+func TestHTTPUserService_NotFound(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "user not found"}`))
+	})
+
+	_, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.NotErrorIs(t, err, models.ErrUserServiceUnavailable)
+
+	var serviceErr *models.UserServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, http.StatusNotFound, serviceErr.StatusCode)
+}
+
+func TestHTTPUserService_ServerError(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := service.GetOptOutStatus(context.Background(), "user-123")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.NotErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestHTTPUserService_ClientError(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	_, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, models.ErrUserNotFound)
+	assert.NotErrorIs(t, err, models.ErrUserServiceUnavailable)
+	assert.Contains(t, err.Error(), "status 400")
+}
+
+func TestHTTPUserService_InvalidJSON(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{invalid`))
+	})
+
+	_, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to unmarshal response")
+}
+
+func TestHTTPUserService_InjectedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	service := NewHTTPUserService(HTTPUserServiceConfig{
+		BaseURL:    server.URL,
+		Timeout:    time.Hour,
+		HTTPClient: &http.Client{Timeout: 10 * time.Millisecond},
+	})
+
+	_, err := service.GetPreferences(context.Background(), "user-123")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "user service request failed")
+}
+
+func TestHTTPUserService_GetPreferencesBulk_PartialFailure(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/users/missing/preferences":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v1/users/broken/preferences":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"email_enabled": true}`))
+		}
+	})
+
+	results, err := service.GetPreferencesBulk(context.Background(), []string{"user-1", "missing", "user-2", "broken"})
+
+	require.Error(t, err)
+	assert.Len(t, results, 2)
+	assert.True(t, results["user-1"].Email)
+	assert.True(t, results["user-2"].Email)
+
+	var bulkErr *models.BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	require.Len(t, bulkErr.Errors, 2)
+	assert.ErrorIs(t, bulkErr.Errors["missing"], models.ErrUserNotFound)
+	assert.ErrorIs(t, bulkErr.Errors["broken"], models.ErrUserServiceUnavailable)
+	assert.Contains(t, err.Error(), "failed to fetch 2 users")
+}
+
+func TestHTTPUserService_GetPreferencesBulk_Deduplicates(t *testing.T) {
+	var requests atomic.Int32
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"push_enabled": true}`))
+	})
+
+	results, err := service.GetPreferencesBulk(context.Background(), []string{"user-1", "user-2", "user-1", "user-1"})
+
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestHTTPUserService_GetPreferencesBulk_BoundedConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	service := NewHTTPUserService(HTTPUserServiceConfig{BaseURL: server.URL, BulkConcurrency: 2})
+	userIDs := strings.Split("a b c d e f", " ")
+
+	results, err := service.GetPreferencesBulk(context.Background(), userIDs)
+
+	require.NoError(t, err)
+	assert.Len(t, results, len(userIDs))
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestUserServiceMock_GetPreferencesBulk(t *testing.T) {
+	mock := mocks.NewUserServiceMock()
+
+	results, err := mock.GetPreferencesBulk(context.Background(), []string{"usr_1", "notfound_1", "usr_noemail_2", "usr_1"})
+
+	require.Error(t, err)
+	assert.Len(t, results, 2)
+	assert.False(t, results["usr_noemail_2"].Email)
+
+	var bulkErr *models.BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.Contains(t, bulkErr.Errors, "notfound_1")
+}
+
+func TestHTTPUserService_CancelledContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	service := NewHTTPUserService(HTTPUserServiceConfig{BaseURL: server.URL, Timeout: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := service.GetPreferences(ctx, "user-123")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestHTTPUserService_GetPreferencesBulk_CancelledContext(t *testing.T) {
+	var requests atomic.Int32
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{}`))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := service.GetPreferencesBulk(ctx, []string{"user-1", "user-2"})
+
+	require.Error(t, err)
+	assert.Empty(t, results)
+	assert.ErrorIs(t, err.(*models.BulkError).Errors["user-1"], context.Canceled)
+	assert.Zero(t, requests.Load())
+}
+
+func TestUserServiceMock_CancelledContext(t *testing.T) {
+	mock := mocks.NewUserServiceMock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := mock.GetPreferences(ctx, "usr_123")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = mock.GetOptOutStatus(ctx, "usr_123")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUserServiceMock_DeadlineAbortsSlowCall(t *testing.T) {
+	mock := mocks.NewUserServiceMock()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := mock.GetPreferences(ctx, "timeout_123")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestHTTPUserService_UpdatePreferences_Success(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/v1/users/user-123/preferences", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var prefs models.UserPreferences
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&prefs))
+		assert.Equal(t, "user-123", prefs.UserID)
+		assert.Equal(t, "Africa/Nairobi", prefs.Timezone)
+		assert.True(t, prefs.Email)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := service.UpdatePreferences(context.Background(), &models.UserPreferences{
+		UserID:   "user-123",
+		Email:    true,
+		Timezone: "Africa/Nairobi",
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestHTTPUserService_UpdatePreferences_ValidationFailure(t *testing.T) {
+	var requests atomic.Int32
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	})
+
+	tests := []struct {
+		name    string
+		prefs   *models.UserPreferences
+		wantErr string
+	}{
+		{name: "nil", prefs: nil, wantErr: "preferences are required"},
+		{name: "missing user", prefs: &models.UserPreferences{Timezone: "UTC"}, wantErr: "user_id: is required"},
+		{name: "bad timezone", prefs: &models.UserPreferences{UserID: "user-123", Timezone: "Mars/Olympus"}, wantErr: "timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.UpdatePreferences(context.Background(), tt.prefs)
+
+			require.Error(t, err)
+			var validationErr *models.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+	assert.Zero(t, requests.Load())
+}
+
+func TestHTTPUserService_UpdatePreferences_ServerError(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "database unavailable"}`))
+	})
+
+	err := service.UpdatePreferences(context.Background(), &models.UserPreferences{UserID: "user-123"})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrUserServiceUnavailable)
+	var validationErr *models.ValidationError
+	assert.False(t, errors.As(err, &validationErr))
+}
+
+func TestUserServiceMock_UpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock()
+
+	err := mock.UpdatePreferences(ctx, &models.UserPreferences{UserID: "usr_123", Email: false, Push: true, Language: "sw"})
+	require.NoError(t, err)
+
+	prefs, err := mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.False(t, prefs.Email)
+	assert.Equal(t, "sw", prefs.Language)
+
+	err = mock.UpdatePreferences(ctx, &models.UserPreferences{UserID: "usr_123", Language: "xx"})
+	var validationErr *models.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+
+	mock.Reset()
+	prefs, err = mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.True(t, prefs.Email)
+}
+
+func TestUserServiceMock_UpdatePreferences_Versioned(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock()
+
+	prefs, err := mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.Zero(t, prefs.Version)
+
+	update := *prefs
+	update.UserID = "usr_123"
+	update.Language = "sw"
+	require.NoError(t, mock.UpdatePreferences(ctx, &update))
+
+	prefs, err = mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, prefs.Version)
+	assert.Equal(t, "sw", prefs.Language)
+
+	update = *prefs
+	update.Language = "fr"
+	require.NoError(t, mock.UpdatePreferences(ctx, &update))
+
+	prefs, err = mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.Equal(t, 2, prefs.Version)
+	assert.Equal(t, "fr", prefs.Language)
+}
+
+func TestUserServiceMock_UpdatePreferences_VersionConflict(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock()
+
+	prefs, err := mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	first, second := *prefs, *prefs
+	first.UserID, first.Language = "usr_123", "sw"
+	second.UserID, second.Language = "usr_123", "fr"
+
+	require.NoError(t, mock.UpdatePreferences(ctx, &first))
+	err = mock.UpdatePreferences(ctx, &second)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+	assert.Contains(t, err.Error(), "at version 1, not 0")
+	prefs, err = mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.Equal(t, "sw", prefs.Language)
+}
+
+func TestHTTPUserService_UpdatePreferences_VersionConflict(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		var prefs models.UserPreferences
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&prefs))
+		assert.Equal(t, 3, prefs.Version)
+
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error": "stale version"}`))
+	})
+
+	err := service.UpdatePreferences(context.Background(), &models.UserPreferences{UserID: "user-123", Version: 3})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+	assert.NotErrorIs(t, err, models.ErrUserServiceUnavailable)
+}
+
+func TestUserServiceMock_ExportUserData(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock()
+	data, err := mock.ExportUserData(ctx, "usr_123")
+	require.NoError(t, err)
+	require.NoError(t, models.ValidateExport(data))
+
+	var export models.UserDataExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, "usr_123", export.UserID)
+	require.NotNil(t, export.Preferences)
+	assert.True(t, export.Preferences.Email)
+	// Device tokens are exported unredacted but flagged as sensitive
+	require.NotEmpty(t, export.Preferences.Channels.Push.Devices)
+	assert.Equal(t, "mock_push_token_usr_123", export.Preferences.Channels.Push.Devices[0].Token)
+	require.NotNil(t, export.OptOut)
+	assert.True(t, export.OptOut.Categories[models.CategoryMarketing])
+	assert.Contains(t, export.Sensitive, models.SensitiveDeviceTokens)
+	assert.Contains(t, export.Sensitive, models.SensitiveWebhookSecret)
+}
+
+func TestHTTPUserService_ExportUserData(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/users/user-123/preferences":
+			w.Write([]byte(`{"email_enabled": true, "language": "sw"}`))
+		case "/api/v1/users/user-123/opt-out":
+			w.Write([]byte(`{"user_id": "user-123", "global": false, "channels": {"sms": true}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	data, err := service.ExportUserData(context.Background(), "user-123")
+	require.NoError(t, err)
+	require.NoError(t, models.ValidateExport(data))
+
+	var export models.UserDataExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, "sw", export.Preferences.Language)
+	assert.True(t, export.OptOut.Channels[models.ChannelSMS])
+}
+
+func TestHTTPUserService_ExportUserData_NotFound(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, err := service.ExportUserData(context.Background(), "user-123")
+
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestUserServiceMock_DeleteUserData(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock()
+	require.NoError(t, mock.UpdatePreferences(ctx, &models.UserPreferences{UserID: "usr_123", Email: true, Language: "sw"}))
+	require.NoError(t, mock.RegisterDevice(ctx, "usr_123", models.Device{DeviceID: "dev-1", Token: "tok-1", Platform: "ios"}))
+
+	require.NoError(t, mock.DeleteUserData(ctx, "usr_123"))
+
+	_, err := mock.GetPreferences(ctx, "usr_123")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = mock.GetOptOutStatus(ctx, "usr_123")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = mock.ExportUserData(ctx, "usr_123")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.ErrorIs(t, mock.RegisterDevice(ctx, "usr_123", models.Device{DeviceID: "dev-2", Token: "tok-2", Platform: "ios"}), models.ErrUserNotFound)
+	// Deleting again is not an error
+	assert.NoError(t, mock.DeleteUserData(ctx, "usr_123"))
+
+	// Other users are untouched
+	_, err = mock.GetPreferences(ctx, "usr_456")
+	assert.NoError(t, err)
+
+	// Writing preferences again brings the user back, from scratch
+	require.NoError(t, mock.UpdatePreferences(ctx, &models.UserPreferences{UserID: "usr_123", Language: "fr"}))
+	prefs, err := mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.Equal(t, "fr", prefs.Language)
+	assert.Equal(t, 1, prefs.Version)
+}
+
+func TestUserServiceMock_DeleteUserData_RemovesScriptedOptOut(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock().
+		WithPreferences("user-1", &models.UserPreferences{Email: true}).
+		WithOptOut("user-1", &models.OptOutStatus{UserID: "user-1", Global: true})
+
+	require.NoError(t, mock.DeleteUserData(ctx, "user-1"))
+
+	_, err := mock.GetOptOutStatus(ctx, "user-1")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestHTTPUserService_DeleteUserData(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/v1/users/user-123/data", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.NoError(t, service.DeleteUserData(context.Background(), "user-123"))
+}
+
+func TestHTTPUserService_DeleteUserData_AlreadyGone(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	assert.NoError(t, service.DeleteUserData(context.Background(), "user-123"))
+}
+
+func TestHTTPUserService_RegisterDevice_EvictsBeyondCap(t *testing.T) {
+	var deleted []string
+	var registered models.Device
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"channels": {"push": {"enabled": true, "devices": [
+				{"device_id": "dev-old", "token": "a", "platform": "ios", "last_seen": "2025-01-01T00:00:00Z"},
+				{"device_id": "dev-recent", "token": "b", "platform": "android", "last_seen": "2025-01-15T00:00:00Z"}
+			]}}}`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost:
+			assert.Equal(t, "/api/v1/users/user-123/devices", r.URL.Path)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&registered))
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(server.Close)
+	service := NewHTTPUserService(HTTPUserServiceConfig{BaseURL: server.URL, MaxDevices: 2})
+
+	err := service.RegisterDevice(context.Background(), "user-123", models.Device{DeviceID: "dev-new", Token: "c", Platform: "web"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/api/v1/users/user-123/devices/dev-old"}, deleted)
+	assert.Equal(t, "dev-new", registered.DeviceID)
+}
+
+func TestHTTPUserService_RemoveDevice_NotFound(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/v1/users/user-123/devices/dev-missing", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	err := service.RemoveDevice(context.Background(), "user-123", "dev-missing")
+
+	assert.ErrorIs(t, err, models.ErrDeviceNotFound)
+}
+
+func TestUserServiceMock_Devices(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMockWithConfig(mocks.MockBehaviorConfig{MaxDevices: 2})
+
+	require.NoError(t, mock.RegisterDevice(ctx, "usr_123", models.Device{DeviceID: "dev-1", Token: "old", Platform: "ios"}))
+	require.NoError(t, mock.RegisterDevice(ctx, "usr_123", models.Device{DeviceID: "dev-1", Token: "new", Platform: "ios"}))
+
+	prefs, err := mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	require.Len(t, prefs.Channels.Push.Devices, 2)
+	tokens := map[string]string{}
+	for _, d := range prefs.Channels.Push.Devices {
+		tokens[d.DeviceID] = d.Token
+	}
+	assert.Equal(t, "new", tokens["dev-1"])
+
+	err = mock.RemoveDevice(ctx, "usr_123", "dev-missing")
+	assert.ErrorIs(t, err, models.ErrDeviceNotFound)
+
+	require.NoError(t, mock.RemoveDevice(ctx, "usr_123", "dev-1"))
+	prefs, err = mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+	assert.Len(t, prefs.Channels.Push.Devices, 1)
+}
+
+func TestUserServiceMock_DevicesByPlatform(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock()
+
+	require.NoError(t, mock.RegisterDevice(ctx, "usr_123", models.Device{DeviceID: "iphone", Token: "apns-token", Platform: "ios", Active: true}))
+	require.NoError(t, mock.RegisterDevice(ctx, "usr_123", models.Device{DeviceID: "old-android", Token: "stale-token", Platform: "android"}))
+
+	prefs, err := mock.GetPreferences(ctx, "usr_123")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"android": {"mock_push_token_usr_123"},
+		"ios":     {"apns-token"},
+	}, prefs.Channels.Push.TokensByPlatform())
+}
+
+func TestUserServiceMock_ExpiredDevice(t *testing.T) {
+	prefs, err := mocks.NewUserServiceMock().GetPreferences(context.Background(), "usr_123")
+	require.NoError(t, err)
+
+	now := time.Now()
+	expired := prefs.Channels.Push.ExpiredDevices(now)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "dev_usr_123_ipad", expired[0].DeviceID)
+	assert.NotContains(t, prefs.Channels.Push.TokensByPlatformAt(now), "ios")
+	assert.Len(t, prefs.Channels.Push.ActiveDevicesAt(models.MaxDeviceAge, now), 1)
+}
+
+func TestUserServiceMock_ScriptedResponses(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("scripted outage")
+	mock := mocks.NewUserServiceMock().
+		WithPreferences("alice", &models.UserPreferences{Email: false, Push: true, Language: "sw"}).
+		WithOptOut("alice", &models.OptOutStatus{UserID: "alice", Channels: map[string]bool{models.ChannelEmail: true}}).
+		WithPreferences("bob", &models.UserPreferences{Email: true}).
+		WithError("carol", errDown)
+
+	prefs, err := mock.GetPreferences(ctx, "alice")
+	require.NoError(t, err)
+	assert.False(t, prefs.Email)
+	assert.Equal(t, "sw", prefs.Language)
+
+	status, err := mock.GetOptOutStatus(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, status.Channels[models.ChannelEmail])
+
+	// No scripted opt-out, so it is derived from the scripted preferences
+	status, err = mock.GetOptOutStatus(ctx, "bob")
+	require.NoError(t, err)
+	assert.False(t, status.Channels[models.ChannelEmail])
+	assert.True(t, status.Channels[models.ChannelPush])
+
+	_, err = mock.GetPreferences(ctx, "carol")
+	assert.ErrorIs(t, err, errDown)
+	_, err = mock.GetOptOutStatus(ctx, "carol")
+	assert.ErrorIs(t, err, errDown)
+}
+
+func TestUserServiceMock_ScriptedUnknownUser(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewUserServiceMock().WithPreferences("alice", &models.UserPreferences{})
+
+	// Pattern IDs are not matched once scripted
+	_, err := mock.GetPreferences(ctx, "usr_123")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = mock.GetOptOutStatus(ctx, "usr_123")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	prefsByUser, err := mock.GetPreferencesBulk(ctx, []string{"alice", "usr_123"})
+	require.Error(t, err)
+	assert.Contains(t, prefsByUser, "alice")
+	var bulkErr *models.BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.ErrorIs(t, bulkErr.Errors["usr_123"], models.ErrUserNotFound)
+}
+
+func TestUserServiceMock_ScriptedPreferencesAreCopied(t *testing.T) {
+	ctx := context.Background()
+	prefs := &models.UserPreferences{Language: "en"}
+	mock := mocks.NewUserServiceMock().WithPreferences("alice", prefs)
+	prefs.Language = "fr"
+
+	got, err := mock.GetPreferences(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "en", got.Language)
+
+	got.Language = "de"
+	got, err = mock.GetPreferences(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "en", got.Language)
+}
+
+func TestHTTPUserService_ListUserIDs(t *testing.T) {
+	service := newTestUserService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users", r.URL.Path)
+		assert.Equal(t, "100", r.URL.Query().Get("limit"))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"success": true, "data": [{"user_id": "user-1"}, {"user_id": "user-2"}], "meta": {"page": 1, "has_next": true}}`))
+		case "2":
+			w.Write([]byte(`{"success": true, "data": [{"user_id": "user-3"}], "meta": {"page": 2, "has_next": false}}`))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+
+	userIDs, err := service.ListUserIDs(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2", "user-3"}, userIDs)
+}
+
+func TestUserServiceMock_ListUserIDs(t *testing.T) {
+	mock := mocks.NewUserServiceMock().WithPreferences("usr_b", &models.UserPreferences{Email: true})
+	ctx := context.Background()
+	require.NoError(t, mock.RegisterDevice(ctx, "usr_b", models.Device{DeviceID: "dev-1", Token: "token-1", Platform: "ios"}))
+	require.NoError(t, mock.UpdatePreferences(ctx, &models.UserPreferences{UserID: "usr_a", Email: true}))
+
+	userIDs, err := mock.ListUserIDs(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"usr_a", "usr_b"}, userIDs)
+}
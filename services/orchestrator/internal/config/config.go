@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,6 +15,15 @@ type Config struct {
 	Kafka      KafkaConfig
 	Redis      RedisConfig
 	PostgreSQL PostgreSQLConfig
+	RateLimit  RateLimitConfig
+	Throttle   ThrottleConfig
+	Dedup      DedupConfig
+	Frequency  FrequencyConfig
+	Scheduler  SchedulerConfig
+	I18n       I18nConfig
+	Delivery   DeliveryConfig
+	Webhook    WebhookConfig
+	Devices    DeviceConfig
 }
 
 type ServerConfig struct {
@@ -27,6 +37,8 @@ type ServicesConfig struct {
 	UserService     ServiceEndpoint
 	TemplateService ServiceEndpoint
 	UseMockServices bool
+	UserCache       UserCacheConfig
+	UserBreaker     BreakerConfig
 }
 
 type ServiceEndpoint struct {
@@ -37,6 +49,20 @@ type ServiceEndpoint struct {
 	RetryMaxDelay     time.Duration
 }
 
+// UserCacheConfig configures caching of user preferences in front of the
+// user service
+type UserCacheConfig struct {
+	Enabled    bool
+	TTL        time.Duration
+	MaxEntries int
+}
+
+// BreakerConfig configures the circuit breaker in front of a service
+type BreakerConfig struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
 type LoggingConfig struct {
 	Level  string
 	Format string // json or console
@@ -50,6 +76,20 @@ type KafkaConfig struct {
 	Username    string
 	Password    string
 	UseTLS      bool
+	Compression string
+
+	SASLMechanism string
+	Balancer      string
+	DryRun        bool
+	InMemory      bool
+	LogValues     bool
+
+	BatchSize    int
+	BatchBytes   int
+	BatchTimeout time.Duration
+
+	EmailPriorityTopic string
+	PushPriorityTopic  string
 }
 
 type RedisConfig struct {
@@ -60,6 +100,68 @@ type RedisConfig struct {
 	IdempotencyTTL time.Duration // TTL for idempotency keys
 }
 
+// RateLimitConfig configures the per-user notification rate limiter
+type RateLimitConfig struct {
+	Enabled    bool
+	Rate       float64 // notifications per second
+	Burst      int
+	PerChannel bool
+}
+
+// ThrottleConfig caps aggregate sends per second on each channel. A rate of
+// zero leaves the channel unthrottled.
+type ThrottleConfig struct {
+	EmailRate float64
+	PushRate  float64
+	MaxWait   time.Duration
+}
+
+// DedupConfig configures suppression of identical notifications
+type DedupConfig struct {
+	Enabled bool
+	Window  time.Duration
+}
+
+// FrequencyConfig configures enforcement of each channel's hourly or daily
+// frequency setting
+type FrequencyConfig struct {
+	Enabled bool
+}
+
+// SchedulerConfig configures holding notifications until their
+// scheduled_for time
+type SchedulerConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+}
+
+// I18nConfig points at the message catalogs used to localize template
+// variables
+type I18nConfig struct {
+	CatalogDir string
+}
+
+// DeliveryConfig configures delivery status tracking and publish retries
+type DeliveryConfig struct {
+	TrackingEnabled bool
+	RetryAttempts   int
+	RetryBackoff    time.Duration
+	Fallback        bool
+}
+
+// WebhookConfig configures delivery to users' webhook channels
+type WebhookConfig struct {
+	Enabled bool
+	Timeout time.Duration
+}
+
+// DeviceConfig configures the background removal of stale push devices
+type DeviceConfig struct {
+	PruneEnabled  bool
+	PruneInterval time.Duration
+	MaxAge        time.Duration
+}
+
 type PostgreSQLConfig struct {
 	Host     string
 	Port     string
@@ -94,6 +196,15 @@ func Load() *Config {
 				RetryMaxDelay:     getDurationEnv("TEMPLATE_SERVICE_RETRY_MAX_DELAY", 5*time.Second),
 			},
 			UseMockServices: getBoolEnv("USE_MOCK_SERVICES", true),
+			UserCache: UserCacheConfig{
+				Enabled:    getBoolEnv("USER_CACHE_ENABLED", false),
+				TTL:        getDurationEnv("USER_CACHE_TTL", 5*time.Minute),
+				MaxEntries: getIntEnv("USER_CACHE_MAX_ENTRIES", 1000),
+			},
+			UserBreaker: BreakerConfig{
+				FailureThreshold: getIntEnv("USER_SERVICE_BREAKER_FAILURES", 5),
+				Cooldown:         getDurationEnv("USER_SERVICE_BREAKER_COOLDOWN", time.Minute),
+			},
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -108,6 +219,20 @@ func Load() *Config {
 			Username:    getEnv("KAFKA_USERNAME", ""),
 			Password:    getEnv("KAFKA_PASSWORD", ""),
 			UseTLS:      getBoolEnv("KAFKA_USE_TLS", false),
+			Compression: getEnv("KAFKA_COMPRESSION", ""),
+
+			SASLMechanism: getEnv("KAFKA_SASL_MECHANISM", ""),
+			Balancer:      getEnv("KAFKA_BALANCER", ""),
+			DryRun:        getBoolEnv("KAFKA_DRY_RUN", false),
+			InMemory:      getBoolEnv("KAFKA_IN_MEMORY", false),
+			LogValues:     getBoolEnv("KAFKA_LOG_VALUES", false),
+
+			BatchSize:    getIntEnv("KAFKA_BATCH_SIZE", 0),
+			BatchBytes:   getIntEnv("KAFKA_BATCH_BYTES", 0),
+			BatchTimeout: getDurationEnv("KAFKA_BATCH_TIMEOUT", 0),
+
+			EmailPriorityTopic: getEnv("KAFKA_EMAIL_PRIORITY_TOPIC", ""),
+			PushPriorityTopic:  getEnv("KAFKA_PUSH_PRIORITY_TOPIC", ""),
 		},
 		Redis: RedisConfig{
 			Host:           getEnv("REDIS_HOST", "localhost"),
@@ -125,6 +250,46 @@ func Load() *Config {
 			SSLMode:  getEnv("POSTGRES_SSLMODE", "disable"),
 			MaxConns: getIntEnv("POSTGRES_MAX_CONNS", 25),
 		},
+		RateLimit: RateLimitConfig{
+			Enabled:    getBoolEnv("RATE_LIMIT_ENABLED", false),
+			Rate:       getFloatEnv("RATE_LIMIT_RATE", 1),
+			Burst:      getIntEnv("RATE_LIMIT_BURST", 10),
+			PerChannel: getBoolEnv("RATE_LIMIT_PER_CHANNEL", false),
+		},
+		Throttle: ThrottleConfig{
+			EmailRate: getFloatEnv("THROTTLE_EMAIL_RATE", 0),
+			PushRate:  getFloatEnv("THROTTLE_PUSH_RATE", 0),
+			MaxWait:   getDurationEnv("THROTTLE_MAX_WAIT", time.Second),
+		},
+		Dedup: DedupConfig{
+			Enabled: getBoolEnv("DEDUP_ENABLED", false),
+			Window:  getDurationEnv("DEDUP_WINDOW", 10*time.Minute),
+		},
+		Frequency: FrequencyConfig{
+			Enabled: getBoolEnv("FREQUENCY_LIMIT_ENABLED", false),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:      getBoolEnv("SCHEDULER_ENABLED", false),
+			PollInterval: getDurationEnv("SCHEDULER_POLL_INTERVAL", time.Second),
+		},
+		I18n: I18nConfig{
+			CatalogDir: getEnv("I18N_CATALOG_DIR", ""),
+		},
+		Delivery: DeliveryConfig{
+			TrackingEnabled: getBoolEnv("DELIVERY_TRACKING_ENABLED", false),
+			RetryAttempts:   getIntEnv("DELIVERY_RETRY_ATTEMPTS", 1),
+			RetryBackoff:    getDurationEnv("DELIVERY_RETRY_BACKOFF", 100*time.Millisecond),
+			Fallback:        getBoolEnv("DELIVERY_FALLBACK_ENABLED", false),
+		},
+		Webhook: WebhookConfig{
+			Enabled: getBoolEnv("WEBHOOK_DELIVERY_ENABLED", false),
+			Timeout: getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Devices: DeviceConfig{
+			PruneEnabled:  getBoolEnv("DEVICE_PRUNE_ENABLED", false),
+			PruneInterval: getDurationEnv("DEVICE_PRUNE_INTERVAL", 24*time.Hour),
+			MaxAge:        getDurationEnv("DEVICE_MAX_AGE", 90*24*time.Hour),
+		},
 	}
 }
 
@@ -161,6 +326,15 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getSliceEnv(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
 		return strings.Split(value, ",")
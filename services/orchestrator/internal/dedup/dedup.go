@@ -0,0 +1,95 @@
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+)
+
+// DefaultWindow is how long a notification suppresses identical ones
+const DefaultWindow = 10 * time.Minute
+
+// sweepInterval is how often MemoryStore drops expired keys
+const sweepInterval = time.Minute
+
+// Store remembers recently sent notifications. SeenRecently must check and
+// record a key atomically so a shared backend such as Redis can deduplicate
+// across orchestrator instances.
+type Store interface {
+	// SeenRecently reports whether key was recorded within window, and
+	// records it now if it was not
+	SeenRecently(key string, window time.Duration) bool
+
+	// Forget removes key so a notification whose send failed can be retried
+	Forget(key string)
+}
+
+// Key identifies a notification by user, channel and a hash of its content.
+// Each content part is length-prefixed so ("ab", "c") and ("a", "bc") differ.
+func Key(userID, channel string, content ...string) string {
+	h := sha256.New()
+	for _, part := range content {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(part)))
+		h.Write(size[:])
+		h.Write([]byte(part))
+	}
+	return userID + ":" + channel + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryStore keeps keys in process memory, dropping them once their window
+// has passed
+type MemoryStore struct {
+	clock clock.Clock
+
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore returns an empty store. A nil clk uses the real clock.
+func NewMemoryStore(clk clock.Clock) *MemoryStore {
+	return &MemoryStore{clock: clock.OrReal(clk), expires: make(map[string]time.Time)}
+}
+
+// SeenRecently implements Store
+func (s *MemoryStore) SeenRecently(key string, window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, expiresAt := range s.expires {
+			if !expiresAt.After(now) {
+				delete(s.expires, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expiresAt, ok := s.expires[key]; ok && expiresAt.After(now) {
+		return true
+	}
+	s.expires[key] = now.Add(window)
+	return false
+}
+
+// Forget implements Store
+func (s *MemoryStore) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.expires, key)
+}
+
+// Len returns how many keys are held, including any not yet swept
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.expires)
+}
@@ -0,0 +1,63 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore() (*MemoryStore, *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	return NewMemoryStore(clk), clk
+}
+
+func TestMemoryStore_SuppressesWithinWindow(t *testing.T) {
+	store, clk := newTestStore()
+	key := Key("usr_1", "email", "Welcome", "<p>Hi</p>")
+
+	assert.False(t, store.SeenRecently(key, time.Minute))
+	assert.True(t, store.SeenRecently(key, time.Minute))
+
+	clk.Advance(59 * time.Second)
+	assert.True(t, store.SeenRecently(key, time.Minute))
+
+	clk.Advance(time.Second)
+	assert.False(t, store.SeenRecently(key, time.Minute))
+}
+
+func TestMemoryStore_DistinctContentPasses(t *testing.T) {
+	store, _ := newTestStore()
+
+	assert.False(t, store.SeenRecently(Key("usr_1", "email", "Welcome", "Hi"), time.Minute))
+	assert.False(t, store.SeenRecently(Key("usr_1", "email", "Welcome", "Hello"), time.Minute))
+	assert.False(t, store.SeenRecently(Key("usr_1", "push", "Welcome", "Hi"), time.Minute))
+	assert.False(t, store.SeenRecently(Key("usr_2", "email", "Welcome", "Hi"), time.Minute))
+}
+
+func TestMemoryStore_Forget(t *testing.T) {
+	store, _ := newTestStore()
+	key := Key("usr_1", "email", "Welcome")
+
+	store.SeenRecently(key, time.Minute)
+	store.Forget(key)
+
+	assert.False(t, store.SeenRecently(key, time.Minute))
+}
+
+func TestMemoryStore_SweepsExpiredKeys(t *testing.T) {
+	store, clk := newTestStore()
+	store.SeenRecently("a", time.Second)
+	store.SeenRecently("b", time.Hour)
+
+	clk.Advance(2 * time.Minute)
+	store.SeenRecently("c", time.Second)
+
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestKey_SeparatesContentParts(t *testing.T) {
+	assert.NotEqual(t, Key("usr_1", "email", "ab", "c"), Key("usr_1", "email", "a", "bc"))
+	assert.Equal(t, Key("usr_1", "email", "ab", "c"), Key("usr_1", "email", "ab", "c"))
+}
@@ -0,0 +1,176 @@
+package digest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+)
+
+// DigestItem is one notification held back for a digest
+type DigestItem struct {
+	NotificationID string            `json:"notification_id"`
+	Category       string            `json:"category"`
+	Title          string            `json:"title"`
+	Body           string            `json:"body,omitempty"`
+	Variables      map[string]string `json:"variables,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// DigestBatch is the consolidated payload sent to a user in place of the
+// notifications it collects. Items are grouped by category in the order
+// they were added.
+type DigestBatch struct {
+	UserID       string                  `json:"user_id"`
+	Frequency    string                  `json:"frequency"`
+	ScheduledFor time.Time               `json:"scheduled_for"`
+	Categories   map[string][]DigestItem `json:"categories"`
+	ItemCount    int                     `json:"item_count"`
+}
+
+// PreferencesFunc looks up the preferences that decide when a user's digest
+// is due
+type PreferencesFunc func(userID string) (*models.UserPreferences, error)
+
+// Aggregator collects digest items per user and releases them as one batch
+// once the user's next digest time has passed. It is safe for concurrent
+// use.
+type Aggregator struct {
+	preferences PreferencesFunc
+	clock       clock.Clock
+
+	mu      sync.Mutex
+	pending map[string]*pendingDigest
+}
+
+// pendingDigest is what has been collected for one user. since is the
+// earliest item, and the digest is due at the first digest time after it.
+type pendingDigest struct {
+	since time.Time
+	items []DigestItem
+}
+
+// NewAggregator returns an empty aggregator. A nil clk uses the real clock.
+func NewAggregator(preferences PreferencesFunc, clk clock.Clock) *Aggregator {
+	return &Aggregator{
+		preferences: preferences,
+		clock:       clock.OrReal(clk),
+		pending:     make(map[string]*pendingDigest),
+	}
+}
+
+// Add holds item for the user's next digest. Items without a CreatedAt are
+// stamped with the current time.
+func (a *Aggregator) Add(userID string, item DigestItem) {
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = a.clock.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p, ok := a.pending[userID]
+	if !ok {
+		p = &pendingDigest{since: item.CreatedAt}
+		a.pending[userID] = p
+	}
+	if item.CreatedAt.Before(p.since) {
+		p.since = item.CreatedAt
+	}
+	p.items = append(p.items, item)
+}
+
+// Flush returns a batch for every user whose digest is due at now, sorted by
+// user ID, and forgets their items. The schedule follows Digest.Frequency
+// and Digest.Time in the user's timezone, or UTC when it is unset or
+// unknown. Items for users with digests disabled are dropped without a
+// batch. Users whose preferences cannot be loaded or whose digest settings
+// are invalid keep their items for a later flush.
+func (a *Aggregator) Flush(now time.Time) []DigestBatch {
+	a.mu.Lock()
+	since := make(map[string]time.Time, len(a.pending))
+	for userID, p := range a.pending {
+		since[userID] = p.since
+	}
+	a.mu.Unlock()
+
+	// Preferences are looked up without holding the lock so Add is not
+	// blocked on the user service
+	due := make(map[string]time.Time)
+	disabled := make(map[string]bool)
+	frequencies := make(map[string]string)
+	for userID, start := range since {
+		prefs, err := a.preferences(userID)
+		if err != nil || prefs == nil {
+			continue
+		}
+		if !prefs.Digest.Enabled {
+			disabled[userID] = true
+			continue
+		}
+
+		scheduled, err := prefs.Digest.NextAt(start, location(prefs.Timezone))
+		if err != nil || scheduled.After(now) {
+			continue
+		}
+		due[userID] = scheduled
+		frequencies[userID] = prefs.Digest.Frequency
+		if frequencies[userID] == "" {
+			frequencies[userID] = models.DigestDaily
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	batches := []DigestBatch{}
+	for userID := range disabled {
+		delete(a.pending, userID)
+	}
+	for userID, scheduled := range due {
+		p, ok := a.pending[userID]
+		if !ok {
+			continue
+		}
+		delete(a.pending, userID)
+
+		batch := DigestBatch{
+			UserID:       userID,
+			Frequency:    frequencies[userID],
+			ScheduledFor: scheduled,
+			Categories:   make(map[string][]DigestItem),
+			ItemCount:    len(p.items),
+		}
+		for _, item := range p.items {
+			batch.Categories[item.Category] = append(batch.Categories[item.Category], item)
+		}
+		batches = append(batches, batch)
+	}
+
+	sort.Slice(batches, func(i, j int) bool { return batches[i].UserID < batches[j].UserID })
+	return batches
+}
+
+// Pending returns how many items are waiting for the user's next digest
+func (a *Aggregator) Pending(userID string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if p, ok := a.pending[userID]; ok {
+		return len(p.items)
+	}
+	return 0
+}
+
+// location resolves the user's timezone. Preferences are validated when
+// they are saved, so an invalid one falls back to UTC rather than holding
+// the digest back forever.
+func location(timezone string) *time.Location {
+	loc, err := models.LoadTimezone(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,154 @@
+package digest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// preferencesOf serves fixed preferences per user, failing for anyone else
+func preferencesOf(prefs map[string]*models.UserPreferences) PreferencesFunc {
+	return func(userID string) (*models.UserPreferences, error) {
+		p, ok := prefs[userID]
+		if !ok {
+			return nil, models.ErrUserNotFound
+		}
+		return p, nil
+	}
+}
+
+func digestPrefs(frequency, at, timezone string) *models.UserPreferences {
+	return &models.UserPreferences{
+		Timezone: timezone,
+		Digest:   models.Digest{Enabled: true, Frequency: frequency, Time: at},
+	}
+}
+
+func item(id, category string, at time.Time) DigestItem {
+	return DigestItem{NotificationID: id, Category: category, Title: id, CreatedAt: at}
+}
+
+func TestAggregator_DailyVsWeekly(t *testing.T) {
+	agg := NewAggregator(preferencesOf(map[string]*models.UserPreferences{
+		"daily":  digestPrefs(models.DigestDaily, "08:00", "UTC"),
+		"weekly": digestPrefs(models.DigestWeekly, "08:00", "UTC"),
+	}), nil)
+
+	// Wednesday and Thursday afternoon
+	wednesday := time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC)
+	thursday := time.Date(2025, 1, 16, 14, 0, 0, 0, time.UTC)
+	for _, userID := range []string{"daily", "weekly"} {
+		agg.Add(userID, item("n1", models.CategoryMarketing, wednesday))
+		agg.Add(userID, item("n2", models.CategoryReminders, wednesday.Add(time.Hour)))
+	}
+
+	batches := agg.Flush(time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC))
+	require.Len(t, batches, 1)
+	assert.Equal(t, "daily", batches[0].UserID)
+	assert.Equal(t, models.DigestDaily, batches[0].Frequency)
+	assert.Equal(t, 2, batches[0].ItemCount)
+	assert.Equal(t, 2, agg.Pending("weekly"))
+
+	for _, userID := range []string{"daily", "weekly"} {
+		agg.Add(userID, item("n3", models.CategoryMarketing, thursday))
+	}
+
+	batches = agg.Flush(time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC))
+	require.Len(t, batches, 2)
+	assert.Equal(t, "daily", batches[0].UserID)
+	assert.Equal(t, 1, batches[0].ItemCount)
+	assert.Equal(t, "weekly", batches[1].UserID)
+	assert.Equal(t, 3, batches[1].ItemCount)
+	assert.Equal(t, time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC), batches[1].ScheduledFor)
+	assert.Zero(t, agg.Pending("weekly"))
+}
+
+func TestAggregator_GroupsByCategory(t *testing.T) {
+	agg := NewAggregator(preferencesOf(map[string]*models.UserPreferences{
+		"usr_1": digestPrefs(models.DigestDaily, "08:00", ""),
+	}), nil)
+	at := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	agg.Add("usr_1", item("m1", models.CategoryMarketing, at))
+	agg.Add("usr_1", item("r1", models.CategoryReminders, at.Add(time.Minute)))
+	agg.Add("usr_1", item("m2", models.CategoryMarketing, at.Add(2*time.Minute)))
+
+	batches := agg.Flush(at.Add(24 * time.Hour))
+
+	require.Len(t, batches, 1)
+	marketing := batches[0].Categories[models.CategoryMarketing]
+	require.Len(t, marketing, 2)
+	assert.Equal(t, "m1", marketing[0].NotificationID)
+	assert.Equal(t, "m2", marketing[1].NotificationID)
+	assert.Len(t, batches[0].Categories[models.CategoryReminders], 1)
+}
+
+func TestAggregator_FlushesOnUserWallClock(t *testing.T) {
+	agg := NewAggregator(preferencesOf(map[string]*models.UserPreferences{
+		"nairobi":  digestPrefs(models.DigestDaily, "08:00", "Africa/Nairobi"),
+		"new_york": digestPrefs(models.DigestDaily, "08:00", "America/New_York"),
+	}), nil)
+	// After 08:00 on both wall clocks
+	added := time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC)
+	agg.Add("nairobi", item("n1", models.CategoryMarketing, added))
+	agg.Add("new_york", item("n1", models.CategoryMarketing, added))
+
+	// 08:00 in Nairobi is 05:00 UTC, 08:00 in New York is 13:00 UTC
+	assert.Empty(t, agg.Flush(time.Date(2025, 1, 16, 4, 59, 0, 0, time.UTC)))
+
+	batches := agg.Flush(time.Date(2025, 1, 16, 5, 0, 0, 0, time.UTC))
+	require.Len(t, batches, 1)
+	assert.Equal(t, "nairobi", batches[0].UserID)
+
+	assert.Empty(t, agg.Flush(time.Date(2025, 1, 16, 12, 59, 0, 0, time.UTC)))
+
+	batches = agg.Flush(time.Date(2025, 1, 16, 13, 0, 0, 0, time.UTC))
+	require.Len(t, batches, 1)
+	assert.Equal(t, "new_york", batches[0].UserID)
+}
+
+func TestAggregator_DisabledDigestDropsItems(t *testing.T) {
+	prefs := digestPrefs(models.DigestDaily, "08:00", "UTC")
+	prefs.Digest.Enabled = false
+	agg := NewAggregator(preferencesOf(map[string]*models.UserPreferences{"usr_1": prefs}), nil)
+	agg.Add("usr_1", item("n1", models.CategoryMarketing, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)))
+
+	batches := agg.Flush(time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC))
+
+	assert.Empty(t, batches)
+	assert.Zero(t, agg.Pending("usr_1"))
+}
+
+func TestAggregator_KeepsItemsWhenPreferencesFail(t *testing.T) {
+	calls := 0
+	agg := NewAggregator(func(userID string) (*models.UserPreferences, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("user service unavailable")
+		}
+		return digestPrefs(models.DigestDaily, "08:00", "UTC"), nil
+	}, nil)
+	agg.Add("usr_1", item("n1", models.CategoryMarketing, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)))
+	flushAt := time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, agg.Flush(flushAt))
+	assert.Equal(t, 1, agg.Pending("usr_1"))
+
+	assert.Len(t, agg.Flush(flushAt), 1)
+}
+
+func TestAggregator_MonthlyFlushesOnClampedDay(t *testing.T) {
+	prefs := digestPrefs(models.DigestMonthly, "08:00", "UTC")
+	prefs.Digest.DayOfMonth = 31
+	agg := NewAggregator(preferencesOf(map[string]*models.UserPreferences{"usr_1": prefs}), nil)
+	agg.Add("usr_1", item("n1", models.CategoryMarketing, time.Date(2025, 2, 3, 12, 0, 0, 0, time.UTC)))
+
+	assert.Empty(t, agg.Flush(time.Date(2025, 2, 27, 8, 0, 0, 0, time.UTC)))
+
+	batches := agg.Flush(time.Date(2025, 2, 28, 8, 0, 0, 0, time.UTC))
+	require.Len(t, batches, 1)
+	assert.Equal(t, models.DigestMonthly, batches[0].Frequency)
+}
@@ -0,0 +1,103 @@
+package frequency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+)
+
+// Store tracks when each user was last sent a notification on a channel.
+// Claim must check and record atomically so a shared backend such as Redis
+// can enforce one limit across orchestrator instances.
+type Store interface {
+	// Claim records now as the last send for key unless the previous one
+	// was less than period ago, reporting whether it did
+	Claim(key string, period time.Duration, now time.Time) bool
+
+	// Release drops the claim on key, so the next Claim succeeds
+	Release(key string)
+
+	// LastSent returns when key was last claimed
+	LastSent(key string) (time.Time, bool)
+}
+
+// Limiter holds non-immediate channels to one notification per period per
+// user, following the channel's Frequency setting. Notifications over the
+// limit are dropped, not batched into a later digest.
+type Limiter struct {
+	store Store
+	clock clock.Clock
+}
+
+// New returns a limiter backed by store, a MemoryStore when nil. A nil clk
+// uses the real clock.
+func New(store Store, clk clock.Clock) *Limiter {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Limiter{store: store, clock: clock.OrReal(clk)}
+}
+
+// Allow reports whether userID may be sent a notification on channel given
+// the channel's frequency, recording the send when it may. Immediate and
+// unknown frequencies are always allowed; ValidatePreferences rejects the
+// latter.
+func (l *Limiter) Allow(userID, channel, frequency string) bool {
+	period, err := models.FrequencyPeriod(frequency)
+	if err != nil || period <= 0 {
+		return true
+	}
+	return l.store.Claim(key(userID, channel), period, l.clock.Now())
+}
+
+// Forget gives back the send Allow recorded for userID on channel, for a
+// notification that was let through but then not sent
+func (l *Limiter) Forget(userID, channel string) {
+	l.store.Release(key(userID, channel))
+}
+
+func key(userID, channel string) string {
+	return userID + ":" + channel
+}
+
+// MemoryStore keeps last-sent times in process memory. Entries are never
+// evicted, which is fine for a bounded user base but not for unbounded keys.
+type MemoryStore struct {
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{lastSent: make(map[string]time.Time)}
+}
+
+// Claim implements Store
+func (s *MemoryStore) Claim(key string, period time.Duration, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.lastSent[key]; ok && now.Sub(last) < period {
+		return false
+	}
+	s.lastSent[key] = now
+	return true
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.lastSent, key)
+}
+
+// LastSent implements Store
+func (s *MemoryStore) LastSent(key string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last, ok := s.lastSent[key]
+	return last, ok
+}
@@ -0,0 +1,76 @@
+package frequency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter() (*Limiter, *MemoryStore, *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryStore()
+	return New(store, clk), store, clk
+}
+
+func TestLimiter_HourlySuppressesSecondSendWithinTheHour(t *testing.T) {
+	limiter, store, clk := newTestLimiter()
+	start := clk.Now()
+
+	assert.True(t, limiter.Allow("user-1", "email", models.FrequencyHourly))
+	clk.Advance(59 * time.Minute)
+	assert.False(t, limiter.Allow("user-1", "email", models.FrequencyHourly))
+
+	// The suppressed send does not push the period back
+	last, ok := store.LastSent("user-1:email")
+	assert.True(t, ok)
+	assert.Equal(t, start, last)
+
+	clk.Advance(time.Minute)
+	assert.True(t, limiter.Allow("user-1", "email", models.FrequencyHourly))
+}
+
+func TestLimiter_Daily(t *testing.T) {
+	limiter, _, clk := newTestLimiter()
+
+	assert.True(t, limiter.Allow("user-1", "push", models.FrequencyDaily))
+	clk.Advance(23 * time.Hour)
+	assert.False(t, limiter.Allow("user-1", "push", models.FrequencyDaily))
+	clk.Advance(time.Hour)
+	assert.True(t, limiter.Allow("user-1", "push", models.FrequencyDaily))
+}
+
+func TestLimiter_KeysByUserAndChannel(t *testing.T) {
+	limiter, _, _ := newTestLimiter()
+
+	assert.True(t, limiter.Allow("user-1", "email", models.FrequencyHourly))
+	assert.True(t, limiter.Allow("user-1", "push", models.FrequencyHourly))
+	assert.True(t, limiter.Allow("user-2", "email", models.FrequencyHourly))
+	assert.False(t, limiter.Allow("user-1", "email", models.FrequencyHourly))
+}
+
+func TestLimiter_ImmediateIsNeverLimited(t *testing.T) {
+	limiter, store, _ := newTestLimiter()
+
+	for _, frequency := range []string{models.FrequencyImmediate, "", "fortnightly"} {
+		for i := 0; i < 3; i++ {
+			assert.True(t, limiter.Allow("user-1", "email", frequency))
+		}
+	}
+	_, ok := store.LastSent("user-1:email")
+	assert.False(t, ok)
+}
+
+func TestLimiter_ForgetReleasesTheSend(t *testing.T) {
+	limiter, store, _ := newTestLimiter()
+
+	assert.True(t, limiter.Allow("user-1", "email", models.FrequencyDaily))
+	limiter.Forget("user-1", "email")
+
+	_, ok := store.LastSent("user-1:email")
+	assert.False(t, ok)
+	assert.True(t, limiter.Allow("user-1", "email", models.FrequencyDaily))
+	assert.False(t, limiter.Allow("user-1", "email", models.FrequencyDaily))
+}
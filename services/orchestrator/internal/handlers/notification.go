@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -56,6 +58,9 @@ func (h *NotificationHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+	if req.CorrelationID == "" {
+		req.CorrelationID = c.GetHeader(correlation.Header)
+	}
 
 	// Check for idempotency - use the RequestID field as the idempotency key
 	ctx := context.Background()
@@ -92,7 +97,17 @@ func (h *NotificationHandler) Create(c *gin.Context) {
 			zap.String("request_id", requestID.(string)),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, models.Response{
+		status := http.StatusInternalServerError
+		var validationErr *models.ValidationError
+		switch {
+		case errors.Is(err, models.ErrShuttingDown):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, models.ErrRequestInProgress):
+			status = http.StatusConflict
+		case errors.As(err, &validationErr):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.Response{
 			Success: false,
 			Message: "Failed to process notification",
 			Error:   err.Error(),
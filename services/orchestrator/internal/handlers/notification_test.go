@@ -262,6 +262,71 @@ func TestNotificationHandler_Create_ProcessingError(t *testing.T) {
 	mockIdem.AssertExpectations(t)
 }
 
+func TestNotificationHandler_Create_ValidationError(t *testing.T) {
+	mockOrch := new(MockOrchestrationService)
+	mockIdem := new(MockIdempotencyService)
+
+	notifRequest := &models.NotificationRequest{
+		RequestID:        "req-123",
+		UserID:           "user-456",
+		NotificationType: models.NotificationEmail,
+		TemplateCode:     "test_template",
+	}
+
+	mockIdem.On("GetCachedResponse", mock.Anything, "req-123").Return(nil, nil)
+	mockOrch.On("ProcessNotification", notifRequest).Return(nil, &models.ValidationError{Err: errors.New("category: unknown category \"promo\"")})
+
+	handler := NewNotificationHandler(mockOrch, mockIdem)
+	router := setupNotificationTestRouter()
+	router.POST("/notifications", handler.Create)
+
+	body, _ := json.Marshal(notifRequest)
+	req, _ := http.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Response
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Contains(t, response.Error, "unknown category")
+	mockIdem.AssertNotCalled(t, "StoreResponse")
+}
+
+func TestNotificationHandler_Create_RequestInProgress(t *testing.T) {
+	mockOrch := new(MockOrchestrationService)
+	mockIdem := new(MockIdempotencyService)
+
+	notifRequest := &models.NotificationRequest{
+		RequestID:        "req-123",
+		UserID:           "user-456",
+		NotificationType: models.NotificationEmail,
+		TemplateCode:     "test_template",
+		IdempotencyKey:   "order-789-shipped",
+	}
+
+	mockIdem.On("GetCachedResponse", mock.Anything, "req-123").Return(nil, nil)
+	mockOrch.On("ProcessNotification", notifRequest).Return(nil, models.ErrRequestInProgress)
+
+	handler := NewNotificationHandler(mockOrch, mockIdem)
+	router := setupNotificationTestRouter()
+	router.POST("/notifications", handler.Create)
+
+	body, _ := json.Marshal(notifRequest)
+	req, _ := http.NewRequest(http.MethodPost, "/notifications", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockIdem.AssertNotCalled(t, "StoreResponse")
+}
+
 func TestNotificationHandler_Create_IdempotencyCheckError(t *testing.T) {
 	mockOrch := new(MockOrchestrationService)
 	mockIdem := new(MockIdempotencyService)
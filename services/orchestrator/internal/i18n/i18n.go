@@ -0,0 +1,174 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is used for keys missing from the requested locale
+const DefaultLanguage = "en"
+
+// Message is a translated string with optional plural forms. Strings are
+// fmt format strings. Other is used for every count that is not one; a
+// message without plural forms only sets Other.
+type Message struct {
+	One   string `json:"one,omitempty"`
+	Other string `json:"other"`
+}
+
+// UnmarshalJSON accepts either a plain string or an object with "one" and
+// "other" forms
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = Message{Other: text}
+		return nil
+	}
+
+	type plain Message
+	var forms plain
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return err
+	}
+	*m = Message(forms)
+	return nil
+}
+
+// Catalog maps message keys to one locale's messages
+type Catalog map[string]Message
+
+// Bundle holds message catalogs by language. It is safe for concurrent use.
+type Bundle struct {
+	mu       sync.RWMutex
+	catalogs map[string]Catalog
+}
+
+// NewBundle returns a bundle with no catalogs
+func NewBundle() *Bundle {
+	return &Bundle{catalogs: make(map[string]Catalog)}
+}
+
+// AddCatalog merges catalog into the messages held for lang
+func (b *Bundle) AddCatalog(lang string, catalog Catalog) {
+	lang = strings.ToLower(lang)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	existing, ok := b.catalogs[lang]
+	if !ok {
+		existing = make(Catalog, len(catalog))
+		b.catalogs[lang] = existing
+	}
+	for key, msg := range catalog {
+		existing[key] = msg
+	}
+}
+
+// LoadJSON parses a JSON catalog, e.g.
+// {"greeting": "Hello %s", "new_messages": {"one": "%d new message", "other": "%d new messages"}},
+// and adds it for lang
+func (b *Bundle) LoadJSON(lang string, data []byte) error {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return fmt.Errorf("parse %s catalog: %w", lang, err)
+	}
+	b.AddCatalog(lang, catalog)
+	return nil
+}
+
+// LoadDir loads every <lang>.json catalog in dir, e.g. en.json and sw.json
+func (b *Bundle) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read catalog: %w", err)
+		}
+		lang := strings.TrimSuffix(filepath.Base(path), ".json")
+		if err := b.LoadJSON(lang, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Translate formats the message for key in lang with args. It tries the
+// exact language, then its base language (sw for sw-KE), then
+// DefaultLanguage, and returns the key itself when no catalog has it.
+func (b *Bundle) Translate(lang, key string, args ...interface{}) string {
+	msg, _, ok := b.lookup(lang, key)
+	if !ok {
+		return key
+	}
+	return format(msg.Other, args)
+}
+
+// Plural formats the form of key that matches count in lang. count is
+// passed to the format string first, followed by args, so "%d new messages"
+// renders as "3 new messages". Missing keys fall back as in Translate.
+func (b *Bundle) Plural(lang, key string, count int, args ...interface{}) string {
+	msg, found, ok := b.lookup(lang, key)
+	if !ok {
+		return key
+	}
+
+	text := msg.Other
+	if msg.One != "" && isOne(found, count) {
+		text = msg.One
+	}
+	return format(text, append([]interface{}{count}, args...))
+}
+
+// lookup finds key in the most specific catalog that has it and returns
+// that catalog's language
+func (b *Bundle) lookup(lang, key string) (Message, string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, candidate := range fallbacks(lang) {
+		if msg, ok := b.catalogs[candidate][key]; ok {
+			return msg, candidate, true
+		}
+	}
+	return Message{}, "", false
+}
+
+// fallbacks lists the languages to try for lang, most specific first
+func fallbacks(lang string) []string {
+	lang = strings.ToLower(lang)
+	candidates := []string{}
+	if lang != "" {
+		candidates = append(candidates, lang)
+		if base, _, found := strings.Cut(lang, "-"); found {
+			candidates = append(candidates, base)
+		}
+	}
+	return append(candidates, DefaultLanguage)
+}
+
+// isOne reports whether count takes the singular form in lang. French and
+// Portuguese treat zero as singular too.
+func isOne(lang string, count int) bool {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	switch base {
+	case "fr", "pt":
+		return count == 0 || count == 1
+	default:
+		return count == 1
+	}
+}
+
+func format(text string, args []interface{}) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
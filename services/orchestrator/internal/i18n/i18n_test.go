@@ -0,0 +1,97 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	b := NewBundle()
+	require.NoError(t, b.LoadJSON("en", []byte(`{
+		"greeting": "Hello %s",
+		"footer": "Sent by %s",
+		"new_messages": {"one": "%d new message", "other": "%d new messages"}
+	}`)))
+	require.NoError(t, b.LoadJSON("sw", []byte(`{
+		"greeting": "Habari %s",
+		"new_messages": {"one": "Ujumbe %d mpya", "other": "Jumbe %d mpya"}
+	}`)))
+	require.NoError(t, b.LoadJSON("fr", []byte(`{
+		"new_messages": {"one": "%d nouveau message", "other": "%d nouveaux messages"}
+	}`)))
+	return b
+}
+
+func TestBundle_TranslateLoadedLocale(t *testing.T) {
+	b := newTestBundle(t)
+
+	assert.Equal(t, "Habari Amina", b.Translate("sw", "greeting", "Amina"))
+	assert.Equal(t, "Hello Amina", b.Translate("en", "greeting", "Amina"))
+}
+
+func TestBundle_TranslateFallback(t *testing.T) {
+	b := newTestBundle(t)
+
+	// Region falls back to the base language
+	assert.Equal(t, "Habari Amina", b.Translate("sw-KE", "greeting", "Amina"))
+	// Missing key falls back to English
+	assert.Equal(t, "Sent by Acme", b.Translate("sw", "footer", "Acme"))
+	// Missing locale falls back to English
+	assert.Equal(t, "Hello Amina", b.Translate("ar", "greeting", "Amina"))
+	assert.Equal(t, "Hello Amina", b.Translate("", "greeting", "Amina"))
+	// Unknown keys come back as they are
+	assert.Equal(t, "order.shipped", b.Translate("sw", "order.shipped"))
+}
+
+func TestBundle_Plural(t *testing.T) {
+	b := newTestBundle(t)
+
+	tests := []struct {
+		lang     string
+		count    int
+		expected string
+	}{
+		{lang: "en", count: 1, expected: "1 new message"},
+		{lang: "en", count: 3, expected: "3 new messages"},
+		{lang: "en", count: 0, expected: "0 new messages"},
+		{lang: "sw", count: 1, expected: "Ujumbe 1 mpya"},
+		{lang: "sw", count: 5, expected: "Jumbe 5 mpya"},
+		{lang: "fr", count: 0, expected: "0 nouveau message"},
+		{lang: "fr", count: 2, expected: "2 nouveaux messages"},
+		// Portuguese has no catalog, so English rules apply to the English text
+		{lang: "pt", count: 0, expected: "0 new messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			assert.Equal(t, tt.expected, b.Plural(tt.lang, "new_messages", tt.count))
+		})
+	}
+}
+
+func TestBundle_PluralWithoutForms(t *testing.T) {
+	b := NewBundle()
+	b.AddCatalog("en", Catalog{"unread": {Other: "%d unread"}})
+
+	assert.Equal(t, "1 unread", b.Plural("en", "unread", 1))
+	assert.Equal(t, "4 unread", b.Plural("en", "unread", 4))
+}
+
+func TestBundle_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sw.json"), []byte(`{"greeting": "Habari %s"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600))
+
+	b := NewBundle()
+	require.NoError(t, b.LoadDir(dir))
+
+	assert.Equal(t, "Habari Amina", b.Translate("sw", "greeting", "Amina"))
+}
+
+func TestBundle_LoadJSONInvalid(t *testing.T) {
+	assert.Error(t, NewBundle().LoadJSON("en", []byte(`{"greeting": 42}`)))
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate picks the locale in available that best serves a user who
+// prefers preferred, so rendering always resolves a locale. preferred is a
+// language tag such as "pt-BR" or an Accept-Language value such as
+// "pt-BR,pt;q=0.9,en;q=0.8". Each preferred tag, highest weight first, is
+// tried exactly and then with subtags dropped from the end (pt for pt-BR);
+// when nothing matches, DefaultLanguage is returned. Matching ignores case
+// and treats "_" as "-", and the result is spelled as it is in available.
+func Negotiate(preferred string, available []string) string {
+	byTag := make(map[string]string, len(available))
+	for _, locale := range available {
+		tag := normalizeTag(locale)
+		if _, ok := byTag[tag]; !ok {
+			byTag[tag] = locale
+		}
+	}
+
+	for _, tag := range preferredTags(preferred) {
+		for candidate := tag; candidate != ""; candidate = parentTag(candidate) {
+			if locale, ok := byTag[candidate]; ok {
+				return locale
+			}
+		}
+	}
+	if locale, ok := byTag[DefaultLanguage]; ok {
+		return locale
+	}
+	return DefaultLanguage
+}
+
+// preferredTags parses an Accept-Language style list into normalized tags,
+// highest weight first. Tags with q=0 and the "*" wildcard are dropped;
+// the wildcard's job is done by the DefaultLanguage fallback.
+func preferredTags(preferred string) []string {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(preferred, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = normalizeTag(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if weight <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, weight: weight})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].weight > tags[j].weight })
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// parentTag drops the last subtag, e.g. zh-hant for zh-hant-tw, and returns
+// "" for a bare language
+func parentTag(tag string) string {
+	i := strings.LastIndex(tag, "-")
+	if i < 0 {
+		return ""
+	}
+	return tag[:i]
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
@@ -0,0 +1,38 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	available := []string{"en", "sw", "pt", "pt-BR", "zh-Hant"}
+
+	tests := []struct {
+		name      string
+		preferred string
+		expected  string
+	}{
+		{name: "exact match", preferred: "pt-BR", expected: "pt-BR"},
+		{name: "exact match ignores case and underscores", preferred: "pt_br", expected: "pt-BR"},
+		{name: "base language fallback", preferred: "sw-KE", expected: "sw"},
+		{name: "drops subtags one at a time", preferred: "zh-Hant-TW", expected: "zh-Hant"},
+		{name: "default fallback", preferred: "fr-FR", expected: "en"},
+		{name: "empty preference", preferred: "", expected: "en"},
+		{name: "accept-language weights", preferred: "fr;q=0.9,sw;q=0.5,pt-PT", expected: "pt"},
+		{name: "skips zero weight and wildcard", preferred: "pt;q=0,*,sw;q=0.1", expected: "sw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Negotiate(tt.preferred, available))
+		})
+	}
+}
+
+func TestNegotiate_DefaultNotAvailable(t *testing.T) {
+	assert.Equal(t, "sw", Negotiate("sw-TZ", []string{"sw"}))
+	assert.Equal(t, DefaultLanguage, Negotiate("fr", []string{"sw"}))
+	assert.Equal(t, DefaultLanguage, Negotiate("fr", nil))
+}
@@ -0,0 +1,174 @@
+// Package maintenance holds background jobs that keep user data tidy
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/metrics"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DevicePruner defaults
+const (
+	DefaultDeviceMaxAge  = 90 * 24 * time.Hour
+	DefaultPruneInterval = 24 * time.Hour
+	DefaultPruneBatch    = 100
+)
+
+// UserService is the part of the user service the pruner needs.
+// clients.UserService implements it.
+type UserService interface {
+	GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error)
+	RemoveDevice(ctx context.Context, userID, deviceID string) error
+}
+
+// UserLister reports which users to scan for stale devices
+type UserLister interface {
+	ListUserIDs(ctx context.Context) ([]string, error)
+}
+
+// Metrics counts pruned devices. metrics.Prometheus implements it.
+type Metrics interface {
+	DevicePruned(platform string)
+}
+
+// DevicePrunerConfig configures a DevicePruner
+type DevicePrunerConfig struct {
+	Users  UserService
+	Lister UserLister
+	// MaxAge is how long a device may go unseen, by LastSeen or else
+	// CreatedAt, before it is removed. Defaults to DefaultDeviceMaxAge.
+	MaxAge time.Duration
+	// Interval is how often Run prunes. Defaults to DefaultPruneInterval.
+	Interval time.Duration
+	// BatchSize is how many users each GetPreferencesBulk call fetches.
+	// Defaults to DefaultPruneBatch.
+	BatchSize int
+	Metrics   Metrics     // defaults to metrics.Noop
+	Clock     clock.Clock // defaults to the real clock
+}
+
+// DevicePruner removes push devices that have not been seen for a while,
+// so fan-out does not keep sending to phones that were lost, reset or
+// uninstalled the app
+type DevicePruner struct {
+	users     UserService
+	lister    UserLister
+	maxAge    time.Duration
+	interval  time.Duration
+	batchSize int
+	metrics   Metrics
+	clock     clock.Clock
+}
+
+func NewDevicePruner(cfg DevicePrunerConfig) *DevicePruner {
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultDeviceMaxAge
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultPruneInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultPruneBatch
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = metrics.Noop{}
+	}
+
+	return &DevicePruner{
+		users:     cfg.Users,
+		lister:    cfg.Lister,
+		maxAge:    cfg.MaxAge,
+		interval:  cfg.Interval,
+		batchSize: cfg.BatchSize,
+		metrics:   cfg.Metrics,
+		clock:     clock.OrReal(cfg.Clock),
+	}
+}
+
+// Prune scans every listed user once and removes their stale devices,
+// returning how many were removed. Devices that are already gone are
+// skipped and not counted. Users whose preferences or devices fail are
+// reported in the joined error without stopping the scan, which ends early
+// only when ctx is done.
+func (p *DevicePruner) Prune(ctx context.Context) (int, error) {
+	userIDs, err := p.lister.ListUserIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	now := p.clock.Now()
+	pruned := 0
+	var errs []error
+	for start := 0; start < len(userIDs); start += p.batchSize {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		batch := userIDs[start:min(start+p.batchSize, len(userIDs))]
+		prefs, err := p.users.GetPreferencesBulk(ctx, batch)
+		if err != nil {
+			// Partial results are still pruned
+			errs = append(errs, err)
+		}
+
+		for _, userID := range batch {
+			userPrefs, ok := prefs[userID]
+			if !ok || userPrefs == nil {
+				continue
+			}
+			// Work on a copy so the caller's preferences are left alone
+			push := userPrefs.Channels.Push
+			for _, device := range push.PruneStaleDevicesAt(p.maxAge, now) {
+				err := p.users.RemoveDevice(ctx, userID, device.DeviceID)
+				if errors.Is(err, models.ErrDeviceNotFound) {
+					continue
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: failed to remove device %s: %w", userID, device.DeviceID, err))
+					continue
+				}
+				pruned++
+				p.metrics.DevicePruned(device.Platform)
+			}
+		}
+	}
+	return pruned, errors.Join(errs...)
+}
+
+// Run prunes straight away and then every interval until ctx is cancelled
+func (p *DevicePruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.pruneAndLog(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *DevicePruner) pruneAndLog(ctx context.Context) {
+	pruned, err := p.Prune(ctx)
+	if err != nil && ctx.Err() == nil {
+		logger.Log.Error("Failed to prune stale devices",
+			zap.Int("pruned", pruned),
+			zap.Error(err),
+		)
+		return
+	}
+	if pruned > 0 {
+		logger.Log.Info("Pruned stale devices", zap.Int("pruned", pruned))
+	}
+}
@@ -0,0 +1,227 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/clients"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/metrics"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ UserService = (clients.UserService)(nil)
+	_ Metrics     = (*metrics.Prometheus)(nil)
+	_ Metrics     = metrics.Noop{}
+)
+
+// MockUserService mocks the UserService interface
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	args := m.Called(ctx, userIDs)
+	prefs, _ := args.Get(0).(map[string]*models.UserPreferences)
+	return prefs, args.Error(1)
+}
+
+func (m *MockUserService) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	return m.Called(ctx, userID, deviceID).Error(0)
+}
+
+type staticLister []string
+
+func (l staticLister) ListUserIDs(ctx context.Context) ([]string, error) {
+	return l, ctx.Err()
+}
+
+// countingLister lists one user and counts how often it was asked
+type countingLister struct {
+	calls atomic.Int32
+}
+
+func (l *countingLister) ListUserIDs(ctx context.Context) ([]string, error) {
+	l.calls.Add(1)
+	return []string{"user-1"}, nil
+}
+
+type recordingMetrics struct {
+	mu     sync.Mutex
+	pruned []string
+}
+
+func (r *recordingMetrics) DevicePruned(platform string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruned = append(r.pruned, platform)
+}
+
+var now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func daysAgo(days int) *time.Time {
+	t := now.AddDate(0, 0, -days)
+	return &t
+}
+
+func pushPrefs(devices ...models.UserDevice) *models.UserPreferences {
+	return &models.UserPreferences{Channels: models.Channels{Push: models.PushChannel{Enabled: true, Devices: devices}}}
+}
+
+func TestDevicePruner_RemovesStaleKeepsFresh(t *testing.T) {
+	users := new(MockUserService)
+	recorder := &recordingMetrics{}
+	pruner := NewDevicePruner(DevicePrunerConfig{
+		Users:   users,
+		Lister:  staticLister{"user-1", "user-2"},
+		MaxAge:  30 * 24 * time.Hour,
+		Metrics: recorder,
+		Clock:   clocktest.NewFakeClock(now),
+	})
+
+	user1 := pushPrefs(
+		models.UserDevice{DeviceID: "old-phone", Platform: "android", LastSeen: daysAgo(45), CreatedAt: now.AddDate(-1, 0, 0)},
+		models.UserDevice{DeviceID: "new-phone", Platform: "ios", LastSeen: daysAgo(2), CreatedAt: now.AddDate(-1, 0, 0)},
+	)
+	user2 := pushPrefs(
+		// Never seen, so judged by when it was registered
+		models.UserDevice{DeviceID: "tablet", Platform: "web", CreatedAt: now.AddDate(0, 0, -31)},
+		models.UserDevice{DeviceID: "laptop", Platform: "web", CreatedAt: now.AddDate(0, 0, -29)},
+	)
+	users.On("GetPreferencesBulk", mock.Anything, []string{"user-1", "user-2"}).
+		Return(map[string]*models.UserPreferences{"user-1": user1, "user-2": user2}, nil)
+	users.On("RemoveDevice", mock.Anything, "user-1", "old-phone").Return(nil)
+	users.On("RemoveDevice", mock.Anything, "user-2", "tablet").Return(nil)
+
+	pruned, err := pruner.Prune(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	assert.Equal(t, []string{"android", "web"}, recorder.pruned)
+	users.AssertExpectations(t)
+	users.AssertNotCalled(t, "RemoveDevice", mock.Anything, "user-1", "new-phone")
+	users.AssertNotCalled(t, "RemoveDevice", mock.Anything, "user-2", "laptop")
+	// The fetched preferences are not modified
+	assert.Len(t, user1.Channels.Push.Devices, 2)
+}
+
+func TestDevicePruner_FakeClockAges(t *testing.T) {
+	users := new(MockUserService)
+	clk := clocktest.NewFakeClock(now)
+	pruner := NewDevicePruner(DevicePrunerConfig{Users: users, Lister: staticLister{"user-1"}, MaxAge: 7 * 24 * time.Hour, Clock: clk})
+
+	prefs := pushPrefs(models.UserDevice{DeviceID: "phone", Platform: "ios", LastSeen: daysAgo(5)})
+	users.On("GetPreferencesBulk", mock.Anything, []string{"user-1"}).
+		Return(map[string]*models.UserPreferences{"user-1": prefs}, nil)
+	users.On("RemoveDevice", mock.Anything, "user-1", "phone").Return(nil)
+
+	pruned, err := pruner.Prune(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+	users.AssertNotCalled(t, "RemoveDevice", mock.Anything, mock.Anything, mock.Anything)
+
+	clk.Advance(3 * 24 * time.Hour)
+	pruned, err = pruner.Prune(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	users.AssertCalled(t, "RemoveDevice", mock.Anything, "user-1", "phone")
+}
+
+func TestDevicePruner_Batches(t *testing.T) {
+	users := new(MockUserService)
+	pruner := NewDevicePruner(DevicePrunerConfig{
+		Users:     users,
+		Lister:    staticLister{"user-1", "user-2", "user-3"},
+		BatchSize: 2,
+		Clock:     clocktest.NewFakeClock(now),
+	})
+	users.On("GetPreferencesBulk", mock.Anything, []string{"user-1", "user-2"}).Return(map[string]*models.UserPreferences{}, nil).Once()
+	users.On("GetPreferencesBulk", mock.Anything, []string{"user-3"}).Return(map[string]*models.UserPreferences{}, nil).Once()
+
+	pruned, err := pruner.Prune(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+	users.AssertExpectations(t)
+}
+
+func TestDevicePruner_ContinuesPastFailures(t *testing.T) {
+	users := new(MockUserService)
+	pruner := NewDevicePruner(DevicePrunerConfig{
+		Users:  users,
+		Lister: staticLister{"user-1", "user-2", "user-3"},
+		MaxAge: 24 * time.Hour,
+		Clock:  clocktest.NewFakeClock(now),
+	})
+	stale := func(ids ...string) *models.UserPreferences {
+		devices := make([]models.UserDevice, len(ids))
+		for i, id := range ids {
+			devices[i] = models.UserDevice{DeviceID: id, Platform: "android", LastSeen: daysAgo(10)}
+		}
+		return pushPrefs(devices...)
+	}
+	bulkErr := &models.BulkError{Errors: map[string]error{"user-3": models.ErrUserNotFound}}
+	users.On("GetPreferencesBulk", mock.Anything, []string{"user-1", "user-2", "user-3"}).
+		Return(map[string]*models.UserPreferences{"user-1": stale("a", "b"), "user-2": stale("c")}, bulkErr)
+	users.On("RemoveDevice", mock.Anything, "user-1", "a").Return(errors.New("user service returned 503"))
+	users.On("RemoveDevice", mock.Anything, "user-1", "b").Return(fmt.Errorf("%w: b", models.ErrDeviceNotFound))
+	users.On("RemoveDevice", mock.Anything, "user-2", "c").Return(nil)
+
+	pruned, err := pruner.Prune(context.Background())
+
+	require.Error(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.Contains(t, err.Error(), "user-1: failed to remove device a")
+	var partial *models.BulkError
+	assert.True(t, errors.As(err, &partial))
+	users.AssertExpectations(t)
+}
+
+func TestDevicePruner_RunStopsOnCancel(t *testing.T) {
+	users := new(MockUserService)
+	users.On("GetPreferencesBulk", mock.Anything, mock.Anything).Return(map[string]*models.UserPreferences{}, nil)
+	lister := &countingLister{}
+	pruner := NewDevicePruner(DevicePrunerConfig{
+		Users:    users,
+		Lister:   lister,
+		Interval: time.Millisecond,
+		Clock:    clocktest.NewFakeClock(now),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pruner.Run(ctx)
+		close(done)
+	}()
+
+	// Run prunes straight away and then on every tick
+	require.Eventually(t, func() bool {
+		return lister.calls.Load() >= 2
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
+
+func TestNewDevicePruner_Defaults(t *testing.T) {
+	pruner := NewDevicePruner(DevicePrunerConfig{})
+
+	assert.Equal(t, DefaultDeviceMaxAge, pruner.maxAge)
+	assert.Equal(t, DefaultPruneInterval, pruner.interval)
+	assert.Equal(t, DefaultPruneBatch, pruner.batchSize)
+	assert.Equal(t, metrics.Noop{}, pruner.metrics)
+}
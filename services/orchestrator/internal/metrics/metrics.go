@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons a notification is suppressed or deferred
+const (
+	ReasonChannelDisabled = "channel_disabled"
+	ReasonOptOut          = "opt_out"
+	ReasonRateLimited     = "rate_limited"
+	ReasonDuplicate       = "duplicate"
+	ReasonQuietHours      = "quiet_hours"
+	ReasonThrottled       = "throttled"
+	ReasonFrequency       = "frequency"
+	ReasonUserNotFound    = "user_not_found"
+)
+
+// Metrics records what the orchestrator decides about each notification.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Processed counts a notification entering the pipeline
+	Processed(channel string)
+	// Suppressed counts a notification dropped by a gate, e.g. ReasonOptOut
+	Suppressed(channel, reason string)
+	// Deferred counts a notification held back for later, e.g.
+	// ReasonQuietHours
+	Deferred(channel, reason string)
+	// Delivered counts a notification published on channel
+	Delivered(channel string)
+	// Failed counts a notification that could not be published
+	Failed(channel string)
+	// ObservePublish records how long publishing on channel took, retries
+	// included
+	ObservePublish(channel string, elapsed time.Duration)
+}
+
+// Noop discards everything. It is the orchestrator's default.
+type Noop struct{}
+
+func (Noop) Processed(string)                     {}
+func (Noop) Suppressed(string, string)            {}
+func (Noop) Deferred(string, string)              {}
+func (Noop) Delivered(string)                     {}
+func (Noop) Failed(string)                        {}
+func (Noop) ObservePublish(string, time.Duration) {}
+func (Noop) DevicePruned(string)                  {}
+
+// Prometheus exports the orchestrator metrics as Prometheus collectors
+type Prometheus struct {
+	processed  *prometheus.CounterVec
+	suppressed *prometheus.CounterVec
+	deferred   *prometheus.CounterVec
+	delivered  *prometheus.CounterVec
+	failed     *prometheus.CounterVec
+	publish    *prometheus.HistogramVec
+	pruned     *prometheus.CounterVec
+}
+
+// NewPrometheus creates the collectors; call Register to expose them
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_notifications_processed_total",
+			Help: "Total number of notifications that entered the orchestrator pipeline.",
+		}, []string{"channel"}),
+		suppressed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_notifications_suppressed_total",
+			Help: "Total number of notifications dropped before publishing, by reason.",
+		}, []string{"channel", "reason"}),
+		deferred: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_notifications_deferred_total",
+			Help: "Total number of notifications held back for later delivery, by reason.",
+		}, []string{"channel", "reason"}),
+		delivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_notifications_delivered_total",
+			Help: "Total number of notifications published to a channel queue.",
+		}, []string{"channel"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_notifications_failed_total",
+			Help: "Total number of notifications that could not be published.",
+		}, []string{"channel"}),
+		publish: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "orchestrator_publish_duration_seconds",
+			Help:    "Time taken to publish a notification, including retries.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"channel"}),
+		pruned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_devices_pruned_total",
+			Help: "Total number of push devices removed for not being seen recently, by platform.",
+		}, []string{"platform"}),
+	}
+}
+
+// Register registers every collector on reg
+func (p *Prometheus) Register(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{p.processed, p.suppressed, p.deferred, p.delivered, p.failed, p.publish, p.pruned} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Prometheus) Processed(channel string) {
+	p.processed.WithLabelValues(channel).Inc()
+}
+
+func (p *Prometheus) Suppressed(channel, reason string) {
+	p.suppressed.WithLabelValues(channel, reason).Inc()
+}
+
+func (p *Prometheus) Deferred(channel, reason string) {
+	p.deferred.WithLabelValues(channel, reason).Inc()
+}
+
+func (p *Prometheus) Delivered(channel string) {
+	p.delivered.WithLabelValues(channel).Inc()
+}
+
+func (p *Prometheus) Failed(channel string) {
+	p.failed.WithLabelValues(channel).Inc()
+}
+
+func (p *Prometheus) ObservePublish(channel string, elapsed time.Duration) {
+	p.publish.WithLabelValues(channel).Observe(elapsed.Seconds())
+}
+
+// DevicePruned counts a stale push device removed on platform
+func (p *Prometheus) DevicePruned(platform string) {
+	p.pruned.WithLabelValues(platform).Inc()
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheus_RecordsCounters(t *testing.T) {
+	m := NewPrometheus()
+
+	m.Processed("email")
+	m.Processed("email")
+	m.Suppressed("email", ReasonOptOut)
+	m.Deferred("push", ReasonQuietHours)
+	m.Delivered("email")
+	m.Failed("push")
+	m.ObservePublish("email", 20*time.Millisecond)
+	m.DevicePruned("android")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.processed.WithLabelValues("email")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.suppressed.WithLabelValues("email", ReasonOptOut)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.suppressed.WithLabelValues("email", ReasonDuplicate)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.deferred.WithLabelValues("push", ReasonQuietHours)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.delivered.WithLabelValues("email")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.failed.WithLabelValues("push")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.publish))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.pruned.WithLabelValues("android")))
+}
+
+func TestPrometheus_Register(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewPrometheus()
+
+	require.NoError(t, m.Register(reg))
+	// The same collectors cannot be registered twice
+	assert.Error(t, m.Register(reg))
+}
@@ -1,10 +1,13 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
@@ -19,6 +22,23 @@ type UserServiceMock struct {
 	delayMax         time.Duration
 	requestCount     int
 	failureThreshold int // Fail after N successful requests
+	maxDevices       int
+
+	// Preferences written with UpdatePreferences, responses scripted with
+	// the With methods and users removed with DeleteUserData, keyed by user
+	// ID
+	mu       sync.Mutex
+	stored   map[string]*models.UserPreferences
+	scripted map[string]*scriptedUser
+	deleted  map[string]bool
+}
+
+// scriptedUser holds the responses set up for one user. Nil fields fall back
+// to the pattern-based responses for that user ID.
+type scriptedUser struct {
+	prefs  *models.UserPreferences
+	optOut *models.OptOutStatus
+	err    error
 }
 
 // MockBehaviorConfig allows configuring mock behavior
@@ -29,6 +49,7 @@ type MockBehaviorConfig struct {
 	DelayMin         time.Duration
 	DelayMax         time.Duration
 	FailureThreshold int // Fail after N requests (for circuit breaker testing)
+	MaxDevices       int // Push devices per user, defaults to models.DefaultMaxDevices
 }
 
 func NewUserServiceMock() *UserServiceMock {
@@ -77,16 +98,71 @@ func NewUserServiceMockWithConfig(cfg MockBehaviorConfig) *UserServiceMock {
 		delayMax:         cfg.DelayMax,
 		requestCount:     0,
 		failureThreshold: cfg.FailureThreshold,
+		maxDevices:       cfg.MaxDevices,
+	}
+}
+
+// WithPreferences scripts the preferences returned for userID. Once any
+// user is scripted the mock stops matching ID patterns, and users that were
+// not scripted get models.ErrUserNotFound.
+func (m *UserServiceMock) WithPreferences(userID string, p *models.UserPreferences) *UserServiceMock {
+	prefs := *p
+	m.script(userID, func(u *scriptedUser) { u.prefs = &prefs })
+	return m
+}
+
+// WithOptOut scripts the opt-out status returned for userID. See
+// WithPreferences.
+func (m *UserServiceMock) WithOptOut(userID string, o *models.OptOutStatus) *UserServiceMock {
+	status := *o
+	m.script(userID, func(u *scriptedUser) { u.optOut = &status })
+	return m
+}
+
+// WithError makes every lookup for userID fail with err. See
+// WithPreferences.
+func (m *UserServiceMock) WithError(userID string, err error) *UserServiceMock {
+	m.script(userID, func(u *scriptedUser) { u.err = err })
+	return m
+}
+
+func (m *UserServiceMock) script(userID string, set func(*scriptedUser)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scripted == nil {
+		m.scripted = make(map[string]*scriptedUser)
+	}
+	user, ok := m.scripted[userID]
+	if !ok {
+		user = &scriptedUser{}
+		m.scripted[userID] = user
 	}
+	set(user)
 }
 
-func (m *UserServiceMock) GetPreferences(userID string) (*models.UserPreferences, error) {
+// scriptedResponse returns the user's scripted responses, and whether the
+// mock is in scripted mode at all
+func (m *UserServiceMock) scriptedResponse(userID string) (*scriptedUser, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scripted == nil {
+		return nil, false
+	}
+	return m.scripted[userID], true
+}
+
+func (m *UserServiceMock) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	m.requestCount++
 
 	// Simulate network delay (realistic latency)
 	if m.simulateDelay {
 		delay := m.delayMin + time.Duration(rand.Int63n(int64(m.delayMax-m.delayMin)))
-		time.Sleep(delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
 	}
 
 	// Simulate failure threshold (for circuit breaker testing)
@@ -99,6 +175,27 @@ func (m *UserServiceMock) GetPreferences(userID string) (*models.UserPreferences
 		return m.simulateError(userID)
 	}
 
+	// Preferences written with UpdatePreferences take precedence, and
+	// deleted users are gone whatever their ID
+	if m.isDeleted(userID) {
+		return nil, fmt.Errorf("%w: %s", models.ErrUserNotFound, userID)
+	}
+	if prefs, ok := m.storedPreferences(userID); ok {
+		return prefs, nil
+	}
+
+	if user, scripted := m.scriptedResponse(userID); scripted {
+		switch {
+		case user == nil:
+			return nil, fmt.Errorf("%w: %s", models.ErrUserNotFound, userID)
+		case user.err != nil:
+			return nil, user.err
+		case user.prefs != nil:
+			prefs := *user.prefs
+			return &prefs, nil
+		}
+	}
+
 	// Simulate specific error scenarios based on user ID patterns
 	if strings.HasPrefix(userID, "error_") {
 		return m.simulateError(userID)
@@ -111,7 +208,10 @@ func (m *UserServiceMock) GetPreferences(userID string) (*models.UserPreferences
 
 	// Simulate timeout scenario
 	if strings.HasPrefix(userID, "timeout_") {
-		time.Sleep(5 * time.Second) // Longer than typical timeout
+		// Longer than typical timeout
+		if err := sleep(ctx, 5*time.Second); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("request timeout: user service did not respond")
 	}
 
@@ -131,6 +231,186 @@ func (m *UserServiceMock) GetPreferences(userID string) (*models.UserPreferences
 	return prefs, nil
 }
 
+// UpdatePreferences validates prefs and keeps a copy in memory, returned by
+// later GetPreferences calls for the same user instead of the patterns. Each
+// write bumps the stored version, starting from 0 for users that were never
+// written, and prefs.Version must match it.
+func (m *UserServiceMock) UpdatePreferences(ctx context.Context, prefs *models.UserPreferences) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := models.ValidateUpdate(prefs); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current := m.versionLocked(prefs.UserID); prefs.Version != current {
+		return fmt.Errorf("%w: user %s is at version %d, not %d", models.ErrVersionConflict, prefs.UserID, current, prefs.Version)
+	}
+	m.storeLocked(prefs.UserID, prefs)
+	return nil
+}
+
+// versionLocked returns the stored version of userID's preferences, 0 when
+// they were never written. m.mu must be held.
+func (m *UserServiceMock) versionLocked(userID string) int {
+	if stored, ok := m.stored[userID]; ok {
+		return stored.Version
+	}
+	return 0
+}
+
+// storeLocked saves a copy of prefs as userID's preferences at the next
+// version. m.mu must be held.
+func (m *UserServiceMock) storeLocked(userID string, prefs *models.UserPreferences) {
+	if m.stored == nil {
+		m.stored = make(map[string]*models.UserPreferences)
+	}
+	stored := *prefs
+	stored.UserID = userID
+	stored.Version = m.versionLocked(userID) + 1
+	m.stored[userID] = &stored
+	delete(m.deleted, userID)
+}
+
+// RegisterDevice adds or refreshes a device in the user's in-memory device
+// list, starting from the pattern-based preferences on first use
+func (m *UserServiceMock) RegisterDevice(ctx context.Context, userID string, d models.Device) error {
+	return m.updateDevices(ctx, userID, func(push *models.PushChannel) error {
+		_, err := push.RegisterDevice(d, m.maxDevices)
+		return err
+	})
+}
+
+// RemoveDevice drops a device from the user's in-memory device list
+func (m *UserServiceMock) RemoveDevice(ctx context.Context, userID, deviceID string) error {
+	return m.updateDevices(ctx, userID, func(push *models.PushChannel) error {
+		return push.RemoveDevice(deviceID)
+	})
+}
+
+// DeleteUserData removes the user's stored preferences, devices and scripted
+// responses. Later lookups return models.ErrUserNotFound until preferences
+// are written for the user again.
+func (m *UserServiceMock) DeleteUserData(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.stored, userID)
+	delete(m.scripted, userID)
+	if m.deleted == nil {
+		m.deleted = make(map[string]bool)
+	}
+	m.deleted[userID] = true
+	return nil
+}
+
+// ExportUserData assembles the export document from the same preferences and
+// opt-out status GetPreferences and GetOptOutStatus return
+func (m *UserServiceMock) ExportUserData(ctx context.Context, userID string) ([]byte, error) {
+	prefs, err := m.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := m.GetOptOutStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return models.MarshalUserDataExport(userID, prefs, status, time.Now())
+}
+
+// ListUserIDs returns the users the mock holds data for: those whose
+// preferences or devices were written and those that were scripted, sorted.
+// Pattern-based users exist for any ID and are not listed.
+func (m *UserServiceMock) ListUserIDs(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[string]bool)
+	for userID := range m.stored {
+		seen[userID] = true
+	}
+	for userID := range m.scripted {
+		seen[userID] = true
+	}
+	userIDs := make([]string, 0, len(seen))
+	for userID := range seen {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+// updateDevices applies change to the user's push channel and stores the
+// result
+func (m *UserServiceMock) updateDevices(ctx context.Context, userID string, change func(*models.PushChannel) error) error {
+	prefs, err := m.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := change(&prefs.Channels.Push); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storeLocked(userID, prefs)
+	return nil
+}
+
+// storedPreferences returns a copy of preferences saved by UpdatePreferences
+func (m *UserServiceMock) storedPreferences(userID string) (*models.UserPreferences, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.stored[userID]
+	if !ok {
+		return nil, false
+	}
+	prefs := *stored
+	return &prefs, true
+}
+
+func (m *UserServiceMock) isDeleted(userID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleted[userID]
+}
+
+// GetPreferencesBulk looks up each distinct user ID in turn, applying the
+// same user ID patterns as GetPreferences
+func (m *UserServiceMock) GetPreferencesBulk(ctx context.Context, userIDs []string) (map[string]*models.UserPreferences, error) {
+	results := make(map[string]*models.UserPreferences)
+	errs := make(map[string]error)
+	for _, userID := range userIDs {
+		if _, done := results[userID]; done {
+			continue
+		}
+		if _, done := errs[userID]; done {
+			continue
+		}
+
+		prefs, err := m.GetPreferences(ctx, userID)
+		if err != nil {
+			errs[userID] = err
+			continue
+		}
+		results[userID] = prefs
+	}
+
+	if len(errs) > 0 {
+		return results, &models.BulkError{Errors: errs}
+	}
+	return results, nil
+}
+
 func (m *UserServiceMock) simulateError(userID string) (*models.UserPreferences, error) {
 	errorTypes := []string{
 		"internal_server_error",
@@ -159,6 +439,103 @@ func (m *UserServiceMock) simulateError(userID string) (*models.UserPreferences,
 }
 
 func (m *UserServiceMock) getRealisticPreferences(userID string) *models.UserPreferences {
+	prefs := m.getChannelToggles(userID)
+	prefs.NotificationEnabled = !strings.Contains(userID, "no_notifications") && !strings.HasPrefix(userID, "usr_nonotif_")
+	prefs.Marketing = strings.Contains(userID, "marketing")
+	prefs.Transactional = true
+	prefs.Reminders = true
+	prefs.Channels = realisticChannels(userID, prefs)
+	return prefs
+}
+
+// realisticChannels builds channel settings consistent with the toggles.
+// Device activity is relative to now so the phone stays within
+// models.MaxDeviceAge and push stays deliverable.
+func realisticChannels(userID string, prefs *models.UserPreferences) models.Channels {
+	smsEnabled := !strings.Contains(userID, "no_sms") && !strings.HasPrefix(userID, "usr_nosms_")
+	whatsAppEnabled := !strings.Contains(userID, "no_whatsapp") && !strings.HasPrefix(userID, "usr_nowhatsapp_")
+	optedInAt := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+	now := time.Now().UTC()
+	deviceSeenAt := now.Add(-time.Hour)
+	tokenExpiredAt := now.Add(-24 * time.Hour)
+	webhookEnabled := strings.Contains(userID, "webhook")
+	inAppEnabled := !strings.Contains(userID, "no_inapp") && !strings.HasPrefix(userID, "usr_noinapp_")
+
+	emailQuietHours := models.QuietHours{
+		Enabled:  true,
+		Start:    "22:00",
+		End:      "07:00",
+		Timezone: "Africa/Nairobi",
+	}
+	// Users with a midday do-not-disturb window as well as the overnight one
+	if strings.Contains(userID, "two_windows") || strings.HasPrefix(userID, "usr_twowindows_") {
+		emailQuietHours = models.QuietHours{
+			Enabled:  true,
+			Timezone: "Africa/Nairobi",
+			Windows: []models.QuietHours{
+				{Start: "13:00", End: "14:00"},
+				{Start: "22:00", End: "07:00"},
+			},
+		}
+	}
+
+	return models.Channels{
+		Email: models.EmailChannel{
+			Enabled:    prefs.Email,
+			Verified:   true,
+			Frequency:  "immediate",
+			QuietHours: emailQuietHours,
+		},
+		Push: models.PushChannel{
+			Enabled:   prefs.Push,
+			Verified:  true,
+			Frequency: "immediate",
+			Devices: []models.UserDevice{
+				{
+					DeviceID:  "dev_" + userID,
+					Token:     "mock_push_token_" + userID,
+					Platform:  "android",
+					Active:    true,
+					LastSeen:  &deviceSeenAt,
+					CreatedAt: optedInAt,
+				},
+				// An iPad whose APNs token lapsed and was never refreshed
+				{
+					DeviceID:  "dev_" + userID + "_ipad",
+					Token:     "mock_expired_push_token_" + userID,
+					Platform:  "ios",
+					Active:    true,
+					LastSeen:  &deviceSeenAt,
+					CreatedAt: optedInAt,
+					ExpiresAt: tokenExpiredAt,
+				},
+			},
+		},
+		SMS: models.SMSChannel{
+			Enabled:   smsEnabled,
+			Verified:  true,
+			Frequency: "immediate",
+		},
+		WhatsApp: models.WhatsAppChannel{
+			Enabled:   whatsAppEnabled,
+			Verified:  true,
+			Phone:     "+254712345678",
+			OptedInAt: &optedInAt,
+		},
+		Webhook: models.WebhookChannel{
+			Enabled: webhookEnabled,
+			URL:     "https://hooks.example.com/notifications",
+			Secret:  "mock_webhook_secret",
+			Format:  models.WebhookFormatGenericJSON,
+		},
+		InApp: models.InAppChannel{
+			Enabled:       inAppEnabled,
+			RetentionDays: models.DefaultInAppRetentionDays,
+		},
+	}
+}
+
+func (m *UserServiceMock) getChannelToggles(userID string) *models.UserPreferences {
 	// Simulate different user preference scenarios
 	prefs := &models.UserPreferences{}
 
@@ -203,12 +580,75 @@ func (m *UserServiceMock) getRealisticPreferences(userID string) *models.UserPre
 	return prefs
 }
 
-// Reset resets the mock state (useful for testing)
+// GetOptOutStatus returns the channels and categories a user has
+// unsubscribed from. Users matching the no_* patterns are reported as opted
+// out of those channels, and users without *marketing* out of marketing.
+func (m *UserServiceMock) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	prefs, err := m.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user, _ := m.scriptedResponse(userID); user != nil && user.optOut != nil {
+		status := *user.optOut
+		return &status, nil
+	}
+
+	// Users who paused all notifications, with the pause still running or
+	// already lapsed
+	if strings.Contains(userID, "snoozed") || strings.HasPrefix(userID, "usr_snoozed_") {
+		expiresAt := time.Now().Add(30 * 24 * time.Hour)
+		return &models.OptOutStatus{UserID: userID, Global: true, ExpiresAt: &expiresAt}, nil
+	}
+	if strings.Contains(userID, "expired_optout") || strings.HasPrefix(userID, "usr_expiredoptout_") {
+		expiresAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		return &models.OptOutStatus{UserID: userID, Global: true, ExpiresAt: &expiresAt}, nil
+	}
+
+	return &models.OptOutStatus{
+		UserID: userID,
+		Global: strings.Contains(userID, "no_notifications") || strings.HasPrefix(userID, "usr_nonotif_"),
+		Channels: map[string]bool{
+			models.ChannelEmail:    !prefs.Email,
+			models.ChannelPush:     !prefs.Push,
+			models.ChannelSMS:      !prefs.Channels.SMS.Enabled,
+			models.ChannelWhatsApp: !prefs.Channels.WhatsApp.Enabled,
+			models.ChannelWebhook:  !prefs.Channels.Webhook.Enabled,
+			models.ChannelInApp:    !prefs.Channels.InApp.Enabled,
+		},
+		Categories: map[string]bool{
+			models.CategoryMarketing:     !prefs.Marketing,
+			models.CategoryTransactional: !prefs.Transactional,
+			models.CategoryReminders:     !prefs.Reminders,
+		},
+	}, nil
+}
+
+// Reset resets the mock state (useful for testing). Scripted responses are
+// kept.
 func (m *UserServiceMock) Reset() {
 	m.requestCount = 0
+
+	m.mu.Lock()
+	m.stored = nil
+	m.deleted = nil
+	m.mu.Unlock()
 }
 
 // GetRequestCount returns the number of requests made (for testing)
 func (m *UserServiceMock) GetRequestCount() int {
 	return m.requestCount
 }
+
+// sleep waits for d, returning early with the context's error if ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
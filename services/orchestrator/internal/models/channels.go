@@ -0,0 +1,192 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Channel names used as keys in OptOutStatus.Channels
+const (
+	ChannelEmail    = "email"
+	ChannelPush     = "push"
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+	ChannelWebhook  = "webhook"
+	ChannelInApp    = "in_app"
+)
+
+// DefaultInAppRetentionDays is how long inbox items are kept when
+// InAppChannel.RetentionDays is not set
+const DefaultInAppRetentionDays = 30
+
+// Payload formats for WebhookChannel.Format
+const (
+	WebhookFormatGenericJSON = "generic-json"
+	WebhookFormatSlack       = "slack"
+)
+
+// Channels holds the per-channel delivery settings for a user, mirroring the
+// user service's user_channels table.
+type Channels struct {
+	Email    EmailChannel    `json:"email"`
+	Push     PushChannel     `json:"push"`
+	SMS      SMSChannel      `json:"sms"`
+	WhatsApp WhatsAppChannel `json:"whatsapp"`
+	Webhook  WebhookChannel  `json:"webhook"`
+	InApp    InAppChannel    `json:"in_app"`
+}
+
+// IsChannelEnabled reports whether channel can be used for delivery. Email,
+// SMS and WhatsApp must also be verified, SMS must not be carrier opted out
+// and WhatsApp needs a recorded opt-in. Push has no verification step since
+// it is tied to registered devices, and webhooks only need a URL. Unknown
+// channels are never enabled.
+func (c Channels) IsChannelEnabled(channel string) bool {
+	switch channel {
+	case ChannelPush:
+		return c.Push.Enabled
+	default:
+		ok, _ := c.DeliverableAt(channel, time.Time{})
+		return ok
+	}
+}
+
+// QuietHours is a daily window, in HH:MM local to Timezone, during which a
+// channel should not deliver. Additional windows go in Windows; they inherit
+// Enabled and, when they have none, Timezone and Days from the outer value.
+// Start and End remain for the original single-window payloads, see Migrate.
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start,omitempty"`
+	End      string `json:"end,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+
+	// Days limits the window to the listed weekdays in Timezone; empty
+	// means every day. A window spanning midnight belongs to the day it
+	// starts on, so a Friday 22:00-07:00 window covers early Saturday.
+	Days []time.Weekday `json:"days,omitempty"`
+
+	Windows []QuietHours `json:"windows,omitempty"`
+}
+
+// EmailChannel holds email delivery settings.
+type EmailChannel struct {
+	Enabled    bool       `json:"enabled"`
+	Verified   bool       `json:"verified"`
+	Frequency  string     `json:"frequency,omitempty"`
+	QuietHours QuietHours `json:"quiet_hours"`
+}
+
+// PushChannel holds push delivery settings and the user's registered devices.
+type PushChannel struct {
+	Enabled    bool         `json:"enabled"`
+	Verified   bool         `json:"verified"`
+	Frequency  string       `json:"frequency,omitempty"`
+	QuietHours QuietHours   `json:"quiet_hours"`
+	Devices    []UserDevice `json:"devices,omitempty"`
+}
+
+// HasActiveDevice reports whether any registered device can receive pushes
+// now, i.e. is active and its token has not expired
+func (c PushChannel) HasActiveDevice() bool {
+	now := time.Now()
+	for _, device := range c.Devices {
+		if device.Active && !device.Expired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// SMSChannel holds SMS delivery settings. CarrierOptOut is set when the
+// carrier reported a STOP reply, which overrides Enabled.
+type SMSChannel struct {
+	Enabled       bool       `json:"enabled"`
+	Verified      bool       `json:"verified"`
+	Frequency     string     `json:"frequency,omitempty"`
+	QuietHours    QuietHours `json:"quiet_hours"`
+	CarrierOptOut bool       `json:"carrier_opt_out"`
+}
+
+// WhatsAppChannel holds WhatsApp Business delivery settings. WhatsApp only
+// allows business messages to users who explicitly opted in, so OptedInAt
+// records when that happened and is nil until then.
+type WhatsAppChannel struct {
+	Enabled    bool       `json:"enabled"`
+	Verified   bool       `json:"verified"`
+	Phone      string     `json:"phone,omitempty"`
+	OptedInAt  *time.Time `json:"opted_in_at,omitempty"`
+	QuietHours QuietHours `json:"quiet_hours"`
+}
+
+// WebhookChannel delivers notifications to a customer's own endpoint or a
+// Slack incoming webhook. Secret is the key used to HMAC-sign payloads, and
+// Format selects the payload shape, defaulting to generic JSON.
+type WebhookChannel struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url,omitempty"`
+	Secret  string `json:"secret,omitempty"`
+	Format  string `json:"format,omitempty"`
+}
+
+// Validate checks that an enabled webhook has an absolute https URL and a
+// supported format. Disabled webhooks are not checked.
+func (w WebhookChannel) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+
+	if w.URL == "" {
+		return fmt.Errorf("webhook url is required when the channel is enabled")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute https url, got %q", w.URL)
+	}
+
+	switch w.Format {
+	case "", WebhookFormatGenericJSON, WebhookFormatSlack:
+	default:
+		return fmt.Errorf("unsupported webhook format: %s", w.Format)
+	}
+
+	return nil
+}
+
+// InAppChannel delivers to the user's in-app inbox. RetentionDays controls
+// how long inbox items persist; 0 means DefaultInAppRetentionDays.
+type InAppChannel struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days,omitempty"`
+}
+
+// RespectsQuietHours is always false: the inbox is pull-based, so items can
+// be delivered at any time without disturbing the user.
+func (InAppChannel) RespectsQuietHours() bool {
+	return false
+}
+
+// Retention returns how long inbox items are kept
+func (c InAppChannel) Retention() time.Duration {
+	days := c.RetentionDays
+	if days <= 0 {
+		days = DefaultInAppRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// OptOutStatus reports which channels and notification categories a user
+// has unsubscribed from. A true entry in Channels or Categories means the
+// user opted out of that channel or category. ExpiresAt is set for
+// temporary opt-outs, after which the user is subscribed again.
+type OptOutStatus struct {
+	UserID     string          `json:"user_id"`
+	Global     bool            `json:"global"`
+	Channels   map[string]bool `json:"channels"`
+	Categories map[string]bool `json:"categories,omitempty"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
+}
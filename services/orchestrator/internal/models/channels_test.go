@@ -0,0 +1,214 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMSChannel_JSONShape(t *testing.T) {
+	channel := SMSChannel{
+		Enabled:   true,
+		Verified:  true,
+		Frequency: "immediate",
+		QuietHours: QuietHours{
+			Enabled:  true,
+			Start:    "22:00",
+			End:      "07:00",
+			Timezone: "Africa/Nairobi",
+		},
+		CarrierOptOut: true,
+	}
+
+	data, err := json.Marshal(channel)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"enabled": true,
+		"verified": true,
+		"frequency": "immediate",
+		"quiet_hours": {"enabled": true, "start": "22:00", "end": "07:00", "timezone": "Africa/Nairobi"},
+		"carrier_opt_out": true
+	}`, string(data))
+}
+
+func TestUserPreferences_ChannelsRoundTrip(t *testing.T) {
+	payload := `{
+		"email_enabled": true,
+		"push_enabled": false,
+		"notification_enabled": true,
+		"marketing": false,
+		"transactional": true,
+		"reminders": true,
+		"channels": {
+			"email": {"enabled": true, "verified": true, "quiet_hours": {"enabled": false}},
+			"push": {"enabled": false, "verified": false, "quiet_hours": {"enabled": false}},
+			"sms": {"enabled": true, "verified": false, "quiet_hours": {"enabled": false}, "carrier_opt_out": false},
+			"whatsapp": {"enabled": false, "verified": false, "quiet_hours": {"enabled": false}},
+			"webhook": {"enabled": true, "url": "https://hooks.slack.com/services/T000/B000/XXX", "format": "slack"},
+			"in_app": {"enabled": true, "retention_days": 90}
+		},
+		"digest": {"enabled": true, "frequency": "weekly", "time": "08:30"}
+	}`
+
+	var prefs UserPreferences
+	require.NoError(t, json.Unmarshal([]byte(payload), &prefs))
+
+	assert.True(t, prefs.Email)
+	assert.True(t, prefs.NotificationEnabled)
+	assert.True(t, prefs.Transactional)
+	assert.True(t, prefs.Channels.SMS.Enabled)
+	assert.False(t, prefs.Channels.SMS.Verified)
+	assert.Equal(t, DigestWeekly, prefs.Digest.Frequency)
+
+	data, err := json.Marshal(prefs)
+	require.NoError(t, err)
+	assert.JSONEq(t, payload, string(data))
+}
+
+func TestOptOutStatus_JSONShape(t *testing.T) {
+	status := OptOutStatus{
+		UserID:   "usr_123",
+		Channels: map[string]bool{ChannelEmail: false, ChannelPush: true, ChannelSMS: false},
+	}
+
+	data, err := json.Marshal(status)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"user_id": "usr_123",
+		"global": false,
+		"channels": {"email": false, "push": true, "sms": false}
+	}`, string(data))
+}
+
+func TestChannels_IsChannelEnabled(t *testing.T) {
+	optedIn := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		channels Channels
+		channel  string
+		expected bool
+	}{
+		{name: "email enabled", channels: Channels{Email: EmailChannel{Enabled: true, Verified: true}}, channel: ChannelEmail, expected: true},
+		{name: "email disabled", channels: Channels{Email: EmailChannel{Enabled: false, Verified: true}}, channel: ChannelEmail, expected: false},
+		{name: "email unverified", channels: Channels{Email: EmailChannel{Enabled: true}}, channel: ChannelEmail, expected: false},
+		{name: "push enabled", channels: Channels{Push: PushChannel{Enabled: true}}, channel: ChannelPush, expected: true},
+		{name: "push disabled", channels: Channels{Push: PushChannel{Verified: true}}, channel: ChannelPush, expected: false},
+		{name: "sms enabled", channels: Channels{SMS: SMSChannel{Enabled: true, Verified: true}}, channel: ChannelSMS, expected: true},
+		{name: "sms unverified", channels: Channels{SMS: SMSChannel{Enabled: true}}, channel: ChannelSMS, expected: false},
+		{name: "sms carrier opt out", channels: Channels{SMS: SMSChannel{Enabled: true, Verified: true, CarrierOptOut: true}}, channel: ChannelSMS, expected: false},
+		{
+			name:     "whatsapp enabled",
+			channels: Channels{WhatsApp: WhatsAppChannel{Enabled: true, Verified: true, Phone: "+254712345678", OptedInAt: &optedIn}},
+			channel:  ChannelWhatsApp,
+			expected: true,
+		},
+		{
+			name:     "whatsapp disabled",
+			channels: Channels{WhatsApp: WhatsAppChannel{Verified: true, OptedInAt: &optedIn}},
+			channel:  ChannelWhatsApp,
+			expected: false,
+		},
+		{
+			name:     "whatsapp unverified",
+			channels: Channels{WhatsApp: WhatsAppChannel{Enabled: true, OptedInAt: &optedIn}},
+			channel:  ChannelWhatsApp,
+			expected: false,
+		},
+		{
+			name:     "whatsapp without opt in",
+			channels: Channels{WhatsApp: WhatsAppChannel{Enabled: true, Verified: true}},
+			channel:  ChannelWhatsApp,
+			expected: false,
+		},
+		{name: "webhook enabled", channels: Channels{Webhook: WebhookChannel{Enabled: true, URL: "https://example.com/hook"}}, channel: ChannelWebhook, expected: true},
+		{name: "webhook without url", channels: Channels{Webhook: WebhookChannel{Enabled: true}}, channel: ChannelWebhook, expected: false},
+		{name: "in-app enabled", channels: Channels{InApp: InAppChannel{Enabled: true}}, channel: ChannelInApp, expected: true},
+		{name: "in-app disabled", channels: Channels{InApp: InAppChannel{RetentionDays: 7}}, channel: ChannelInApp, expected: false},
+		{name: "unknown channel", channels: Channels{Email: EmailChannel{Enabled: true, Verified: true}}, channel: "fax", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.channels.IsChannelEnabled(tt.channel))
+		})
+	}
+}
+
+func TestWhatsAppChannel_JSONShape(t *testing.T) {
+	optedIn := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+	channel := WhatsAppChannel{Enabled: true, Verified: true, Phone: "+254712345678", OptedInAt: &optedIn}
+
+	data, err := json.Marshal(channel)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"enabled": true,
+		"verified": true,
+		"phone": "+254712345678",
+		"opted_in_at": "2025-01-10T08:00:00Z",
+		"quiet_hours": {"enabled": false}
+	}`, string(data))
+}
+
+func TestWebhookChannel_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel WebhookChannel
+		wantErr string
+	}{
+		{name: "valid generic", channel: WebhookChannel{Enabled: true, URL: "https://example.com/hooks/notify", Secret: "s3cret"}},
+		{name: "valid slack", channel: WebhookChannel{Enabled: true, URL: "https://hooks.slack.com/services/T000/B000/XXX", Format: WebhookFormatSlack}},
+		{name: "disabled is not checked", channel: WebhookChannel{URL: "not a url"}},
+		{name: "missing url", channel: WebhookChannel{Enabled: true}, wantErr: "webhook url is required"},
+		{name: "http url", channel: WebhookChannel{Enabled: true, URL: "http://example.com/hook"}, wantErr: "must be an absolute https url"},
+		{name: "relative url", channel: WebhookChannel{Enabled: true, URL: "/hooks/notify"}, wantErr: "must be an absolute https url"},
+		{name: "no host", channel: WebhookChannel{Enabled: true, URL: "https:///hook"}, wantErr: "must be an absolute https url"},
+		{name: "unparseable url", channel: WebhookChannel{Enabled: true, URL: "https://exa mple.com"}, wantErr: "invalid webhook url"},
+		{name: "unknown format", channel: WebhookChannel{Enabled: true, URL: "https://example.com", Format: "xml"}, wantErr: "unsupported webhook format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.channel.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestInAppChannel_Defaults(t *testing.T) {
+	var channel InAppChannel
+
+	assert.False(t, channel.Enabled)
+	assert.False(t, channel.RespectsQuietHours())
+	assert.Equal(t, DefaultInAppRetentionDays*24*time.Hour, channel.Retention())
+
+	channel.RetentionDays = 7
+	assert.Equal(t, 7*24*time.Hour, channel.Retention())
+}
+
+func TestInAppChannel_JSONTags(t *testing.T) {
+	data, err := json.Marshal(InAppChannel{Enabled: true, RetentionDays: 14})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled": true, "retention_days": 14}`, string(data))
+
+	data, err = json.Marshal(InAppChannel{Enabled: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled": true}`, string(data))
+}
+
+func TestPushChannel_HasActiveDevice(t *testing.T) {
+	assert.False(t, PushChannel{Enabled: true}.HasActiveDevice())
+	assert.False(t, PushChannel{Devices: []UserDevice{{Token: "a", Active: false}}}.HasActiveDevice())
+	assert.True(t, PushChannel{Devices: []UserDevice{{Token: "a"}, {Token: "b", Active: true}}}.HasActiveDevice())
+}
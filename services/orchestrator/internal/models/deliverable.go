@@ -0,0 +1,144 @@
+package models
+
+import "time"
+
+// MaxDeviceAge is how long since a device was last seen before it is no
+// longer sent pushes at all
+const MaxDeviceAge = 90 * 24 * time.Hour
+
+// Reasons a channel is not deliverable. UndeliverableUnverified means the
+// user should be asked to verify the address or number again.
+const (
+	UndeliverableDisabled      = "channel disabled"
+	UndeliverableUnverified    = "not verified"
+	UndeliverableCarrierOptOut = "carrier opted out"
+	UndeliverableNoOptIn       = "no opt-in recorded"
+	UndeliverableNoDevice      = "no active device"
+	UndeliverableNoURL         = "no webhook url"
+	UndeliverableUnknown       = "unknown channel"
+)
+
+// Deliverable reports whether email can be sent, and why not
+func (c EmailChannel) Deliverable() (bool, string) {
+	switch {
+	case !c.Enabled:
+		return false, UndeliverableDisabled
+	case !c.Verified:
+		return false, UndeliverableUnverified
+	}
+	return true, ""
+}
+
+// Deliverable reports whether pushes can be sent now, and why not. See
+// DeliverableAt.
+func (c PushChannel) Deliverable() (bool, string) {
+	return c.DeliverableAt(time.Now())
+}
+
+// DeliverableAt reports whether pushes can be sent at the given time, which
+// needs an active device seen within MaxDeviceAge
+func (c PushChannel) DeliverableAt(at time.Time) (bool, string) {
+	switch {
+	case !c.Enabled:
+		return false, UndeliverableDisabled
+	case len(c.ActiveDevicesAt(MaxDeviceAge, at)) == 0:
+		return false, UndeliverableNoDevice
+	}
+	return true, ""
+}
+
+// Deliverable reports whether SMS can be sent, and why not
+func (c SMSChannel) Deliverable() (bool, string) {
+	switch {
+	case !c.Enabled:
+		return false, UndeliverableDisabled
+	case !c.Verified:
+		return false, UndeliverableUnverified
+	case c.CarrierOptOut:
+		return false, UndeliverableCarrierOptOut
+	}
+	return true, ""
+}
+
+// Deliverable reports whether WhatsApp messages can be sent, and why not
+func (c WhatsAppChannel) Deliverable() (bool, string) {
+	switch {
+	case !c.Enabled:
+		return false, UndeliverableDisabled
+	case !c.Verified:
+		return false, UndeliverableUnverified
+	case c.OptedInAt == nil:
+		return false, UndeliverableNoOptIn
+	}
+	return true, ""
+}
+
+// Deliverable reports whether the webhook can be called, and why not
+func (w WebhookChannel) Deliverable() (bool, string) {
+	switch {
+	case !w.Enabled:
+		return false, UndeliverableDisabled
+	case w.URL == "":
+		return false, UndeliverableNoURL
+	}
+	return true, ""
+}
+
+// Deliverable reports whether items can be added to the inbox, and why not
+func (c InAppChannel) Deliverable() (bool, string) {
+	if !c.Enabled {
+		return false, UndeliverableDisabled
+	}
+	return true, ""
+}
+
+// Deliverable reports whether channel can be delivered on now, and why not.
+// See DeliverableAt.
+func (c Channels) Deliverable(channel string) (bool, string) {
+	return c.DeliverableAt(channel, time.Now())
+}
+
+// DeliverableAt reports whether channel can be delivered on at the given
+// time. Unlike IsChannelEnabled it also checks that push has a recently
+// seen device.
+func (c Channels) DeliverableAt(channel string, at time.Time) (bool, string) {
+	switch channel {
+	case ChannelEmail:
+		return c.Email.Deliverable()
+	case ChannelPush:
+		return c.Push.DeliverableAt(at)
+	case ChannelSMS:
+		return c.SMS.Deliverable()
+	case ChannelWhatsApp:
+		return c.WhatsApp.Deliverable()
+	case ChannelWebhook:
+		return c.Webhook.Deliverable()
+	case ChannelInApp:
+		return c.InApp.Deliverable()
+	default:
+		return false, UndeliverableUnknown
+	}
+}
+
+// Reported reports whether the user service sent any details for channel.
+// Preferences from before per-channel records only carry the top-level
+// email_enabled and push_enabled toggles and leave the channel zero, in
+// which case DeliverableAt has nothing to go on.
+func (c Channels) Reported(channel string) bool {
+	switch channel {
+	case ChannelEmail:
+		return c.Email.Enabled || c.Email.Verified
+	case ChannelPush:
+		return c.Push.Enabled || c.Push.Verified || len(c.Push.Devices) > 0
+	case ChannelSMS:
+		return c.SMS.Enabled || c.SMS.Verified
+	case ChannelWhatsApp:
+		return c.WhatsApp.Enabled || c.WhatsApp.Verified
+	case ChannelWebhook:
+		return c.Webhook.Enabled || c.Webhook.URL != ""
+	case ChannelInApp:
+		return c.InApp.Enabled
+	default:
+		return false
+	}
+}
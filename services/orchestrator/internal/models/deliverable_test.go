@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannels_DeliverableAt(t *testing.T) {
+	at := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	optedIn := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+	recent := at.Add(-time.Hour)
+	stale := at.Add(-MaxDeviceAge - time.Hour)
+
+	tests := []struct {
+		name     string
+		channels Channels
+		channel  string
+		reason   string
+	}{
+		{name: "email deliverable", channels: Channels{Email: EmailChannel{Enabled: true, Verified: true}}, channel: ChannelEmail},
+		{name: "email disabled", channels: Channels{Email: EmailChannel{Verified: true}}, channel: ChannelEmail, reason: UndeliverableDisabled},
+		{name: "email unverified", channels: Channels{Email: EmailChannel{Enabled: true}}, channel: ChannelEmail, reason: UndeliverableUnverified},
+		{
+			name:     "push deliverable",
+			channels: Channels{Push: PushChannel{Enabled: true, Devices: []UserDevice{{Token: "a", Active: true, LastSeen: &recent}}}},
+			channel:  ChannelPush,
+		},
+		{
+			name:     "push with stale device",
+			channels: Channels{Push: PushChannel{Enabled: true, Devices: []UserDevice{{Token: "a", Active: true, LastSeen: &stale}}}},
+			channel:  ChannelPush,
+			reason:   UndeliverableNoDevice,
+		},
+		{name: "push disabled", channels: Channels{Push: PushChannel{}}, channel: ChannelPush, reason: UndeliverableDisabled},
+		{name: "sms deliverable", channels: Channels{SMS: SMSChannel{Enabled: true, Verified: true}}, channel: ChannelSMS},
+		{name: "sms unverified", channels: Channels{SMS: SMSChannel{Enabled: true}}, channel: ChannelSMS, reason: UndeliverableUnverified},
+		{
+			name:     "sms carrier opt out",
+			channels: Channels{SMS: SMSChannel{Enabled: true, Verified: true, CarrierOptOut: true}},
+			channel:  ChannelSMS,
+			reason:   UndeliverableCarrierOptOut,
+		},
+		{
+			name:     "whatsapp deliverable",
+			channels: Channels{WhatsApp: WhatsAppChannel{Enabled: true, Verified: true, OptedInAt: &optedIn}},
+			channel:  ChannelWhatsApp,
+		},
+		{
+			name:     "whatsapp unverified",
+			channels: Channels{WhatsApp: WhatsAppChannel{Enabled: true, OptedInAt: &optedIn}},
+			channel:  ChannelWhatsApp,
+			reason:   UndeliverableUnverified,
+		},
+		{
+			name:     "whatsapp without opt in",
+			channels: Channels{WhatsApp: WhatsAppChannel{Enabled: true, Verified: true}},
+			channel:  ChannelWhatsApp,
+			reason:   UndeliverableNoOptIn,
+		},
+		{name: "webhook without url", channels: Channels{Webhook: WebhookChannel{Enabled: true}}, channel: ChannelWebhook, reason: UndeliverableNoURL},
+		{name: "in-app disabled", channels: Channels{}, channel: ChannelInApp, reason: UndeliverableDisabled},
+		{name: "unknown channel", channels: Channels{}, channel: "fax", reason: UndeliverableUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := tt.channels.DeliverableAt(tt.channel, at)
+
+			assert.Equal(t, tt.reason == "", ok)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestChannels_Reported(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels Channels
+		channel  string
+		reported bool
+	}{
+		{name: "legacy email", channels: Channels{}, channel: ChannelEmail},
+		{name: "unverified email", channels: Channels{Email: EmailChannel{Enabled: true}}, channel: ChannelEmail, reported: true},
+		{name: "legacy push", channels: Channels{}, channel: ChannelPush},
+		{name: "push devices only", channels: Channels{Push: PushChannel{Devices: []UserDevice{{DeviceID: "d1"}}}}, channel: ChannelPush, reported: true},
+		{name: "webhook url only", channels: Channels{Webhook: WebhookChannel{URL: "https://example.com"}}, channel: ChannelWebhook, reported: true},
+		{name: "unknown channel", channels: Channels{}, channel: "fax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.reported, tt.channels.Reported(tt.channel))
+		})
+	}
+}
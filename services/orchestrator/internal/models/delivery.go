@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeliveryStatus is where a notification is on its way to one channel
+type DeliveryStatus string
+
+const (
+	// DeliveryPending means the notification was accepted but not yet
+	// handed to the channel, e.g. while it is scheduled
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySent means the notification was queued for the channel worker
+	DeliverySent DeliveryStatus = "sent"
+	// DeliveryDelivered means the channel provider confirmed delivery
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed means sending failed; a failed delivery may be retried
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliverySuppressed means the notification was deliberately not sent,
+	// e.g. because the user opted out or it was a duplicate
+	DeliverySuppressed DeliveryStatus = "suppressed"
+)
+
+// ErrInvalidTransition is returned when a delivery cannot move to a status
+// from the one it is in
+var ErrInvalidTransition = errors.New("invalid delivery status transition")
+
+// deliveryTransitions lists the statuses each status can move to. Delivered
+// and suppressed are final.
+var deliveryTransitions = map[DeliveryStatus][]DeliveryStatus{
+	DeliveryPending: {DeliverySent, DeliveryFailed, DeliverySuppressed},
+	DeliverySent:    {DeliveryDelivered, DeliveryFailed},
+	DeliveryFailed:  {DeliveryPending, DeliverySent},
+}
+
+// CanTransition reports whether a delivery in status from may move to to.
+// A new delivery can start in any status.
+func CanTransition(from, to DeliveryStatus) bool {
+	if from == "" {
+		return to.Valid()
+	}
+	for _, next := range deliveryTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether s is a known delivery status
+func (s DeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliverySent, DeliveryDelivered, DeliveryFailed, DeliverySuppressed:
+		return true
+	default:
+		return false
+	}
+}
+
+// DeliveryTransition is one status change in a DeliveryRecord's history
+type DeliveryTransition struct {
+	Status DeliveryStatus `json:"status"`
+	At     time.Time      `json:"at"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// DeliveryRecord tracks one notification on one channel
+type DeliveryRecord struct {
+	NotificationID string               `json:"notification_id"`
+	Channel        string               `json:"channel"`
+	Status         DeliveryStatus       `json:"status"`
+	Error          string               `json:"error,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	History        []DeliveryTransition `json:"history"`
+}
+
+// Transition moves the record to status at the given time, recording detail
+// as the error for failed and suppressed deliveries. It returns an error
+// wrapping ErrInvalidTransition if the move is not allowed.
+func (r *DeliveryRecord) Transition(status DeliveryStatus, detail string, at time.Time) error {
+	if !CanTransition(r.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, r.Status, status)
+	}
+
+	if r.Status == "" {
+		r.CreatedAt = at
+	}
+	r.Status = status
+	r.Error = ""
+	if status == DeliveryFailed || status == DeliverySuppressed {
+		r.Error = detail
+	}
+	r.UpdatedAt = at
+	r.History = append(r.History, DeliveryTransition{Status: status, At: at, Error: r.Error})
+	return nil
+}
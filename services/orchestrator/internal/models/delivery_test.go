@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to DeliveryStatus
+		allowed  bool
+	}{
+		{from: "", to: DeliveryPending, allowed: true},
+		{from: "", to: DeliverySuppressed, allowed: true},
+		{from: "", to: "bounced", allowed: false},
+		{from: DeliveryPending, to: DeliverySent, allowed: true},
+		{from: DeliveryPending, to: DeliveryDelivered, allowed: false},
+		{from: DeliverySent, to: DeliveryDelivered, allowed: true},
+		{from: DeliverySent, to: DeliveryFailed, allowed: true},
+		{from: DeliveryFailed, to: DeliveryPending, allowed: true},
+		{from: DeliveryDelivered, to: DeliveryFailed, allowed: false},
+		{from: DeliverySuppressed, to: DeliverySent, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestDeliveryRecord_Transition(t *testing.T) {
+	start := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	record := &DeliveryRecord{NotificationID: "n1", Channel: ChannelEmail}
+
+	require.NoError(t, record.Transition(DeliveryPending, "", start))
+	require.NoError(t, record.Transition(DeliverySent, "", start.Add(time.Second)))
+	require.NoError(t, record.Transition(DeliveryFailed, "mailbox full", start.Add(time.Minute)))
+	assert.Equal(t, "mailbox full", record.Error)
+
+	// A retry clears the error
+	require.NoError(t, record.Transition(DeliverySent, "", start.Add(time.Hour)))
+	require.NoError(t, record.Transition(DeliveryDelivered, "", start.Add(2*time.Hour)))
+
+	assert.Equal(t, DeliveryDelivered, record.Status)
+	assert.Empty(t, record.Error)
+	assert.Equal(t, start, record.CreatedAt)
+	assert.Equal(t, start.Add(2*time.Hour), record.UpdatedAt)
+	require.Len(t, record.History, 5)
+	assert.Equal(t, "mailbox full", record.History[2].Error)
+
+	err := record.Transition(DeliveryFailed, "late bounce", start.Add(3*time.Hour))
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+	assert.Equal(t, DeliveryDelivered, record.Status)
+	assert.Len(t, record.History, 5)
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultMaxDevices is how many push devices a user may register when no
+// other cap is configured
+const DefaultMaxDevices = 10
+
+var devicePlatforms = map[string]bool{"ios": true, "android": true, "web": true}
+
+// RegisterDevice adds d to the channel's devices, or replaces the device with
+// the same DeviceID so a refreshed token takes effect. When that leaves more
+// than maxDevices the least recently seen devices are dropped and returned.
+// A maxDevices of zero or less means DefaultMaxDevices.
+func (c *PushChannel) RegisterDevice(d Device, maxDevices int) ([]Device, error) {
+	if err := validateDevice(d); err != nil {
+		return nil, err
+	}
+	if maxDevices <= 0 {
+		maxDevices = DefaultMaxDevices
+	}
+
+	// Copy so callers sharing the old slice are not affected
+	devices := make([]Device, 0, len(c.Devices)+1)
+	replaced := false
+	for _, existing := range c.Devices {
+		if existing.DeviceID == d.DeviceID {
+			if d.CreatedAt.IsZero() {
+				d.CreatedAt = existing.CreatedAt
+			}
+			existing = d
+			replaced = true
+		}
+		devices = append(devices, existing)
+	}
+	if !replaced {
+		devices = append(devices, d)
+	}
+
+	var evicted []Device
+	if len(devices) > maxDevices {
+		// Most recently seen first; the newly registered device always stays
+		sort.SliceStable(devices, func(i, j int) bool {
+			if devices[i].DeviceID == d.DeviceID {
+				return true
+			}
+			if devices[j].DeviceID == d.DeviceID {
+				return false
+			}
+			return lastActivity(devices[i]).After(lastActivity(devices[j]))
+		})
+		evicted = append(evicted, devices[maxDevices:]...)
+		devices = devices[:maxDevices]
+	}
+
+	c.Devices = devices
+	return evicted, nil
+}
+
+// RemoveDevice drops the device with deviceID, returning ErrDeviceNotFound
+// if the channel has no such device
+func (c *PushChannel) RemoveDevice(deviceID string) error {
+	devices := make([]Device, 0, len(c.Devices))
+	found := false
+	for _, existing := range c.Devices {
+		if existing.DeviceID == deviceID {
+			found = true
+			continue
+		}
+		devices = append(devices, existing)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrDeviceNotFound, deviceID)
+	}
+
+	c.Devices = devices
+	return nil
+}
+
+// ActiveDevices returns the active devices seen within maxAge of now. See
+// ActiveDevicesAt.
+func (c PushChannel) ActiveDevices(maxAge time.Duration) []Device {
+	return c.ActiveDevicesAt(maxAge, time.Now())
+}
+
+// ActiveDevicesAt returns the active devices seen within maxAge of at whose
+// tokens have not expired. A device that has never reported in is judged by
+// when it was registered.
+func (c PushChannel) ActiveDevicesAt(maxAge time.Duration, at time.Time) []Device {
+	devices := []Device{}
+	for _, device := range c.Devices {
+		if device.Active && !isStale(device, maxAge, at) && !device.Expired(at) {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// DevicesByPlatform groups the devices that can be pushed to now by
+// platform. See DevicesByPlatformAt.
+func (c PushChannel) DevicesByPlatform() map[string][]Device {
+	return c.DevicesByPlatformAt(time.Now())
+}
+
+// DevicesByPlatformAt groups the active devices by platform, e.g. "ios" for
+// APNs and "android" for FCM, in registration order. Devices without a
+// token, or whose token has expired at the given time, cannot be pushed to
+// and are left out.
+func (c PushChannel) DevicesByPlatformAt(at time.Time) map[string][]Device {
+	groups := make(map[string][]Device)
+	for _, device := range c.Devices {
+		if !device.Active || device.Token == "" || device.Expired(at) {
+			continue
+		}
+		groups[device.Platform] = append(groups[device.Platform], device)
+	}
+	return groups
+}
+
+// TokensByPlatform returns the tokens of DevicesByPlatform
+func (c PushChannel) TokensByPlatform() map[string][]string {
+	return c.TokensByPlatformAt(time.Now())
+}
+
+// TokensByPlatformAt returns the tokens of DevicesByPlatformAt
+func (c PushChannel) TokensByPlatformAt(at time.Time) map[string][]string {
+	tokens := make(map[string][]string)
+	for platform, devices := range c.DevicesByPlatformAt(at) {
+		for _, device := range devices {
+			tokens[platform] = append(tokens[platform], device.Token)
+		}
+	}
+	return tokens
+}
+
+// ExpiredDevices returns the devices, active or not, whose tokens have
+// expired at now, so a cleanup job can refresh them with the provider or
+// remove them
+func (c PushChannel) ExpiredDevices(now time.Time) []Device {
+	var expired []Device
+	for _, device := range c.Devices {
+		if device.Expired(now) {
+			expired = append(expired, device)
+		}
+	}
+	return expired
+}
+
+// PruneStaleDevices removes devices not seen within maxAge of now. See
+// PruneStaleDevicesAt.
+func (c *PushChannel) PruneStaleDevices(maxAge time.Duration) []Device {
+	return c.PruneStaleDevicesAt(maxAge, time.Now())
+}
+
+// PruneStaleDevicesAt removes devices not seen within maxAge of at,
+// whether or not they are active, and returns them so their tokens can be
+// cleaned up with the push provider
+func (c *PushChannel) PruneStaleDevicesAt(maxAge time.Duration, at time.Time) []Device {
+	kept := make([]Device, 0, len(c.Devices))
+	var pruned []Device
+	for _, device := range c.Devices {
+		if isStale(device, maxAge, at) {
+			pruned = append(pruned, device)
+			continue
+		}
+		kept = append(kept, device)
+	}
+
+	c.Devices = kept
+	return pruned
+}
+
+func isStale(d Device, maxAge time.Duration, at time.Time) bool {
+	return at.Sub(lastActivity(d)) > maxAge
+}
+
+func validateDevice(d Device) error {
+	var errs []error
+	if d.DeviceID == "" {
+		errs = append(errs, errors.New("device_id: is required"))
+	}
+	if d.Token == "" {
+		errs = append(errs, errors.New("token: is required"))
+	}
+	if !devicePlatforms[d.Platform] {
+		errs = append(errs, fmt.Errorf("platform: must be ios, android or web, got %q", d.Platform))
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Err: errors.Join(errs...)}
+	}
+	return nil
+}
+
+// lastActivity is when the device was last seen, falling back to when it
+// was registered
+func lastActivity(d Device) time.Time {
+	if d.LastSeen != nil {
+		return *d.LastSeen
+	}
+	return d.CreatedAt
+}
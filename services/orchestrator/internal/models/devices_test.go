@@ -0,0 +1,248 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seenAt(hour int) *time.Time {
+	t := time.Date(2025, 1, 15, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestPushChannel_RegisterDevice_BeyondCap(t *testing.T) {
+	channel := PushChannel{Enabled: true}
+	for i, hour := range []int{9, 7, 8} {
+		_, err := channel.RegisterDevice(Device{
+			DeviceID: fmt.Sprintf("dev-%d", i),
+			Token:    fmt.Sprintf("token-%d", i),
+			Platform: "android",
+			LastSeen: seenAt(hour),
+		}, 3)
+		require.NoError(t, err)
+	}
+
+	evicted, err := channel.RegisterDevice(Device{DeviceID: "dev-new", Token: "token-new", Platform: "ios"}, 3)
+
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, "dev-1", evicted[0].DeviceID)
+	require.Len(t, channel.Devices, 3)
+	ids := []string{}
+	for _, d := range channel.Devices {
+		ids = append(ids, d.DeviceID)
+	}
+	assert.ElementsMatch(t, []string{"dev-new", "dev-0", "dev-2"}, ids)
+}
+
+func TestPushChannel_RegisterDevice_DefaultCap(t *testing.T) {
+	channel := PushChannel{}
+	for i := 0; i < DefaultMaxDevices+2; i++ {
+		_, err := channel.RegisterDevice(Device{DeviceID: fmt.Sprintf("dev-%d", i), Token: "t", Platform: "web"}, 0)
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, channel.Devices, DefaultMaxDevices)
+}
+
+func TestPushChannel_RegisterDevice_UpdatesToken(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	channel := PushChannel{Devices: []Device{
+		{DeviceID: "dev-1", Token: "old-token", Platform: "ios", Active: true, CreatedAt: created},
+	}}
+	original := channel.Devices
+
+	evicted, err := channel.RegisterDevice(Device{DeviceID: "dev-1", Token: "new-token", Platform: "ios", Active: true}, 5)
+
+	require.NoError(t, err)
+	assert.Empty(t, evicted)
+	require.Len(t, channel.Devices, 1)
+	assert.Equal(t, "new-token", channel.Devices[0].Token)
+	assert.Equal(t, created, channel.Devices[0].CreatedAt)
+	assert.Equal(t, "old-token", original[0].Token)
+}
+
+func TestPushChannel_RegisterDevice_Invalid(t *testing.T) {
+	channel := PushChannel{}
+
+	_, err := channel.RegisterDevice(Device{Platform: "blackberry"}, 5)
+
+	require.Error(t, err)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, err.Error(), "device_id: is required")
+	assert.Contains(t, err.Error(), "token: is required")
+	assert.Contains(t, err.Error(), "platform")
+	assert.Empty(t, channel.Devices)
+}
+
+func TestPushChannel_RemoveDevice(t *testing.T) {
+	channel := PushChannel{Devices: []Device{
+		{DeviceID: "dev-1", Token: "a", Platform: "ios"},
+		{DeviceID: "dev-2", Token: "b", Platform: "android"},
+	}}
+
+	require.NoError(t, channel.RemoveDevice("dev-1"))
+
+	require.Len(t, channel.Devices, 1)
+	assert.Equal(t, "dev-2", channel.Devices[0].DeviceID)
+}
+
+func TestPushChannel_RemoveDevice_NotFound(t *testing.T) {
+	channel := PushChannel{Devices: []Device{{DeviceID: "dev-1", Token: "a", Platform: "ios"}}}
+
+	err := channel.RemoveDevice("dev-missing")
+
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+	assert.Len(t, channel.Devices, 1)
+}
+
+// staleFixture has devices last seen 1h, 29 days, 31 days and 6 months
+// before now, relative to a 30 day threshold
+func staleFixture(now time.Time) PushChannel {
+	ago := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	day := 24 * time.Hour
+	return PushChannel{Devices: []Device{
+		{DeviceID: "hour", Token: "a", Platform: "ios", Active: true, LastSeen: ago(time.Hour)},
+		{DeviceID: "inside", Token: "b", Platform: "android", Active: true, LastSeen: ago(29 * day)},
+		{DeviceID: "outside", Token: "c", Platform: "android", Active: true, LastSeen: ago(31 * day)},
+		{DeviceID: "months", Token: "d", Platform: "web", Active: true, LastSeen: ago(180 * day)},
+		{DeviceID: "inactive", Token: "e", Platform: "ios", Active: false, LastSeen: ago(time.Hour)},
+		{DeviceID: "registered", Token: "f", Platform: "ios", Active: true, CreatedAt: now.Add(-2 * day)},
+		{DeviceID: "unknown", Token: "g", Platform: "ios", Active: true},
+	}}
+}
+
+func deviceIDs(devices []Device) []string {
+	ids := []string{}
+	for _, d := range devices {
+		ids = append(ids, d.DeviceID)
+	}
+	return ids
+}
+
+func TestPushChannel_ActiveDevicesAt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	channel := staleFixture(now)
+
+	active := channel.ActiveDevicesAt(30*24*time.Hour, now)
+
+	assert.Equal(t, []string{"hour", "inside", "registered"}, deviceIDs(active))
+	assert.Len(t, channel.Devices, 7)
+}
+
+func TestPushChannel_ActiveDevicesAt_ExactlyAtThreshold(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	seen := now.Add(-time.Hour)
+	channel := PushChannel{Devices: []Device{{DeviceID: "edge", Token: "a", Platform: "ios", Active: true, LastSeen: &seen}}}
+
+	assert.Len(t, channel.ActiveDevicesAt(time.Hour, now), 1)
+	assert.Empty(t, channel.ActiveDevicesAt(time.Hour-time.Second, now))
+}
+
+func TestPushChannel_PruneStaleDevicesAt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	channel := staleFixture(now)
+
+	pruned := channel.PruneStaleDevicesAt(30*24*time.Hour, now)
+
+	assert.Equal(t, []string{"outside", "months", "unknown"}, deviceIDs(pruned))
+	assert.Equal(t, []string{"hour", "inside", "inactive", "registered"}, deviceIDs(channel.Devices))
+}
+
+func TestPushChannel_PruneStaleDevices_NothingStale(t *testing.T) {
+	seen := time.Now()
+	channel := PushChannel{Devices: []Device{{DeviceID: "fresh", Token: "a", Platform: "ios", Active: true, LastSeen: &seen}}}
+
+	assert.Empty(t, channel.PruneStaleDevices(time.Hour))
+	assert.Len(t, channel.Devices, 1)
+}
+
+func TestPushChannel_DevicesByPlatform(t *testing.T) {
+	channel := PushChannel{Devices: []Device{
+		{DeviceID: "phone", Token: "apns-1", Platform: "ios", Active: true},
+		{DeviceID: "tablet", Token: "fcm-1", Platform: "android", Active: true},
+		{DeviceID: "old-phone", Token: "fcm-2", Platform: "android", Active: false},
+		{DeviceID: "ipad", Token: "apns-2", Platform: "ios", Active: true},
+		{DeviceID: "unregistered", Token: "", Platform: "web", Active: true},
+	}}
+
+	groups := channel.DevicesByPlatform()
+
+	assert.Len(t, groups, 2)
+	assert.Equal(t, []string{"phone", "ipad"}, deviceIDs(groups["ios"]))
+	assert.Equal(t, []string{"tablet"}, deviceIDs(groups["android"]))
+	assert.Equal(t, map[string][]string{"ios": {"apns-1", "apns-2"}, "android": {"fcm-1"}}, channel.TokensByPlatform())
+}
+
+func TestPushChannel_DevicesByPlatform_NoDevices(t *testing.T) {
+	assert.Empty(t, PushChannel{}.DevicesByPlatform())
+	assert.Empty(t, PushChannel{}.TokensByPlatform())
+}
+
+// expiryFixture has tokens that expired an hour ago, expire in an hour and
+// never expire
+func expiryFixture(now time.Time) PushChannel {
+	seen := now.Add(-time.Hour)
+	return PushChannel{Devices: []Device{
+		{DeviceID: "expired", Token: "apns-1", Platform: "ios", Active: true, LastSeen: &seen, ExpiresAt: now.Add(-time.Hour)},
+		{DeviceID: "valid", Token: "fcm-1", Platform: "android", Active: true, LastSeen: &seen, ExpiresAt: now.Add(time.Hour)},
+		{DeviceID: "forever", Token: "apns-2", Platform: "ios", Active: true, LastSeen: &seen},
+		{DeviceID: "inactive-expired", Token: "fcm-2", Platform: "android", Active: false, LastSeen: &seen, ExpiresAt: now.Add(-time.Minute)},
+	}}
+}
+
+func TestDevice_Expired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, Device{ExpiresAt: now.Add(-time.Second)}.Expired(now))
+	assert.True(t, Device{ExpiresAt: now}.Expired(now))
+	assert.False(t, Device{ExpiresAt: now.Add(time.Second)}.Expired(now))
+	assert.False(t, Device{}.Expired(now))
+}
+
+func TestPushChannel_ActiveDevicesAt_ExcludesExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	channel := expiryFixture(now)
+
+	assert.Equal(t, []string{"valid", "forever"}, deviceIDs(channel.ActiveDevicesAt(24*time.Hour, now)))
+	// Once the second token lapses only the never-expiring one is left
+	assert.Equal(t, []string{"forever"}, deviceIDs(channel.ActiveDevicesAt(24*time.Hour, now.Add(2*time.Hour))))
+}
+
+func TestPushChannel_DevicesByPlatformAt_ExcludesExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	channel := expiryFixture(now)
+
+	assert.Equal(t, map[string][]string{"ios": {"apns-2"}, "android": {"fcm-1"}}, channel.TokensByPlatformAt(now))
+	assert.Equal(t, []string{"forever"}, deviceIDs(channel.DevicesByPlatformAt(now.Add(2 * time.Hour))["ios"]))
+}
+
+func TestPushChannel_ExpiredDevices(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	channel := expiryFixture(now)
+
+	assert.Equal(t, []string{"expired", "inactive-expired"}, deviceIDs(channel.ExpiredDevices(now)))
+	assert.Equal(t, []string{"expired", "valid", "inactive-expired"}, deviceIDs(channel.ExpiredDevices(now.Add(2*time.Hour))))
+	assert.Empty(t, PushChannel{Devices: []Device{{DeviceID: "forever"}}}.ExpiredDevices(now))
+	assert.Len(t, channel.Devices, 4)
+}
+
+func TestDevice_ExpiresAtJSON(t *testing.T) {
+	data, err := json.Marshal(Device{DeviceID: "forever", Token: "a", Platform: "ios"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "expires_at")
+
+	var device Device
+	require.NoError(t, json.Unmarshal([]byte(`{"device_id": "d", "token": "a", "platform": "ios", "expires_at": "2025-06-01T12:00:00Z"}`), &device))
+	assert.Equal(t, time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), device.ExpiresAt)
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Digest frequencies
+const (
+	DigestDaily   = "daily"
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+)
+
+// DefaultDigestTime is when digests go out when Digest.Time is unset
+const DefaultDigestTime = "09:00"
+
+// Digest holds the user's digest settings. Notifications held for a digest
+// are sent together at Time on the user's wall clock, every day, every week
+// on DayOfWeek (Monday when unset) or every month on DayOfMonth (the 1st
+// when unset). A DayOfMonth past the end of a short month means its last
+// day, so 31 sends on 28 or 29 February.
+type Digest struct {
+	Enabled    bool          `json:"enabled"`
+	Frequency  string        `json:"frequency,omitempty"`
+	Time       string        `json:"time,omitempty"`
+	DayOfWeek  *time.Weekday `json:"day_of_week,omitempty"`
+	DayOfMonth int           `json:"day_of_month,omitempty"`
+}
+
+// NextAt returns the first scheduled digest strictly after after, on the
+// wall clock of loc. An unset frequency is daily; unknown frequencies,
+// invalid times and out of range days return an error.
+func (d Digest) NextAt(after time.Time, loc *time.Location) (time.Time, error) {
+	clock := d.Time
+	if clock == "" {
+		clock = DefaultDigestTime
+	}
+	minutes, err := parseClockTime(clock)
+	if err != nil {
+		return after, fmt.Errorf("invalid digest time: %w", err)
+	}
+
+	local := after.In(loc)
+	year, month, day := local.Date()
+	switch d.Frequency {
+	case DigestDaily, "":
+		next := wallClock(loc, year, month, day, minutes)
+		if !next.After(after) {
+			next = wallClock(loc, year, month, day+1, minutes)
+		}
+		return next, nil
+	case DigestWeekly:
+		weekday := time.Monday
+		if d.DayOfWeek != nil {
+			weekday = *d.DayOfWeek
+		}
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return after, fmt.Errorf("invalid digest day of week %d", weekday)
+		}
+		ahead := (int(weekday) - int(local.Weekday()) + 7) % 7
+		next := wallClock(loc, year, month, day+ahead, minutes)
+		if !next.After(after) {
+			next = wallClock(loc, year, month, day+ahead+7, minutes)
+		}
+		return next, nil
+	case DigestMonthly:
+		dayOfMonth := d.DayOfMonth
+		if dayOfMonth == 0 {
+			dayOfMonth = 1
+		}
+		if dayOfMonth < 1 || dayOfMonth > 31 {
+			return after, fmt.Errorf("invalid digest day of month %d", dayOfMonth)
+		}
+		next := wallClock(loc, year, month, clampDay(year, month, dayOfMonth), minutes)
+		if !next.After(after) {
+			year, month = year+int(month)/12, month%12+1
+			next = wallClock(loc, year, month, clampDay(year, month, dayOfMonth), minutes)
+		}
+		return next, nil
+	default:
+		return after, fmt.Errorf("unknown digest frequency %q", d.Frequency)
+	}
+}
+
+// clampDay limits day to the number of days in the month
+func clampDay(year int, month time.Month, day int) int {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > last {
+		return last
+	}
+	return day
+}
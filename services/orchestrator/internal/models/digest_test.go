@@ -0,0 +1,164 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest_NextAt(t *testing.T) {
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	require.NoError(t, err)
+
+	// Wednesday 2025-01-15 10:00 in Nairobi
+	wednesday := time.Date(2025, 1, 15, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		digest   Digest
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "daily later today",
+			digest:   Digest{Frequency: DigestDaily, Time: "18:00"},
+			after:    wednesday,
+			expected: time.Date(2025, 1, 15, 18, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "daily already sent today",
+			digest:   Digest{Frequency: DigestDaily, Time: "08:00"},
+			after:    wednesday,
+			expected: time.Date(2025, 1, 16, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "exactly at the digest time moves to tomorrow",
+			digest:   Digest{Frequency: DigestDaily, Time: "10:00"},
+			after:    wednesday,
+			expected: time.Date(2025, 1, 16, 10, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "unset frequency and time",
+			digest:   Digest{},
+			after:    wednesday,
+			expected: time.Date(2025, 1, 16, 9, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "weekly goes to next monday",
+			digest:   Digest{Frequency: DigestWeekly, Time: "08:00"},
+			after:    wednesday,
+			expected: time.Date(2025, 1, 20, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "weekly later on monday",
+			digest:   Digest{Frequency: DigestWeekly, Time: "08:00"},
+			after:    time.Date(2025, 1, 20, 7, 59, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 20, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "weekly already sent on monday",
+			digest:   Digest{Frequency: DigestWeekly, Time: "08:00"},
+			after:    time.Date(2025, 1, 20, 8, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 27, 8, 0, 0, 0, nairobi),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := tt.digest.NextAt(tt.after, nairobi)
+
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+		})
+	}
+}
+
+func weekday(d time.Weekday) *time.Weekday {
+	return &d
+}
+
+func TestDigest_NextAt_Frequencies(t *testing.T) {
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		digest   Digest
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "weekly on friday",
+			digest:   Digest{Frequency: DigestWeekly, Time: "17:00", DayOfWeek: weekday(time.Friday)},
+			after:    time.Date(2025, 1, 15, 10, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 17, 17, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "weekly on sunday",
+			digest:   Digest{Frequency: DigestWeekly, Time: "08:00", DayOfWeek: weekday(time.Sunday)},
+			after:    time.Date(2025, 1, 19, 9, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 26, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "monthly later this month",
+			digest:   Digest{Frequency: DigestMonthly, Time: "08:00", DayOfMonth: 20},
+			after:    time.Date(2025, 1, 15, 10, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 20, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "monthly defaults to the first",
+			digest:   Digest{Frequency: DigestMonthly, Time: "08:00"},
+			after:    time.Date(2025, 1, 15, 10, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 2, 1, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "monthly rolls over the year",
+			digest:   Digest{Frequency: DigestMonthly, Time: "08:00", DayOfMonth: 10},
+			after:    time.Date(2025, 12, 15, 10, 0, 0, 0, nairobi),
+			expected: time.Date(2026, 1, 10, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "day 31 clamps to end of february",
+			digest:   Digest{Frequency: DigestMonthly, Time: "08:00", DayOfMonth: 31},
+			after:    time.Date(2025, 1, 31, 9, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 2, 28, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "day 31 clamps to leap day",
+			digest:   Digest{Frequency: DigestMonthly, Time: "08:00", DayOfMonth: 31},
+			after:    time.Date(2024, 2, 10, 9, 0, 0, 0, nairobi),
+			expected: time.Date(2024, 2, 29, 8, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "day 31 in a 30 day month",
+			digest:   Digest{Frequency: DigestMonthly, Time: "08:00", DayOfMonth: 31},
+			after:    time.Date(2025, 3, 31, 9, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 4, 30, 8, 0, 0, 0, nairobi),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := tt.digest.NextAt(tt.after, nairobi)
+
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+		})
+	}
+}
+
+func TestDigest_NextAt_Invalid(t *testing.T) {
+	_, err := Digest{Frequency: "hourly"}.NextAt(time.Now(), time.UTC)
+	assert.ErrorContains(t, err, "unknown digest frequency")
+
+	_, err = Digest{Time: "9am"}.NextAt(time.Now(), time.UTC)
+	assert.ErrorContains(t, err, "invalid digest time")
+
+	_, err = Digest{Frequency: DigestMonthly, DayOfMonth: 32}.NextAt(time.Now(), time.UTC)
+	assert.ErrorContains(t, err, "invalid digest day of month")
+
+	_, err = Digest{Frequency: DigestWeekly, DayOfWeek: weekday(9)}.NextAt(time.Now(), time.UTC)
+	assert.ErrorContains(t, err, "invalid digest day of week")
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Errors returned by UserService implementations
+var (
+	ErrUserNotFound           = errors.New("user not found")
+	ErrUserServiceUnavailable = errors.New("user service unavailable")
+	ErrDeviceNotFound         = errors.New("device not found")
+	// ErrVersionConflict means the preferences changed since they were
+	// read; refetch them, reapply the change and retry
+	ErrVersionConflict = errors.New("preferences version conflict")
+)
+
+// ErrShuttingDown is returned for notifications submitted after the
+// orchestrator has started shutting down
+var ErrShuttingDown = errors.New("orchestrator is shutting down")
+
+// ErrRequestInProgress is returned for a notification whose idempotency key
+// is held by another request that is still being processed
+var ErrRequestInProgress = errors.New("a request with this idempotency key is already being processed")
+
+// UserServiceError is returned when the user service answers with a non-200
+// status. It matches ErrUserNotFound for 404s, ErrVersionConflict for 409s
+// and 412s, and ErrUserServiceUnavailable for 5xx responses with errors.Is.
+type UserServiceError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UserServiceError) Error() string {
+	return fmt.Sprintf("user service returned status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *UserServiceError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrUserNotFound
+	case e.StatusCode == http.StatusConflict, e.StatusCode == http.StatusPreconditionFailed:
+		return ErrVersionConflict
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrUserServiceUnavailable
+	default:
+		return nil
+	}
+}
+
+// BulkError is returned alongside the partial results of a bulk lookup and
+// holds the error for each user ID that could not be fetched
+type BulkError struct {
+	Errors map[string]error
+}
+
+func (e *BulkError) Error() string {
+	userIDs := make([]string, 0, len(e.Errors))
+	for userID := range e.Errors {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	failures := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		failures = append(failures, fmt.Sprintf("%s: %v", userID, e.Errors[userID]))
+	}
+	return fmt.Sprintf("failed to fetch %d users: %s", len(userIDs), strings.Join(failures, "; "))
+}
+
+// ValidationError is returned when a preferences update, device or
+// notification request is rejected before reaching the user service. Err
+// holds every violation found.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %v", e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ExportSchemaVersion identifies the layout of UserDataExport. Bump it when a
+// field is renamed or removed so compliance tooling can tell exports apart.
+const ExportSchemaVersion = 1
+
+// Paths of the secrets in an export. They are exported unredacted, so every
+// export lists them in Sensitive to warn whoever handles the document.
+const (
+	SensitiveDeviceTokens  = "preferences.channels.push.devices[].token"
+	SensitiveWebhookSecret = "preferences.channels.webhook.secret"
+)
+
+// sensitivePaths are the paths every export must list in Sensitive
+var sensitivePaths = []string{SensitiveDeviceTokens, SensitiveWebhookSecret}
+
+// UserDataExport is everything stored about a user's notification settings,
+// as returned by UserService.ExportUserData
+type UserDataExport struct {
+	SchemaVersion int              `json:"schema_version"`
+	UserID        string           `json:"user_id"`
+	ExportedAt    time.Time        `json:"exported_at"`
+	Preferences   *UserPreferences `json:"preferences"`
+	OptOut        *OptOutStatus    `json:"opt_out"`
+	// Sensitive lists the paths of fields holding secrets, e.g.
+	// SensitiveDeviceTokens and SensitiveWebhookSecret
+	Sensitive []string `json:"sensitive"`
+}
+
+// MarshalUserDataExport builds userID's export document from their
+// preferences and opt-out status
+func MarshalUserDataExport(userID string, prefs *UserPreferences, optOut *OptOutStatus, exportedAt time.Time) ([]byte, error) {
+	data, err := json.Marshal(UserDataExport{
+		SchemaVersion: ExportSchemaVersion,
+		UserID:        userID,
+		ExportedAt:    exportedAt.UTC(),
+		Preferences:   prefs,
+		OptOut:        optOut,
+		Sensitive:     slices.Clone(sensitivePaths),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal user data export: %w", err)
+	}
+	return data, nil
+}
+
+// ValidateExport checks that data is an export document in the current
+// schema and returns every violation found, joined with errors.Join
+func ValidateExport(data []byte) error {
+	var export UserDataExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("invalid export: %w", err)
+	}
+
+	var errs []error
+	if export.SchemaVersion != ExportSchemaVersion {
+		errs = append(errs, fmt.Errorf("schema_version: must be %d, got %d", ExportSchemaVersion, export.SchemaVersion))
+	}
+	if export.UserID == "" {
+		errs = append(errs, errors.New("user_id: is required"))
+	}
+	if export.ExportedAt.IsZero() {
+		errs = append(errs, errors.New("exported_at: is required"))
+	}
+	if export.Preferences == nil {
+		errs = append(errs, errors.New("preferences: is required"))
+	}
+	if export.OptOut == nil {
+		errs = append(errs, errors.New("opt_out: is required"))
+	} else if export.OptOut.UserID != "" && export.OptOut.UserID != export.UserID {
+		errs = append(errs, fmt.Errorf("opt_out.user_id: must match user_id %q, got %q", export.UserID, export.OptOut.UserID))
+	}
+	for _, path := range sensitivePaths {
+		if !slices.Contains(export.Sensitive, path) {
+			errs = append(errs, fmt.Errorf("sensitive: must list %s", path))
+		}
+	}
+	return errors.Join(errs...)
+}
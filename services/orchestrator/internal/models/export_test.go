@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUserDataExport_ValidatesAgainstSchema(t *testing.T) {
+	prefs := &UserPreferences{UserID: "user-1", Email: true, Channels: Channels{
+		Push: PushChannel{
+			Enabled: true,
+			Devices: []Device{{DeviceID: "dev-1", Token: "tok-1", Platform: "ios"}},
+		},
+		Webhook: WebhookChannel{Enabled: true, URL: "https://example.com/hook", Secret: "whsec-1"},
+	}}
+	optOut := &OptOutStatus{UserID: "user-1", Channels: map[string]bool{ChannelSMS: true}}
+
+	data, err := MarshalUserDataExport("user-1", prefs, optOut, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.NoError(t, ValidateExport(data))
+
+	var export UserDataExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, ExportSchemaVersion, export.SchemaVersion)
+	assert.Equal(t, "tok-1", export.Preferences.Channels.Push.Devices[0].Token)
+	assert.Equal(t, "whsec-1", export.Preferences.Channels.Webhook.Secret)
+	assert.True(t, export.OptOut.Channels[ChannelSMS])
+	assert.Equal(t, []string{SensitiveDeviceTokens, SensitiveWebhookSecret}, export.Sensitive)
+}
+
+func TestValidateExport_Violations(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr []string
+	}{
+		{name: "not json", data: `[`, wantErr: []string{"invalid export"}},
+		{
+			name: "empty",
+			data: `{}`,
+			wantErr: []string{
+				"schema_version: must be 1, got 0", "user_id: is required", "exported_at", "preferences: is required", "opt_out: is required",
+				"sensitive: must list " + SensitiveDeviceTokens, "sensitive: must list " + SensitiveWebhookSecret,
+			},
+		},
+		{
+			name:    "mismatched opt-out",
+			data:    `{"schema_version": 1, "user_id": "user-1", "exported_at": "2025-01-15T12:00:00Z", "preferences": {}, "opt_out": {"user_id": "user-2"}, "sensitive": ["preferences.channels.push.devices[].token", "preferences.channels.webhook.secret"]}`,
+			wantErr: []string{`opt_out.user_id: must match user_id "user-1", got "user-2"`},
+		},
+		{
+			name:    "webhook secret not flagged",
+			data:    `{"schema_version": 1, "user_id": "user-1", "exported_at": "2025-01-15T12:00:00Z", "preferences": {}, "opt_out": {}, "sensitive": ["preferences.channels.push.devices[].token"]}`,
+			wantErr: []string{"sensitive: must list " + SensitiveWebhookSecret},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExport([]byte(tt.data))
+
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+			assert.Len(t, strings.Split(err.Error(), "\n"), len(tt.wantErr))
+		})
+	}
+}
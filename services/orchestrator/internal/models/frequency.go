@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Channel send frequencies. A channel that is not immediate sends a user at
+// most one notification per period.
+const (
+	FrequencyImmediate = "immediate"
+	FrequencyHourly    = "hourly"
+	FrequencyDaily     = "daily"
+)
+
+// FrequencyPeriod returns how long a send on a channel with frequency holds
+// back the next one. Immediate, and an unset frequency, return zero.
+func FrequencyPeriod(frequency string) (time.Duration, error) {
+	switch frequency {
+	case FrequencyImmediate, "":
+		return 0, nil
+	case FrequencyHourly:
+		return time.Hour, nil
+	case FrequencyDaily:
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("must be immediate, hourly or daily, got %q", frequency)
+	}
+}
+
+// FrequencyFor returns the send frequency configured for channel. Channels
+// without a frequency setting are always immediate.
+func (c Channels) FrequencyFor(channel string) string {
+	switch channel {
+	case ChannelEmail:
+		return c.Email.Frequency
+	case ChannelPush:
+		return c.Push.Frequency
+	case ChannelSMS:
+		return c.SMS.Frequency
+	default:
+		return FrequencyImmediate
+	}
+}
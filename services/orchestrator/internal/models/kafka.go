@@ -14,8 +14,22 @@ type KafkaNotificationPayload struct {
 	TextBody         string                 `json:"text_body,omitempty"`
 	Priority         string                 `json:"priority"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	CorrelationID    string                 `json:"correlation_id,omitempty"`
 	CreatedAt        time.Time              `json:"created_at"`
 
 	RetryCount  int       `json:"retry_count,omitempty"`
 	LastRetryAt time.Time `json:"last_retry_at,omitempty"`
+
+	// DeviceTokens holds the push tokens to deliver to, keyed by platform
+	// ("ios", "android", "web"), so the push service can route each group
+	// to APNs or FCM. It is only set on push notifications.
+	DeviceTokens map[string][]string `json:"device_tokens,omitempty"`
+
+	// Attributes are published as message headers, not in the value
+	Attributes map[string]string `json:"-"`
+}
+
+// KafkaMetadata returns the attributes for the publisher to send as headers
+func (p *KafkaNotificationPayload) KafkaMetadata() map[string]string {
+	return p.Attributes
 }
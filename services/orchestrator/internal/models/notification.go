@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 type NotificationRequest struct {
 	RequestID        string                 `json:"request_id" binding:"required"`
@@ -9,8 +13,64 @@ type NotificationRequest struct {
 	TemplateCode     string                 `json:"template_code" binding:"required"`
 	Variables        map[string]interface{} `json:"variables"`
 	Priority         int                    `json:"priority,omitempty"`
+	Category         string                 `json:"category,omitempty" binding:"omitempty,oneof=transactional urgent marketing reminders"`
 	ScheduledFor     *time.Time             `json:"scheduled_for,omitempty"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+
+	// Attributes are string fields such as a campaign ID or A/B variant
+	// that consumers route or count by. Unlike Metadata they travel as
+	// Kafka headers rather than in the message value, so consumers can
+	// filter without decoding it.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// IdempotencyKey identifies the logical notification. Requests repeating
+	// a key already processed get the original response back instead of
+	// sending again.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// CorrelationID follows the notification through every service and
+	// Kafka message it touches. One is generated when it is empty.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Notification categories. Transactional and urgent notifications are sent
+// straight away, the others wait for the end of the user's quiet hours.
+const (
+	CategoryTransactional = "transactional"
+	CategoryUrgent        = "urgent"
+	CategoryMarketing     = "marketing"
+	CategoryReminders     = "reminders"
+)
+
+// Validate checks a request before it enters the pipeline and returns every
+// violation found, joined with errors.Join, or nil when it is valid. It
+// covers the same rules as the binding tags for requests that do not come
+// through the HTTP handler.
+func (r *NotificationRequest) Validate() error {
+	if r == nil {
+		return errors.New("notification request is required")
+	}
+
+	var errs []error
+	if r.UserID == "" {
+		errs = append(errs, errors.New("user_id: is required"))
+	}
+	switch r.NotificationType {
+	case NotificationEmail, NotificationPush:
+	case "":
+		errs = append(errs, errors.New("notification_type: is required"))
+	default:
+		errs = append(errs, fmt.Errorf("notification_type: must be %s or %s, got %q", NotificationEmail, NotificationPush, r.NotificationType))
+	}
+	if r.TemplateCode == "" {
+		errs = append(errs, errors.New("template_code: is required"))
+	}
+	switch r.Category {
+	case "", CategoryTransactional, CategoryUrgent, CategoryMarketing, CategoryReminders:
+	default:
+		errs = append(errs, fmt.Errorf("category: unknown category %q", r.Category))
+	}
+	return errors.Join(errs...)
 }
 
 type NotificationType string
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationRequest_Validate(t *testing.T) {
+	valid := func() *NotificationRequest {
+		return &NotificationRequest{
+			RequestID:        "req-1",
+			NotificationType: NotificationEmail,
+			UserID:           "user-1",
+			TemplateCode:     "welcome_email",
+			Category:         CategoryTransactional,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *NotificationRequest)
+		wantErr []string
+	}{
+		{name: "valid", modify: func(r *NotificationRequest) {}},
+		{name: "valid without category", modify: func(r *NotificationRequest) { r.Category = "" }},
+		{name: "valid push", modify: func(r *NotificationRequest) { r.NotificationType = NotificationPush }},
+		{name: "missing user", modify: func(r *NotificationRequest) { r.UserID = "" }, wantErr: []string{"user_id: is required"}},
+		{name: "missing type", modify: func(r *NotificationRequest) { r.NotificationType = "" }, wantErr: []string{"notification_type: is required"}},
+		{name: "unknown type", modify: func(r *NotificationRequest) { r.NotificationType = "fax" }, wantErr: []string{`notification_type: must be email or push, got "fax"`}},
+		{name: "missing template", modify: func(r *NotificationRequest) { r.TemplateCode = "" }, wantErr: []string{"template_code: is required"}},
+		{name: "unknown category", modify: func(r *NotificationRequest) { r.Category = "promo" }, wantErr: []string{`category: unknown category "promo"`}},
+		{
+			name:    "several",
+			modify:  func(r *NotificationRequest) { *r = NotificationRequest{Category: "promo"} },
+			wantErr: []string{"user_id", "notification_type", "template_code", "category"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(req)
+
+			err := req.Validate()
+
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Len(t, strings.Split(err.Error(), "\n"), len(tt.wantErr))
+				for _, want := range tt.wantErr {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestNotificationRequest_ValidateNil(t *testing.T) {
+	var req *NotificationRequest
+	assert.EqualError(t, req.Validate(), "notification request is required")
+}
@@ -0,0 +1,126 @@
+package models
+
+// PreferencesPatch is a partial preferences update, e.g. from a settings
+// screen that changes one toggle. Its JSON shape mirrors UserPreferences,
+// but every field is a pointer so a field left out (nil) can be told apart
+// from one set to false or empty. Verification state and push devices are
+// owned by the user service and the device APIs, so they cannot be patched.
+type PreferencesPatch struct {
+	Email *bool `json:"email_enabled,omitempty"`
+	Push  *bool `json:"push_enabled,omitempty"`
+
+	EmailAddress *string `json:"email,omitempty"`
+	Phone        *string `json:"phone,omitempty"`
+	Timezone     *string `json:"timezone,omitempty"`
+	Language     *string `json:"language,omitempty"`
+
+	NotificationEnabled *bool `json:"notification_enabled,omitempty"`
+	Marketing           *bool `json:"marketing,omitempty"`
+	Transactional       *bool `json:"transactional,omitempty"`
+	Reminders           *bool `json:"reminders,omitempty"`
+
+	Channels *ChannelsPatch `json:"channels,omitempty"`
+	// Digest replaces the digest settings as a whole
+	Digest *Digest `json:"digest,omitempty"`
+}
+
+// ChannelsPatch updates individual channels. QuietHours, when set, replace
+// the channel's quiet hours as a whole.
+type ChannelsPatch struct {
+	Email    *ChannelPatch `json:"email,omitempty"`
+	Push     *ChannelPatch `json:"push,omitempty"`
+	SMS      *ChannelPatch `json:"sms,omitempty"`
+	WhatsApp *ChannelPatch `json:"whatsapp,omitempty"`
+	Webhook  *WebhookPatch `json:"webhook,omitempty"`
+	InApp    *InAppPatch   `json:"in_app,omitempty"`
+}
+
+// ChannelPatch updates the settings email, push, SMS and WhatsApp share.
+// WhatsApp has no frequency, so Frequency is ignored for it.
+type ChannelPatch struct {
+	Enabled    *bool       `json:"enabled,omitempty"`
+	Frequency  *string     `json:"frequency,omitempty"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+}
+
+// WebhookPatch updates the webhook channel
+type WebhookPatch struct {
+	Enabled *bool   `json:"enabled,omitempty"`
+	URL     *string `json:"url,omitempty"`
+	Secret  *string `json:"secret,omitempty"`
+	Format  *string `json:"format,omitempty"`
+}
+
+// InAppPatch updates the in-app channel
+type InAppPatch struct {
+	Enabled       *bool `json:"enabled,omitempty"`
+	RetentionDays *int  `json:"retention_days,omitempty"`
+}
+
+// MergePreferences returns a copy of current with every field set in patch
+// applied, so false and empty values in the patch take effect while fields
+// it leaves nil keep their current value. current is not modified. The
+// result should be checked with ValidateUpdate before it is stored.
+func MergePreferences(current *UserPreferences, patch *PreferencesPatch) *UserPreferences {
+	merged := &UserPreferences{}
+	if current != nil {
+		*merged = *current
+		merged.Channels.Push.Devices = append([]UserDevice(nil), current.Channels.Push.Devices...)
+	}
+	if patch == nil {
+		return merged
+	}
+
+	patchField(&merged.Email, patch.Email)
+	patchField(&merged.Push, patch.Push)
+	patchField(&merged.EmailAddress, patch.EmailAddress)
+	patchField(&merged.Phone, patch.Phone)
+	patchField(&merged.Timezone, patch.Timezone)
+	patchField(&merged.Language, patch.Language)
+	patchField(&merged.NotificationEnabled, patch.NotificationEnabled)
+	patchField(&merged.Marketing, patch.Marketing)
+	patchField(&merged.Transactional, patch.Transactional)
+	patchField(&merged.Reminders, patch.Reminders)
+	patchField(&merged.Digest, patch.Digest)
+
+	if c := patch.Channels; c != nil {
+		channels := &merged.Channels
+		if p := c.Email; p != nil {
+			patchField(&channels.Email.Enabled, p.Enabled)
+			patchField(&channels.Email.Frequency, p.Frequency)
+			patchField(&channels.Email.QuietHours, p.QuietHours)
+		}
+		if p := c.Push; p != nil {
+			patchField(&channels.Push.Enabled, p.Enabled)
+			patchField(&channels.Push.Frequency, p.Frequency)
+			patchField(&channels.Push.QuietHours, p.QuietHours)
+		}
+		if p := c.SMS; p != nil {
+			patchField(&channels.SMS.Enabled, p.Enabled)
+			patchField(&channels.SMS.Frequency, p.Frequency)
+			patchField(&channels.SMS.QuietHours, p.QuietHours)
+		}
+		if p := c.WhatsApp; p != nil {
+			patchField(&channels.WhatsApp.Enabled, p.Enabled)
+			patchField(&channels.WhatsApp.QuietHours, p.QuietHours)
+		}
+		if p := c.Webhook; p != nil {
+			patchField(&channels.Webhook.Enabled, p.Enabled)
+			patchField(&channels.Webhook.URL, p.URL)
+			patchField(&channels.Webhook.Secret, p.Secret)
+			patchField(&channels.Webhook.Format, p.Format)
+		}
+		if p := c.InApp; p != nil {
+			patchField(&channels.InApp.Enabled, p.Enabled)
+			patchField(&channels.InApp.RetentionDays, p.RetentionDays)
+		}
+	}
+	return merged
+}
+
+// patchField assigns *value to *field when value is not nil
+func patchField[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
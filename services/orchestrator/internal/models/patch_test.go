@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func strPtr(s string) *string { return &s }
+
+func TestMergePreferences_DisablesChannel(t *testing.T) {
+	current := validPreferences()
+	current.Marketing = true
+
+	var patch PreferencesPatch
+	require.NoError(t, json.Unmarshal([]byte(`{"email_enabled": false, "channels": {"email": {"enabled": false}}}`), &patch))
+
+	merged := MergePreferences(current, &patch)
+
+	assert.False(t, merged.Email)
+	assert.False(t, merged.Channels.Email.Enabled)
+	// Fields the patch left out keep their value
+	assert.True(t, merged.Push)
+	assert.True(t, merged.Marketing)
+	assert.True(t, merged.Channels.Email.Verified)
+	assert.Equal(t, current.Channels.Email.QuietHours, merged.Channels.Email.QuietHours)
+	assert.Equal(t, "Africa/Nairobi", merged.Timezone)
+	// current is left alone
+	assert.True(t, current.Email)
+	assert.True(t, current.Channels.Email.Enabled)
+}
+
+func TestMergePreferences_EnablesChannel(t *testing.T) {
+	current := validPreferences()
+	patch := &PreferencesPatch{Channels: &ChannelsPatch{
+		SMS: &ChannelPatch{Enabled: boolPtr(true), Frequency: strPtr(FrequencyHourly)},
+	}}
+
+	merged := MergePreferences(current, patch)
+
+	assert.True(t, merged.Channels.SMS.Enabled)
+	assert.Equal(t, FrequencyHourly, merged.Channels.SMS.Frequency)
+	assert.False(t, merged.Channels.SMS.Verified)
+	assert.Equal(t, current.Channels.Email, merged.Channels.Email)
+	assert.Equal(t, current.Channels.Push, merged.Channels.Push)
+	assert.False(t, current.Channels.SMS.Enabled)
+}
+
+func TestMergePreferences_EmptyPatchKeepsEverything(t *testing.T) {
+	current := validPreferences()
+	current.Channels.Push.Devices = []Device{{DeviceID: "dev-1", Token: "a", Platform: "ios"}}
+
+	merged := MergePreferences(current, &PreferencesPatch{})
+	assert.Equal(t, current, merged)
+
+	// The copy does not share the device list
+	merged.Channels.Push.Devices[0].Token = "b"
+	assert.Equal(t, "a", current.Channels.Push.Devices[0].Token)
+
+	assert.Equal(t, current, MergePreferences(current, nil))
+}
+
+func TestMergePreferences_ClearsAndReplaces(t *testing.T) {
+	current := validPreferences()
+	current.Digest = Digest{Enabled: true, Frequency: DigestWeekly}
+	patch := &PreferencesPatch{
+		Phone:  strPtr(""),
+		Digest: &Digest{Enabled: false},
+		Channels: &ChannelsPatch{
+			Email:   &ChannelPatch{QuietHours: &QuietHours{}},
+			Webhook: &WebhookPatch{Enabled: boolPtr(true), URL: strPtr("https://example.com/hook")},
+		},
+	}
+
+	merged := MergePreferences(current, patch)
+
+	assert.Empty(t, merged.Phone)
+	assert.Equal(t, Digest{}, merged.Digest)
+	assert.Equal(t, QuietHours{}, merged.Channels.Email.QuietHours)
+	assert.True(t, merged.Channels.Email.Enabled)
+	assert.Equal(t, WebhookChannel{Enabled: true, URL: "https://example.com/hook"}, merged.Channels.Webhook)
+	assert.NoError(t, ValidatePreferences(merged))
+}
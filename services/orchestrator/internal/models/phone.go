@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPhoneRegion is assumed for numbers written in local format
+const DefaultPhoneRegion = "KE"
+
+// phoneRegion describes how local numbers are written in a region
+type phoneRegion struct {
+	callingCode    string
+	nationalDigits int
+}
+
+// phoneRegions covers the markets we deliver SMS to. National numbers are
+// written with a leading trunk 0 that is dropped in E.164.
+var phoneRegions = map[string]phoneRegion{
+	"KE": {callingCode: "254", nationalDigits: 9},
+	"UG": {callingCode: "256", nationalDigits: 9},
+	"TZ": {callingCode: "255", nationalDigits: 9},
+	"RW": {callingCode: "250", nationalDigits: 9},
+}
+
+// NormalizePhone converts raw to E.164. International numbers (+... or
+// 00...) are kept as they are; local numbers such as 0712345678 or
+// 254712345678 are interpreted in defaultRegion, e.g. "KE". Spaces, dashes,
+// dots and parentheses are ignored.
+func NormalizePhone(raw, defaultRegion string) (string, error) {
+	cleaned := strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(raw))
+	if cleaned == "" {
+		return "", fmt.Errorf("phone number is empty")
+	}
+
+	if strings.HasPrefix(cleaned, "00") {
+		cleaned = "+" + cleaned[2:]
+	}
+
+	if strings.HasPrefix(cleaned, "+") {
+		if !e164Pattern.MatchString(cleaned) {
+			return "", fmt.Errorf("invalid phone number %q", raw)
+		}
+		for _, region := range phoneRegions {
+			if national, ok := strings.CutPrefix(cleaned[1:], region.callingCode); ok && len(national) != region.nationalDigits {
+				return "", fmt.Errorf("invalid phone number %q: expected %d digits after +%s", raw, region.nationalDigits, region.callingCode)
+			}
+		}
+		return cleaned, nil
+	}
+
+	if !isDigits(cleaned) {
+		return "", fmt.Errorf("invalid phone number %q", raw)
+	}
+
+	region, ok := phoneRegions[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return "", fmt.Errorf("unsupported phone region %q for local number %q", defaultRegion, raw)
+	}
+
+	var national string
+	switch {
+	case strings.HasPrefix(cleaned, region.callingCode) && len(cleaned) == len(region.callingCode)+region.nationalDigits:
+		national = cleaned[len(region.callingCode):]
+	case strings.HasPrefix(cleaned, "0") && len(cleaned) == region.nationalDigits+1:
+		national = cleaned[1:]
+	case len(cleaned) == region.nationalDigits && cleaned[0] != '0':
+		national = cleaned
+	default:
+		return "", fmt.Errorf("invalid phone number %q for region %s", raw, strings.ToUpper(defaultRegion))
+	}
+
+	return "+" + region.callingCode + national, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone_Valid(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		region   string
+		expected string
+	}{
+		{name: "local with trunk zero", raw: "0712345678", region: "KE", expected: "+254712345678"},
+		{name: "local with spaces", raw: "0712 345 678", region: "KE", expected: "+254712345678"},
+		{name: "local new 01 prefix", raw: "0110123456", region: "KE", expected: "+254110123456"},
+		{name: "country code without plus", raw: "254712345678", region: "KE", expected: "+254712345678"},
+		{name: "national digits only", raw: "712345678", region: "KE", expected: "+254712345678"},
+		{name: "lowercase region", raw: "0712345678", region: "ke", expected: "+254712345678"},
+		{name: "already e164", raw: "+254712345678", region: "KE", expected: "+254712345678"},
+		{name: "international with dashes", raw: "+254-712-345-678", region: "", expected: "+254712345678"},
+		{name: "international 00 prefix", raw: "00254712345678", region: "KE", expected: "+254712345678"},
+		{name: "foreign e164", raw: "+14155552671", region: "KE", expected: "+14155552671"},
+		{name: "uganda local", raw: "0772123456", region: "UG", expected: "+256772123456"},
+		{name: "parentheses", raw: "(0712) 345678", region: "KE", expected: "+254712345678"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.raw, tt.region)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalizePhone_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		region string
+	}{
+		{name: "empty", raw: "", region: "KE"},
+		{name: "too short", raw: "07123", region: "KE"},
+		{name: "too long local", raw: "07123456789", region: "KE"},
+		{name: "letters", raw: "07123abc78", region: "KE"},
+		{name: "kenyan e164 wrong length", raw: "+2547123456", region: "KE"},
+		{name: "plus with letters", raw: "+2547abc", region: "KE"},
+		{name: "plus zero", raw: "+0712345678", region: "KE"},
+		{name: "local without region", raw: "0712345678", region: ""},
+		{name: "unknown region", raw: "0712345678", region: "ZZ"},
+		{name: "e164 too long", raw: "+1234567890123456", region: "KE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.raw, tt.region)
+			assert.Error(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
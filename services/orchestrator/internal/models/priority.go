@@ -0,0 +1,29 @@
+package models
+
+// Priority is how urgently a notification must be delivered, as named in
+// the Kafka message contract. High and urgent notifications are published
+// to the priority topics, so a marketing blast cannot hold up a one-time
+// password.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// PriorityFromLevel maps NotificationRequest.Priority to a Priority: 1 is
+// low, 2 normal, 3 high and 4 urgent. 0 and unknown levels are normal.
+func PriorityFromLevel(level int) Priority {
+	switch level {
+	case 1:
+		return PriorityLow
+	case 3:
+		return PriorityHigh
+	case 4:
+		return PriorityUrgent
+	default:
+		return PriorityNormal
+	}
+}
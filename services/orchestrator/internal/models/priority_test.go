@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityFromLevel(t *testing.T) {
+	tests := []struct {
+		level    int
+		expected Priority
+	}{
+		{0, PriorityNormal},
+		{1, PriorityLow},
+		{2, PriorityNormal},
+		{3, PriorityHigh},
+		{4, PriorityUrgent},
+		{99, PriorityNormal},
+		{-1, PriorityNormal},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, PriorityFromLevel(tt.level), "level %d", tt.level)
+	}
+}
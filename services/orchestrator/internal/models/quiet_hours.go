@@ -0,0 +1,207 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IsActiveAt reports whether t falls inside any quiet-hours window, evaluated
+// on the wall clock of the window's timezone (UTC when unset). Windows that
+// end before they start, such as 22:00-07:00, span midnight. Disabled quiet
+// hours are never active; invalid times or timezones return an error.
+func (q QuietHours) IsActiveAt(t time.Time) (bool, error) {
+	if !q.Enabled {
+		return false, nil
+	}
+
+	for _, w := range q.windows() {
+		window, err := w.parse()
+		if err != nil {
+			return false, err
+		}
+		if window.activeAt(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AppliesTo reports whether quiet hours hold back notifications in category.
+// Transactional and urgent notifications, such as OTPs and fraud alerts,
+// bypass them; every other category, including none, obeys them.
+func (q QuietHours) AppliesTo(category string) bool {
+	switch category {
+	case CategoryTransactional, CategoryUrgent:
+		return false
+	default:
+		return true
+	}
+}
+
+// NextAllowedTime returns from when it is outside quiet hours, or else the
+// moment the windows covering it end, in the window's timezone and on the
+// next day when a window spans midnight. Overlapping and adjacent windows
+// are followed through to the end of the last one. If an end time is skipped
+// by a DST change the moment the clocks jumped is returned, so the result is
+// always a real instant.
+func (q QuietHours) NextAllowedTime(from time.Time) (time.Time, error) {
+	if !q.Enabled {
+		return from, nil
+	}
+
+	var windows []quietWindow
+	for _, w := range q.windows() {
+		window, err := w.parse()
+		if err != nil {
+			return from, err
+		}
+		windows = append(windows, window)
+	}
+
+	// Each pass either leaves next unchanged or moves it to the end of a
+	// window, so windows that cover the whole day are the only way to loop
+	next := from
+	for pass := 0; pass <= len(windows); pass++ {
+		moved := false
+		for _, window := range windows {
+			if window.activeAt(next) {
+				next = window.endAfter(next)
+				moved = true
+			}
+		}
+		if !moved {
+			return next, nil
+		}
+	}
+	return from, fmt.Errorf("quiet hours cover the entire day")
+}
+
+// Migrate returns the quiet hours with the single-window Start and End moved
+// into Windows, so stored preferences can be rewritten in the new shape.
+func (q QuietHours) Migrate() QuietHours {
+	return QuietHours{
+		Enabled:  q.Enabled,
+		Timezone: q.Timezone,
+		Days:     q.Days,
+		Windows:  q.windows(),
+	}
+}
+
+// windows returns every configured window, the single-window fields first,
+// with Enabled, Timezone and Days inherited
+func (q QuietHours) windows() []QuietHours {
+	var windows []QuietHours
+	if q.Start != "" || q.End != "" {
+		windows = append(windows, QuietHours{Enabled: q.Enabled, Start: q.Start, End: q.End, Timezone: q.Timezone, Days: q.Days})
+	}
+	for _, w := range q.Windows {
+		window := QuietHours{Enabled: q.Enabled, Start: w.Start, End: w.End, Timezone: w.Timezone, Days: w.Days}
+		if window.Timezone == "" {
+			window.Timezone = q.Timezone
+		}
+		if len(window.Days) == 0 {
+			window.Days = q.Days
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// quietWindow is a single parsed window with start and end as minutes past
+// midnight. days is nil when the window applies every day.
+type quietWindow struct {
+	loc        *time.Location
+	start, end int
+	days       map[time.Weekday]bool
+}
+
+func (w quietWindow) activeAt(t time.Time) bool {
+	local := t.In(w.loc)
+	now := local.Hour()*60 + local.Minute()
+	if w.start < w.end {
+		return now >= w.start && now < w.end && w.appliesOn(local.Weekday())
+	}
+	if now >= w.start {
+		return w.appliesOn(local.Weekday())
+	}
+	// After midnight the window started the previous local day
+	return now < w.end && w.appliesOn((local.Weekday()+6)%7)
+}
+
+func (w quietWindow) appliesOn(day time.Weekday) bool {
+	return w.days == nil || w.days[day]
+}
+
+// endAfter returns the first end of the window after from
+func (w quietWindow) endAfter(from time.Time) time.Time {
+	local := from.In(w.loc)
+	year, month, day := local.Date()
+	next := wallClock(w.loc, year, month, day, w.end)
+	if !next.After(from) {
+		next = wallClock(w.loc, year, month, day+1, w.end)
+	}
+	return next
+}
+
+// wallClock returns the instant the clock in loc shows minutes past
+// midnight on the given day. Wall times that do not exist because of a DST
+// jump resolve to the moment of the jump.
+func wallClock(loc *time.Location, year int, month time.Month, day, minutes int) time.Time {
+	t := time.Date(year, month, day, minutes/60, minutes%60, 0, 0, loc)
+	got := t.Hour()*60 + t.Minute()
+	if got == minutes {
+		return t
+	}
+
+	start, end := t.ZoneBounds()
+	if got < minutes {
+		return end
+	}
+	return start
+}
+
+// parse resolves the timezone and the window times of a single window
+func (q QuietHours) parse() (quietWindow, error) {
+	loc, err := LoadTimezone(q.Timezone)
+	if err != nil {
+		return quietWindow{}, fmt.Errorf("invalid quiet hours timezone: %w", err)
+	}
+
+	start, err := parseClockTime(q.Start)
+	if err != nil {
+		return quietWindow{}, fmt.Errorf("invalid quiet hours start: %w", err)
+	}
+	end, err := parseClockTime(q.End)
+	if err != nil {
+		return quietWindow{}, fmt.Errorf("invalid quiet hours end: %w", err)
+	}
+	if start == end {
+		return quietWindow{}, fmt.Errorf("quiet hours start and end must differ, both are %s", q.Start)
+	}
+
+	var days map[time.Weekday]bool
+	if len(q.Days) > 0 {
+		days = make(map[time.Weekday]bool, len(q.Days))
+		for _, day := range q.Days {
+			if day < time.Sunday || day > time.Saturday {
+				return quietWindow{}, fmt.Errorf("invalid quiet hours day %d", day)
+			}
+			days[day] = true
+		}
+	}
+
+	return quietWindow{loc: loc, start: start, end: end, days: days}, nil
+}
+
+// parseClockTime converts HH:MM into minutes past midnight
+func parseClockTime(value string) (int, error) {
+	if !clockTimePattern.MatchString(value) {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	hours, minutes, _ := strings.Cut(value, ":")
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	return h*60 + m, nil
+}
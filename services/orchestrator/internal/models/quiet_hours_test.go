@@ -0,0 +1,420 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestQuietHours_IsActiveAt(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	overnight := QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "Africa/Nairobi"}
+	midday := QuietHours{Enabled: true, Start: "13:00", End: "14:00", Timezone: "Africa/Nairobi"}
+	nyOvernight := QuietHours{Enabled: true, Start: "01:00", End: "04:00", Timezone: "America/New_York"}
+
+	tests := []struct {
+		name     string
+		hours    QuietHours
+		at       time.Time
+		expected bool
+	}{
+		{name: "overnight before start", hours: overnight, at: time.Date(2025, 1, 15, 21, 59, 0, 0, nairobi), expected: false},
+		{name: "overnight at start", hours: overnight, at: time.Date(2025, 1, 15, 22, 0, 0, 0, nairobi), expected: true},
+		{name: "overnight before midnight", hours: overnight, at: time.Date(2025, 1, 15, 23, 30, 0, 0, nairobi), expected: true},
+		{name: "overnight after midnight", hours: overnight, at: time.Date(2025, 1, 16, 3, 0, 0, 0, nairobi), expected: true},
+		{name: "overnight at end", hours: overnight, at: time.Date(2025, 1, 16, 7, 0, 0, 0, nairobi), expected: false},
+		{name: "overnight midday", hours: overnight, at: time.Date(2025, 1, 16, 12, 0, 0, 0, nairobi), expected: false},
+		{name: "same day inside", hours: midday, at: time.Date(2025, 1, 16, 13, 30, 0, 0, nairobi), expected: true},
+		{name: "same day outside", hours: midday, at: time.Date(2025, 1, 16, 14, 0, 0, 0, nairobi), expected: false},
+		// 20:00 UTC is 23:00 in Nairobi
+		{name: "converts from utc", hours: overnight, at: time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC), expected: true},
+		{name: "utc when timezone unset", hours: QuietHours{Enabled: true, Start: "22:00", End: "07:00"}, at: time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC), expected: true},
+		{name: "disabled", hours: QuietHours{Start: "22:00", End: "07:00"}, at: time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC), expected: false},
+		// 2024-03-10 02:00 EST jumps to 03:00 EDT: 06:30 UTC is 01:30 EST, 07:30 UTC is 03:30 EDT
+		{name: "before spring forward", hours: nyOvernight, at: time.Date(2024, 3, 10, 6, 30, 0, 0, time.UTC), expected: true},
+		{name: "after spring forward", hours: nyOvernight, at: time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC), expected: true},
+		{name: "spring forward window end", hours: nyOvernight, at: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), expected: false},
+		// 2024-11-03 02:00 EDT falls back to 01:00 EST: 05:30 and 06:30 UTC are both 01:30 local
+		{name: "first 01:30 on fall back", hours: nyOvernight, at: time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), expected: true},
+		{name: "second 01:30 on fall back", hours: nyOvernight, at: time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), expected: true},
+		{name: "after fall back window", hours: nyOvernight, at: time.Date(2024, 11, 3, 4, 0, 0, 0, newYork), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := tt.hours.IsActiveAt(tt.at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+		})
+	}
+}
+
+func TestQuietHours_IsActiveAt_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours QuietHours
+	}{
+		{name: "invalid timezone", hours: QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"}},
+		{name: "malformed start", hours: QuietHours{Enabled: true, Start: "10pm", End: "07:00"}},
+		{name: "malformed end", hours: QuietHours{Enabled: true, Start: "22:00", End: "24:00"}},
+		{name: "start equals end", hours: QuietHours{Enabled: true, Start: "22:00", End: "22:00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := tt.hours.IsActiveAt(time.Now())
+			assert.Error(t, err)
+			assert.False(t, active)
+		})
+	}
+}
+
+func TestQuietHours_NextAllowedTime(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+
+	overnight := QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "Africa/Nairobi"}
+	midday := QuietHours{Enabled: true, Start: "13:00", End: "14:00", Timezone: "Africa/Nairobi"}
+
+	tests := []struct {
+		name     string
+		hours    QuietHours
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "outside window returns from",
+			hours:    overnight,
+			from:     time.Date(2025, 1, 15, 12, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 15, 12, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "evening part ends next day",
+			hours:    overnight,
+			from:     time.Date(2025, 1, 15, 23, 15, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 16, 7, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "morning part ends same day",
+			hours:    overnight,
+			from:     time.Date(2025, 1, 16, 3, 0, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 16, 7, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "same day window",
+			hours:    midday,
+			from:     time.Date(2025, 1, 16, 13, 45, 0, 0, nairobi),
+			expected: time.Date(2025, 1, 16, 14, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "utc input converted to window timezone",
+			hours:    overnight,
+			from:     time.Date(2025, 1, 31, 20, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 2, 1, 7, 0, 0, 0, nairobi),
+		},
+		{
+			name:     "disabled returns from",
+			hours:    QuietHours{Start: "22:00", End: "07:00"},
+			from:     time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := tt.hours.NextAllowedTime(tt.from)
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+		})
+	}
+}
+
+func TestQuietHours_NextAllowedTime_SpringForward(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	// On 2024-03-10 clocks jump from 02:00 EST to 03:00 EDT, so 02:30 never
+	// happens and the window ends when the clocks jump
+	hours := QuietHours{Enabled: true, Start: "23:00", End: "02:30", Timezone: "America/New_York"}
+	from := time.Date(2024, 3, 9, 23, 30, 0, 0, newYork)
+
+	next, err := hours.NextAllowedTime(from)
+
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC).Equal(next), "got %s", next)
+	assert.Equal(t, "EDT", next.Format("MST"))
+	active, err := hours.IsActiveAt(next)
+	require.NoError(t, err)
+	assert.False(t, active)
+}
+
+func TestQuietHours_NextAllowedTime_AcrossSpringForward(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	// The night is an hour shorter, but delivery still resumes at 07:00 local
+	hours := QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "America/New_York"}
+	from := time.Date(2024, 3, 9, 22, 30, 0, 0, newYork)
+
+	next, err := hours.NextAllowedTime(from)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-10 07:00 EDT", next.Format("2006-01-02 15:04 MST"))
+}
+
+func TestQuietHours_NextAllowedTime_Error(t *testing.T) {
+	hours := QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"}
+
+	_, err := hours.NextAllowedTime(time.Now())
+
+	assert.Error(t, err)
+}
+
+func TestQuietHours_MultipleWindows(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+	hours := QuietHours{
+		Enabled:  true,
+		Timezone: "Africa/Nairobi",
+		Windows: []QuietHours{
+			{Start: "13:00", End: "14:00"},
+			{Start: "22:00", End: "07:00"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected bool
+	}{
+		{name: "midday window", at: time.Date(2025, 1, 15, 13, 30, 0, 0, nairobi), expected: true},
+		{name: "overnight window", at: time.Date(2025, 1, 15, 23, 0, 0, 0, nairobi), expected: true},
+		{name: "between windows", at: time.Date(2025, 1, 15, 16, 0, 0, 0, nairobi), expected: false},
+		{name: "morning", at: time.Date(2025, 1, 15, 9, 0, 0, 0, nairobi), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := hours.IsActiveAt(tt.at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+		})
+	}
+}
+
+func TestQuietHours_LegacyFieldsCombineWithWindows(t *testing.T) {
+	hours := QuietHours{
+		Enabled: true,
+		Start:   "22:00",
+		End:     "07:00",
+		Windows: []QuietHours{{Start: "13:00", End: "14:00"}},
+	}
+
+	active, err := hours.IsActiveAt(time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	active, err = hours.IsActiveAt(time.Date(2025, 1, 15, 13, 15, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, active)
+}
+
+func TestQuietHours_WindowTimezoneOverride(t *testing.T) {
+	hours := QuietHours{
+		Enabled:  true,
+		Timezone: "Africa/Nairobi",
+		Windows:  []QuietHours{{Start: "09:00", End: "10:00", Timezone: "UTC"}},
+	}
+
+	// 09:30 UTC is 12:30 in Nairobi
+	active, err := hours.IsActiveAt(time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, active)
+}
+
+func TestQuietHours_NextAllowedTime_OverlappingWindows(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+	hours := QuietHours{
+		Enabled:  true,
+		Timezone: "Africa/Nairobi",
+		Windows: []QuietHours{
+			{Start: "14:00", End: "16:00"},
+			{Start: "13:00", End: "15:00"},
+		},
+	}
+
+	next, err := hours.NextAllowedTime(time.Date(2025, 1, 15, 13, 30, 0, 0, nairobi))
+
+	require.NoError(t, err)
+	assert.True(t, time.Date(2025, 1, 15, 16, 0, 0, 0, nairobi).Equal(next), "got %s", next)
+}
+
+func TestQuietHours_NextAllowedTime_AdjacentWindows(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+	hours := QuietHours{
+		Enabled:  true,
+		Timezone: "Africa/Nairobi",
+		Windows: []QuietHours{
+			{Start: "14:00", End: "15:00"},
+			{Start: "13:00", End: "14:00"},
+		},
+	}
+
+	at := time.Date(2025, 1, 15, 14, 0, 0, 0, nairobi)
+	active, err := hours.IsActiveAt(at)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	next, err := hours.NextAllowedTime(time.Date(2025, 1, 15, 13, 10, 0, 0, nairobi))
+	require.NoError(t, err)
+	assert.True(t, time.Date(2025, 1, 15, 15, 0, 0, 0, nairobi).Equal(next), "got %s", next)
+}
+
+func TestQuietHours_NextAllowedTime_WholeDay(t *testing.T) {
+	hours := QuietHours{
+		Enabled: true,
+		Windows: []QuietHours{
+			{Start: "00:00", End: "12:00"},
+			{Start: "12:00", End: "00:00"},
+		},
+	}
+
+	_, err := hours.NextAllowedTime(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+
+	assert.Error(t, err)
+}
+
+func TestQuietHours_Migrate(t *testing.T) {
+	legacy := QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "Africa/Nairobi"}
+
+	migrated := legacy.Migrate()
+
+	assert.Empty(t, migrated.Start)
+	assert.Empty(t, migrated.End)
+	assert.True(t, migrated.Enabled)
+	assert.Equal(t, "Africa/Nairobi", migrated.Timezone)
+	require.Len(t, migrated.Windows, 1)
+	assert.Equal(t, "22:00", migrated.Windows[0].Start)
+	assert.Equal(t, "07:00", migrated.Windows[0].End)
+
+	at := time.Date(2025, 1, 15, 20, 30, 0, 0, time.UTC)
+	before, err := legacy.IsActiveAt(at)
+	require.NoError(t, err)
+	after, err := migrated.IsActiveAt(at)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestQuietHours_Days(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+	// 2025-01-13 is a Monday
+	weekdayMornings := QuietHours{
+		Enabled:  true,
+		Start:    "06:00",
+		End:      "10:00",
+		Timezone: "Africa/Nairobi",
+		Days:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+	fridayNight := QuietHours{
+		Enabled:  true,
+		Start:    "22:00",
+		End:      "07:00",
+		Timezone: "Africa/Nairobi",
+		Days:     []time.Weekday{time.Friday},
+	}
+
+	tests := []struct {
+		name     string
+		hours    QuietHours
+		at       time.Time
+		expected bool
+	}{
+		{name: "weekday inside", hours: weekdayMornings, at: time.Date(2025, 1, 13, 8, 0, 0, 0, nairobi), expected: true},
+		{name: "saturday not listed", hours: weekdayMornings, at: time.Date(2025, 1, 18, 8, 0, 0, 0, nairobi), expected: false},
+		{name: "sunday not listed", hours: weekdayMornings, at: time.Date(2025, 1, 19, 8, 0, 0, 0, nairobi), expected: false},
+		{name: "friday evening", hours: fridayNight, at: time.Date(2025, 1, 17, 23, 0, 0, 0, nairobi), expected: true},
+		{name: "early saturday belongs to friday", hours: fridayNight, at: time.Date(2025, 1, 18, 3, 0, 0, 0, nairobi), expected: true},
+		{name: "early friday belongs to thursday", hours: fridayNight, at: time.Date(2025, 1, 17, 3, 0, 0, 0, nairobi), expected: false},
+		{name: "saturday evening", hours: fridayNight, at: time.Date(2025, 1, 18, 23, 0, 0, 0, nairobi), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := tt.hours.IsActiveAt(tt.at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+		})
+	}
+}
+
+func TestQuietHours_Days_UseLocalWeekday(t *testing.T) {
+	// Auckland is UTC+13 in January: Sunday 19:30 UTC is Monday 08:30 there
+	hours := QuietHours{
+		Enabled:  true,
+		Start:    "07:00",
+		End:      "09:00",
+		Timezone: "Pacific/Auckland",
+		Days:     []time.Weekday{time.Monday},
+	}
+	sundayUTC := time.Date(2025, 1, 12, 19, 30, 0, 0, time.UTC)
+	require.Equal(t, time.Sunday, sundayUTC.Weekday())
+
+	active, err := hours.IsActiveAt(sundayUTC)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	// The same UTC time a day later is Tuesday in Auckland
+	active, err = hours.IsActiveAt(sundayUTC.Add(24 * time.Hour))
+	require.NoError(t, err)
+	assert.False(t, active)
+}
+
+func TestQuietHours_Days_PerWindow(t *testing.T) {
+	nairobi := mustLoadLocation(t, "Africa/Nairobi")
+	hours := QuietHours{
+		Enabled:  true,
+		Timezone: "Africa/Nairobi",
+		Windows: []QuietHours{
+			{Start: "08:00", End: "12:00", Days: []time.Weekday{time.Saturday, time.Sunday}},
+			{Start: "22:00", End: "07:00"},
+		},
+	}
+
+	// Weekend lie-in applies on Saturday only, the overnight window every day
+	active, err := hours.IsActiveAt(time.Date(2025, 1, 18, 9, 0, 0, 0, nairobi))
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	active, err = hours.IsActiveAt(time.Date(2025, 1, 15, 9, 0, 0, 0, nairobi))
+	require.NoError(t, err)
+	assert.False(t, active)
+
+	next, err := hours.NextAllowedTime(time.Date(2025, 1, 18, 3, 0, 0, 0, nairobi))
+	require.NoError(t, err)
+	assert.True(t, time.Date(2025, 1, 18, 7, 0, 0, 0, nairobi).Equal(next), "got %s", next)
+}
+
+func TestQuietHours_Days_Invalid(t *testing.T) {
+	hours := QuietHours{Enabled: true, Start: "22:00", End: "07:00", Days: []time.Weekday{7}}
+
+	_, err := hours.IsActiveAt(time.Now())
+
+	assert.Error(t, err)
+}
+
+func TestQuietHours_AppliesTo(t *testing.T) {
+	hours := QuietHours{Enabled: true, Start: "22:00", End: "07:00"}
+
+	assert.False(t, hours.AppliesTo(CategoryTransactional))
+	assert.False(t, hours.AppliesTo(CategoryUrgent))
+	assert.True(t, hours.AppliesTo(CategoryMarketing))
+	assert.True(t, hours.AppliesTo(CategoryReminders))
+	assert.True(t, hours.AppliesTo(""))
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrInvalidTimezone is returned by LoadTimezone for names that are not IANA
+// timezones
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// timezones caches locations by name, since time.LoadLocation reads the
+// zoneinfo database on every call
+var timezones sync.Map
+
+// LoadTimezone resolves an IANA timezone name such as "Africa/Nairobi". An
+// empty name is UTC. "Local" is rejected because it depends on the host.
+// Loaded locations are cached.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := timezones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("%w %q: host-dependent timezones are not allowed", ErrInvalidTimezone, name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidTimezone, name, err)
+	}
+	timezones.Store(name, loc)
+	return loc, nil
+}
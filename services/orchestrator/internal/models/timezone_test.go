@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTimezone_Valid(t *testing.T) {
+	loc, err := LoadTimezone("Africa/Nairobi")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Africa/Nairobi", loc.String())
+
+	cached, err := LoadTimezone("Africa/Nairobi")
+	require.NoError(t, err)
+	assert.Same(t, loc, cached)
+}
+
+func TestLoadTimezone_Empty(t *testing.T) {
+	loc, err := LoadTimezone("")
+
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+}
+
+func TestLoadTimezone_Invalid(t *testing.T) {
+	for _, name := range []string{"Africa/Nairobbi", "GMT+3", "Local"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadTimezone(name)
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidTimezone))
+			assert.Contains(t, err.Error(), name)
+		})
+	}
+}
+
+func TestValidateUpdate_RejectsInvalidTimezone(t *testing.T) {
+	prefs := &UserPreferences{UserID: "usr_1", Timezone: "Africa/Nairobbi"}
+
+	err := ValidateUpdate(prefs)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, err.Error(), "timezone")
+}
@@ -17,11 +17,27 @@ type UserMetaData struct {
 
 // UserDevice represents a registered device for push notifications.
 type UserDevice struct {
-	Token     string    `json:"token" binding:"required"`
-	Platform  string    `json:"platform" binding:"required,oneof=ios android web"`
-	CreatedAt time.Time `json:"created_at"`
+	DeviceID  string     `json:"device_id,omitempty"`
+	Token     string     `json:"token" binding:"required"`
+	Platform  string     `json:"platform" binding:"required,oneof=ios android web"`
+	Active    bool       `json:"active"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// ExpiresAt is when the provider stops accepting Token, as reported by
+	// FCM or APNs. The zero value means the token never expires.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// Expired reports whether the device's token has expired at the given
+// time. Tokens without an ExpiresAt never expire.
+func (d UserDevice) Expired(at time.Time) bool {
+	return !d.ExpiresAt.IsZero() && !at.Before(d.ExpiresAt)
 }
 
+// Device is the name used for UserDevice by the device management APIs
+type Device = UserDevice
+
 // UserCreationRequest is the payload for creating a new user.
 type UserCreationRequest struct {
 	Name        string          `json:"name" binding:"required"`
@@ -33,6 +49,32 @@ type UserCreationRequest struct {
 
 // UserPreferences holds all notification preferences for a user.
 type UserPreferences struct {
+	UserID string `json:"user_id,omitempty"`
+
+	// Version is bumped by the user service on every write. An update must
+	// carry the version it was read at and is rejected with
+	// ErrVersionConflict when the stored preferences have moved on, so two
+	// clients editing at once cannot overwrite each other. Preferences that
+	// were never written are at version 0.
+	Version int `json:"version,omitempty"`
+
 	Email bool `json:"email_enabled"`
 	Push  bool `json:"push_enabled"`
+
+	// Contact and locale details from the user service. EmailAddress maps to
+	// the "email" field since Email is the channel toggle.
+	EmailAddress string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Timezone     string `json:"timezone,omitempty"`
+	Language     string `json:"language,omitempty"`
+
+	// NotificationEnabled is the user's global switch; the category flags
+	// opt in to marketing, transactional and reminder notifications.
+	NotificationEnabled bool `json:"notification_enabled"`
+	Marketing           bool `json:"marketing"`
+	Transactional       bool `json:"transactional"`
+	Reminders           bool `json:"reminders"`
+
+	Channels Channels `json:"channels"`
+	Digest   Digest   `json:"digest"`
 }
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	e164Pattern      = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	clockTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+// SupportedLanguages lists the language codes templates are available in.
+// Region subtags such as en-KE are accepted for any listed base language.
+var SupportedLanguages = map[string]bool{
+	"en": true,
+	"sw": true,
+	"fr": true,
+	"es": true,
+	"pt": true,
+	"ar": true,
+}
+
+// ValidateUpdate checks preferences about to be written to the user service,
+// which must also name the user, and wraps any violations in a
+// *ValidationError
+func ValidateUpdate(p *UserPreferences) error {
+	err := ValidatePreferences(p)
+	if err == nil && p.UserID == "" {
+		err = errors.New("user_id: is required")
+	}
+	if err != nil {
+		return &ValidationError{Err: err}
+	}
+	return nil
+}
+
+// ValidatePreferences checks an incoming preferences payload and returns
+// every violation found, joined with errors.Join, or nil when it is valid.
+// Empty optional fields are not checked. Phone numbers only need to be
+// normalizable with NormalizePhone in DefaultPhoneRegion.
+func ValidatePreferences(p *UserPreferences) error {
+	if p == nil {
+		return errors.New("preferences are required")
+	}
+
+	var errs []error
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if p.EmailAddress != "" {
+		if addr, err := mail.ParseAddress(p.EmailAddress); err != nil || addr.Address != p.EmailAddress {
+			add("email", "invalid email address %q", p.EmailAddress)
+		}
+	}
+	if p.Phone != "" {
+		if _, err := NormalizePhone(p.Phone, DefaultPhoneRegion); err != nil {
+			add("phone", "%v", err)
+		}
+	}
+	if p.Timezone != "" && !isValidTimezone(p.Timezone) {
+		add("timezone", "unknown IANA timezone %q", p.Timezone)
+	}
+	if p.Language != "" && !isSupportedLanguage(p.Language) {
+		add("language", "unsupported language %q", p.Language)
+	}
+
+	quietHours := []struct {
+		channel string
+		hours   QuietHours
+	}{
+		{ChannelEmail, p.Channels.Email.QuietHours},
+		{ChannelPush, p.Channels.Push.QuietHours},
+		{ChannelSMS, p.Channels.SMS.QuietHours},
+		{ChannelWhatsApp, p.Channels.WhatsApp.QuietHours},
+	}
+	for _, qh := range quietHours {
+		for _, err := range validateQuietHours(qh.hours) {
+			errs = append(errs, fmt.Errorf("channels.%s.quiet_hours.%w", qh.channel, err))
+		}
+	}
+
+	for _, channel := range []string{ChannelEmail, ChannelPush, ChannelSMS} {
+		if _, err := FrequencyPeriod(p.Channels.FrequencyFor(channel)); err != nil {
+			add("channels."+channel+".frequency", "%v", err)
+		}
+	}
+
+	if phone := p.Channels.WhatsApp.Phone; phone != "" {
+		if _, err := NormalizePhone(phone, DefaultPhoneRegion); err != nil {
+			add("channels.whatsapp.phone", "%v", err)
+		}
+	}
+	if err := p.Channels.Webhook.Validate(); err != nil {
+		add("channels.webhook", "%v", err)
+	}
+	for _, err := range validateDigest(p.Digest) {
+		errs = append(errs, fmt.Errorf("digest.%w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateQuietHours checks the times and timezone of every window.
+// Disabled quiet hours are not checked.
+func validateQuietHours(q QuietHours) []error {
+	if !q.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if q.Start != "" || q.End != "" || len(q.Windows) == 0 {
+		errs = append(errs, validateWindow("", q)...)
+	} else if q.Timezone != "" && !isValidTimezone(q.Timezone) {
+		errs = append(errs, fmt.Errorf("timezone: unknown IANA timezone %q", q.Timezone))
+	}
+	for i, w := range q.Windows {
+		errs = append(errs, validateWindow(fmt.Sprintf("windows[%d].", i), w)...)
+	}
+	return errs
+}
+
+// validateDigest checks the frequency, time and day of a digest. Disabled
+// digests are not checked.
+func validateDigest(d Digest) []error {
+	if !d.Enabled {
+		return nil
+	}
+
+	var errs []error
+	switch d.Frequency {
+	case "", DigestDaily, DigestWeekly, DigestMonthly:
+	default:
+		errs = append(errs, fmt.Errorf("frequency: must be daily, weekly or monthly, got %q", d.Frequency))
+	}
+	if d.Time != "" && !clockTimePattern.MatchString(d.Time) {
+		errs = append(errs, fmt.Errorf("time: must be HH:MM, got %q", d.Time))
+	}
+	if d.DayOfWeek != nil && (*d.DayOfWeek < time.Sunday || *d.DayOfWeek > time.Saturday) {
+		errs = append(errs, fmt.Errorf("day_of_week: invalid weekday %d", *d.DayOfWeek))
+	}
+	if d.DayOfMonth < 0 || d.DayOfMonth > 31 {
+		errs = append(errs, fmt.Errorf("day_of_month: must be between 1 and 31, got %d", d.DayOfMonth))
+	}
+	return errs
+}
+
+// validateWindow checks a single window's fields, prefixing their names
+func validateWindow(prefix string, w QuietHours) []error {
+	var errs []error
+	if !clockTimePattern.MatchString(w.Start) {
+		errs = append(errs, fmt.Errorf("%sstart: must be HH:MM, got %q", prefix, w.Start))
+	}
+	if !clockTimePattern.MatchString(w.End) {
+		errs = append(errs, fmt.Errorf("%send: must be HH:MM, got %q", prefix, w.End))
+	}
+	if w.Start != "" && w.Start == w.End {
+		errs = append(errs, fmt.Errorf("%send: must differ from start %q", prefix, w.Start))
+	}
+	if w.Timezone != "" && !isValidTimezone(w.Timezone) {
+		errs = append(errs, fmt.Errorf("%stimezone: unknown IANA timezone %q", prefix, w.Timezone))
+	}
+	for _, day := range w.Days {
+		if day < time.Sunday || day > time.Saturday {
+			errs = append(errs, fmt.Errorf("%sdays: invalid weekday %d", prefix, day))
+		}
+	}
+	return errs
+}
+
+func isValidTimezone(name string) bool {
+	_, err := LoadTimezone(name)
+	return err == nil
+}
+
+func isSupportedLanguage(code string) bool {
+	base, _, _ := strings.Cut(strings.ToLower(code), "-")
+	return SupportedLanguages[base]
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validPreferences() *UserPreferences {
+	return &UserPreferences{
+		Email:        true,
+		Push:         true,
+		EmailAddress: "user@example.com",
+		Phone:        "+254712345678",
+		Timezone:     "Africa/Nairobi",
+		Language:     "en",
+		Channels: Channels{
+			Email: EmailChannel{
+				Enabled:  true,
+				Verified: true,
+				QuietHours: QuietHours{
+					Enabled:  true,
+					Start:    "22:00",
+					End:      "07:00",
+					Timezone: "Africa/Nairobi",
+				},
+			},
+			Push: PushChannel{Enabled: true},
+		},
+	}
+}
+
+func TestValidatePreferences_Valid(t *testing.T) {
+	assert.NoError(t, ValidatePreferences(validPreferences()))
+}
+
+func TestValidatePreferences_EmptyOptionalFields(t *testing.T) {
+	assert.NoError(t, ValidatePreferences(&UserPreferences{Email: true}))
+}
+
+func TestValidatePreferences_Nil(t *testing.T) {
+	assert.Error(t, ValidatePreferences(nil))
+}
+
+func TestValidatePreferences_Violations(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(p *UserPreferences)
+		wantErr string
+	}{
+		{
+			name:    "invalid email",
+			mutate:  func(p *UserPreferences) { p.EmailAddress = "not-an-email" },
+			wantErr: "email: invalid email address",
+		},
+		{
+			name:    "email with display name",
+			mutate:  func(p *UserPreferences) { p.EmailAddress = "User <user@example.com>" },
+			wantErr: "email: invalid email address",
+		},
+		{
+			name:    "invalid phone",
+			mutate:  func(p *UserPreferences) { p.Phone = "07123" },
+			wantErr: "phone: invalid phone number",
+		},
+		{
+			name:    "unknown timezone",
+			mutate:  func(p *UserPreferences) { p.Timezone = "Mars/Olympus" },
+			wantErr: "timezone: unknown IANA timezone",
+		},
+		{
+			name:    "local timezone",
+			mutate:  func(p *UserPreferences) { p.Timezone = "Local" },
+			wantErr: "timezone: unknown IANA timezone",
+		},
+		{
+			name:    "unsupported language",
+			mutate:  func(p *UserPreferences) { p.Language = "xx" },
+			wantErr: "language: unsupported language",
+		},
+		{
+			name:    "malformed quiet hours start",
+			mutate:  func(p *UserPreferences) { p.Channels.Email.QuietHours.Start = "25:00" },
+			wantErr: "channels.email.quiet_hours.start: must be HH:MM",
+		},
+		{
+			name:    "malformed quiet hours end",
+			mutate:  func(p *UserPreferences) { p.Channels.Email.QuietHours.End = "7am" },
+			wantErr: "channels.email.quiet_hours.end: must be HH:MM",
+		},
+		{
+			name:    "quiet hours start equals end",
+			mutate:  func(p *UserPreferences) { p.Channels.Email.QuietHours.End = "22:00" },
+			wantErr: "channels.email.quiet_hours.end: must differ from start",
+		},
+		{
+			name: "quiet hours invalid timezone",
+			mutate: func(p *UserPreferences) {
+				p.Channels.SMS.QuietHours = QuietHours{Enabled: true, Start: "21:00", End: "06:00", Timezone: "Nowhere"}
+			},
+			wantErr: "channels.sms.quiet_hours.timezone: unknown IANA timezone",
+		},
+		{
+			name: "invalid extra window",
+			mutate: func(p *UserPreferences) {
+				p.Channels.Email.QuietHours.Windows = []QuietHours{{Start: "13:00", End: "1400"}}
+			},
+			wantErr: "channels.email.quiet_hours.windows[0].end: must be HH:MM",
+		},
+		{
+			name: "enabled without any window",
+			mutate: func(p *UserPreferences) {
+				p.Channels.Push.QuietHours = QuietHours{Enabled: true}
+			},
+			wantErr: "channels.push.quiet_hours.start: must be HH:MM",
+		},
+		{
+			name:    "invalid quiet hours day",
+			mutate:  func(p *UserPreferences) { p.Channels.Email.QuietHours.Days = []time.Weekday{9} },
+			wantErr: "channels.email.quiet_hours.days: invalid weekday 9",
+		},
+		{
+			name:    "unknown digest frequency",
+			mutate:  func(p *UserPreferences) { p.Digest = Digest{Enabled: true, Frequency: "hourly"} },
+			wantErr: "digest.frequency: must be daily, weekly or monthly",
+		},
+		{
+			name:    "invalid digest time",
+			mutate:  func(p *UserPreferences) { p.Digest = Digest{Enabled: true, Time: "25:00"} },
+			wantErr: "digest.time: must be HH:MM",
+		},
+		{
+			name: "invalid digest day of week",
+			mutate: func(p *UserPreferences) {
+				day := time.Weekday(7)
+				p.Digest = Digest{Enabled: true, Frequency: DigestWeekly, DayOfWeek: &day}
+			},
+			wantErr: "digest.day_of_week: invalid weekday 7",
+		},
+		{
+			name:    "invalid digest day of month",
+			mutate:  func(p *UserPreferences) { p.Digest = Digest{Enabled: true, Frequency: DigestMonthly, DayOfMonth: 32} },
+			wantErr: "digest.day_of_month: must be between 1 and 31",
+		},
+		{
+			name:    "unknown email frequency",
+			mutate:  func(p *UserPreferences) { p.Channels.Email.Frequency = "weekly" },
+			wantErr: "channels.email.frequency: must be immediate, hourly or daily",
+		},
+		{
+			name:    "unknown sms frequency",
+			mutate:  func(p *UserPreferences) { p.Channels.SMS.Frequency = "Hourly" },
+			wantErr: "channels.sms.frequency: must be immediate, hourly or daily",
+		},
+		{
+			name:    "invalid whatsapp phone",
+			mutate:  func(p *UserPreferences) { p.Channels.WhatsApp.Phone = "+2547123" },
+			wantErr: "channels.whatsapp.phone: invalid phone number",
+		},
+		{
+			name: "webhook not https",
+			mutate: func(p *UserPreferences) {
+				p.Channels.Webhook = WebhookChannel{Enabled: true, URL: "http://example.com"}
+			},
+			wantErr: "channels.webhook: webhook url must be an absolute https url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := validPreferences()
+			tt.mutate(prefs)
+
+			err := ValidatePreferences(prefs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidatePreferences_DisabledQuietHoursNotChecked(t *testing.T) {
+	prefs := validPreferences()
+	prefs.Channels.Push.QuietHours = QuietHours{Enabled: false, Start: "bad", End: "bad"}
+
+	assert.NoError(t, ValidatePreferences(prefs))
+}
+
+func TestValidatePreferences_DisabledDigestNotChecked(t *testing.T) {
+	prefs := validPreferences()
+	prefs.Digest = Digest{Enabled: false, Frequency: "hourly", DayOfMonth: 40}
+
+	assert.NoError(t, ValidatePreferences(prefs))
+}
+
+func TestValidatePreferences_WindowsOnly(t *testing.T) {
+	prefs := validPreferences()
+	prefs.Channels.Email.QuietHours = QuietHours{
+		Enabled:  true,
+		Timezone: "Africa/Nairobi",
+		Windows:  []QuietHours{{Start: "13:00", End: "14:00"}, {Start: "22:00", End: "07:00"}},
+	}
+
+	assert.NoError(t, ValidatePreferences(prefs))
+}
+
+func TestValidatePreferences_LocalPhoneFormat(t *testing.T) {
+	prefs := validPreferences()
+	prefs.Phone = "0712 345 678"
+
+	assert.NoError(t, ValidatePreferences(prefs))
+}
+
+func TestValidatePreferences_LanguageWithRegion(t *testing.T) {
+	prefs := validPreferences()
+	prefs.Language = "sw-KE"
+
+	assert.NoError(t, ValidatePreferences(prefs))
+}
+
+func TestValidatePreferences_ReportsEveryViolation(t *testing.T) {
+	prefs := validPreferences()
+	prefs.EmailAddress = "bad"
+	prefs.Phone = "12345"
+	prefs.Timezone = "Nowhere"
+	prefs.Language = "xx"
+	prefs.Channels.Email.QuietHours.Start = "9:00"
+
+	err := ValidatePreferences(prefs)
+	require.Error(t, err)
+
+	var multi interface{ Unwrap() []error }
+	require.True(t, errors.As(err, &multi))
+	assert.Len(t, multi.Unwrap(), 5)
+	for _, field := range []string{"email:", "phone:", "timezone:", "language:", "channels.email.quiet_hours.start:"} {
+		assert.Contains(t, err.Error(), field)
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	prefs := validPreferences()
+	prefs.UserID = "usr_123"
+	assert.NoError(t, ValidateUpdate(prefs))
+
+	prefs.UserID = ""
+	err := ValidateUpdate(prefs)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, err.Error(), "user_id: is required")
+
+	prefs.UserID = "usr_123"
+	prefs.Timezone = "Nowhere/Town"
+	err = ValidateUpdate(prefs)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, err.Error(), "timezone")
+}
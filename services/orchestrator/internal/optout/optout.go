@@ -0,0 +1,30 @@
+package optout
+
+import (
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// IsBlocked reports whether the user has unsubscribed from channel, either
+// globally or for that channel alone, or from category when one is given.
+// Transactional notifications cannot be opted out of by category. A nil
+// status means no opt-out is on record. See IsBlockedAt for expiry.
+func IsBlocked(status *models.OptOutStatus, channel, category string) bool {
+	return IsBlockedAt(status, channel, category, time.Now())
+}
+
+// IsBlockedAt is IsBlocked evaluated at the given time. An opt-out whose
+// ExpiresAt is not after at has lapsed and no longer blocks anything.
+func IsBlockedAt(status *models.OptOutStatus, channel, category string, at time.Time) bool {
+	if status == nil {
+		return false
+	}
+	if status.ExpiresAt != nil && !status.ExpiresAt.After(at) {
+		return false
+	}
+	if status.Global || status.Channels[channel] {
+		return true
+	}
+	return category != "" && category != models.CategoryTransactional && status.Categories[category]
+}
@@ -0,0 +1,124 @@
+package optout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBlocked_GlobalOptOut(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID:   "usr_123",
+		Global:   true,
+		Channels: map[string]bool{models.ChannelEmail: false},
+	}
+
+	assert.True(t, IsBlocked(status, models.ChannelEmail, ""))
+	assert.True(t, IsBlocked(status, models.ChannelPush, ""))
+	assert.True(t, IsBlocked(status, models.ChannelSMS, ""))
+}
+
+func TestIsBlocked_ChannelOptOut(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID:   "usr_123",
+		Channels: map[string]bool{models.ChannelEmail: true, models.ChannelPush: false},
+	}
+
+	assert.True(t, IsBlocked(status, models.ChannelEmail, ""))
+	assert.False(t, IsBlocked(status, models.ChannelPush, ""))
+	assert.False(t, IsBlocked(status, models.ChannelSMS, ""))
+}
+
+func TestIsBlocked_OptedIn(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID: "usr_123",
+		Channels: map[string]bool{
+			models.ChannelEmail: false,
+			models.ChannelPush:  false,
+		},
+	}
+
+	assert.False(t, IsBlocked(status, models.ChannelEmail, ""))
+	assert.False(t, IsBlocked(status, models.ChannelPush, ""))
+}
+
+func TestIsBlocked_NilStatus(t *testing.T) {
+	assert.False(t, IsBlocked(nil, models.ChannelEmail, models.CategoryMarketing))
+}
+
+func TestIsBlocked_CategoryOptOut(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID:     "usr_123",
+		Channels:   map[string]bool{models.ChannelEmail: false},
+		Categories: map[string]bool{models.CategoryMarketing: true},
+	}
+
+	assert.True(t, IsBlocked(status, models.ChannelEmail, models.CategoryMarketing))
+	assert.False(t, IsBlocked(status, models.ChannelEmail, models.CategoryTransactional))
+	assert.False(t, IsBlocked(status, models.ChannelEmail, models.CategoryReminders))
+	assert.False(t, IsBlocked(status, models.ChannelEmail, ""))
+}
+
+func TestIsBlocked_TransactionalIgnoresCategoryOptOut(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID:     "usr_123",
+		Categories: map[string]bool{models.CategoryTransactional: true},
+	}
+
+	assert.False(t, IsBlocked(status, models.ChannelSMS, models.CategoryTransactional))
+}
+
+func TestIsBlocked_ChannelOptOutBlocksTransactional(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID:   "usr_123",
+		Channels: map[string]bool{models.ChannelSMS: true},
+	}
+
+	assert.True(t, IsBlocked(status, models.ChannelSMS, models.CategoryTransactional))
+}
+
+func TestIsBlockedAt_ExpiredOptOut(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	status := &models.OptOutStatus{
+		UserID:     "usr_123",
+		Global:     true,
+		Channels:   map[string]bool{models.ChannelEmail: true},
+		Categories: map[string]bool{models.CategoryMarketing: true},
+		ExpiresAt:  &expired,
+	}
+
+	assert.False(t, IsBlockedAt(status, models.ChannelEmail, models.CategoryMarketing, now))
+	assert.False(t, IsBlockedAt(status, models.ChannelPush, "", now))
+}
+
+func TestIsBlockedAt_ExpiresAtBoundary(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := &models.OptOutStatus{UserID: "usr_123", Global: true, ExpiresAt: &now}
+
+	assert.False(t, IsBlockedAt(status, models.ChannelEmail, "", now))
+	assert.True(t, IsBlockedAt(status, models.ChannelEmail, "", now.Add(-time.Second)))
+}
+
+func TestIsBlockedAt_ActiveOptOut(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(30 * 24 * time.Hour)
+	status := &models.OptOutStatus{
+		UserID:    "usr_123",
+		Channels:  map[string]bool{models.ChannelSMS: true},
+		ExpiresAt: &expiresAt,
+	}
+
+	assert.True(t, IsBlockedAt(status, models.ChannelSMS, "", now))
+	assert.False(t, IsBlockedAt(status, models.ChannelEmail, "", now))
+}
+
+func TestIsBlocked_UsesCurrentTime(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	assert.False(t, IsBlocked(&models.OptOutStatus{Global: true, ExpiresAt: &past}, models.ChannelEmail, ""))
+	assert.True(t, IsBlocked(&models.OptOutStatus{Global: true, ExpiresAt: &future}, models.ChannelEmail, ""))
+}
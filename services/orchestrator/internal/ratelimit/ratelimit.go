@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Defaults used when Config leaves a field unset
+const (
+	DefaultRate  = 1.0 // tokens per second
+	DefaultBurst = 10
+)
+
+// Store keeps the token buckets. Take must be atomic per key so a shared
+// backend such as Redis can enforce one limit across orchestrator instances.
+type Store interface {
+	// Take refills the bucket for key at rate tokens per second, up to
+	// burst, and removes one token if there is one, reporting whether it did
+	Take(key string, rate float64, burst int, now time.Time) bool
+}
+
+// Config configures a Limiter
+type Config struct {
+	Rate  float64 // tokens per second, defaults to DefaultRate
+	Burst int     // bucket size, defaults to DefaultBurst
+
+	// PerChannel gives each of a user's channels its own bucket instead of
+	// one shared across channels
+	PerChannel bool
+
+	Store Store       // defaults to a MemoryStore
+	Clock clock.Clock // defaults to the real clock
+}
+
+// Limiter is a token bucket rate limiter keyed by user ID and, optionally,
+// channel
+type Limiter struct {
+	rate       float64
+	burst      int
+	perChannel bool
+	store      Store
+	clock      clock.Clock
+	rejected   *prometheus.CounterVec
+}
+
+func NewLimiter(cfg Config) *Limiter {
+	if cfg.Rate <= 0 {
+		cfg.Rate = DefaultRate
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = DefaultBurst
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+
+	return &Limiter{
+		rate:       cfg.Rate,
+		burst:      cfg.Burst,
+		perChannel: cfg.PerChannel,
+		store:      cfg.Store,
+		clock:      clock.OrReal(cfg.Clock),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "orchestrator_rate_limited_total",
+			Help: "Total number of notifications rejected by the per-user rate limiter.",
+		}, []string{"channel"}),
+	}
+}
+
+// Allow takes a token for the user's notification on channel, reporting
+// false and counting a rejection when the bucket is empty
+func (l *Limiter) Allow(userID, channel string) bool {
+	key := userID
+	if l.perChannel {
+		key = userID + ":" + channel
+	}
+
+	if l.store.Take(key, l.rate, l.burst, l.clock.Now()) {
+		return true
+	}
+	l.rejected.WithLabelValues(channel).Inc()
+	return false
+}
+
+// RegisterMetrics registers the rejection counter on reg
+func (l *Limiter) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(l.rejected)
+}
+
+// MemoryStore keeps token buckets in process memory. Buckets are never
+// evicted, which is fine for a bounded user base but not for unbounded keys.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(key string, rate float64, burst int, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed.Seconds()*rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(cfg Config) (*Limiter, *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	cfg.Clock = clk
+	return NewLimiter(cfg), clk
+}
+
+func TestLimiter_BurstExhaustion(t *testing.T) {
+	limiter, _ := newTestLimiter(Config{Rate: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("usr_1", "email"), "request %d", i)
+	}
+	assert.False(t, limiter.Allow("usr_1", "email"))
+	assert.False(t, limiter.Allow("usr_1", "push"))
+
+	// Other users have their own bucket
+	assert.True(t, limiter.Allow("usr_2", "email"))
+}
+
+func TestLimiter_RefillOverTime(t *testing.T) {
+	limiter, clk := newTestLimiter(Config{Rate: 0.5, Burst: 2})
+
+	assert.True(t, limiter.Allow("usr_1", "email"))
+	assert.True(t, limiter.Allow("usr_1", "email"))
+	assert.False(t, limiter.Allow("usr_1", "email"))
+
+	// Half a token per second: one token after two seconds
+	clk.Advance(time.Second)
+	assert.False(t, limiter.Allow("usr_1", "email"))
+	clk.Advance(time.Second)
+	assert.True(t, limiter.Allow("usr_1", "email"))
+	assert.False(t, limiter.Allow("usr_1", "email"))
+
+	// A long pause refills no more than the burst
+	clk.Advance(time.Hour)
+	assert.True(t, limiter.Allow("usr_1", "email"))
+	assert.True(t, limiter.Allow("usr_1", "email"))
+	assert.False(t, limiter.Allow("usr_1", "email"))
+}
+
+func TestLimiter_PerChannel(t *testing.T) {
+	limiter, _ := newTestLimiter(Config{Rate: 1, Burst: 1, PerChannel: true})
+
+	assert.True(t, limiter.Allow("usr_1", "email"))
+	assert.False(t, limiter.Allow("usr_1", "email"))
+	assert.True(t, limiter.Allow("usr_1", "push"))
+}
+
+func TestLimiter_Defaults(t *testing.T) {
+	limiter, _ := newTestLimiter(Config{})
+
+	for i := 0; i < DefaultBurst; i++ {
+		require.True(t, limiter.Allow("usr_1", "email"))
+	}
+	assert.False(t, limiter.Allow("usr_1", "email"))
+}
+
+func TestLimiter_CountsRejections(t *testing.T) {
+	limiter, _ := newTestLimiter(Config{Rate: 1, Burst: 1})
+	reg := prometheus.NewRegistry()
+	require.NoError(t, limiter.RegisterMetrics(reg))
+
+	limiter.Allow("usr_1", "email")
+	limiter.Allow("usr_1", "email")
+	limiter.Allow("usr_1", "email")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(limiter.rejected.WithLabelValues("email")))
+}
+
+// recordingStore checks a custom Store receives the limiter's settings
+type recordingStore struct {
+	keys []string
+}
+
+func (s *recordingStore) Take(key string, rate float64, burst int, now time.Time) bool {
+	s.keys = append(s.keys, key)
+	return rate == 2 && burst == 5
+}
+
+func TestLimiter_CustomStore(t *testing.T) {
+	store := &recordingStore{}
+	limiter, _ := newTestLimiter(Config{Rate: 2, Burst: 5, PerChannel: true, Store: store})
+
+	assert.True(t, limiter.Allow("usr_1", "sms"))
+	assert.Equal(t, []string{"usr_1:sms"}, store.keys)
+}
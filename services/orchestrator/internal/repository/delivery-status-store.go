@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+)
+
+// StatusStore persists the delivery status of notifications per channel
+type StatusStore interface {
+	// SetStatus moves the notification's delivery on channel to status,
+	// creating the record on first use. detail is the error for failed and
+	// suppressed deliveries. Disallowed moves return an error wrapping
+	// models.ErrInvalidTransition.
+	SetStatus(ctx context.Context, notificationID, channel string, status models.DeliveryStatus, detail string) error
+	// GetByNotificationID returns the notification's delivery records
+	// ordered by channel, or an empty slice when there are none
+	GetByNotificationID(ctx context.Context, notificationID string) ([]models.DeliveryRecord, error)
+}
+
+// MemoryStatusStore is a StatusStore that keeps records in memory. It is
+// safe for concurrent use.
+type MemoryStatusStore struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	records map[string]map[string]*models.DeliveryRecord
+}
+
+// NewMemoryStatusStore returns an empty store. A nil clk uses the real
+// clock.
+func NewMemoryStatusStore(clk clock.Clock) *MemoryStatusStore {
+	return &MemoryStatusStore{
+		clock:   clock.OrReal(clk),
+		records: make(map[string]map[string]*models.DeliveryRecord),
+	}
+}
+
+func (s *MemoryStatusStore) SetStatus(ctx context.Context, notificationID, channel string, status models.DeliveryStatus, detail string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byChannel, ok := s.records[notificationID]
+	if !ok {
+		byChannel = make(map[string]*models.DeliveryRecord)
+		s.records[notificationID] = byChannel
+	}
+	record, ok := byChannel[channel]
+	if !ok {
+		record = &models.DeliveryRecord{NotificationID: notificationID, Channel: channel}
+	}
+
+	if err := record.Transition(status, detail, s.clock.Now()); err != nil {
+		return err
+	}
+	byChannel[channel] = record
+	return nil
+}
+
+func (s *MemoryStatusStore) GetByNotificationID(ctx context.Context, notificationID string) ([]models.DeliveryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]models.DeliveryRecord, 0, len(s.records[notificationID]))
+	for _, record := range s.records[notificationID] {
+		copied := *record
+		copied.History = append([]models.DeliveryTransition(nil), record.History...)
+		records = append(records, copied)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Channel < records[j].Channel })
+	return records, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStatusStore_GetByNotificationID(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryStatusStore(clk)
+	ctx := context.Background()
+
+	require.NoError(t, store.SetStatus(ctx, "n1", "push", models.DeliveryPending, ""))
+	require.NoError(t, store.SetStatus(ctx, "n1", "email", models.DeliveryPending, ""))
+	clk.Advance(time.Second)
+	require.NoError(t, store.SetStatus(ctx, "n1", "push", models.DeliverySent, ""))
+	require.NoError(t, store.SetStatus(ctx, "n1", "email", models.DeliveryFailed, "smtp timeout"))
+	require.NoError(t, store.SetStatus(ctx, "n2", "sms", models.DeliverySuppressed, "user opted out"))
+
+	records, err := store.GetByNotificationID(ctx, "n1")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "email", records[0].Channel)
+	assert.Equal(t, models.DeliveryFailed, records[0].Status)
+	assert.Equal(t, "smtp timeout", records[0].Error)
+	assert.Equal(t, "push", records[1].Channel)
+	assert.Equal(t, models.DeliverySent, records[1].Status)
+	assert.Equal(t, clk.Now(), records[1].UpdatedAt)
+	assert.Len(t, records[1].History, 2)
+
+	none, err := store.GetByNotificationID(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestMemoryStatusStore_RejectsInvalidTransition(t *testing.T) {
+	store := NewMemoryStatusStore(nil)
+	ctx := context.Background()
+
+	require.NoError(t, store.SetStatus(ctx, "n1", "email", models.DeliverySuppressed, "duplicate"))
+	err := store.SetStatus(ctx, "n1", "email", models.DeliverySent, "")
+
+	assert.True(t, errors.Is(err, models.ErrInvalidTransition))
+	records, _ := store.GetByNotificationID(ctx, "n1")
+	assert.Equal(t, models.DeliverySuppressed, records[0].Status)
+}
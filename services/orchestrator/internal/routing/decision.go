@@ -0,0 +1,96 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// Action is what to do with a notification on one channel
+type Action string
+
+const (
+	// ActionSend delivers on the channel straight away
+	ActionSend Action = "send"
+	// ActionDefer holds the notification until DeferUntil, the end of the
+	// channel's quiet hours
+	ActionDefer Action = "defer"
+	// ActionSuppress never delivers on the channel
+	ActionSuppress Action = "suppress"
+)
+
+// ChannelDecision is the routing outcome for one channel. DeferUntil is only
+// set for ActionDefer, and Reason explains any action other than ActionSend;
+// undeliverable channels carry one of the models.Undeliverable reasons, so
+// callers can prompt for re-verification on models.UndeliverableUnverified.
+type ChannelDecision struct {
+	Channel    string
+	Action     Action
+	DeferUntil time.Time
+	Reason     string
+}
+
+// Decide returns a decision for every channel in ChannelOrder for a
+// notification of category right now. See DecideAt.
+func Decide(prefs *models.UserPreferences, category string) ([]ChannelDecision, error) {
+	return DecideAt(prefs, category, time.Now())
+}
+
+// DecideAt returns a decision for every channel in ChannelOrder for a
+// notification of category at the given time. Channels ResolveAt would skip
+// for being disabled, unverified or without a recent device are suppressed,
+// as is every channel when the user has notifications switched off or has
+// not opted in to the category. Channels in quiet hours that apply to the
+// category are deferred until the quiet hours end; transactional and urgent
+// notifications are never deferred. Unknown categories and invalid quiet
+// hours return an error.
+func DecideAt(prefs *models.UserPreferences, category string, at time.Time) ([]ChannelDecision, error) {
+	if prefs == nil {
+		return nil, fmt.Errorf("user preferences are required")
+	}
+
+	optedIn, err := categoryAllowed(prefs, category)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]ChannelDecision, 0, len(ChannelOrder))
+	for _, channel := range ChannelOrder {
+		decision, err := decideChannel(prefs, channel, category, optedIn, at)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channel, err)
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, nil
+}
+
+func decideChannel(prefs *models.UserPreferences, channel, category string, optedIn bool, at time.Time) (ChannelDecision, error) {
+	suppress := func(reason string) (ChannelDecision, error) {
+		return ChannelDecision{Channel: channel, Action: ActionSuppress, Reason: reason}, nil
+	}
+
+	switch {
+	case !prefs.NotificationEnabled:
+		return suppress("notifications disabled")
+	case !optedIn:
+		return suppress(fmt.Sprintf("not opted in to %s notifications", category))
+	}
+	if ok, reason := prefs.Channels.DeliverableAt(channel, at); !ok {
+		return suppress(reason)
+	}
+
+	quietHours := quietHoursFor(prefs.Channels, channel)
+	if !quietHours.AppliesTo(category) {
+		return ChannelDecision{Channel: channel, Action: ActionSend}, nil
+	}
+	until, err := quietHours.NextAllowedTime(at)
+	if err != nil {
+		return ChannelDecision{}, err
+	}
+	if until.After(at) {
+		return ChannelDecision{Channel: channel, Action: ActionDefer, DeferUntil: until, Reason: "quiet hours"}, nil
+	}
+	return ChannelDecision{Channel: channel, Action: ActionSend}, nil
+}
@@ -0,0 +1,138 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mixedPreferences gives one preference set that sends, defers and
+// suppresses: quiet hours on email and SMS, WhatsApp disabled
+func mixedPreferences() *models.UserPreferences {
+	prefs := allChannels()
+	prefs.Channels.Email.QuietHours = overnight()
+	prefs.Channels.SMS.QuietHours = overnight()
+	prefs.Channels.WhatsApp.Enabled = false
+	return prefs
+}
+
+func TestDecideAt_AllActions(t *testing.T) {
+	decisions, err := DecideAt(mixedPreferences(), models.CategoryMarketing, lateNight)
+	require.NoError(t, err)
+
+	// 07:00 Nairobi the next morning
+	morning := time.Date(2025, 1, 16, 4, 0, 0, 0, time.UTC)
+	expected := []ChannelDecision{
+		{Channel: models.ChannelPush, Action: ActionSend},
+		{Channel: models.ChannelEmail, Action: ActionDefer, DeferUntil: morning, Reason: "quiet hours"},
+		{Channel: models.ChannelInApp, Action: ActionSend},
+		{Channel: models.ChannelSMS, Action: ActionDefer, DeferUntil: morning, Reason: "quiet hours"},
+		{Channel: models.ChannelWhatsApp, Action: ActionSuppress, Reason: models.UndeliverableDisabled},
+		{Channel: models.ChannelWebhook, Action: ActionSend},
+	}
+	require.Len(t, decisions, len(expected))
+	for i, want := range expected {
+		got := decisions[i]
+		assert.Equal(t, want.Channel, got.Channel)
+		assert.Equal(t, want.Action, got.Action, want.Channel)
+		assert.Equal(t, want.Reason, got.Reason, want.Channel)
+		assert.True(t, want.DeferUntil.Equal(got.DeferUntil), "%s: got %s", want.Channel, got.DeferUntil)
+	}
+}
+
+func TestDecideAt_TransactionalBypassesDeferral(t *testing.T) {
+	for _, category := range []string{models.CategoryTransactional, models.CategoryUrgent} {
+		decisions, err := DecideAt(mixedPreferences(), category, lateNight)
+		require.NoError(t, err)
+
+		for _, decision := range decisions {
+			assert.NotEqual(t, ActionDefer, decision.Action, "%s on %s", category, decision.Channel)
+		}
+	}
+}
+
+func TestDecideAt_OutsideQuietHoursSends(t *testing.T) {
+	decisions, err := DecideAt(mixedPreferences(), models.CategoryMarketing, midday)
+	require.NoError(t, err)
+
+	for _, decision := range decisions {
+		if decision.Channel == models.ChannelWhatsApp {
+			continue
+		}
+		assert.Equal(t, ActionSend, decision.Action, decision.Channel)
+		assert.True(t, decision.DeferUntil.IsZero())
+	}
+}
+
+func TestDecideAt_SuppressesEveryChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(p *models.UserPreferences)
+		category string
+		reason   string
+	}{
+		{
+			name:     "notifications disabled",
+			mutate:   func(p *models.UserPreferences) { p.NotificationEnabled = false },
+			category: models.CategoryUrgent,
+			reason:   "notifications disabled",
+		},
+		{
+			name:     "not opted in",
+			mutate:   func(p *models.UserPreferences) { p.Marketing = false },
+			category: models.CategoryMarketing,
+			reason:   "not opted in to marketing notifications",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := mixedPreferences()
+			tt.mutate(prefs)
+
+			decisions, err := DecideAt(prefs, tt.category, lateNight)
+
+			require.NoError(t, err)
+			require.Len(t, decisions, len(ChannelOrder))
+			for _, decision := range decisions {
+				assert.Equal(t, ActionSuppress, decision.Action)
+				assert.Equal(t, tt.reason, decision.Reason)
+			}
+		})
+	}
+}
+
+func TestDecideAt_Errors(t *testing.T) {
+	_, err := DecideAt(nil, models.CategoryMarketing, midday)
+	assert.Error(t, err)
+
+	_, err = DecideAt(allChannels(), "newsletter", midday)
+	assert.Error(t, err)
+
+	prefs := allChannels()
+	prefs.Channels.SMS.QuietHours = models.QuietHours{Enabled: true, Start: "25:00", End: "07:00"}
+	_, err = DecideAt(prefs, models.CategoryMarketing, midday)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel sms")
+}
+
+func TestDecideAt_UnverifiedEmailIsSuppressed(t *testing.T) {
+	prefs := allChannels()
+	prefs.Channels.Email.Verified = false
+
+	decisions, err := DecideAt(prefs, models.CategoryTransactional, midday)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChannelDecision{
+		Channel: models.ChannelEmail,
+		Action:  ActionSuppress,
+		Reason:  models.UndeliverableUnverified,
+	}, decisions[1])
+
+	channels, err := ResolveAt(prefs, models.CategoryTransactional, midday)
+	require.NoError(t, err)
+	assert.NotContains(t, channels, models.ChannelEmail)
+}
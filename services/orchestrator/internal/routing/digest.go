@@ -0,0 +1,54 @@
+package routing
+
+import (
+	"fmt"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// Delivery is when a notification goes out
+type Delivery string
+
+const (
+	// DeliveryImmediate sends the notification on its own straight away
+	DeliveryImmediate Delivery = "immediate"
+	// DeliveryDigest holds the notification for the user's next digest
+	DeliveryDigest Delivery = "digest"
+)
+
+// DeliveryFor decides whether a notification of category is sent straight
+// away or added to the user's digest. With the digest enabled, marketing and
+// reminder notifications, and those without a category, go to the digest;
+// transactional and urgent notifications are always sent immediately. An
+// unknown category or digest frequency returns an error, since such a
+// digest would never be flushed.
+func DeliveryFor(prefs *models.UserPreferences, category string) (Delivery, error) {
+	if prefs == nil {
+		return "", fmt.Errorf("user preferences are required")
+	}
+	if _, err := categoryAllowed(prefs, category); err != nil {
+		return "", err
+	}
+
+	if !prefs.Digest.Enabled || !digestible(category) {
+		return DeliveryImmediate, nil
+	}
+
+	switch prefs.Digest.Frequency {
+	case models.DigestDaily, models.DigestWeekly, models.DigestMonthly, "":
+		return DeliveryDigest, nil
+	default:
+		return "", fmt.Errorf("unknown digest frequency %q", prefs.Digest.Frequency)
+	}
+}
+
+// digestible reports whether notifications of category may wait for a
+// digest
+func digestible(category string) bool {
+	switch category {
+	case models.CategoryTransactional, models.CategoryUrgent:
+		return false
+	default:
+		return true
+	}
+}
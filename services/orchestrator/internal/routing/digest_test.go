@@ -0,0 +1,58 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryFor(t *testing.T) {
+	tests := []struct {
+		category string
+		enabled  Delivery
+		disabled Delivery
+	}{
+		{category: models.CategoryMarketing, enabled: DeliveryDigest, disabled: DeliveryImmediate},
+		{category: models.CategoryReminders, enabled: DeliveryDigest, disabled: DeliveryImmediate},
+		{category: "", enabled: DeliveryDigest, disabled: DeliveryImmediate},
+		{category: models.CategoryTransactional, enabled: DeliveryImmediate, disabled: DeliveryImmediate},
+		{category: models.CategoryUrgent, enabled: DeliveryImmediate, disabled: DeliveryImmediate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			prefs := allChannels()
+			prefs.Digest = models.Digest{Enabled: true, Frequency: models.DigestDaily}
+
+			delivery, err := DeliveryFor(prefs, tt.category)
+			require.NoError(t, err)
+			assert.Equal(t, tt.enabled, delivery)
+
+			prefs.Digest.Enabled = false
+
+			delivery, err = DeliveryFor(prefs, tt.category)
+			require.NoError(t, err)
+			assert.Equal(t, tt.disabled, delivery)
+		})
+	}
+}
+
+func TestDeliveryFor_Errors(t *testing.T) {
+	_, err := DeliveryFor(nil, models.CategoryMarketing)
+	assert.Error(t, err)
+
+	_, err = DeliveryFor(allChannels(), "newsletter")
+	assert.Error(t, err)
+
+	prefs := allChannels()
+	prefs.Digest = models.Digest{Enabled: true, Frequency: "hourly"}
+	_, err = DeliveryFor(prefs, models.CategoryMarketing)
+	assert.Error(t, err)
+
+	// Immediate categories do not depend on the digest schedule
+	delivery, err := DeliveryFor(prefs, models.CategoryTransactional)
+	require.NoError(t, err)
+	assert.Equal(t, DeliveryImmediate, delivery)
+}
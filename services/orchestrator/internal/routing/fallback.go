@@ -0,0 +1,72 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// FallbackOrder is the escalation order used when a channel fails
+var FallbackOrder = []string{
+	models.ChannelPush,
+	models.ChannelEmail,
+	models.ChannelSMS,
+}
+
+// StaleDeviceAfter is how long since a device was last seen before push is
+// no longer trusted to reach the user
+const StaleDeviceAfter = 24 * time.Hour
+
+// FallbackChain returns the channels to try, in FallbackOrder, for a
+// notification of category right now. See FallbackChainAt.
+func FallbackChain(prefs *models.UserPreferences, category string) []string {
+	return FallbackChainAt(prefs, category, time.Now())
+}
+
+// FallbackChainAt returns the channels to try, in FallbackOrder, for a
+// notification of category at the given time. It applies the same checks as
+// ResolveAt and drops push unless an active device was seen within
+// StaleDeviceAfter. Preferences that cannot be resolved give an empty chain.
+func FallbackChainAt(prefs *models.UserPreferences, category string, at time.Time) []string {
+	resolved, err := ResolveAt(prefs, category, at)
+	if err != nil {
+		return []string{}
+	}
+
+	usable := make(map[string]bool, len(resolved))
+	for _, channel := range resolved {
+		usable[channel] = true
+	}
+
+	chain := []string{}
+	for _, channel := range FallbackOrder {
+		if !usable[channel] {
+			continue
+		}
+		if channel == models.ChannelPush && len(prefs.Channels.Push.ActiveDevicesAt(StaleDeviceAfter, at)) == 0 {
+			continue
+		}
+		chain = append(chain, channel)
+	}
+	return chain
+}
+
+// Escalate calls send for each channel in chain until one succeeds, and
+// returns that channel. If every attempt fails the errors are joined.
+func Escalate(chain []string, send func(channel string) error) (string, error) {
+	if len(chain) == 0 {
+		return "", fmt.Errorf("no channels available")
+	}
+
+	var errs []error
+	for _, channel := range chain {
+		err := send(channel)
+		if err == nil {
+			return channel, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+	}
+	return "", fmt.Errorf("all channels failed: %w", errors.Join(errs...))
+}
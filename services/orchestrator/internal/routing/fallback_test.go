@@ -0,0 +1,144 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withFreshDevice sets a single active push device last seen at seenAt
+func withFreshDevice(p *models.UserPreferences, seenAt time.Time) {
+	p.Channels.Push.Devices = []models.UserDevice{{Token: "token", Platform: "android", Active: true, LastSeen: &seenAt}}
+}
+
+func TestFallbackChainAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(p *models.UserPreferences)
+		category string
+		expected []string
+	}{
+		{
+			name:     "full chain",
+			mutate:   func(p *models.UserPreferences) { withFreshDevice(p, midday.Add(-time.Hour)) },
+			category: models.CategoryTransactional,
+			expected: []string{"push", "email", "sms"},
+		},
+		{
+			name:     "stale device skips push",
+			mutate:   func(p *models.UserPreferences) { withFreshDevice(p, midday.Add(-25*time.Hour)) },
+			category: models.CategoryTransactional,
+			expected: []string{"email", "sms"},
+		},
+		{
+			name: "never seen device skips push",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Push.Devices = []models.UserDevice{{Token: "token", Platform: "ios", Active: true}}
+			},
+			category: models.CategoryTransactional,
+			expected: []string{"email", "sms"},
+		},
+		{
+			name: "inactive recent device skips push",
+			mutate: func(p *models.UserPreferences) {
+				withFreshDevice(p, midday.Add(-time.Hour))
+				p.Channels.Push.Devices[0].Active = false
+			},
+			category: models.CategoryTransactional,
+			expected: []string{"email", "sms"},
+		},
+		{
+			name: "push disabled starts at email",
+			mutate: func(p *models.UserPreferences) {
+				withFreshDevice(p, midday.Add(-time.Hour))
+				p.Channels.Push.Enabled = false
+			},
+			category: models.CategoryTransactional,
+			expected: []string{"email", "sms"},
+		},
+		{
+			name: "push and email disabled goes to sms",
+			mutate: func(p *models.UserPreferences) {
+				withFreshDevice(p, midday.Add(-time.Hour))
+				p.Channels.Push.Enabled = false
+				p.Channels.Email.Enabled = false
+			},
+			category: models.CategoryTransactional,
+			expected: []string{"sms"},
+		},
+		{
+			name: "unverified email is skipped",
+			mutate: func(p *models.UserPreferences) {
+				withFreshDevice(p, midday.Add(-time.Hour))
+				p.Channels.Email.Verified = false
+			},
+			category: models.CategoryTransactional,
+			expected: []string{"push", "sms"},
+		},
+		{
+			name: "carrier opt out ends chain at email",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.SMS.CarrierOptOut = true
+			},
+			category: models.CategoryTransactional,
+			expected: []string{"email"},
+		},
+		{
+			name:     "opted out of category",
+			mutate:   func(p *models.UserPreferences) { p.Marketing = false },
+			category: models.CategoryMarketing,
+			expected: []string{},
+		},
+		{
+			name:     "unknown category",
+			category: "newsletter",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := allChannels()
+			if tt.mutate != nil {
+				tt.mutate(prefs)
+			}
+
+			assert.Equal(t, tt.expected, FallbackChainAt(prefs, tt.category, midday))
+		})
+	}
+}
+
+func TestEscalate_StopsAtFirstSuccess(t *testing.T) {
+	var attempted []string
+	channel, err := Escalate([]string{"push", "email", "sms"}, func(channel string) error {
+		attempted = append(attempted, channel)
+		if channel == "push" {
+			return errors.New("device unreachable")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "email", channel)
+	assert.Equal(t, []string{"push", "email"}, attempted)
+}
+
+func TestEscalate_AllFail(t *testing.T) {
+	_, err := Escalate([]string{"push", "email"}, func(channel string) error {
+		return errors.New("unavailable")
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "push: unavailable")
+	assert.Contains(t, err.Error(), "email: unavailable")
+}
+
+func TestEscalate_EmptyChain(t *testing.T) {
+	_, err := Escalate(nil, func(string) error { return nil })
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// ChannelOrder is the order channels are tried in when several are usable
+var ChannelOrder = []string{
+	models.ChannelPush,
+	models.ChannelEmail,
+	models.ChannelInApp,
+	models.ChannelSMS,
+	models.ChannelWhatsApp,
+	models.ChannelWebhook,
+}
+
+// MaxDeviceAge is how long since a device was last seen before it is no
+// longer sent pushes at all. See models.MaxDeviceAge.
+const MaxDeviceAge = models.MaxDeviceAge
+
+// Resolve returns the channels a notification of category should be
+// delivered on right now, in ChannelOrder. See ResolveAt.
+func Resolve(prefs *models.UserPreferences, category string) ([]string, error) {
+	return ResolveAt(prefs, category, time.Now())
+}
+
+// ResolveAt returns the channels a notification of category should be
+// delivered on at the given time, in ChannelOrder. Nothing is returned when
+// the user has notifications switched off or has not opted in to the
+// category. A channel is used when it is enabled and verified, push also
+// needs an active device seen within MaxDeviceAge, and channels in quiet hours are skipped unless
+// the category bypasses them. An empty category only skips the opt-in
+// check; unknown categories and invalid quiet hours return an error.
+func ResolveAt(prefs *models.UserPreferences, category string, at time.Time) ([]string, error) {
+	decisions, err := DecideAt(prefs, category, at)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := []string{}
+	for _, decision := range decisions {
+		if decision.Action == ActionSend {
+			channels = append(channels, decision.Channel)
+		}
+	}
+	return channels, nil
+}
+
+// categoryAllowed reports whether the user opted in to category. Urgent
+// notifications have no opt-out of their own.
+func categoryAllowed(prefs *models.UserPreferences, category string) (bool, error) {
+	switch category {
+	case models.CategoryMarketing:
+		return prefs.Marketing, nil
+	case models.CategoryTransactional:
+		return prefs.Transactional, nil
+	case models.CategoryReminders:
+		return prefs.Reminders, nil
+	case models.CategoryUrgent, "":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown notification category: %s", category)
+	}
+}
+
+// quietHoursFor returns the quiet hours configured for channel
+func quietHoursFor(channels models.Channels, channel string) models.QuietHours {
+	switch channel {
+	case models.ChannelEmail:
+		return channels.Email.QuietHours
+	case models.ChannelPush:
+		return channels.Push.QuietHours
+	case models.ChannelSMS:
+		return channels.SMS.QuietHours
+	case models.ChannelWhatsApp:
+		return channels.WhatsApp.QuietHours
+	default:
+		// In-app and webhooks are not intrusive, so they ignore quiet hours
+		return models.QuietHours{}
+	}
+}
@@ -0,0 +1,255 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 2025-01-15 12:00 in Nairobi, outside the overnight quiet hours
+var midday = time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+
+// 2025-01-15 23:00 in Nairobi, inside the overnight quiet hours
+var lateNight = time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC)
+
+func overnight() models.QuietHours {
+	return models.QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "Africa/Nairobi"}
+}
+
+// allChannels returns preferences with every channel usable and opted in to
+// every category
+func allChannels() *models.UserPreferences {
+	optedIn := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+	seenAt := time.Date(2025, 1, 12, 18, 30, 0, 0, time.UTC)
+	return &models.UserPreferences{
+		Email:               true,
+		Push:                true,
+		NotificationEnabled: true,
+		Marketing:           true,
+		Transactional:       true,
+		Reminders:           true,
+		Channels: models.Channels{
+			Email: models.EmailChannel{Enabled: true, Verified: true},
+			Push: models.PushChannel{
+				Enabled: true,
+				Devices: []models.UserDevice{{Token: "token", Platform: "android", Active: true, LastSeen: &seenAt}},
+			},
+			SMS:      models.SMSChannel{Enabled: true, Verified: true},
+			WhatsApp: models.WhatsAppChannel{Enabled: true, Verified: true, Phone: "+254712345678", OptedInAt: &optedIn},
+			Webhook:  models.WebhookChannel{Enabled: true, URL: "https://hooks.example.com/n", Format: models.WebhookFormatGenericJSON},
+			InApp:    models.InAppChannel{Enabled: true},
+		},
+	}
+}
+
+func TestResolve_NilPreferences(t *testing.T) {
+	_, err := Resolve(nil, models.CategoryTransactional)
+
+	assert.Error(t, err)
+}
+
+func TestResolve_UnknownCategory(t *testing.T) {
+	_, err := Resolve(allChannels(), "newsletter")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown notification category")
+}
+
+func TestResolveAt_Permutations(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(p *models.UserPreferences)
+		category string
+		at       time.Time
+		expected []string
+	}{
+		{
+			name:     "all channels in order",
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{"push", "email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name:     "notifications disabled",
+			mutate:   func(p *models.UserPreferences) { p.NotificationEnabled = false },
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{},
+		},
+		{
+			name:     "notifications disabled blocks urgent",
+			mutate:   func(p *models.UserPreferences) { p.NotificationEnabled = false },
+			category: models.CategoryUrgent,
+			at:       midday,
+			expected: []string{},
+		},
+		{
+			name:     "marketing opted out",
+			mutate:   func(p *models.UserPreferences) { p.Marketing = false },
+			category: models.CategoryMarketing,
+			at:       midday,
+			expected: []string{},
+		},
+		{
+			name:     "transactional opted out",
+			mutate:   func(p *models.UserPreferences) { p.Transactional = false },
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{},
+		},
+		{
+			name:     "reminders opted out",
+			mutate:   func(p *models.UserPreferences) { p.Reminders = false },
+			category: models.CategoryReminders,
+			at:       midday,
+			expected: []string{},
+		},
+		{
+			name:     "marketing opt out does not affect reminders",
+			mutate:   func(p *models.UserPreferences) { p.Marketing = false },
+			category: models.CategoryReminders,
+			at:       midday,
+			expected: []string{"push", "email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name:     "uncategorised ignores opt ins",
+			mutate:   func(p *models.UserPreferences) { p.Marketing, p.Transactional, p.Reminders = false, false, false },
+			category: "",
+			at:       midday,
+			expected: []string{"push", "email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "unverified email and sms",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Email.Verified = false
+				p.Channels.SMS.Verified = false
+			},
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{"push", "in_app", "whatsapp", "webhook"},
+		},
+		{
+			name: "disabled channels",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.WhatsApp.Enabled = false
+				p.Channels.Webhook.Enabled = false
+				p.Channels.InApp.Enabled = false
+			},
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{"push", "email", "sms"},
+		},
+		{
+			name:     "push without devices",
+			mutate:   func(p *models.UserPreferences) { p.Channels.Push.Devices = nil },
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{"email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "push with only inactive devices",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Push.Devices = []models.UserDevice{{Token: "old", Platform: "ios", Active: false}}
+			},
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{"email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "push with only devices not seen in months",
+			mutate: func(p *models.UserPreferences) {
+				seenAt := midday.Add(-MaxDeviceAge - time.Hour)
+				p.Channels.Push.Devices[0].LastSeen = &seenAt
+			},
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{"email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "marketing skips channels in quiet hours",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Email.QuietHours = overnight()
+				p.Channels.Push.QuietHours = overnight()
+				p.Channels.SMS.QuietHours = overnight()
+				p.Channels.WhatsApp.QuietHours = overnight()
+			},
+			category: models.CategoryMarketing,
+			at:       lateNight,
+			expected: []string{"in_app", "webhook"},
+		},
+		{
+			name: "quiet hours outside window",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Email.QuietHours = overnight()
+				p.Channels.Push.QuietHours = overnight()
+			},
+			category: models.CategoryMarketing,
+			at:       midday,
+			expected: []string{"push", "email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "transactional bypasses quiet hours",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Email.QuietHours = overnight()
+				p.Channels.Push.QuietHours = overnight()
+			},
+			category: models.CategoryTransactional,
+			at:       lateNight,
+			expected: []string{"push", "email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "urgent bypasses quiet hours",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.SMS.QuietHours = overnight()
+			},
+			category: models.CategoryUrgent,
+			at:       lateNight,
+			expected: []string{"push", "email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "reminders obey quiet hours on one channel",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels.Push.QuietHours = overnight()
+			},
+			category: models.CategoryReminders,
+			at:       lateNight,
+			expected: []string{"email", "in_app", "sms", "whatsapp", "webhook"},
+		},
+		{
+			name: "nothing usable",
+			mutate: func(p *models.UserPreferences) {
+				p.Channels = models.Channels{}
+			},
+			category: models.CategoryTransactional,
+			at:       midday,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := allChannels()
+			if tt.mutate != nil {
+				tt.mutate(prefs)
+			}
+
+			channels, err := ResolveAt(prefs, tt.category, tt.at)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, channels)
+		})
+	}
+}
+
+func TestResolveAt_InvalidQuietHours(t *testing.T) {
+	prefs := allChannels()
+	prefs.Channels.Email.QuietHours = models.QuietHours{Enabled: true, Start: "25:00", End: "07:00"}
+
+	_, err := ResolveAt(prefs, models.CategoryMarketing, midday)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel email")
+}
@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/kafka"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultPollInterval is how often Run checks for due notifications
+const DefaultPollInterval = time.Second
+
+// Item is a notification waiting to be published at SendAt
+type Item struct {
+	ID               string                           `json:"id"`
+	NotificationType string                           `json:"notification_type"`
+	Payload          *models.KafkaNotificationPayload `json:"payload"`
+	SendAt           time.Time                        `json:"send_at"`
+
+	// QuietHours and Category, when set, push SendAt past the user's quiet
+	// hours as the orchestrator does for immediate sends
+	QuietHours *models.QuietHours `json:"quiet_hours,omitempty"`
+	Category   string             `json:"category,omitempty"`
+
+	// Priority is passed to the publisher as a kafka.WithPriority hint, so
+	// elevated items still go to the priority topics when released
+	Priority kafka.Priority `json:"priority,omitempty"`
+
+	// CorrelationID ties the released publish back to the request that
+	// scheduled it
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Store persists scheduled items so they survive a restart
+type Store interface {
+	Save(item Item) error
+	// Due returns the items whose SendAt is not after at, earliest first
+	Due(at time.Time) ([]Item, error)
+	Delete(id string) error
+}
+
+// Publisher sends a due notification. kafka.Manager implements it.
+type Publisher interface {
+	PublishByType(ctx context.Context, notificationType, notificationID string, payload interface{}) error
+}
+
+// Config configures a Scheduler
+type Config struct {
+	Store        Store // defaults to a MemoryStore
+	Publisher    Publisher
+	PollInterval time.Duration // defaults to DefaultPollInterval
+	Clock        clock.Clock   // defaults to the real clock
+
+	// OnRelease, when set, is called after each attempt ReleaseDue makes to
+	// publish an item, with the publish error or nil. ctx carries the
+	// item's priority and correlation ID.
+	OnRelease func(ctx context.Context, item Item, err error)
+}
+
+// Scheduler holds notifications until their send time and then publishes
+// them
+type Scheduler struct {
+	store        Store
+	publisher    Publisher
+	pollInterval time.Duration
+	clock        clock.Clock
+	onRelease    func(ctx context.Context, item Item, err error)
+}
+
+func New(cfg Config) *Scheduler {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+
+	return &Scheduler{
+		store:        cfg.Store,
+		publisher:    cfg.Publisher,
+		pollInterval: cfg.PollInterval,
+		clock:        clock.OrReal(cfg.Clock),
+		onRelease:    cfg.OnRelease,
+	}
+}
+
+// Schedule publishes item at its SendAt, deferred to the end of any quiet
+// hours that apply to it. Items that are already due are published right
+// away; the rest are saved to the store.
+func (s *Scheduler) Schedule(ctx context.Context, item Item) error {
+	if item.ID == "" {
+		return fmt.Errorf("scheduled item needs an id")
+	}
+	if item.Payload == nil {
+		return fmt.Errorf("scheduled item %s has no payload", item.ID)
+	}
+
+	if item.QuietHours != nil && item.QuietHours.AppliesTo(item.Category) {
+		next, err := item.QuietHours.NextAllowedTime(item.SendAt)
+		if err != nil {
+			return fmt.Errorf("failed to apply quiet hours to %s: %w", item.ID, err)
+		}
+		item.SendAt = next
+	}
+
+	if !item.SendAt.After(s.clock.Now()) {
+		return s.publish(ctx, item)
+	}
+	if err := s.store.Save(item); err != nil {
+		return fmt.Errorf("failed to save scheduled item %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+// ReleaseDue publishes every item that is due and removes it from the store,
+// returning how many were published. Items that fail to publish stay in the
+// store for the next call and their errors are joined.
+func (s *Scheduler) ReleaseDue(ctx context.Context) (int, error) {
+	due, err := s.store.Due(s.clock.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due items: %w", err)
+	}
+
+	released := 0
+	var errs []error
+	for _, item := range due {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		err := s.publish(ctx, item)
+		if s.onRelease != nil {
+			s.onRelease(itemContext(ctx, item), item, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.ID, err))
+			continue
+		}
+		if err := s.store.Delete(item.ID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to delete: %w", item.ID, err))
+		}
+		released++
+	}
+	return released, errors.Join(errs...)
+}
+
+// Run releases due items every poll interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if released, err := s.ReleaseDue(ctx); err != nil {
+				logger.Log.Error("Failed to release scheduled notifications",
+					zap.Int("released", released),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// publish sends item under the priority and correlation ID it was scheduled
+// with, which the context Run releases items under does not carry
+func (s *Scheduler) publish(ctx context.Context, item Item) error {
+	return s.publisher.PublishByType(itemContext(ctx, item), item.NotificationType, item.ID, item.Payload)
+}
+
+// itemContext annotates ctx with item's priority and correlation ID
+func itemContext(ctx context.Context, item Item) context.Context {
+	ctx = kafka.WithPriority(ctx, item.Priority)
+	return correlation.NewContext(ctx, item.CorrelationID)
+}
+
+// MemoryStore keeps scheduled items in process memory. Items are lost on
+// restart; a persistent Store should be used where that matters.
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]Item
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]Item)}
+}
+
+// Save implements Store, replacing any item with the same ID
+func (s *MemoryStore) Save(item Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[item.ID] = item
+	return nil
+}
+
+// Due implements Store
+func (s *MemoryStore) Due(at time.Time) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []Item{}
+	for _, item := range s.items {
+		if !item.SendAt.After(at) {
+			due = append(due, item)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].SendAt.Before(due[j].SendAt) })
+	return due, nil
+}
+
+// Delete implements Store. Deleting an unknown ID is not an error.
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, id)
+	return nil
+}
+
+// Len returns how many items are waiting
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.items)
+}
@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher keeps the IDs it was asked to publish and the
+// context each was published under
+type recordingPublisher struct {
+	mu        sync.Mutex
+	published []string
+	contexts  []context.Context
+	err       error
+}
+
+func (p *recordingPublisher) PublishByType(ctx context.Context, notificationType, notificationID string, payload interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, notificationID)
+	p.contexts = append(p.contexts, ctx)
+	return nil
+}
+
+func (p *recordingPublisher) ids() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.published...)
+}
+
+func newTestScheduler() (*Scheduler, *recordingPublisher, *MemoryStore, *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	publisher := &recordingPublisher{}
+	store := NewMemoryStore()
+	s := New(Config{Store: store, Publisher: publisher, Clock: clk})
+	return s, publisher, store, clk
+}
+
+func item(id string, sendAt time.Time) Item {
+	return Item{
+		ID:               id,
+		NotificationType: "email",
+		Payload:          &models.KafkaNotificationPayload{NotificationID: id},
+		SendAt:           sendAt,
+	}
+}
+
+func TestScheduler_PastItemSendsImmediately(t *testing.T) {
+	s, publisher, store, clk := newTestScheduler()
+
+	require.NoError(t, s.Schedule(context.Background(), item("n1", clk.Now().Add(-time.Hour))))
+	require.NoError(t, s.Schedule(context.Background(), item("n2", clk.Now())))
+
+	assert.Equal(t, []string{"n1", "n2"}, publisher.ids())
+	assert.Zero(t, store.Len())
+}
+
+func TestScheduler_FutureItemFiresWhenDue(t *testing.T) {
+	s, publisher, store, clk := newTestScheduler()
+	ctx := context.Background()
+
+	require.NoError(t, s.Schedule(ctx, item("later", clk.Now().Add(2*time.Hour))))
+	require.NoError(t, s.Schedule(ctx, item("soon", clk.Now().Add(time.Hour))))
+	assert.Empty(t, publisher.ids())
+	assert.Equal(t, 2, store.Len())
+
+	clk.Advance(59 * time.Minute)
+	released, err := s.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released)
+
+	clk.Advance(time.Minute)
+	released, err = s.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.Equal(t, []string{"soon"}, publisher.ids())
+
+	clk.Advance(3 * time.Hour)
+	released, err = s.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.Equal(t, []string{"soon", "later"}, publisher.ids())
+	assert.Zero(t, store.Len())
+}
+
+func TestScheduler_ReleasedItemKeepsPriority(t *testing.T) {
+	s, publisher, _, clk := newTestScheduler()
+
+	critical := item("otp", clk.Now().Add(time.Hour))
+	critical.Priority = kafka.PriorityCritical
+	require.NoError(t, s.Schedule(context.Background(), critical))
+
+	clk.Advance(time.Hour)
+	released, err := s.ReleaseDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	require.Len(t, publisher.contexts, 1)
+	assert.Equal(t, kafka.PriorityCritical, kafka.PriorityFromContext(publisher.contexts[0]))
+}
+
+func TestScheduler_ReleasedItemKeepsCorrelationID(t *testing.T) {
+	s, publisher, _, clk := newTestScheduler()
+
+	scheduled := item("n1", clk.Now().Add(time.Hour))
+	scheduled.CorrelationID = "corr-123"
+	require.NoError(t, s.Schedule(context.Background(), scheduled))
+
+	clk.Advance(time.Hour)
+	_, err := s.ReleaseDue(context.Background())
+	require.NoError(t, err)
+	require.Len(t, publisher.contexts, 1)
+	assert.Equal(t, "corr-123", correlation.FromContext(publisher.contexts[0]))
+}
+
+func TestScheduler_ReleasesEarliestFirst(t *testing.T) {
+	s, publisher, _, clk := newTestScheduler()
+	ctx := context.Background()
+	for _, id := range []string{"c", "a", "b"} {
+		offset := map[string]time.Duration{"a": time.Minute, "b": 2 * time.Minute, "c": 3 * time.Minute}[id]
+		require.NoError(t, s.Schedule(ctx, item(id, clk.Now().Add(offset))))
+	}
+
+	clk.Advance(time.Hour)
+	_, err := s.ReleaseDue(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, publisher.ids())
+}
+
+func TestScheduler_FailedPublishStaysScheduled(t *testing.T) {
+	s, publisher, store, clk := newTestScheduler()
+	ctx := context.Background()
+	require.NoError(t, s.Schedule(ctx, item("n1", clk.Now().Add(time.Minute))))
+
+	clk.Advance(time.Hour)
+	publisher.err = errors.New("broker unavailable")
+	released, err := s.ReleaseDue(ctx)
+	assert.Error(t, err)
+	assert.Zero(t, released)
+	assert.Equal(t, 1, store.Len())
+
+	publisher.err = nil
+	released, err = s.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+}
+
+func TestScheduler_ReportsEachRelease(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	publisher := &recordingPublisher{}
+
+	type release struct {
+		id            string
+		correlationID string
+		err           error
+	}
+	var releases []release
+	s := New(Config{
+		Publisher: publisher,
+		Clock:     clk,
+		OnRelease: func(ctx context.Context, item Item, err error) {
+			releases = append(releases, release{item.ID, correlation.FromContext(ctx), err})
+		},
+	})
+	ctx := context.Background()
+
+	scheduled := item("n1", clk.Now().Add(time.Minute))
+	scheduled.CorrelationID = "corr-123"
+	require.NoError(t, s.Schedule(ctx, scheduled))
+
+	clk.Advance(time.Hour)
+	brokerDown := errors.New("broker unavailable")
+	publisher.err = brokerDown
+	_, err := s.ReleaseDue(ctx)
+	assert.Error(t, err)
+
+	publisher.err = nil
+	_, err = s.ReleaseDue(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []release{
+		{"n1", "corr-123", brokerDown},
+		{"n1", "corr-123", nil},
+	}, releases)
+}
+
+func TestScheduler_DefersToQuietHours(t *testing.T) {
+	s, publisher, store, clk := newTestScheduler()
+	ctx := context.Background()
+	quiet := &models.QuietHours{Enabled: true, Start: "11:00", End: "14:00", Timezone: "UTC"}
+
+	marketing := item("marketing", clk.Now())
+	marketing.QuietHours, marketing.Category = quiet, models.CategoryMarketing
+	urgent := item("urgent", clk.Now())
+	urgent.QuietHours, urgent.Category = quiet, models.CategoryUrgent
+
+	require.NoError(t, s.Schedule(ctx, marketing))
+	require.NoError(t, s.Schedule(ctx, urgent))
+	assert.Equal(t, []string{"urgent"}, publisher.ids())
+	require.Equal(t, 1, store.Len())
+
+	due, err := store.Due(clk.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC), due[0].SendAt)
+}
+
+func TestScheduler_RejectsInvalidItems(t *testing.T) {
+	s, _, _, clk := newTestScheduler()
+
+	assert.Error(t, s.Schedule(context.Background(), Item{Payload: &models.KafkaNotificationPayload{}, SendAt: clk.Now()}))
+	assert.Error(t, s.Schedule(context.Background(), Item{ID: "n1", SendAt: clk.Now()}))
+}
@@ -45,6 +45,11 @@ func (s *IdempotencyService) GetCachedResponse(ctx context.Context, idempotencyK
 		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
 	}
 
+	if val == idempotencyReservedMarker {
+		// Reserved by a request that has not stored its response yet
+		return nil, nil
+	}
+
 	// Deserialize the cached response
 	var response models.NotificationResponse
 	if err := json.Unmarshal([]byte(val), &response); err != nil {
@@ -96,8 +101,53 @@ func (s *IdempotencyService) StoreResponse(ctx context.Context, idempotencyKey s
 	return nil
 }
 
+// idempotencyReservedMarker is stored under a key reserved by Reserve until
+// StoreResponse replaces it with the response
+const idempotencyReservedMarker = "reserved"
+
+// Reserve claims the key with SETNX, so of several concurrent requests only
+// one reserves it
+func (s *IdempotencyService) Reserve(ctx context.Context, idempotencyKey string) (bool, *models.NotificationResponse, error) {
+	key := s.getRedisKey(idempotencyKey)
+
+	reserved, err := s.redisClient.SetNX(ctx, key, idempotencyReservedMarker, reservationTTL(s.ttl)).Result()
+	if err != nil {
+		logger.Log.Error("Failed to reserve idempotency key in Redis",
+			zap.String("idempotency_key", idempotencyKey),
+			zap.Error(err),
+		)
+		return false, nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return true, nil, nil
+	}
+
+	cached, err := s.GetCachedResponse(ctx, idempotencyKey)
+	if err != nil {
+		return false, nil, err
+	}
+	return false, cached, nil
+}
+
+// Release drops a reservation whose request failed, so it can be retried.
+// A stored response is left in place.
+func (s *IdempotencyService) Release(ctx context.Context, idempotencyKey string) error {
+	key := s.getRedisKey(idempotencyKey)
+
+	val, err := s.redisClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && val != idempotencyReservedMarker) {
+		return nil
+	}
+	if err == nil {
+		err = s.redisClient.Del(ctx, key).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
 // getRedisKey generates the Redis key for an idempotency key
 func (s *IdempotencyService) getRedisKey(idempotencyKey string) string {
 	return fmt.Sprintf("idempotency:%s", idempotencyKey)
 }
-
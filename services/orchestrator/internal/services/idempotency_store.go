@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+)
+
+// IdempotencyStore remembers the response given for an idempotency key.
+// IdempotencyService stores them in Redis and MemoryIdempotencyStore in
+// process; both expire keys after their TTL.
+type IdempotencyStore interface {
+	// GetCachedResponse returns the stored response, or nil when the key
+	// is unknown, expired or still reserved
+	GetCachedResponse(ctx context.Context, key string) (*models.NotificationResponse, error)
+	StoreResponse(ctx context.Context, key string, response *models.NotificationResponse) error
+
+	// Reserve atomically claims key for a request about to be processed,
+	// so concurrent requests with the same key cannot both go ahead. When
+	// the key is already claimed it reports false, along with the stored
+	// response once there is one. StoreResponse fills a reservation in and
+	// Release drops it; unfilled reservations expire after
+	// idempotencyReservationTTL.
+	Reserve(ctx context.Context, key string) (reserved bool, cached *models.NotificationResponse, err error)
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyReservationTTL bounds how long a reserved key blocks retries
+// when the request holding it never stores a response, e.g. because the
+// process died
+const idempotencyReservationTTL = 5 * time.Minute
+
+// reservationTTL is how long a reservation lasts for a store keeping
+// responses for ttl
+func reservationTTL(ttl time.Duration) time.Duration {
+	return min(ttl, idempotencyReservationTTL)
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore that keeps responses in
+// memory. It is safe for concurrent use.
+type MemoryIdempotencyStore struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	response  models.NotificationResponse
+	expiresAt time.Time
+	reserved  bool // claimed by Reserve, no response stored yet
+}
+
+// NewMemoryIdempotencyStore returns an empty store keeping responses for
+// ttl. A nil clk uses the real clock.
+func NewMemoryIdempotencyStore(ttl time.Duration, clk clock.Clock) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:     ttl,
+		clock:   clock.OrReal(clk),
+		entries: make(map[string]idempotencyEntry),
+	}
+}
+
+func (s *MemoryIdempotencyStore) GetCachedResponse(ctx context.Context, key string) (*models.NotificationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getLocked(key), nil
+}
+
+// getLocked returns key's stored response, or nil. s.mu must be held.
+func (s *MemoryIdempotencyStore) getLocked(key string) *models.NotificationResponse {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !s.clock.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	if entry.reserved {
+		return nil
+	}
+	response := entry.response
+	return &response
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string) (bool, *models.NotificationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; ok {
+		// getLocked drops the entry when it has expired
+		if cached := s.getLocked(key); cached != nil {
+			return false, cached, nil
+		}
+		if _, ok := s.entries[key]; ok {
+			return false, nil, nil
+		}
+	}
+	s.entries[key] = idempotencyEntry{expiresAt: s.clock.Now().Add(reservationTTL(s.ttl)), reserved: true}
+	return true, nil, nil
+}
+
+// Release drops key's reservation. Stored responses are kept.
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.reserved {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) StoreResponse(ctx context.Context, key string, response *models.NotificationResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = idempotencyEntry{response: *response, expiresAt: now.Add(s.ttl)}
+	return nil
+}
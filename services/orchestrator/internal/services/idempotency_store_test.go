@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotencyStore_ExpiresAfterTTL(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryIdempotencyStore(time.Hour, clk)
+	ctx := context.Background()
+
+	missing, err := store.GetCachedResponse(ctx, "key-1")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	require.NoError(t, store.StoreResponse(ctx, "key-1", &models.NotificationResponse{NotificationID: "n1", Status: models.StatusPending}))
+
+	clk.Advance(59 * time.Minute)
+	cached, err := store.GetCachedResponse(ctx, "key-1")
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+	assert.Equal(t, "n1", cached.NotificationID)
+
+	clk.Advance(time.Minute)
+	expired, err := store.GetCachedResponse(ctx, "key-1")
+	require.NoError(t, err)
+	assert.Nil(t, expired)
+}
+
+func TestMemoryIdempotencyStore_Reserve(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryIdempotencyStore(time.Hour, clk)
+	ctx := context.Background()
+
+	reserved, cached, err := store.Reserve(ctx, "key-1")
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Nil(t, cached)
+
+	// A reservation blocks other requests but is not a response
+	reserved, cached, err = store.Reserve(ctx, "key-1")
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Nil(t, cached)
+	pending, err := store.GetCachedResponse(ctx, "key-1")
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+
+	require.NoError(t, store.StoreResponse(ctx, "key-1", &models.NotificationResponse{NotificationID: "n1"}))
+	reserved, cached, err = store.Reserve(ctx, "key-1")
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	require.NotNil(t, cached)
+	assert.Equal(t, "n1", cached.NotificationID)
+
+	// Releasing keeps a stored response
+	require.NoError(t, store.Release(ctx, "key-1"))
+	cached, err = store.GetCachedResponse(ctx, "key-1")
+	require.NoError(t, err)
+	assert.NotNil(t, cached)
+}
+
+func TestMemoryIdempotencyStore_ReleaseAndExpiry(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryIdempotencyStore(time.Hour, clk)
+	ctx := context.Background()
+
+	reserved, _, err := store.Reserve(ctx, "key-1")
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.NoError(t, store.Release(ctx, "key-1"))
+	reserved, _, err = store.Reserve(ctx, "key-1")
+	require.NoError(t, err)
+	assert.True(t, reserved, "a released key can be reserved again")
+
+	// An abandoned reservation expires well before the TTL
+	clk.Advance(idempotencyReservationTTL)
+	reserved, _, err = store.Reserve(ctx, "key-1")
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
@@ -2,13 +2,26 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/clients"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/dedup"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/i18n"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/metrics"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/optout"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/repository"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/routing"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/scheduler"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/kafka"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/retry"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -23,6 +36,64 @@ type OrchestrationService struct {
 	templateClient   clients.TemplateClient
 	kafkaManager     KafkaManagerInterface
 	notificationRepo repository.NotificationRepository
+	rateLimiter      RateLimiter
+	frequency        FrequencyLimiter
+	throttle         Throttle
+	dedup            dedup.Store
+	dedupWindow      time.Duration
+	scheduler        Scheduler
+	translator       Translator
+	statusStore      repository.StatusStore
+	deliveryRetry    *retry.Policy
+	deliveryFallback bool
+	idempotency      IdempotencyStore
+	webhooks         WebhookSender
+	consumer         io.Closer
+	metrics          metrics.Metrics
+	clock            clock.Clock
+
+	mu       sync.Mutex
+	closing  bool
+	inflight int
+	idle     chan struct{}
+}
+
+// RateLimiter decides whether a user may be sent another notification on a
+// channel. ratelimit.Limiter implements it.
+type RateLimiter interface {
+	Allow(userID, channel string) bool
+}
+
+// FrequencyLimiter holds a user to one notification per period on channels
+// whose frequency is not immediate. frequency.Limiter implements it.
+type FrequencyLimiter interface {
+	Allow(userID, channel, frequency string) bool
+	// Forget gives back the send Allow recorded, for a notification that
+	// was not sent after all
+	Forget(userID, channel string)
+}
+
+// Scheduler holds notifications until they are due. scheduler.Scheduler
+// implements it.
+type Scheduler interface {
+	Schedule(ctx context.Context, item scheduler.Item) error
+}
+
+// Throttle paces sends on a channel across all users. throttle.Throttle
+// implements it.
+type Throttle interface {
+	Reserve(channel string) (wait time.Duration, ok bool)
+}
+
+// Translator localizes message keys. i18n.Bundle implements it.
+type Translator interface {
+	Translate(lang, key string, args ...interface{}) string
+}
+
+// WebhookSender delivers a notification to a user's webhook channel.
+// webhook.Sender implements it, signing each delivery.
+type WebhookSender interface {
+	Send(ctx context.Context, channel models.WebhookChannel, payload *models.KafkaNotificationPayload) error
 }
 
 func NewOrchestrationService(
@@ -36,88 +107,402 @@ func NewOrchestrationService(
 		templateClient:   templateClient,
 		kafkaManager:     kafkaManager,
 		notificationRepo: notificationRepo,
+		metrics:          metrics.Noop{},
+		clock:            clock.Real(),
+	}
+}
+
+// SetRateLimiter drops notifications for users over their rate limit. A nil
+// limiter, the default, lets everything through.
+func (s *OrchestrationService) SetRateLimiter(limiter RateLimiter) {
+	s.rateLimiter = limiter
+}
+
+// SetFrequencyLimiter drops notifications on hourly and daily channels when
+// the user was already sent one in the current period; they are not batched
+// into a digest. Notifications that are then suppressed as duplicates or fail
+// to publish do not use up the period. Transactional and urgent
+// notifications are never limited. A nil limiter, the default, sends
+// everything.
+func (s *OrchestrationService) SetFrequencyLimiter(limiter FrequencyLimiter) {
+	s.frequency = limiter
+}
+
+// SetThrottle paces sends on each channel. Notifications wait for their slot
+// or, when it is too far off, are scheduled for it. A nil throttle, the
+// default, sends immediately.
+func (s *OrchestrationService) SetThrottle(throttle Throttle) {
+	s.throttle = throttle
+}
+
+// SetDeduplicator drops notifications whose rendered content was already
+// sent to the user on the same channel within window, dedup.DefaultWindow
+// when zero. A nil store, the default, sends everything.
+func (s *OrchestrationService) SetDeduplicator(store dedup.Store, window time.Duration) {
+	if window <= 0 {
+		window = dedup.DefaultWindow
+	}
+	s.dedup = store
+	s.dedupWindow = window
+}
+
+// SetScheduler hands notifications scheduled for later to sched instead of
+// publishing them straight away. A nil scheduler, the default, publishes
+// requests scheduled by the caller immediately, while notifications deferred
+// for quiet hours or throttling are held as pending and never published.
+// Pass RecordRelease as the scheduler's OnRelease so released notifications
+// are recorded as sent.
+func (s *OrchestrationService) SetScheduler(sched Scheduler) {
+	s.scheduler = sched
+}
+
+// RecordRelease records the outcome of the scheduler publishing a held
+// notification, matching scheduler.Config.OnRelease. A failed release is
+// recorded as failed and then pending again, since the scheduler retries it
+// at its next poll.
+func (s *OrchestrationService) RecordRelease(ctx context.Context, item scheduler.Item, err error) {
+	if err != nil {
+		logFor(ctx).Error("Failed to publish scheduled notification",
+			zap.String("notification_id", item.ID),
+			zap.Error(err),
+		)
+		s.trackDelivery(ctx, item.ID, item.NotificationType, models.DeliveryFailed, err.Error())
+		s.metrics.Failed(item.NotificationType)
+		s.trackDelivery(ctx, item.ID, item.NotificationType, models.DeliveryPending, "")
+		return
+	}
+	s.trackDelivery(ctx, item.ID, item.NotificationType, models.DeliverySent, "")
+	s.metrics.Delivered(item.NotificationType)
+}
+
+// SetTranslator localizes template variables into the user's language before
+// rendering: string variables naming a message key are replaced with its
+// translation. A nil translator, the default, passes variables through.
+func (s *OrchestrationService) SetTranslator(translator Translator) {
+	s.translator = translator
+}
+
+// SetStatusStore records each notification's delivery status as it moves
+// through the pipeline. A nil store, the default, tracks nothing.
+func (s *OrchestrationService) SetStatusStore(store repository.StatusStore) {
+	s.statusStore = store
+}
+
+// SetDeliveryRetry retries transient publish failures under policy. With
+// fallback set, a notification whose channel still fails is sent on the
+// next channel of the user's fallback chain instead. By default each
+// publish is tried once.
+func (s *OrchestrationService) SetDeliveryRetry(policy retry.Policy, fallback bool) {
+	s.deliveryRetry = &policy
+	s.deliveryFallback = fallback
+}
+
+// SetIdempotencyStore makes requests with an IdempotencyKey that was already
+// processed return the original response. A request whose key is still being
+// processed fails with models.ErrRequestInProgress. A nil store, the
+// default, processes every request.
+func (s *OrchestrationService) SetIdempotencyStore(store IdempotencyStore) {
+	s.idempotency = store
+}
+
+// SetWebhookSender also delivers each published notification to the user's
+// webhook channel when it is enabled and not opted out of. Webhook failures
+// are logged and tracked but never fail the notification. A nil sender, the
+// default, delivers nothing to webhooks.
+func (s *OrchestrationService) SetWebhookSender(sender WebhookSender) {
+	s.webhooks = sender
+}
+
+// SetMetrics records each notification's outcome on m. A nil m restores the
+// default, which records nothing.
+func (s *OrchestrationService) SetMetrics(m metrics.Metrics) {
+	if m == nil {
+		m = metrics.Noop{}
+	}
+	s.metrics = m
+}
+
+// SetConsumer registers the consumer feeding the orchestrator so Shutdown
+// can stop it before draining
+func (s *OrchestrationService) SetConsumer(consumer io.Closer) {
+	s.consumer = consumer
+}
+
+// shutdownDrainTimeout bounds flushing and closing the producers once the
+// context given to Shutdown has already expired
+const shutdownDrainTimeout = 5 * time.Second
+
+// Shutdown stops taking new notifications, waits for those being processed
+// to finish, then flushes and closes the Kafka producers. New notifications
+// are rejected with models.ErrShuttingDown from the moment it is called.
+// The producers are flushed and closed even when ctx expires first, under
+// a short timeout of their own. It returns the joined errors of waiting
+// for notifications still in flight and of flushing and closing.
+func (s *OrchestrationService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	if s.consumer != nil {
+		if err := s.consumer.Close(); err != nil {
+			logger.Log.Error("Failed to close consumer", zap.Error(err))
+		}
+	}
+
+	var errs []error
+	if err := s.waitIdle(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	drainCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		drainCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), shutdownDrainTimeout)
+		defer cancel()
+	}
+	if flusher, ok := s.kafkaManager.(interface{ Flush(context.Context) error }); ok {
+		if err := flusher.Flush(drainCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush producers: %w", err))
+		}
+	}
+	if closer, ok := s.kafkaManager.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close producers: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// begin registers a notification as in flight, or reports false once
+// shutdown has started
+func (s *OrchestrationService) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return false
 	}
+	s.inflight++
+	return true
 }
 
+// end marks a notification from begin as finished
+func (s *OrchestrationService) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inflight--
+	if s.inflight == 0 && s.idle != nil {
+		close(s.idle)
+		s.idle = nil
+	}
+}
+
+// waitIdle blocks until no notifications are in flight or ctx is done
+func (s *OrchestrationService) waitIdle(ctx context.Context) error {
+	s.mu.Lock()
+	if s.inflight == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.idle == nil {
+		s.idle = make(chan struct{})
+	}
+	idle := s.idle
+	s.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		remaining := s.inflight
+		s.mu.Unlock()
+		return fmt.Errorf("shutdown deadline exceeded with %d notifications in flight: %w", remaining, ctx.Err())
+	}
+}
+
+// logFor returns the logger annotated with the correlation ID carried by ctx
+func logFor(ctx context.Context) *zap.Logger {
+	if id := correlation.FromContext(ctx); id != "" {
+		return logger.Log.With(zap.String("correlation_id", id))
+	}
+	return logger.Log
+}
+
+// idempotencyKeyPrefix keeps notification keys apart from the request IDs
+// the HTTP handler stores in the same IdempotencyService
+const idempotencyKeyPrefix = "notification:"
+
 func (s *OrchestrationService) ProcessNotification(req *models.NotificationRequest) (*models.NotificationResponse, error) {
+	if !s.begin() {
+		return nil, models.ErrShuttingDown
+	}
+	defer s.end()
+
+	// Malformed requests never reach the user service or Kafka
+	if err := req.Validate(); err != nil {
+		return nil, &models.ValidationError{Err: err}
+	}
+	if err := kafka.ValidateMetadata(req.Attributes); err != nil {
+		return nil, &models.ValidationError{Err: fmt.Errorf("attributes: %w", err)}
+	}
+
+	if req.CorrelationID == "" {
+		req.CorrelationID = correlation.New()
+	}
+	ctx := correlation.NewContext(context.Background(), req.CorrelationID)
+
+	if s.idempotency == nil || req.IdempotencyKey == "" {
+		return s.processNotification(ctx, req)
+	}
+
+	// Reserving the key before processing keeps concurrent duplicates from
+	// both being sent
+	key := idempotencyKeyPrefix + req.IdempotencyKey
+	reserved, cached, err := s.idempotency.Reserve(ctx, key)
+	switch {
+	case err != nil:
+		logFor(ctx).Warn("Failed to reserve notification idempotency key, processing anyway",
+			zap.String("idempotency_key", req.IdempotencyKey),
+			zap.Error(err),
+		)
+	case cached != nil:
+		logFor(ctx).Info("Notification already processed, returning original response",
+			zap.String("idempotency_key", req.IdempotencyKey),
+			zap.String("notification_id", cached.NotificationID),
+		)
+		return cached, nil
+	case !reserved:
+		return nil, models.ErrRequestInProgress
+	}
+
+	response, err := s.processNotification(ctx, req)
+	if err != nil {
+		if reserved {
+			// Let the client retry the key
+			if releaseErr := s.idempotency.Release(ctx, key); releaseErr != nil {
+				logFor(ctx).Warn("Failed to release notification idempotency key",
+					zap.String("idempotency_key", req.IdempotencyKey),
+					zap.Error(releaseErr),
+				)
+			}
+		}
+		return nil, err
+	}
+	if storeErr := s.idempotency.StoreResponse(ctx, key, response); storeErr != nil {
+		logFor(ctx).Warn("Failed to store notification idempotency key",
+			zap.String("idempotency_key", req.IdempotencyKey),
+			zap.Error(storeErr),
+		)
+	}
+	return response, nil
+}
+
+func (s *OrchestrationService) processNotification(ctx context.Context, req *models.NotificationRequest) (*models.NotificationResponse, error) {
 	notificationID := uuid.New().String()
-	ctx := context.Background()
+	log := logFor(ctx)
 
-	logger.Log.Info("Processing notification",
+	log.Info("Processing notification",
 		zap.String("notification_id", notificationID),
 		zap.String("user_id", req.UserID),
 		zap.String("template_code", req.TemplateCode),
 		zap.String("notification_type", string(req.NotificationType)),
 	)
+	s.metrics.Processed(string(req.NotificationType))
 
-	// Step 1: Get user preferences
-	userPrefs, err := s.userClient.GetPreferences(req.UserID)
+	// Step 1: Get user preferences. Users that do not exist, e.g. because
+	// their data was deleted, are suppressed on every channel.
+	userPrefs, err := s.userClient.GetPreferences(ctx, req.UserID)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return s.reject(ctx, notificationID, req, metrics.ReasonUserNotFound, fmt.Errorf("user %s not found", req.UserID)), nil
+	}
 	if err != nil {
-		logger.Log.Error("Failed to get user preferences",
+		log.Error("Failed to get user preferences",
 			zap.String("user_id", req.UserID),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("failed to get user preferences: %w", err)
 	}
 
-	// Step 2: Validate channel preferences
-	if err := s.validateChannelPreferences(req.NotificationType, userPrefs); err != nil {
-		logger.Log.Warn("Channel validation failed",
+	// Step 2: Validate channel preferences and unsubscribe status
+	optOut, err := s.getOptOutStatus(ctx, req.UserID)
+	if err != nil {
+		log.Error("Failed to get opt-out status",
 			zap.String("user_id", req.UserID),
-			zap.String("notification_type", string(req.NotificationType)),
 			zap.Error(err),
 		)
+		return nil, fmt.Errorf("failed to get opt-out status: %w", err)
+	}
 
-		// Persist failed notification for audit trail
-		errorMsg := err.Error()
-		notificationRecord := &models.NotificationRecord{
-			ID:               notificationID,
-			UserID:           req.UserID,
-			TemplateCode:     req.TemplateCode,
-			NotificationType: string(req.NotificationType),
-			Status:           models.StatusFailed,
-			Priority:         s.getPriority(req.Priority),
-			Variables:        models.JSONB(req.Variables),
-			ScheduledFor:     req.ScheduledFor,
-			ErrorMessage:     &errorMsg,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
+	err = s.validateChannelPreferences(req.NotificationType, userPrefs)
+	reason := metrics.ReasonChannelDisabled
+	if err == nil && optout.IsBlocked(optOut, string(req.NotificationType), req.Category) {
+		optedOutOf := string(req.NotificationType)
+		if !optout.IsBlocked(optOut, optedOutOf, "") {
+			optedOutOf = req.Category
 		}
-		if req.Metadata != nil {
-			metadata := models.JSONB(req.Metadata)
-			notificationRecord.Metadata = &metadata
+		err = fmt.Errorf("user opted out of %s notifications", optedOutOf)
+		reason = metrics.ReasonOptOut
+	}
+	if err == nil && s.rateLimiter != nil && !s.rateLimiter.Allow(req.UserID, string(req.NotificationType)) {
+		err = fmt.Errorf("rate limit exceeded for %s notifications", req.NotificationType)
+		reason = metrics.ReasonRateLimited
+	}
+	claimed := false
+	if err == nil {
+		var allowed bool
+		allowed, claimed = s.allowedByFrequency(req, userPrefs)
+		if !allowed {
+			err = fmt.Errorf("%s frequency limit reached", req.NotificationType)
+			reason = metrics.ReasonFrequency
 		}
+	}
+	if err != nil {
+		return s.reject(ctx, notificationID, req, reason, err), nil
+	}
 
-		if persistErr := s.notificationRepo.Create(ctx, notificationRecord); persistErr != nil {
-			logger.Log.Error("Failed to persist failed notification record",
-				zap.String("notification_id", notificationID),
-				zap.Error(persistErr),
-			)
-		}
+	// Give the frequency period back unless the notification goes out
+	queued := false
+	if claimed {
+		defer func() {
+			if !queued {
+				s.frequency.Forget(req.UserID, string(req.NotificationType))
+			}
+		}()
+	}
 
-		return &models.NotificationResponse{
-			NotificationID: notificationID,
-			Status:         models.StatusFailed,
-			Timestamp:      time.Now(),
-			Error:          err.Error(),
-		}, nil
+	// Step 3: Hold back until quiet hours end unless the category bypasses them
+	deferred := s.applyQuietHours(ctx, req, userPrefs)
+
+	// Step 4: Pace sends on the channel so provider limits are not exceeded
+	throttled, err := s.applyThrottle(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for send slot: %w", err)
 	}
+	deferred = deferred || throttled
 
-	// Step 3: Render template
-	rendered, err := s.templateClient.RenderTemplate(
-		req.TemplateCode,
-		"en", // Default language
-		req.Variables,
-	)
+	// Step 5: Render template in the user's language
+	rendered, err := s.renderTemplate(ctx, req, userPrefs)
 	if err != nil {
-		logger.Log.Error("Failed to render template",
+		log.Error("Failed to render template",
 			zap.String("template_code", req.TemplateCode),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("failed to render template: %w", err)
 	}
 
-	// Step 4: Create notification record
+	// Step 6: Skip content the user was already sent on this channel
+	dedupKey := ""
+	if s.dedup != nil {
+		dedupKey = dedup.Key(req.UserID, string(req.NotificationType),
+			rendered.Rendered.Subject, rendered.Rendered.Body.HTML, rendered.Rendered.Body.Text)
+		if s.dedup.SeenRecently(dedupKey, s.dedupWindow) {
+			return s.reject(ctx, notificationID, req, metrics.ReasonDuplicate, fmt.Errorf("duplicate notification suppressed")), nil
+		}
+	}
+
+	// Step 7: Create notification record
 	notificationRecord := &models.NotificationRecord{
 		ID:               notificationID,
 		UserID:           req.UserID,
@@ -138,31 +523,49 @@ func (s *OrchestrationService) ProcessNotification(req *models.NotificationReque
 
 	// Persist notification record (even if Kafka publish fails, we want audit trail)
 	if err := s.notificationRepo.Create(ctx, notificationRecord); err != nil {
-		logger.Log.Error("Failed to persist notification record",
+		log.Error("Failed to persist notification record",
 			zap.String("notification_id", notificationID),
 			zap.Error(err),
 		)
 		// Continue processing even if persistence fails, but log the error
 	}
+	s.trackDelivery(ctx, notificationID, string(req.NotificationType), models.DeliveryPending, "")
 
-	// Step 5: Create and publish Kafka payload
+	// Step 8: Create the Kafka payload and publish it, or schedule it when
+	// it is not due yet
 	payload := s.createKafkaPayload(notificationID, req, rendered)
-	if err := s.publishToKafka(ctx, req.NotificationType, notificationID, payload); err != nil {
-		logger.Log.Error("Failed to publish to Kafka",
+	withDeviceTokens(payload, userPrefs, s.clock.Now())
+	sentOn, err := s.dispatch(ctx, req, userPrefs, notificationID, payload, deferred)
+	if err != nil {
+		log.Error("Failed to publish to Kafka",
 			zap.String("notification_id", notificationID),
 			zap.Error(err),
 		)
+		// Let a retry through deduplication since nothing was sent
+		if dedupKey != "" {
+			s.dedup.Forget(dedupKey)
+		}
+		s.trackDelivery(ctx, notificationID, string(req.NotificationType), models.DeliveryFailed, err.Error())
+		s.metrics.Failed(string(req.NotificationType))
 		// Update status to failed if Kafka publish fails
 		if updateErr := s.notificationRepo.UpdateStatus(ctx, notificationID, models.StatusFailed, err.Error()); updateErr != nil {
-			logger.Log.Error("Failed to update notification status after Kafka error",
+			log.Error("Failed to update notification status after Kafka error",
 				zap.String("notification_id", notificationID),
 				zap.Error(updateErr),
 			)
 		}
 		return nil, fmt.Errorf("failed to queue notification: %w", err)
 	}
+	queued = true
+	if sentOn == string(req.NotificationType) {
+		s.trackDelivery(ctx, notificationID, sentOn, models.DeliverySent, "")
+	}
+	if sentOn != "" {
+		s.metrics.Delivered(sentOn)
+		s.notifyWebhook(ctx, notificationID, req, userPrefs, optOut, payload)
+	}
 
-	logger.Log.Info("Notification queued successfully",
+	log.Info("Notification queued successfully",
 		zap.String("notification_id", notificationID),
 		zap.String("notification_type", string(req.NotificationType)),
 	)
@@ -174,6 +577,146 @@ func (s *OrchestrationService) ProcessNotification(req *models.NotificationReque
 	}, nil
 }
 
+// renderTemplate renders the request's template in the user's preferred
+// language, falling back to English when that fails
+func (s *OrchestrationService) renderTemplate(ctx context.Context, req *models.NotificationRequest, prefs *models.UserPreferences) (*models.RenderResponse, error) {
+	language := i18n.DefaultLanguage
+	if prefs != nil && prefs.Language != "" {
+		language = prefs.Language
+	}
+
+	rendered, err := s.templateClient.RenderTemplate(req.TemplateCode, language, s.localize(language, req.Variables))
+	if err == nil || language == i18n.DefaultLanguage {
+		return rendered, err
+	}
+
+	logFor(ctx).Warn("Falling back to default language",
+		zap.String("template_code", req.TemplateCode),
+		zap.String("language", language),
+		zap.Error(err),
+	)
+	return s.templateClient.RenderTemplate(req.TemplateCode, i18n.DefaultLanguage, s.localize(i18n.DefaultLanguage, req.Variables))
+}
+
+// localize returns a copy of variables with string values translated into
+// language. Values that are not message keys translate to themselves.
+func (s *OrchestrationService) localize(language string, variables map[string]interface{}) map[string]interface{} {
+	if s.translator == nil || variables == nil {
+		return variables
+	}
+
+	localized := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		if text, ok := value.(string); ok {
+			value = s.translator.Translate(language, text)
+		}
+		localized[name] = value
+	}
+	return localized
+}
+
+// reject persists a failed record of the notification for the audit trail,
+// counts it as suppressed for metricReason and returns the failed response
+// for reason
+func (s *OrchestrationService) reject(
+	ctx context.Context,
+	notificationID string,
+	req *models.NotificationRequest,
+	metricReason string,
+	reason error,
+) *models.NotificationResponse {
+	logFor(ctx).Warn("Notification rejected",
+		zap.String("user_id", req.UserID),
+		zap.String("notification_type", string(req.NotificationType)),
+		zap.Error(reason),
+	)
+
+	errorMsg := reason.Error()
+	notificationRecord := &models.NotificationRecord{
+		ID:               notificationID,
+		UserID:           req.UserID,
+		TemplateCode:     req.TemplateCode,
+		NotificationType: string(req.NotificationType),
+		Status:           models.StatusFailed,
+		Priority:         s.getPriority(req.Priority),
+		Variables:        models.JSONB(req.Variables),
+		ScheduledFor:     req.ScheduledFor,
+		ErrorMessage:     &errorMsg,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	if req.Metadata != nil {
+		metadata := models.JSONB(req.Metadata)
+		notificationRecord.Metadata = &metadata
+	}
+
+	if persistErr := s.notificationRepo.Create(ctx, notificationRecord); persistErr != nil {
+		logFor(ctx).Error("Failed to persist failed notification record",
+			zap.String("notification_id", notificationID),
+			zap.Error(persistErr),
+		)
+	}
+	s.trackDelivery(ctx, notificationID, string(req.NotificationType), models.DeliverySuppressed, errorMsg)
+	s.metrics.Suppressed(string(req.NotificationType), metricReason)
+
+	return &models.NotificationResponse{
+		NotificationID: notificationID,
+		Status:         models.StatusFailed,
+		Timestamp:      time.Now(),
+		Error:          errorMsg,
+	}
+}
+
+// notifyWebhook delivers payload to the user's webhook channel as well, when
+// a webhook sender is set and the channel accepts it
+func (s *OrchestrationService) notifyWebhook(
+	ctx context.Context,
+	notificationID string,
+	req *models.NotificationRequest,
+	prefs *models.UserPreferences,
+	optOut *models.OptOutStatus,
+	payload *models.KafkaNotificationPayload,
+) {
+	if s.webhooks == nil || !prefs.Channels.Webhook.Enabled || optout.IsBlocked(optOut, models.ChannelWebhook, req.Category) {
+		return
+	}
+
+	s.trackDelivery(ctx, notificationID, models.ChannelWebhook, models.DeliveryPending, "")
+	if err := s.webhooks.Send(ctx, prefs.Channels.Webhook, payload); err != nil {
+		logFor(ctx).Warn("Failed to deliver webhook",
+			zap.String("notification_id", notificationID),
+			zap.Error(err),
+		)
+		s.trackDelivery(ctx, notificationID, models.ChannelWebhook, models.DeliveryFailed, err.Error())
+		s.metrics.Failed(models.ChannelWebhook)
+		return
+	}
+	s.trackDelivery(ctx, notificationID, models.ChannelWebhook, models.DeliverySent, "")
+	s.metrics.Delivered(models.ChannelWebhook)
+}
+
+// trackDelivery records a delivery status change. Failures are logged and
+// never stop the notification.
+func (s *OrchestrationService) trackDelivery(
+	ctx context.Context,
+	notificationID string,
+	channel string,
+	status models.DeliveryStatus,
+	detail string,
+) {
+	if s.statusStore == nil {
+		return
+	}
+	if err := s.statusStore.SetStatus(ctx, notificationID, channel, status, detail); err != nil {
+		logFor(ctx).Error("Failed to record delivery status",
+			zap.String("notification_id", notificationID),
+			zap.String("channel", channel),
+			zap.String("status", string(status)),
+			zap.Error(err),
+		)
+	}
+}
+
 // createKafkaPayload constructs the payload for Kafka based on notification type
 func (s *OrchestrationService) createKafkaPayload(
 	notificationID string,
@@ -187,6 +730,8 @@ func (s *OrchestrationService) createKafkaPayload(
 		TemplateCode:     req.TemplateCode,
 		Priority:         s.getPriority(req.Priority),
 		Metadata:         req.Metadata,
+		Attributes:       req.Attributes,
+		CorrelationID:    req.CorrelationID,
 		CreatedAt:        time.Now(),
 	}
 
@@ -208,13 +753,154 @@ func (s *OrchestrationService) createKafkaPayload(
 	return payload
 }
 
-// publishToKafka sends the notification to the appropriate Kafka topic
+// withDeviceTokens sets the user's push tokens that have not expired at the
+// given time, grouped by platform, on push payloads and clears them from
+// any other
+func withDeviceTokens(payload *models.KafkaNotificationPayload, prefs *models.UserPreferences, at time.Time) {
+	payload.DeviceTokens = nil
+	if payload.NotificationType == string(models.NotificationPush) {
+		payload.DeviceTokens = prefs.Channels.Push.TokensByPlatformAt(at)
+	}
+}
+
+// dispatch publishes payload, or hands it to the scheduler when req is
+// scheduled for later and a scheduler is set. Without a scheduler, a
+// notification the orchestrator deferred itself is held back rather than
+// sent early. sentOn is the channel it was published on straight away, or
+// empty when it was scheduled or held.
+func (s *OrchestrationService) dispatch(
+	ctx context.Context,
+	req *models.NotificationRequest,
+	prefs *models.UserPreferences,
+	notificationID string,
+	payload *models.KafkaNotificationPayload,
+	deferred bool,
+) (sentOn string, err error) {
+	now := s.clock.Now()
+	if req.ScheduledFor == nil || !req.ScheduledFor.After(now) {
+		return s.deliver(ctx, req, prefs, notificationID, payload)
+	}
+	if s.scheduler == nil {
+		if !deferred {
+			return s.deliver(ctx, req, prefs, notificationID, payload)
+		}
+		logFor(ctx).Warn("Holding deferred notification as pending, no scheduler is enabled to release it",
+			zap.String("notification_id", notificationID),
+			zap.Time("scheduled_for", *req.ScheduledFor),
+		)
+		return "", nil
+	}
+
+	logFor(ctx).Info("Scheduling notification",
+		zap.String("notification_id", notificationID),
+		zap.Time("scheduled_for", *req.ScheduledFor),
+	)
+	return "", s.scheduler.Schedule(ctx, scheduler.Item{
+		ID:               notificationID,
+		NotificationType: string(req.NotificationType),
+		Payload:          payload,
+		SendAt:           *req.ScheduledFor,
+		Priority:         kafkaPriority(payload.Priority),
+		CorrelationID:    req.CorrelationID,
+	})
+}
+
+// deliver publishes payload on the request's channel, retrying transient
+// failures under the delivery retry policy. When the channel still fails
+// and fallback is enabled, the payload is published on the first channel
+// of the user's fallback chain that accepts it, and the original channel's
+// delivery is marked failed. It returns the channel that was used.
+func (s *OrchestrationService) deliver(
+	ctx context.Context,
+	req *models.NotificationRequest,
+	prefs *models.UserPreferences,
+	notificationID string,
+	payload *models.KafkaNotificationPayload,
+) (string, error) {
+	channel := string(req.NotificationType)
+	err := s.publishWithRetry(ctx, models.NotificationType(channel), notificationID, payload)
+	if err == nil {
+		return channel, nil
+	}
+	if !s.deliveryFallback {
+		return "", err
+	}
+
+	chain := []string{}
+	for _, next := range routing.FallbackChainAt(prefs, req.Category, s.clock.Now()) {
+		if next != channel && publishableChannels[next] {
+			chain = append(chain, next)
+		}
+	}
+	if len(chain) == 0 {
+		return "", err
+	}
+
+	logFor(ctx).Warn("Falling back to another channel",
+		zap.String("notification_id", notificationID),
+		zap.String("channel", channel),
+		zap.Strings("fallback_chain", chain),
+		zap.Error(err),
+	)
+	used, fallbackErr := routing.Escalate(chain, func(next string) error {
+		s.trackDelivery(ctx, notificationID, next, models.DeliveryPending, "")
+		fallback := *payload
+		fallback.NotificationType = next
+		withDeviceTokens(&fallback, prefs, s.clock.Now())
+		if err := s.publishWithRetry(ctx, models.NotificationType(next), notificationID, &fallback); err != nil {
+			s.trackDelivery(ctx, notificationID, next, models.DeliveryFailed, err.Error())
+			return err
+		}
+		s.trackDelivery(ctx, notificationID, next, models.DeliverySent, "")
+		return nil
+	})
+	if fallbackErr != nil {
+		return "", fmt.Errorf("%w; fallback: %v", err, fallbackErr)
+	}
+	s.trackDelivery(ctx, notificationID, channel, models.DeliveryFailed, err.Error())
+	return used, nil
+}
+
+// publishableChannels are the channels with a Kafka topic
+var publishableChannels = map[string]bool{
+	string(models.NotificationEmail): true,
+	string(models.NotificationPush):  true,
+}
+
+// publishWithRetry publishes under the delivery retry policy, or once when
+// none is set. Cancelled contexts, unserializable payloads, payloads that
+// fail the topic's schema and rejected credentials are never retried.
+func (s *OrchestrationService) publishWithRetry(
+	ctx context.Context,
+	notificationType models.NotificationType,
+	key string,
+	payload *models.KafkaNotificationPayload,
+) error {
+	start := s.clock.Now()
+	defer func() { s.metrics.ObservePublish(string(notificationType), s.clock.Now().Sub(start)) }()
+
+	if s.deliveryRetry == nil {
+		return s.publishToKafka(ctx, notificationType, key, payload)
+	}
+	return retry.Do(ctx, *s.deliveryRetry, func(ctx context.Context) error {
+		err := s.publishToKafka(ctx, notificationType, key, payload)
+		if errors.Is(err, kafka.ErrMarshal) || errors.Is(err, kafka.ErrSchemaInvalid) || errors.Is(err, kafka.ErrMetadataInvalid) || errors.Is(err, kafka.ErrAuth) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+}
+
+// publishToKafka sends the notification to the appropriate Kafka topic. The
+// payload's priority is passed along as a kafka.WithPriority hint, which
+// sends high and urgent notifications to the priority topics.
 func (s *OrchestrationService) publishToKafka(
 	ctx context.Context,
 	notificationType models.NotificationType,
 	key string,
 	payload *models.KafkaNotificationPayload,
 ) error {
+	ctx = kafka.WithPriority(ctx, kafkaPriority(payload.Priority))
 	return s.kafkaManager.PublishByType(
 		ctx,
 		string(notificationType),
@@ -223,6 +909,10 @@ func (s *OrchestrationService) publishToKafka(
 	)
 }
 
+// validateChannelPreferences checks that the user has the notification's
+// channel turned on. When the user service reports the channel's details it
+// must also be deliverable, e.g. have a verified address or a recently seen
+// device, and the error carries the models.Undeliverable reason.
 func (s *OrchestrationService) validateChannelPreferences(
 	notificationType models.NotificationType,
 	prefs *models.UserPreferences,
@@ -239,29 +929,151 @@ func (s *OrchestrationService) validateChannelPreferences(
 	default:
 		return fmt.Errorf("unknown notification type: %s", notificationType)
 	}
+
+	channel := string(notificationType)
+	if !prefs.Channels.Reported(channel) {
+		return nil
+	}
+	if ok, reason := prefs.Channels.DeliverableAt(channel, s.clock.Now()); !ok {
+		return fmt.Errorf("%s channel not deliverable: %s", channel, reason)
+	}
 	return nil
 }
 
-func (s *OrchestrationService) getPriority(priority int) string {
-	// Map integer priority to string values
-	// 0 or unset = normal, 1 = low, 2 = normal, 3 = high, 4 = urgent
-	if priority == 0 {
-		return "normal"
+// applyQuietHours moves req.ScheduledFor to the end of the user's quiet hours
+// for the notification's channel, reporting whether it did. Transactional
+// and urgent notifications, including those sent with urgent priority, are
+// left untouched.
+func (s *OrchestrationService) applyQuietHours(ctx context.Context, req *models.NotificationRequest, prefs *models.UserPreferences) bool {
+	var quietHours models.QuietHours
+	switch req.NotificationType {
+	case models.NotificationEmail:
+		quietHours = prefs.Channels.Email.QuietHours
+	case models.NotificationPush:
+		quietHours = prefs.Channels.Push.QuietHours
+	default:
+		return false
 	}
 
-	priorityMap := map[int]string{
-		1: "low",
-		2: "normal",
-		3: "high",
-		4: "urgent",
+	category := req.Category
+	if category == "" && models.PriorityFromLevel(req.Priority) == models.PriorityUrgent {
+		category = models.CategoryUrgent
+	}
+	if !quietHours.AppliesTo(category) {
+		return false
 	}
 
-	if val, ok := priorityMap[priority]; ok {
-		return val
+	from := s.clock.Now()
+	if req.ScheduledFor != nil && req.ScheduledFor.After(from) {
+		from = *req.ScheduledFor
 	}
 
-	// Default to normal for unknown values
-	return "normal"
+	next, err := quietHours.NextAllowedTime(from)
+	if err != nil {
+		logFor(ctx).Warn("Ignoring invalid quiet hours",
+			zap.String("user_id", req.UserID),
+			zap.Error(err),
+		)
+		return false
+	}
+	if !next.After(from) {
+		return false
+	}
+
+	logFor(ctx).Info("Deferring notification until quiet hours end",
+		zap.String("user_id", req.UserID),
+		zap.String("category", category),
+		zap.Time("scheduled_for", next),
+	)
+	req.ScheduledFor = &next
+	s.metrics.Deferred(string(req.NotificationType), metrics.ReasonQuietHours)
+	return true
+}
+
+// allowedByFrequency reports whether the frequency set on the notification's
+// channel lets the user be sent it now, and whether that used up the
+// period. Transactional and urgent notifications, including those sent with
+// urgent priority, bypass it.
+func (s *OrchestrationService) allowedByFrequency(req *models.NotificationRequest, prefs *models.UserPreferences) (allowed, claimed bool) {
+	if s.frequency == nil {
+		return true, false
+	}
+	switch req.Category {
+	case models.CategoryTransactional, models.CategoryUrgent:
+		return true, false
+	}
+	if req.Category == "" && models.PriorityFromLevel(req.Priority) == models.PriorityUrgent {
+		return true, false
+	}
+
+	channel := string(req.NotificationType)
+	allowed = s.frequency.Allow(req.UserID, channel, prefs.Channels.FrequencyFor(channel))
+	return allowed, allowed
+}
+
+// applyThrottle waits for a send slot on the notification's channel, or
+// schedules the notification for its reserved slot when waiting would take
+// longer than the throttle allows, reporting whether it did. Notifications
+// already scheduled for later are left alone.
+func (s *OrchestrationService) applyThrottle(ctx context.Context, req *models.NotificationRequest) (bool, error) {
+	if s.throttle == nil {
+		return false, nil
+	}
+
+	now := s.clock.Now()
+	if req.ScheduledFor != nil && req.ScheduledFor.After(now) {
+		return false, nil
+	}
+
+	wait, ok := s.throttle.Reserve(string(req.NotificationType))
+	if !ok {
+		next := now.Add(wait)
+		logFor(ctx).Info("Deferring notification until the channel has capacity",
+			zap.String("user_id", req.UserID),
+			zap.String("notification_type", string(req.NotificationType)),
+			zap.Time("scheduled_for", next),
+		)
+		req.ScheduledFor = &next
+		s.metrics.Deferred(string(req.NotificationType), metrics.ReasonThrottled)
+		return true, nil
+	}
+	if wait <= 0 {
+		return false, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// getOptOutStatus returns the user's unsubscribe status, or nil when the
+// user client cannot report it
+func (s *OrchestrationService) getOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	checker, ok := s.userClient.(clients.OptOutChecker)
+	if !ok {
+		return nil, nil
+	}
+	return checker.GetOptOutStatus(ctx, userID)
+}
+
+// kafkaPriority maps a payload priority onto the Kafka routing hint. The
+// contract's "urgent" routes as kafka.PriorityCritical; the payload itself
+// keeps the contract name.
+func kafkaPriority(priority string) kafka.Priority {
+	if models.Priority(priority) == models.PriorityUrgent {
+		return kafka.PriorityCritical
+	}
+	return kafka.Priority(priority)
+}
+
+// getPriority names a request's priority level, see models.PriorityFromLevel
+func (s *OrchestrationService) getPriority(priority int) string {
+	return string(models.PriorityFromLevel(priority))
 }
 
 // UpdateNotificationStatus updates the status of a notification in the database
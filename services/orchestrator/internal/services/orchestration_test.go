@@ -3,12 +3,27 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/dedup"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/frequency"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/i18n"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/mocks"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/repository"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/scheduler"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/throttle"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/kafka"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/retry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockUserClient mocks the UserClient interface
@@ -16,8 +31,8 @@ type MockUserClient struct {
 	mock.Mock
 }
 
-func (m *MockUserClient) GetPreferences(userID string) (*models.UserPreferences, error) {
-	args := m.Called(userID)
+func (m *MockUserClient) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -169,7 +184,7 @@ func TestOrchestrationService_ProcessNotification_Success_Email(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockUserClient.On("GetPreferences", "user-456").Return(userPrefs, nil)
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
 	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
 	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
@@ -231,7 +246,7 @@ func TestOrchestrationService_ProcessNotification_Success_Push(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockUserClient.On("GetPreferences", "user-456").Return(userPrefs, nil)
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
 	mockTemplateClient.On("RenderTemplate", "push_notification", "en", req.Variables).Return(rendered, nil)
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
 	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
@@ -249,6 +264,77 @@ func TestOrchestrationService_ProcessNotification_Success_Push(t *testing.T) {
 	mockKafkaManager.AssertExpectations(t)
 }
 
+func TestOrchestrationService_ProcessNotification_UnverifiedEmailRejected(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+	userPrefs := &models.UserPreferences{
+		Email:    true,
+		Channels: models.Channels{Email: models.EmailChannel{Enabled: true}},
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, response.Status)
+	assert.Contains(t, response.Error, models.UndeliverableUnverified)
+	mockTemplateClient.AssertNotCalled(t, "RenderTemplate", mock.Anything, mock.Anything, mock.Anything)
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+}
+
+func TestOrchestrationService_ProcessNotification_PushCarriesTokensByPlatform(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationPush,
+		UserID:           "user-456",
+		TemplateCode:     "push_notification",
+	}
+	seen := time.Now()
+	userPrefs := &models.UserPreferences{
+		Push: true,
+		Channels: models.Channels{Push: models.PushChannel{Enabled: true, Devices: []models.UserDevice{
+			{DeviceID: "iphone", Token: "apns-token", Platform: "ios", Active: true, LastSeen: &seen},
+			{DeviceID: "pixel", Token: "fcm-token", Platform: "android", Active: true, LastSeen: &seen},
+			{DeviceID: "old", Token: "old-token", Platform: "android"},
+		}}},
+	}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "Hello"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
+	mockTemplateClient.On("RenderTemplate", "push_notification", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"),
+		mock.MatchedBy(func(payload *models.KafkaNotificationPayload) bool {
+			return assert.Equal(t, map[string][]string{"ios": {"apns-token"}, "android": {"fcm-token"}}, payload.DeviceTokens)
+		})).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockKafkaManager.AssertExpectations(t)
+}
+
 func TestOrchestrationService_ProcessNotification_UserPreferencesError(t *testing.T) {
 	mockUserClient := new(MockUserClient)
 	mockTemplateClient := new(MockTemplateClient)
@@ -271,7 +357,7 @@ func TestOrchestrationService_ProcessNotification_UserPreferencesError(t *testin
 	}
 
 	// Mock expectations - user service fails
-	mockUserClient.On("GetPreferences", "user-456").Return(nil, errors.New("user service unavailable"))
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(nil, errors.New("user service unavailable"))
 
 	response, err := service.ProcessNotification(req)
 
@@ -312,7 +398,7 @@ func TestOrchestrationService_ProcessNotification_EmailDisabled(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockUserClient.On("GetPreferences", "user-456").Return(userPrefs, nil)
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
 
 	response, err := service.ProcessNotification(req)
@@ -355,7 +441,7 @@ func TestOrchestrationService_ProcessNotification_PushDisabled(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockUserClient.On("GetPreferences", "user-456").Return(userPrefs, nil)
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
 
 	response, err := service.ProcessNotification(req)
@@ -398,7 +484,7 @@ func TestOrchestrationService_ProcessNotification_TemplateRenderError(t *testing
 	}
 
 	// Mock expectations
-	mockUserClient.On("GetPreferences", "user-456").Return(userPrefs, nil)
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
 	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(nil, errors.New("template not found"))
 
 	response, err := service.ProcessNotification(req)
@@ -455,7 +541,7 @@ func TestOrchestrationService_ProcessNotification_KafkaPublishError(t *testing.T
 	}
 
 	// Mock expectations
-	mockUserClient.On("GetPreferences", "user-456").Return(userPrefs, nil)
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
 	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
 	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(errors.New("kafka unavailable"))
@@ -570,6 +656,26 @@ func TestOrchestrationService_ValidateChannelPreferences(t *testing.T) {
 			prefs:            &models.UserPreferences{Email: true, Push: false},
 			expectedError:    "push notifications disabled",
 		},
+		{
+			name:             "email verified",
+			notificationType: models.NotificationEmail,
+			prefs:            &models.UserPreferences{Email: true, Channels: models.Channels{Email: models.EmailChannel{Enabled: true, Verified: true}}},
+			expectedError:    "",
+		},
+		{
+			name:             "email unverified",
+			notificationType: models.NotificationEmail,
+			prefs:            &models.UserPreferences{Email: true, Channels: models.Channels{Email: models.EmailChannel{Enabled: true}}},
+			expectedError:    models.UndeliverableUnverified,
+		},
+		{
+			name:             "push without active device",
+			notificationType: models.NotificationPush,
+			prefs: &models.UserPreferences{Push: true, Channels: models.Channels{Push: models.PushChannel{Enabled: true, Devices: []models.UserDevice{
+				{DeviceID: "old", Token: "old-token", Platform: "ios"},
+			}}}},
+			expectedError: models.UndeliverableNoDevice,
+		},
 	}
 
 	for _, tt := range tests {
@@ -620,6 +726,59 @@ func TestOrchestrationService_GetPriority(t *testing.T) {
 	}
 }
 
+func TestOrchestrationService_ProcessNotification_PriorityRouting(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority int
+		wire     string
+		routed   kafka.Priority
+	}{
+		{name: "normal", priority: 2, wire: "normal", routed: kafka.PriorityNormal},
+		{name: "high", priority: 3, wire: "high", routed: kafka.PriorityHigh},
+		{name: "urgent routes as critical", priority: 4, wire: "urgent", routed: kafka.PriorityCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-123",
+				NotificationType: models.NotificationEmail,
+				UserID:           "user-456",
+				TemplateCode:     "otp_email",
+				Priority:         tt.priority,
+			}
+			rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Code", Body: models.TemplateBody{Text: "123456"}}}
+
+			var routed kafka.Priority
+			var payload *models.KafkaNotificationPayload
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+			mockTemplateClient.On("RenderTemplate", "otp_email", "en", req.Variables).Return(rendered, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+			mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+				Run(func(args mock.Arguments) {
+					routed = kafka.PriorityFromContext(args.Get(0).(context.Context))
+					payload = args.Get(3).(*models.KafkaNotificationPayload)
+				}).
+				Return(nil)
+
+			_, err := service.ProcessNotification(req)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.routed, routed)
+			if assert.NotNil(t, payload) {
+				assert.Equal(t, tt.wire, payload.Priority)
+			}
+		})
+	}
+}
+
 func TestOrchestrationService_CreateKafkaPayload_Email(t *testing.T) {
 	mockUserClient := new(MockUserClient)
 	mockTemplateClient := new(MockTemplateClient)
@@ -722,3 +881,1738 @@ func TestOrchestrationService_CreateKafkaPayload_Push(t *testing.T) {
 	assert.Equal(t, "Push Notification", payload.Subject)
 	assert.Equal(t, "Hello", payload.Body)
 }
+
+func TestOrchestrationService_ProcessNotification_QuietHours(t *testing.T) {
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	now := time.Date(2025, 1, 15, 3, 0, 0, 0, nairobi)
+	quietEnd := time.Date(2025, 1, 15, 7, 0, 0, 0, nairobi)
+
+	tests := []struct {
+		name      string
+		category  string
+		priority  int
+		scheduled *time.Time
+	}{
+		{name: "transactional sends now", category: models.CategoryTransactional},
+		{name: "urgent sends now", category: models.CategoryUrgent},
+		{name: "urgent priority sends now", priority: 4},
+		{name: "marketing deferred", category: models.CategoryMarketing, scheduled: &quietEnd},
+		{name: "reminders deferred", category: models.CategoryReminders, scheduled: &quietEnd},
+		{name: "uncategorised deferred", scheduled: &quietEnd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			service := NewOrchestrationService(
+				mockUserClient,
+				mockTemplateClient,
+				mockKafkaManager,
+				mockRepo,
+			)
+			service.clock = clocktest.NewFakeClock(now)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-123",
+				NotificationType: models.NotificationEmail,
+				UserID:           "user-456",
+				TemplateCode:     "welcome_email",
+				Variables:        map[string]interface{}{"name": "John"},
+				Priority:         tt.priority,
+				Category:         tt.category,
+			}
+
+			userPrefs := &models.UserPreferences{
+				Email: true,
+				Channels: models.Channels{
+					Email: models.EmailChannel{
+						Enabled:  true,
+						Verified: true,
+						QuietHours: models.QuietHours{
+							Enabled:  true,
+							Start:    "22:00",
+							End:      "07:00",
+							Timezone: "Africa/Nairobi",
+						},
+					},
+				},
+			}
+
+			rendered := &models.RenderResponse{
+				TemplateID: "welcome_email",
+				Rendered: models.RenderedContent{
+					Subject: "Welcome",
+					Body:    models.TemplateBody{HTML: "<h1>Welcome John</h1>", Text: "Welcome John"},
+				},
+			}
+
+			var record *models.NotificationRecord
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
+			mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).
+				Run(func(args mock.Arguments) { record = args.Get(1).(*models.NotificationRecord) }).
+				Return(nil)
+			mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+			response, err := service.ProcessNotification(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.StatusPending, response.Status)
+			if assert.NotNil(t, record) {
+				if tt.scheduled == nil {
+					assert.Nil(t, record.ScheduledFor)
+				} else if assert.NotNil(t, record.ScheduledFor) {
+					assert.True(t, tt.scheduled.Equal(*record.ScheduledFor), "got %s", record.ScheduledFor)
+				}
+			}
+			if tt.scheduled == nil {
+				mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+			} else {
+				// Without a scheduler a deferred notification is held, not sent early
+				mockKafkaManager.AssertNotCalled(t, "PublishByType")
+			}
+		})
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_QuietHoursScheduled(t *testing.T) {
+	now := time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC)
+	quietEnd := time.Date(2025, 1, 16, 7, 0, 0, 0, time.UTC)
+
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	clk := clocktest.NewFakeClock(now)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.clock = clk
+	sched := scheduler.New(scheduler.Config{Publisher: mockKafkaManager, Clock: clk})
+	service.SetScheduler(sched)
+
+	prefs := &models.UserPreferences{
+		Email: true,
+		Channels: models.Channels{Email: models.EmailChannel{
+			QuietHours: models.QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "UTC"},
+		}},
+	}
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		Category:         models.CategoryMarketing,
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Sale", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	_, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+
+	clk.Set(quietEnd)
+	released, err := sched.ReleaseDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+}
+
+// MockOptOutUserClient is a MockUserClient that also reports opt-out status
+type MockOptOutUserClient struct {
+	MockUserClient
+}
+
+func (m *MockOptOutUserClient) GetOptOutStatus(ctx context.Context, userID string) (*models.OptOutStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OptOutStatus), args.Error(1)
+}
+
+func TestOrchestrationService_ProcessNotification_OptedOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *models.OptOutStatus
+	}{
+		{name: "global", status: &models.OptOutStatus{UserID: "user-456", Global: true}},
+		{name: "channel", status: &models.OptOutStatus{UserID: "user-456", Channels: map[string]bool{"email": true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockOptOutUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			service := NewOrchestrationService(
+				mockUserClient,
+				mockTemplateClient,
+				mockKafkaManager,
+				mockRepo,
+			)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-123",
+				NotificationType: models.NotificationEmail,
+				UserID:           "user-456",
+				TemplateCode:     "welcome_email",
+			}
+
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+			mockUserClient.On("GetOptOutStatus", mock.Anything, "user-456").Return(tt.status, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+
+			response, err := service.ProcessNotification(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.StatusFailed, response.Status)
+			assert.Contains(t, response.Error, "opted out of email")
+
+			mockUserClient.AssertExpectations(t)
+			mockTemplateClient.AssertNotCalled(t, "RenderTemplate")
+			mockKafkaManager.AssertNotCalled(t, "PublishByType")
+		})
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_OptedIn(t *testing.T) {
+	mockUserClient := new(MockOptOutUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(
+		mockUserClient,
+		mockTemplateClient,
+		mockKafkaManager,
+		mockRepo,
+	)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationPush,
+		UserID:           "user-456",
+		TemplateCode:     "push_notification",
+	}
+	status := &models.OptOutStatus{UserID: "user-456", Channels: map[string]bool{"email": true, "push": false}}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "Hello"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Push: true}, nil)
+	mockUserClient.On("GetOptOutStatus", mock.Anything, "user-456").Return(status, nil)
+	mockTemplateClient.On("RenderTemplate", "push_notification", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockKafkaManager.AssertExpectations(t)
+}
+
+func TestOrchestrationService_ProcessNotification_OptOutStatusError(t *testing.T) {
+	mockUserClient := new(MockOptOutUserClient)
+	mockRepo := new(MockNotificationRepository)
+	mockKafkaManager := new(MockKafkaManager)
+
+	service := NewOrchestrationService(mockUserClient, new(MockTemplateClient), mockKafkaManager, mockRepo)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockUserClient.On("GetOptOutStatus", mock.Anything, "user-456").Return(nil, errors.New("user service unavailable"))
+
+	response, err := service.ProcessNotification(req)
+
+	assert.Error(t, err)
+	assert.Nil(t, response)
+	assert.Contains(t, err.Error(), "failed to get opt-out status")
+	mockRepo.AssertNotCalled(t, "Create")
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+}
+
+func TestOrchestrationService_ProcessNotification_CategoryOptOut(t *testing.T) {
+	status := &models.OptOutStatus{
+		UserID:     "user-456",
+		Channels:   map[string]bool{"email": false},
+		Categories: map[string]bool{models.CategoryMarketing: true},
+	}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Hi", Body: models.TemplateBody{HTML: "<p>Hi</p>", Text: "Hi"}}}
+
+	tests := []struct {
+		name     string
+		category string
+		expected models.NotificationStatus
+	}{
+		{name: "marketing dropped", category: models.CategoryMarketing, expected: models.StatusFailed},
+		{name: "transactional delivered", category: models.CategoryTransactional, expected: models.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockOptOutUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-123",
+				NotificationType: models.NotificationEmail,
+				UserID:           "user-456",
+				TemplateCode:     "welcome_email",
+				Category:         tt.category,
+			}
+
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+			mockUserClient.On("GetOptOutStatus", mock.Anything, "user-456").Return(status, nil)
+			mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+			mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+			response, err := service.ProcessNotification(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, response.Status)
+			if tt.expected == models.StatusFailed {
+				assert.Contains(t, response.Error, "opted out of marketing")
+			}
+		})
+	}
+}
+
+// stubRateLimiter allows the first n calls
+type stubRateLimiter struct {
+	n     int
+	calls []string
+}
+
+func (l *stubRateLimiter) Allow(userID, channel string) bool {
+	l.calls = append(l.calls, userID+"/"+channel)
+	return len(l.calls) <= l.n
+}
+
+func TestOrchestrationService_ProcessNotification_RateLimited(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	limiter := &stubRateLimiter{n: 1}
+	service.SetRateLimiter(limiter)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Hi", Body: models.TemplateBody{HTML: "<p>Hi</p>", Text: "Hi"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	first, err := service.ProcessNotification(req)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, first.Status)
+
+	second, err := service.ProcessNotification(req)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, second.Status)
+	assert.Contains(t, second.Error, "rate limit exceeded")
+
+	assert.Equal(t, []string{"user-456/email", "user-456/email"}, limiter.calls)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+	mockRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestOrchestrationService_ProcessNotification_FrequencyLimited(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetFrequencyLimiter(frequency.New(nil, clk))
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		Category:         models.CategoryMarketing,
+	}
+	prefs := &models.UserPreferences{
+		Email:    true,
+		Channels: models.Channels{Email: models.EmailChannel{Enabled: true, Verified: true, Frequency: models.FrequencyHourly}},
+	}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Hi", Body: models.TemplateBody{HTML: "<p>Hi</p>", Text: "Hi"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	first, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, first.Status)
+
+	clk.Advance(30 * time.Minute)
+	second, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, second.Status)
+	assert.Contains(t, second.Error, "email frequency limit reached")
+
+	// Transactional notifications are not held to the frequency
+	transactional := *req
+	transactional.Category = models.CategoryTransactional
+	third, err := service.ProcessNotification(&transactional)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, third.Status)
+
+	clk.Advance(30 * time.Minute)
+	fourth, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, fourth.Status)
+
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 3)
+}
+
+func TestOrchestrationService_ProcessNotification_FrequencyReleasedWhenNotSent(t *testing.T) {
+	prefs := &models.UserPreferences{
+		Email:    true,
+		Channels: models.Channels{Email: models.EmailChannel{Enabled: true, Verified: true, Frequency: models.FrequencyHourly}},
+	}
+	newRequest := func(category, template string) *models.NotificationRequest {
+		return &models.NotificationRequest{
+			RequestID:        "req-" + template,
+			NotificationType: models.NotificationEmail,
+			UserID:           "user-456",
+			TemplateCode:     template,
+			Category:         category,
+		}
+	}
+	rendered := func(text string) *models.RenderResponse {
+		return &models.RenderResponse{Rendered: models.RenderedContent{Subject: text, Body: models.TemplateBody{Text: text}}}
+	}
+
+	t.Run("failed publish", func(t *testing.T) {
+		mockUserClient := new(MockUserClient)
+		mockTemplateClient := new(MockTemplateClient)
+		mockKafkaManager := new(MockKafkaManager)
+		mockRepo := new(MockNotificationRepository)
+
+		service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+		service.SetFrequencyLimiter(frequency.New(nil, clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))))
+
+		mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+		mockTemplateClient.On("RenderTemplate", "sale_email", "en", mock.Anything).Return(rendered("Sale"), nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("string"), models.StatusFailed, mock.Anything).Return(nil)
+		mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+			Return(errors.New("broker down")).Once()
+		mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+			Return(nil)
+
+		_, err := service.ProcessNotification(newRequest(models.CategoryMarketing, "sale_email"))
+		require.Error(t, err)
+
+		// The failed send did not use up the hour
+		retried, err := service.ProcessNotification(newRequest(models.CategoryMarketing, "sale_email"))
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPending, retried.Status)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		mockUserClient := new(MockUserClient)
+		mockTemplateClient := new(MockTemplateClient)
+		mockKafkaManager := new(MockKafkaManager)
+		mockRepo := new(MockNotificationRepository)
+
+		clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+		service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+		service.SetFrequencyLimiter(frequency.New(nil, clk))
+		service.SetDeduplicator(dedup.NewMemoryStore(clk), time.Minute)
+
+		mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+		mockTemplateClient.On("RenderTemplate", "receipt_email", "en", mock.Anything).Return(rendered("Receipt"), nil)
+		mockTemplateClient.On("RenderTemplate", "sale_email", "en", mock.Anything).Return(rendered("Sale"), nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+		mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+		// A transactional send bypasses the frequency but is remembered for
+		// deduplication, so the same content as marketing is a duplicate
+		_, err := service.ProcessNotification(newRequest(models.CategoryTransactional, "receipt_email"))
+		require.NoError(t, err)
+		duplicate, err := service.ProcessNotification(newRequest(models.CategoryMarketing, "receipt_email"))
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusFailed, duplicate.Status)
+		assert.Contains(t, duplicate.Error, "duplicate")
+
+		// The suppressed duplicate did not use up the hour
+		sale, err := service.ProcessNotification(newRequest(models.CategoryMarketing, "sale_email"))
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPending, sale.Status)
+		mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 2)
+	})
+}
+
+// stubThrottle hands out a fixed reservation
+type stubThrottle struct {
+	wait     time.Duration
+	ok       bool
+	channels []string
+}
+
+func (t *stubThrottle) Reserve(channel string) (time.Duration, bool) {
+	t.channels = append(t.channels, channel)
+	return t.wait, t.ok
+}
+
+func TestOrchestrationService_ProcessNotification_Throttled(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	deferredUntil := now.Add(30 * time.Second)
+
+	tests := []struct {
+		name       string
+		throttle   *stubThrottle
+		scheduled  *time.Time
+		expected   *time.Time
+		reserved   bool
+		held       bool
+		minElapsed time.Duration
+	}{
+		{name: "slot free", throttle: &stubThrottle{ok: true}, reserved: true},
+		{name: "waits for slot", throttle: &stubThrottle{wait: 20 * time.Millisecond, ok: true}, reserved: true, minElapsed: 20 * time.Millisecond},
+		{name: "deferred to slot", throttle: &stubThrottle{wait: 30 * time.Second}, expected: &deferredUntil, reserved: true, held: true},
+		{name: "already scheduled", throttle: &stubThrottle{}, scheduled: &later, expected: &later},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+			service.clock = clocktest.NewFakeClock(now)
+			service.SetThrottle(tt.throttle)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-123",
+				NotificationType: models.NotificationPush,
+				UserID:           "user-456",
+				TemplateCode:     "push_notification",
+				ScheduledFor:     tt.scheduled,
+			}
+			rendered := &models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "Hello"}}}
+
+			var record *models.NotificationRecord
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Push: true}, nil)
+			mockTemplateClient.On("RenderTemplate", "push_notification", "en", req.Variables).Return(rendered, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).
+				Run(func(args mock.Arguments) { record = args.Get(1).(*models.NotificationRecord) }).
+				Return(nil)
+			mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+			start := time.Now()
+			response, err := service.ProcessNotification(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.StatusPending, response.Status)
+			assert.GreaterOrEqual(t, time.Since(start), tt.minElapsed)
+			if tt.reserved {
+				assert.Equal(t, []string{"push"}, tt.throttle.channels)
+			} else {
+				assert.Empty(t, tt.throttle.channels)
+			}
+			if assert.NotNil(t, record) {
+				if tt.expected == nil {
+					assert.Nil(t, record.ScheduledFor)
+				} else if assert.NotNil(t, record.ScheduledFor) {
+					assert.True(t, tt.expected.Equal(*record.ScheduledFor), "got %s", record.ScheduledFor)
+				}
+			}
+			if tt.held {
+				mockKafkaManager.AssertNotCalled(t, "PublishByType")
+			} else {
+				mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+			}
+		})
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_ThrottledScheduled(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	clk := clocktest.NewFakeClock(now)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.clock = clk
+	service.SetThrottle(throttle.New(throttle.Config{
+		Rates:   map[string]float64{"push": 1},
+		MaxWait: time.Nanosecond,
+		Clock:   clk,
+	}))
+	sched := scheduler.New(scheduler.Config{Publisher: mockKafkaManager, Clock: clk})
+	service.SetScheduler(sched)
+
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "Hello"}}}
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Push: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "push_notification", "en", mock.Anything).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := service.ProcessNotification(&models.NotificationRequest{
+			RequestID:        fmt.Sprintf("req-%d", i),
+			NotificationType: models.NotificationPush,
+			UserID:           "user-456",
+			TemplateCode:     "push_notification",
+		})
+		require.NoError(t, err)
+	}
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+
+	// Each deferred send holds its own slot, so they are released one by one
+	for i := 2; i <= 3; i++ {
+		clk.Advance(time.Second)
+		released, err := sched.ReleaseDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, released)
+		mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", i)
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_Deduplicated(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetDeduplicator(dedup.NewMemoryStore(nil), time.Minute)
+
+	welcome := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+	reset := &models.NotificationRequest{
+		RequestID:        "req-2",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "password_reset",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", welcome.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockTemplateClient.On("RenderTemplate", "password_reset", "en", reset.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Reset", Body: models.TemplateBody{Text: "Code 1234"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	first, err := service.ProcessNotification(welcome)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, first.Status)
+
+	duplicate, err := service.ProcessNotification(welcome)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, duplicate.Status)
+	assert.Contains(t, duplicate.Error, "duplicate")
+
+	distinct, err := service.ProcessNotification(reset)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, distinct.Status)
+
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 2)
+}
+
+func TestOrchestrationService_ProcessNotification_DedupForgetsFailedPublish(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetDeduplicator(dedup.NewMemoryStore(nil), time.Minute)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationPush,
+		UserID:           "user-456",
+		TemplateCode:     "push_notification",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Push: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "push_notification", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "Hello"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("string"), models.StatusFailed, mock.Anything).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(errors.New("broker unavailable")).Once()
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(nil).Once()
+
+	_, err := service.ProcessNotification(req)
+	assert.Error(t, err)
+
+	retry, err := service.ProcessNotification(req)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, retry.Status)
+}
+
+func TestOrchestrationService_ProcessNotification_Scheduled(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		scheduled *time.Time
+		held      bool
+	}{
+		{name: "unscheduled publishes now", scheduled: nil},
+		{name: "past publishes now", scheduled: &yesterday},
+		{name: "future is held", scheduled: &tomorrow, held: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			clk := clocktest.NewFakeClock(now)
+			service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+			service.clock = clk
+			sched := scheduler.New(scheduler.Config{Publisher: mockKafkaManager, Clock: clk})
+			service.SetScheduler(sched)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-123",
+				NotificationType: models.NotificationEmail,
+				UserID:           "user-456",
+				TemplateCode:     "birthday_email",
+				ScheduledFor:     tt.scheduled,
+			}
+			rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Happy birthday", Body: models.TemplateBody{Text: "Hi"}}}
+
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+			mockTemplateClient.On("RenderTemplate", "birthday_email", "en", req.Variables).Return(rendered, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+			mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+			response, err := service.ProcessNotification(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.StatusPending, response.Status)
+			if tt.held {
+				mockKafkaManager.AssertNotCalled(t, "PublishByType")
+
+				clk.Set(tomorrow)
+				released, err := sched.ReleaseDue(context.Background())
+				assert.NoError(t, err)
+				assert.Equal(t, 1, released)
+			}
+			mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+		})
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_ScheduledKeepsPublishContext(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	clk := clocktest.NewFakeClock(now)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.clock = clk
+	sched := scheduler.New(scheduler.Config{Publisher: mockKafkaManager, Clock: clk})
+	service.SetScheduler(sched)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationPush,
+		UserID:           "user-456",
+		TemplateCode:     "security_alert",
+		Priority:         4,
+		ScheduledFor:     &tomorrow,
+		CorrelationID:    "corr-123",
+	}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "New sign-in"}}}
+
+	var publishCtx context.Context
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Push: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "security_alert", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Run(func(args mock.Arguments) { publishCtx = args.Get(0).(context.Context) }).
+		Return(nil)
+
+	_, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+
+	clk.Set(tomorrow)
+	released, err := sched.ReleaseDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	require.NotNil(t, publishCtx)
+	assert.Equal(t, kafka.PriorityCritical, kafka.PriorityFromContext(publishCtx))
+	assert.Equal(t, "corr-123", correlation.FromContext(publishCtx))
+}
+
+func TestOrchestrationService_ProcessNotification_ScheduledRecordsRelease(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	clk := clocktest.NewFakeClock(now)
+	store := repository.NewMemoryStatusStore(clk)
+	m := &fakeMetrics{}
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.clock = clk
+	service.SetStatusStore(store)
+	service.SetMetrics(m)
+	sched := scheduler.New(scheduler.Config{Publisher: mockKafkaManager, Clock: clk, OnRelease: service.RecordRelease})
+	service.SetScheduler(sched)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		ScheduledFor:     &tomorrow,
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hello"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(errors.New("broker unavailable")).Once()
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(nil)
+
+	response, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+
+	clk.Set(tomorrow)
+	_, err = sched.ReleaseDue(context.Background())
+	require.Error(t, err)
+	records, _ := store.GetByNotificationID(context.Background(), response.NotificationID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.DeliveryPending, records[0].Status, "a failed release is retried")
+
+	released, err := sched.ReleaseDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+
+	records, _ = store.GetByNotificationID(context.Background(), response.NotificationID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.DeliverySent, records[0].Status)
+	statuses := []models.DeliveryStatus{}
+	for _, transition := range records[0].History {
+		statuses = append(statuses, transition.Status)
+	}
+	assert.Equal(t, []models.DeliveryStatus{models.DeliveryPending, models.DeliveryFailed, models.DeliveryPending, models.DeliverySent}, statuses)
+	assert.Contains(t, m.recorded, "failed:email")
+	assert.Contains(t, m.recorded, "delivered:email")
+}
+
+func TestOrchestrationService_ProcessNotification_Localized(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	bundle := i18n.NewBundle()
+	bundle.AddCatalog("sw", i18n.Catalog{"greeting.title": {Other: "Karibu"}})
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetTranslator(bundle)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		Variables:        map[string]interface{}{"title": "greeting.title", "name": "Amina", "count": 2},
+	}
+	localized := map[string]interface{}{"title": "Karibu", "name": "Amina", "count": 2}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Karibu", Body: models.TemplateBody{Text: "Habari Amina"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true, Language: "sw"}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "sw", localized).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.MatchedBy(func(p *models.KafkaNotificationPayload) bool {
+		return p.Subject == "Karibu"
+	})).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockTemplateClient.AssertExpectations(t)
+	mockKafkaManager.AssertExpectations(t)
+}
+
+func TestOrchestrationService_ProcessNotification_LanguageFallback(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true, Language: "pt"}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "pt", req.Variables).Return(nil, errors.New("template not available in pt"))
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockTemplateClient.AssertExpectations(t)
+}
+
+func TestOrchestrationService_ProcessNotification_TracksDelivery(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	store := repository.NewMemoryStatusStore(nil)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetStatusStore(store)
+
+	email := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+	push := &models.NotificationRequest{
+		RequestID:        "req-2",
+		NotificationType: models.NotificationPush,
+		UserID:           "user-456",
+		TemplateCode:     "push_notification",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", email.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	sent, err := service.ProcessNotification(email)
+	assert.NoError(t, err)
+	suppressed, err := service.ProcessNotification(push)
+	assert.NoError(t, err)
+
+	records, err := store.GetByNotificationID(context.Background(), sent.NotificationID)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, models.DeliverySent, records[0].Status)
+		assert.Len(t, records[0].History, 2)
+	}
+
+	records, err = store.GetByNotificationID(context.Background(), suppressed.NotificationID)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, models.DeliverySuppressed, records[0].Status)
+		assert.Equal(t, suppressed.Error, records[0].Error)
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_RetriesDelivery(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	store := repository.NewMemoryStatusStore(nil)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetStatusStore(store)
+	service.SetDeliveryRetry(retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond}, false)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(errors.New("broker connection timeout")).Twice()
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(nil).Once()
+
+	response, err := service.ProcessNotification(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 3)
+
+	records, _ := store.GetByNotificationID(context.Background(), response.NotificationID)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, models.DeliverySent, records[0].Status)
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_DoesNotRetryAuthErrors(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetDeliveryRetry(retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond}, false)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("string"), models.StatusFailed, mock.AnythingOfType("string")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(fmt.Errorf("failed to publish message: %w", kafka.ErrAuth))
+
+	_, err := service.ProcessNotification(req)
+
+	assert.True(t, errors.Is(err, kafka.ErrAuth))
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+}
+
+func TestOrchestrationService_ProcessNotification_FallsBackAfterRetries(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	store := repository.NewMemoryStatusStore(nil)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetStatusStore(store)
+	service.SetDeliveryRetry(retry.Policy{MaxAttempts: 2, InitialDelay: time.Millisecond}, true)
+
+	seenAt := time.Now()
+	prefs := &models.UserPreferences{
+		Push:                true,
+		NotificationEnabled: true,
+		Channels: models.Channels{
+			Push: models.PushChannel{
+				Enabled: true,
+				Devices: []models.UserDevice{{Token: "token", Platform: "android", Active: true, LastSeen: &seenAt}},
+			},
+			Email: models.EmailChannel{Enabled: true, Verified: true},
+		},
+	}
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationPush,
+		UserID:           "user-456",
+		TemplateCode:     "push_notification",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+	mockTemplateClient.On("RenderTemplate", "push_notification", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "Hello"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Return(errors.New("service unavailable"))
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.MatchedBy(func(p *models.KafkaNotificationPayload) bool {
+		return p.NotificationType == "email"
+	})).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 3)
+
+	records, _ := store.GetByNotificationID(context.Background(), response.NotificationID)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "email", records[0].Channel)
+		assert.Equal(t, models.DeliverySent, records[0].Status)
+		assert.Equal(t, "push", records[1].Channel)
+		assert.Equal(t, models.DeliveryFailed, records[1].Status)
+		assert.Contains(t, records[1].Error, "gave up after 2 attempts")
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_IdempotencyKey(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetIdempotencyStore(NewMemoryIdempotencyStore(10*time.Minute, clk))
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		IdempotencyKey:   "order-789-shipped",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Shipped", Body: models.TemplateBody{Text: "On its way"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	first, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+
+	// A webhook retry within the TTL gets the original notification back
+	clk.Advance(5 * time.Minute)
+	retried, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	assert.Equal(t, first.NotificationID, retried.NotificationID)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+
+	// After the TTL the key is processed again
+	clk.Advance(10 * time.Minute)
+	later, err := service.ProcessNotification(req)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.NotificationID, later.NotificationID)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 2)
+}
+
+func TestOrchestrationService_ProcessNotification_IdempotencyKeyInProgress(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetIdempotencyStore(NewMemoryIdempotencyStore(10*time.Minute, nil))
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		IdempotencyKey:   "order-789-shipped",
+	}
+	duplicate := *req
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").
+		Run(func(mock.Arguments) {
+			close(started)
+			<-unblock
+		}).
+		Return(nil, errors.New("user service unavailable")).Once()
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Shipped", Body: models.TemplateBody{Text: "On its way"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := service.ProcessNotification(req)
+		firstErr <- err
+	}()
+	<-started
+
+	// A duplicate arriving while the first is processed is not sent again
+	_, err := service.ProcessNotification(&duplicate)
+	assert.ErrorIs(t, err, models.ErrRequestInProgress)
+
+	close(unblock)
+	require.Error(t, <-firstErr)
+
+	// The failed request released the key, so a retry goes ahead
+	retried, err := service.ProcessNotification(&duplicate)
+	require.NoError(t, err)
+	assert.NotEmpty(t, retried.NotificationID)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+}
+
+// drainingKafkaManager is a MockKafkaManager that can also be flushed and
+// closed, like kafka.Manager
+type drainingKafkaManager struct {
+	MockKafkaManager
+}
+
+func (m *drainingKafkaManager) Flush(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *drainingKafkaManager) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestOrchestrationService_Shutdown_DrainsInFlight(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	manager := new(drainingKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, manager, mockRepo)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	publishing := make(chan struct{})
+	release := make(chan struct{})
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	manager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Run(func(mock.Arguments) {
+			close(publishing)
+			<-release
+		}).Return(nil)
+	manager.On("Flush", mock.Anything).Return(nil)
+	manager.On("Close").Return(nil)
+
+	processed := make(chan error, 1)
+	go func() {
+		_, err := service.ProcessNotification(req)
+		processed <- err
+	}()
+	<-publishing
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- service.Shutdown(context.Background()) }()
+
+	// New work is refused while the in-flight notification finishes
+	require.Eventually(t, func() bool {
+		service.mu.Lock()
+		defer service.mu.Unlock()
+		return service.closing
+	}, time.Second, time.Millisecond)
+	_, err := service.ProcessNotification(req)
+	assert.True(t, errors.Is(err, models.ErrShuttingDown))
+	manager.AssertNotCalled(t, "Flush", mock.Anything)
+
+	close(release)
+	require.NoError(t, <-processed)
+	require.NoError(t, <-shutdown)
+
+	manager.AssertCalled(t, "Flush", mock.Anything)
+	manager.AssertCalled(t, "Close")
+	manager.AssertNumberOfCalls(t, "PublishByType", 1)
+}
+
+func TestOrchestrationService_Shutdown_DeadlineExceeded(t *testing.T) {
+	manager := new(drainingKafkaManager)
+	service := NewOrchestrationService(new(MockUserClient), new(MockTemplateClient), manager, new(MockNotificationRepository))
+	require.True(t, service.begin())
+
+	var flushCtxErr error
+	manager.On("Flush", mock.Anything).
+		Run(func(args mock.Arguments) { flushCtxErr = args.Get(0).(context.Context).Err() }).
+		Return(nil)
+	manager.On("Close").Return(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := service.Shutdown(ctx)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Contains(t, err.Error(), "1 notifications in flight")
+
+	// The producers are still flushed, on a context that has not expired,
+	// and closed
+	manager.AssertCalled(t, "Flush", mock.Anything)
+	manager.AssertCalled(t, "Close")
+	assert.NoError(t, flushCtxErr)
+}
+
+func TestOrchestrationService_Shutdown_JoinsFlushAndCloseErrors(t *testing.T) {
+	manager := new(drainingKafkaManager)
+	service := NewOrchestrationService(new(MockUserClient), new(MockTemplateClient), manager, new(MockNotificationRepository))
+
+	flushErr := errors.New("broker unreachable")
+	closeErr := errors.New("writer already closed")
+	manager.On("Flush", mock.Anything).Return(flushErr)
+	manager.On("Close").Return(closeErr)
+
+	err := service.Shutdown(context.Background())
+
+	assert.ErrorIs(t, err, flushErr)
+	assert.ErrorIs(t, err, closeErr)
+}
+
+// fakeMetrics records metric calls as "name:channel[:reason]" strings
+type fakeMetrics struct {
+	mu       sync.Mutex
+	recorded []string
+	publish  int
+}
+
+func (f *fakeMetrics) record(parts ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recorded = append(f.recorded, strings.Join(parts, ":"))
+}
+
+func (f *fakeMetrics) Processed(channel string)          { f.record("processed", channel) }
+func (f *fakeMetrics) Suppressed(channel, reason string) { f.record("suppressed", channel, reason) }
+func (f *fakeMetrics) Deferred(channel, reason string)   { f.record("deferred", channel, reason) }
+func (f *fakeMetrics) Delivered(channel string)          { f.record("delivered", channel) }
+func (f *fakeMetrics) Failed(channel string)             { f.record("failed", channel) }
+
+func (f *fakeMetrics) ObservePublish(channel string, elapsed time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.publish++
+}
+
+func TestOrchestrationService_ProcessNotification_MetricsDelivered(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	recorder := &fakeMetrics{}
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetMetrics(recorder)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	_, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"processed:email", "delivered:email"}, recorder.recorded)
+	assert.Equal(t, 1, recorder.publish)
+}
+
+func TestOrchestrationService_ProcessNotification_MetricsSuppressed(t *testing.T) {
+	mockUserClient := new(MockOptOutUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	recorder := &fakeMetrics{}
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetMetrics(recorder)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockUserClient.On("GetOptOutStatus", mock.Anything, "user-456").Return(&models.OptOutStatus{UserID: "user-456", Global: true}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, response.Status)
+	assert.Equal(t, []string{"processed:email", "suppressed:email:opt_out"}, recorder.recorded)
+	assert.Zero(t, recorder.publish)
+}
+
+func TestOrchestrationService_ProcessNotification_MetricsDeferredForQuietHours(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	recorder := &fakeMetrics{}
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetMetrics(recorder)
+	service.clock = clocktest.NewFakeClock(time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC))
+
+	prefs := &models.UserPreferences{
+		Email: true,
+		Channels: models.Channels{Email: models.EmailChannel{
+			QuietHours: models.QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "UTC"},
+		}},
+	}
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		Category:         models.CategoryMarketing,
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Sale", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	_, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Contains(t, recorder.recorded, "deferred:email:quiet_hours")
+}
+
+func TestOrchestrationService_ProcessNotification_PropagatesCorrelationID(t *testing.T) {
+	tests := []struct {
+		name          string
+		correlationID string
+	}{
+		{name: "given", correlationID: "corr-123"},
+		{name: "generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserClient := new(MockUserClient)
+			mockTemplateClient := new(MockTemplateClient)
+			mockKafkaManager := new(MockKafkaManager)
+			mockRepo := new(MockNotificationRepository)
+
+			service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+			req := &models.NotificationRequest{
+				RequestID:        "req-1",
+				NotificationType: models.NotificationEmail,
+				UserID:           "user-456",
+				TemplateCode:     "welcome_email",
+				CorrelationID:    tt.correlationID,
+			}
+
+			var userCtx, publishCtx context.Context
+			var payload *models.KafkaNotificationPayload
+			mockUserClient.On("GetPreferences", mock.Anything, "user-456").
+				Run(func(args mock.Arguments) { userCtx = args.Get(0).(context.Context) }).
+				Return(&models.UserPreferences{Email: true}, nil)
+			mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+				Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+			mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+				Run(func(args mock.Arguments) {
+					publishCtx = args.Get(0).(context.Context)
+					payload = args.Get(3).(*models.KafkaNotificationPayload)
+				}).
+				Return(nil)
+
+			_, err := service.ProcessNotification(req)
+
+			require.NoError(t, err)
+			require.NotEmpty(t, req.CorrelationID)
+			if tt.correlationID != "" {
+				assert.Equal(t, tt.correlationID, req.CorrelationID)
+			}
+			assert.Equal(t, req.CorrelationID, correlation.FromContext(userCtx))
+			assert.Equal(t, req.CorrelationID, correlation.FromContext(publishCtx))
+			assert.Equal(t, req.CorrelationID, payload.CorrelationID)
+		})
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_DeletedUserSuppressed(t *testing.T) {
+	ctx := context.Background()
+	userService := mocks.NewUserServiceMock()
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	recorder := &fakeMetrics{}
+	service := NewOrchestrationService(userService, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetMetrics(recorder)
+	require.NoError(t, userService.DeleteUserData(ctx, "usr_123"))
+
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+
+	response, err := service.ProcessNotification(&models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "usr_123",
+		TemplateCode:     "welcome_email",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, response.Status)
+	assert.Contains(t, response.Error, "user usr_123 not found")
+	assert.Equal(t, []string{"processed:email", "suppressed:email:user_not_found"}, recorder.recorded)
+	mockTemplateClient.AssertNotCalled(t, "RenderTemplate")
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+}
+
+func TestOrchestrationService_ProcessNotification_MockUserServicePush(t *testing.T) {
+	// The development mock with the real clock, as USE_MOCK_SERVICES runs it
+	userService := mocks.NewUserServiceMock()
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+	service := NewOrchestrationService(userService, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationPush,
+		UserID:           "usr_123",
+		TemplateCode:     "security_alert",
+	}
+
+	var payload *models.KafkaNotificationPayload
+	mockTemplateClient.On("RenderTemplate", "security_alert", mock.Anything, req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Body: models.TemplateBody{Text: "New sign-in"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "push", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).
+		Run(func(args mock.Arguments) { payload = args.Get(3).(*models.KafkaNotificationPayload) }).
+		Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status, response.Error)
+	mockKafkaManager.AssertNumberOfCalls(t, "PublishByType", 1)
+	require.NotNil(t, payload)
+	// Only the phone's token: the iPad's lapsed
+	assert.Equal(t, map[string][]string{"android": {"mock_push_token_usr_123"}}, payload.DeviceTokens)
+}
+
+func TestOrchestrationService_ProcessNotification_InvalidRequest(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	_, err := service.ProcessNotification(&models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		TemplateCode:     "welcome_email",
+		Category:         "promo",
+	})
+
+	var validationErr *models.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, err.Error(), "user_id: is required")
+	assert.Contains(t, err.Error(), `category: unknown category "promo"`)
+	mockUserClient.AssertNotCalled(t, "GetPreferences")
+	mockRepo.AssertNotCalled(t, "Create")
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+}
+
+func TestOrchestrationService_ProcessNotification_InvalidAttributes(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	_, err := service.ProcessNotification(&models.NotificationRequest{
+		RequestID:        "req-1",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		Attributes:       map[string]string{"campaign id": "spring-sale"},
+	})
+
+	var validationErr *models.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.True(t, errors.Is(err, kafka.ErrMetadataInvalid))
+	assert.Contains(t, err.Error(), "attributes:")
+	mockUserClient.AssertNotCalled(t, "GetPreferences")
+	mockKafkaManager.AssertNotCalled(t, "PublishByType")
+}
+
+func TestOrchestrationService_ProcessNotification_Attributes(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+
+	attributes := map[string]string{"campaign_id": "spring-sale", "variant": "b"}
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+		Attributes:       attributes,
+	}
+	userPrefs := &models.UserPreferences{Email: true, Channels: models.Channels{Email: models.EmailChannel{Enabled: true, Verified: true}}}
+	rendered := &models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{HTML: "<p>Hi</p>"}}}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(userPrefs, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).Return(rendered, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"),
+		mock.MatchedBy(func(payload *models.KafkaNotificationPayload) bool {
+			return assert.Equal(t, attributes, payload.KafkaMetadata())
+		})).Return(nil)
+
+	response, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, response.Status)
+	mockKafkaManager.AssertExpectations(t)
+}
+
+type stubWebhookSender struct {
+	err  error
+	sent []*models.KafkaNotificationPayload
+}
+
+func (s *stubWebhookSender) Send(ctx context.Context, channel models.WebhookChannel, payload *models.KafkaNotificationPayload) error {
+	s.sent = append(s.sent, payload)
+	return s.err
+}
+
+func TestOrchestrationService_ProcessNotification_DeliversToWebhook(t *testing.T) {
+	for _, sendErr := range []error{nil, errors.New("webhook returned status 500")} {
+		mockUserClient := new(MockUserClient)
+		mockTemplateClient := new(MockTemplateClient)
+		mockKafkaManager := new(MockKafkaManager)
+		mockRepo := new(MockNotificationRepository)
+
+		sender := &stubWebhookSender{err: sendErr}
+		store := repository.NewMemoryStatusStore(nil)
+		service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+		service.SetWebhookSender(sender)
+		service.SetStatusStore(store)
+
+		req := &models.NotificationRequest{
+			RequestID:        "req-123",
+			NotificationType: models.NotificationEmail,
+			UserID:           "user-456",
+			TemplateCode:     "welcome_email",
+		}
+		prefs := &models.UserPreferences{Email: true}
+		prefs.Channels.Webhook = models.WebhookChannel{Enabled: true, URL: "https://example.com/hook", Secret: "s3cret"}
+
+		mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(prefs, nil)
+		mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+			Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+		mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+		response, err := service.ProcessNotification(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPending, response.Status)
+		require.Len(t, sender.sent, 1)
+		assert.Equal(t, response.NotificationID, sender.sent[0].NotificationID)
+
+		records, err := store.GetByNotificationID(context.Background(), response.NotificationID)
+		require.NoError(t, err)
+		var record models.DeliveryRecord
+		for _, r := range records {
+			if r.Channel == models.ChannelWebhook {
+				record = r
+			}
+		}
+		if sendErr == nil {
+			assert.Equal(t, models.DeliverySent, record.Status)
+		} else {
+			assert.Equal(t, models.DeliveryFailed, record.Status)
+			assert.Equal(t, sendErr.Error(), record.Error)
+		}
+	}
+}
+
+func TestOrchestrationService_ProcessNotification_WebhookDisabled(t *testing.T) {
+	mockUserClient := new(MockUserClient)
+	mockTemplateClient := new(MockTemplateClient)
+	mockKafkaManager := new(MockKafkaManager)
+	mockRepo := new(MockNotificationRepository)
+
+	sender := &stubWebhookSender{}
+	service := NewOrchestrationService(mockUserClient, mockTemplateClient, mockKafkaManager, mockRepo)
+	service.SetWebhookSender(sender)
+
+	req := &models.NotificationRequest{
+		RequestID:        "req-123",
+		NotificationType: models.NotificationEmail,
+		UserID:           "user-456",
+		TemplateCode:     "welcome_email",
+	}
+
+	mockUserClient.On("GetPreferences", mock.Anything, "user-456").Return(&models.UserPreferences{Email: true}, nil)
+	mockTemplateClient.On("RenderTemplate", "welcome_email", "en", req.Variables).
+		Return(&models.RenderResponse{Rendered: models.RenderedContent{Subject: "Welcome", Body: models.TemplateBody{Text: "Hi"}}}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.NotificationRecord")).Return(nil)
+	mockKafkaManager.On("PublishByType", mock.Anything, "email", mock.AnythingOfType("string"), mock.AnythingOfType("*models.KafkaNotificationPayload")).Return(nil)
+
+	_, err := service.ProcessNotification(req)
+
+	require.NoError(t, err)
+	assert.Empty(t, sender.sent)
+}
@@ -0,0 +1,154 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/i18n"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// DefaultLanguage is used when a template has no version in the requested
+// language
+const DefaultLanguage = i18n.DefaultLanguage
+
+// ErrTemplateNotFound is returned when no template matches the ID and
+// channel in the requested language or DefaultLanguage
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Source is one channel's version of a template in one language. Bodies use
+// text/template syntax, e.g. "Hi {{.name}}". Email bodies are HTML and
+// escape substituted values; every other channel is plain text.
+type Source struct {
+	ID       string
+	Channel  string
+	Language string
+	Subject  string
+	Body     string
+}
+
+// Rendered is a template with the data substituted. Language is the
+// language actually used, which differs from the requested one after a
+// fallback.
+type Rendered struct {
+	Subject  string
+	Body     string
+	Language string
+}
+
+// compiled is a parsed Source. body wraps either a text/template or an
+// html/template template.
+type compiled struct {
+	subject *texttemplate.Template
+	body    func(w *bytes.Buffer, data interface{}) error
+}
+
+// Renderer renders registered templates. It is safe for concurrent use.
+type Renderer struct {
+	mu sync.RWMutex
+	// templates holds each template ID and channel's versions by language
+	templates map[string]map[string]compiled
+}
+
+// NewRenderer returns a renderer with no templates
+func NewRenderer() *Renderer {
+	return &Renderer{templates: make(map[string]map[string]compiled)}
+}
+
+// Add parses src and registers it, replacing any template with the same ID,
+// channel and language. An empty language registers it as DefaultLanguage.
+func (r *Renderer) Add(src Source) error {
+	if src.ID == "" || src.Channel == "" {
+		return fmt.Errorf("template ID and channel are required")
+	}
+	if src.Language == "" {
+		src.Language = DefaultLanguage
+	}
+	language := strings.ToLower(src.Language)
+	name := key(src.ID, src.Channel) + "/" + language
+
+	var c compiled
+	if src.Subject != "" {
+		subject, err := texttemplate.New(name + ".subject").Option("missingkey=error").Parse(src.Subject)
+		if err != nil {
+			return fmt.Errorf("parse template %s subject: %w", name, err)
+		}
+		c.subject = subject
+	}
+
+	if src.Channel == models.ChannelEmail {
+		body, err := htmltemplate.New(name).Option("missingkey=error").Parse(src.Body)
+		if err != nil {
+			return fmt.Errorf("parse template %s: %w", name, err)
+		}
+		c.body = func(w *bytes.Buffer, data interface{}) error { return body.Execute(w, data) }
+	} else {
+		body, err := texttemplate.New(name).Option("missingkey=error").Parse(src.Body)
+		if err != nil {
+			return fmt.Errorf("parse template %s: %w", name, err)
+		}
+		c.body = func(w *bytes.Buffer, data interface{}) error { return body.Execute(w, data) }
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.templates[key(src.ID, src.Channel)]
+	if !ok {
+		versions = make(map[string]compiled)
+		r.templates[key(src.ID, src.Channel)] = versions
+	}
+	versions[language] = c
+	return nil
+}
+
+// Render renders the channel's version of templateID in language with data
+// substituted. The version is chosen with i18n.Negotiate: the exact
+// language, then its base language (sw for sw-KE), then DefaultLanguage. A
+// variable missing from data is an error rather than a blank.
+func (r *Renderer) Render(templateID, channel, language string, data map[string]interface{}) (*Rendered, error) {
+	c, used, ok := r.lookup(templateID, channel, language)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s for %s", ErrTemplateNotFound, templateID, channel)
+	}
+
+	rendered := &Rendered{Language: used}
+	var buf bytes.Buffer
+	if c.subject != nil {
+		if err := c.subject.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("render template %s subject: %w", templateID, err)
+		}
+		rendered.Subject = buf.String()
+		buf.Reset()
+	}
+	if err := c.body(&buf, data); err != nil {
+		return nil, fmt.Errorf("render template %s: %w", templateID, err)
+	}
+	rendered.Body = buf.String()
+	return rendered, nil
+}
+
+// lookup finds the best language match for templateID on channel
+func (r *Renderer) lookup(templateID, channel, language string) (compiled, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.templates[key(templateID, channel)]
+	available := make([]string, 0, len(versions))
+	for lang := range versions {
+		available = append(available, lang)
+	}
+
+	used := i18n.Negotiate(language, available)
+	c, ok := versions[used]
+	return c, used, ok
+}
+
+func key(templateID, channel string) string {
+	return templateID + "/" + channel
+}
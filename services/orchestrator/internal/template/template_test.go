@@ -0,0 +1,94 @@
+package template
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRenderer(t *testing.T) *Renderer {
+	r := NewRenderer()
+	sources := []Source{
+		{ID: "welcome", Channel: models.ChannelEmail, Subject: "Welcome, {{.name}}", Body: "<p>Hi {{.name}}, welcome to {{.app}}</p>"},
+		{ID: "welcome", Channel: models.ChannelSMS, Body: "Hi {{.name}}, welcome to {{.app}}"},
+		{ID: "welcome", Channel: models.ChannelSMS, Language: "sw", Body: "Habari {{.name}}, karibu {{.app}}"},
+	}
+	for _, src := range sources {
+		require.NoError(t, r.Add(src))
+	}
+	return r
+}
+
+func TestRenderer_SubstitutesPerChannel(t *testing.T) {
+	r := newTestRenderer(t)
+	data := map[string]interface{}{"name": "Tom & Jerry", "app": "Acme"}
+
+	email, err := r.Render("welcome", models.ChannelEmail, "en", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome, Tom & Jerry", email.Subject)
+	assert.Equal(t, "<p>Hi Tom &amp; Jerry, welcome to Acme</p>", email.Body)
+
+	sms, err := r.Render("welcome", models.ChannelSMS, "en", data)
+	require.NoError(t, err)
+	assert.Empty(t, sms.Subject)
+	assert.Equal(t, "Hi Tom & Jerry, welcome to Acme", sms.Body)
+}
+
+func TestRenderer_MissingVariableErrors(t *testing.T) {
+	r := newTestRenderer(t)
+
+	_, err := r.Render("welcome", models.ChannelSMS, "en", map[string]interface{}{"name": "Amina"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app")
+
+	_, err = r.Render("welcome", models.ChannelEmail, "en", map[string]interface{}{"app": "Acme"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subject")
+}
+
+func TestRenderer_LanguageFallback(t *testing.T) {
+	r := newTestRenderer(t)
+	data := map[string]interface{}{"name": "Amina", "app": "Acme"}
+
+	tests := []struct {
+		language string
+		expected string
+		used     string
+	}{
+		{language: "sw", expected: "Habari Amina, karibu Acme", used: "sw"},
+		{language: "sw-KE", expected: "Habari Amina, karibu Acme", used: "sw"},
+		{language: "SW_tz", expected: "Habari Amina, karibu Acme", used: "sw"},
+		{language: "fr", expected: "Hi Amina, welcome to Acme", used: "en"},
+		{language: "", expected: "Hi Amina, welcome to Acme", used: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			rendered, err := r.Render("welcome", models.ChannelSMS, tt.language, data)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered.Body)
+			assert.Equal(t, tt.used, rendered.Language)
+		})
+	}
+}
+
+func TestRenderer_NotFound(t *testing.T) {
+	r := newTestRenderer(t)
+
+	_, err := r.Render("welcome", models.ChannelPush, "en", nil)
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+
+	_, err = r.Render("reset_password", models.ChannelEmail, "en", nil)
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+}
+
+func TestRenderer_AddRejectsInvalidTemplates(t *testing.T) {
+	r := NewRenderer()
+
+	assert.Error(t, r.Add(Source{Channel: models.ChannelSMS, Body: "Hi"}))
+	assert.Error(t, r.Add(Source{ID: "broken", Channel: models.ChannelSMS, Body: "Hi {{.name"}))
+}
@@ -0,0 +1,83 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+)
+
+// DefaultMaxWait is the longest Reserve lets a caller wait when Config
+// leaves MaxWait unset
+const DefaultMaxWait = time.Second
+
+// Config configures a Throttle
+type Config struct {
+	// Rates is the aggregate sends per second allowed on each channel.
+	// Channels without a positive rate are not throttled.
+	Rates map[string]float64
+
+	// MaxWait is the longest Reserve expects a caller to wait; beyond it
+	// the caller should defer to the reserved slot instead. Defaults to
+	// DefaultMaxWait.
+	MaxWait time.Duration
+
+	Clock clock.Clock // defaults to the real clock
+}
+
+// Throttle paces sends on each channel across all users, so provider
+// account limits are not exceeded during mass sends. It is safe for
+// concurrent use.
+type Throttle struct {
+	intervals map[string]time.Duration
+	maxWait   time.Duration
+	clock     clock.Clock
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func New(cfg Config) *Throttle {
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = DefaultMaxWait
+	}
+
+	intervals := make(map[string]time.Duration, len(cfg.Rates))
+	for channel, rate := range cfg.Rates {
+		if rate > 0 {
+			intervals[channel] = time.Duration(float64(time.Second) / rate)
+		}
+	}
+
+	return &Throttle{
+		intervals: intervals,
+		maxWait:   cfg.MaxWait,
+		clock:     clock.OrReal(cfg.Clock),
+		next:      make(map[string]time.Time),
+	}
+}
+
+// Reserve claims the next send slot on channel and returns how long to wait
+// before sending. Slots are spaced evenly at the channel's rate. When the
+// wait would exceed MaxWait ok is false and the caller should defer the send
+// by wait rather than block; the slot is reserved either way, so deferred
+// sends stay spaced at the channel's rate too.
+func (t *Throttle) Reserve(channel string) (wait time.Duration, ok bool) {
+	interval, throttled := t.intervals[channel]
+	if !throttled {
+		return 0, true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	slot := t.next[channel]
+	if slot.Before(now) {
+		slot = now
+	}
+
+	wait = slot.Sub(now)
+	t.next[channel] = slot.Add(interval)
+	return wait, wait <= t.maxWait
+}
@@ -0,0 +1,101 @@
+package throttle
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestThrottle(cfg Config) (*Throttle, *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	cfg.Clock = clk
+	return New(cfg), clk
+}
+
+func TestThrottle_SpacesReservations(t *testing.T) {
+	throttle, _ := newTestThrottle(Config{Rates: map[string]float64{"push": 10}})
+
+	for i := 0; i < 5; i++ {
+		wait, ok := throttle.Reserve("push")
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(i)*100*time.Millisecond, wait)
+	}
+}
+
+func TestThrottle_FreesSlotsAsTimePasses(t *testing.T) {
+	throttle, clk := newTestThrottle(Config{Rates: map[string]float64{"sms": 2}})
+
+	wait, _ := throttle.Reserve("sms")
+	assert.Zero(t, wait)
+	wait, _ = throttle.Reserve("sms")
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Idle time is not banked as a burst
+	clk.Advance(time.Minute)
+	wait, _ = throttle.Reserve("sms")
+	assert.Zero(t, wait)
+	wait, _ = throttle.Reserve("sms")
+	assert.Equal(t, 500*time.Millisecond, wait)
+}
+
+func TestThrottle_DefersBeyondMaxWait(t *testing.T) {
+	throttle, clk := newTestThrottle(Config{Rates: map[string]float64{"email": 1}, MaxWait: 2 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		_, ok := throttle.Reserve("email")
+		assert.True(t, ok)
+	}
+
+	wait, ok := throttle.Reserve("email")
+	assert.False(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	// Deferred calls still take their slot, so they do not all land on it
+	wait, ok = throttle.Reserve("email")
+	assert.False(t, ok)
+	assert.Equal(t, 4*time.Second, wait)
+
+	clk.Advance(3 * time.Second)
+	wait, ok = throttle.Reserve("email")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+}
+
+func TestThrottle_ChannelsAreIndependent(t *testing.T) {
+	throttle, _ := newTestThrottle(Config{Rates: map[string]float64{"push": 1, "email": 1}})
+
+	throttle.Reserve("push")
+	wait, _ := throttle.Reserve("email")
+	assert.Zero(t, wait)
+
+	// Channels without a rate are never throttled
+	for i := 0; i < 100; i++ {
+		wait, ok := throttle.Reserve("webhook")
+		assert.True(t, ok)
+		assert.Zero(t, wait)
+	}
+}
+
+func TestThrottle_ConcurrentReservationsGetDistinctSlots(t *testing.T) {
+	throttle, _ := newTestThrottle(Config{Rates: map[string]float64{"push": 100}, MaxWait: time.Minute})
+
+	var mu sync.Mutex
+	waits := make(map[time.Duration]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, _ := throttle.Reserve("push")
+			mu.Lock()
+			waits[wait] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, waits, 50)
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+)
+
+// Sender posts notifications to webhook channels. It is safe for
+// concurrent use.
+type Sender struct {
+	httpClient *http.Client
+	clock      clock.Clock
+}
+
+// NewSender returns a Sender using httpClient, http.DefaultClient when nil.
+// A nil clk uses the real clock.
+func NewSender(httpClient *http.Client, clk clock.Clock) *Sender {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Sender{httpClient: httpClient, clock: clock.OrReal(clk)}
+}
+
+// Send renders n for channel with Build, signing it as of now, and posts it
+// to channel.URL. Any non-2xx response is an error.
+func (s *Sender) Send(ctx context.Context, channel models.WebhookChannel, n *models.KafkaNotificationPayload) error {
+	payload, err := Build(channel, n, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.URL, bytes.NewReader(payload.Body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	for key, value := range payload.Headers {
+		req.Header.Set(key, value)
+	}
+	if id := correlation.FromContext(ctx); id != "" {
+		req.Header.Set(correlation.Header, id)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
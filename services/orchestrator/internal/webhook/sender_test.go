@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_SendSigned(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	clk := clocktest.NewFakeClock(signedAt)
+	sender := NewSender(server.Client(), clk)
+	channel := models.WebhookChannel{Enabled: true, URL: server.URL, Secret: "s3cret"}
+	ctx := correlation.NewContext(context.Background(), "corr-1")
+
+	require.NoError(t, sender.Send(ctx, channel, testNotification()))
+
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "corr-1", received.Header.Get(correlation.Header))
+	assert.NoError(t, Verify(body, "s3cret", received.Header, signedAt, 0))
+}
+
+func TestSender_SendNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.Client(), nil)
+	err := sender.Send(context.Background(), models.WebhookChannel{URL: server.URL}, testNotification())
+
+	assert.EqualError(t, err, "webhook returned status 500")
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderTimestamp carries the Unix time, in seconds, at which a generic
+// webhook was signed. It is part of the signed content, so a captured
+// request cannot be replayed once the timestamp falls outside the
+// receiver's tolerance.
+const HeaderTimestamp = "X-Signature-Timestamp"
+
+// DefaultTolerance is how far a signature timestamp may be from the
+// receiver's clock before Verify rejects it
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrMissingSignature means the signature or timestamp header is absent
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	// ErrInvalidSignature means the signature does not match the body and
+	// timestamp
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrSignatureExpired means the timestamp is outside the tolerance
+	ErrSignatureExpired = errors.New("webhook: signature timestamp outside tolerance")
+)
+
+// Sign returns the HeaderSignature value for body: the hex HMAC-SHA256 of
+// body under secret, prefixed with "sha256="
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedHeaders returns the HeaderTimestamp and HeaderSignature values for
+// a delivery of body at the given time. The signature covers
+// "<timestamp>.<body>".
+func SignedHeaders(body []byte, secret string, at time.Time) map[string]string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return map[string]string{
+		HeaderTimestamp: timestamp,
+		HeaderSignature: Sign(signedContent(timestamp, body), secret),
+	}
+}
+
+// Verify checks the signature headers of a received webhook against body.
+// The timestamp must be within tolerance of now, DefaultTolerance when
+// zero.
+func Verify(body []byte, secret string, header http.Header, now time.Time, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	signature, timestamp := header.Get(HeaderSignature), header.Get(HeaderTimestamp)
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(signedContent(timestamp, body), secret)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+func signedContent(timestamp string, body []byte) []byte {
+	content := make([]byte, 0, len(timestamp)+1+len(body))
+	content = append(content, timestamp...)
+	content = append(content, '.')
+	return append(content, body...)
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var signedAt = time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+func signedHeader(body []byte, secret string, at time.Time) http.Header {
+	header := http.Header{}
+	for key, value := range SignedHeaders(body, secret, at) {
+		header.Set(key, value)
+	}
+	return header
+}
+
+func TestSign(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(`{"a":1}`))
+
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), Sign([]byte(`{"a":1}`), "s3cret"))
+	assert.NotEqual(t, Sign([]byte(`{"a":1}`), "s3cret"), Sign([]byte(`{"a":1}`), "other"))
+}
+
+func TestSignedHeaders_CoverTimestamp(t *testing.T) {
+	headers := SignedHeaders([]byte(`{"a":1}`), "s3cret", signedAt)
+
+	assert.Equal(t, "1740821400", headers[HeaderTimestamp])
+	assert.Equal(t, Sign([]byte(`1740821400.{"a":1}`), "s3cret"), headers[HeaderSignature])
+}
+
+func TestVerify_Valid(t *testing.T) {
+	body := []byte(`{"notification_id":"notif-123"}`)
+	header := signedHeader(body, "s3cret", signedAt)
+
+	assert.NoError(t, Verify(body, "s3cret", header, signedAt, 0))
+	assert.NoError(t, Verify(body, "s3cret", header, signedAt.Add(4*time.Minute), 0))
+}
+
+func TestVerify_TamperedBody(t *testing.T) {
+	header := signedHeader([]byte(`{"amount":10}`), "s3cret", signedAt)
+
+	assert.ErrorIs(t, Verify([]byte(`{"amount":1000}`), "s3cret", header, signedAt, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify([]byte(`{"amount":10}`), "wrong", header, signedAt, 0), ErrInvalidSignature)
+}
+
+func TestVerify_TamperedTimestamp(t *testing.T) {
+	body := []byte(`{"amount":10}`)
+	header := signedHeader(body, "s3cret", signedAt)
+	header.Set(HeaderTimestamp, "1740825000")
+
+	assert.ErrorIs(t, Verify(body, "s3cret", header, time.Unix(1740825000, 0), 0), ErrInvalidSignature)
+}
+
+func TestVerify_ExpiredTimestamp(t *testing.T) {
+	body := []byte(`{"amount":10}`)
+	header := signedHeader(body, "s3cret", signedAt)
+
+	assert.ErrorIs(t, Verify(body, "s3cret", header, signedAt.Add(6*time.Minute), 0), ErrSignatureExpired)
+	assert.ErrorIs(t, Verify(body, "s3cret", header, signedAt.Add(-6*time.Minute), 0), ErrSignatureExpired)
+	assert.NoError(t, Verify(body, "s3cret", header, signedAt.Add(6*time.Minute), 10*time.Minute))
+}
+
+func TestVerify_MissingHeaders(t *testing.T) {
+	body := []byte(`{}`)
+	header := signedHeader(body, "s3cret", signedAt)
+	header.Del(HeaderTimestamp)
+
+	assert.ErrorIs(t, Verify(body, "s3cret", header, signedAt, 0), ErrMissingSignature)
+	assert.ErrorIs(t, Verify(body, "s3cret", http.Header{}, signedAt, 0), ErrMissingSignature)
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+)
+
+// HeaderSignature carries the HMAC-SHA256 of a generic webhook's timestamp
+// and body, hex encoded and prefixed with "sha256=". See SignedHeaders.
+const HeaderSignature = "X-Signature"
+
+// Slack rejects header blocks longer than this many characters
+const slackHeaderMaxChars = 150
+
+// Payload is a rendered webhook request: the JSON body and the headers to
+// send with it
+type Payload struct {
+	Body    []byte
+	Headers map[string]string
+}
+
+// Build renders n in the format selected by channel. Slack payloads are
+// unsigned because Slack authenticates incoming webhooks by URL; generic
+// payloads are signed with channel.Secret as of at when one is set.
+func Build(channel models.WebhookChannel, n *models.KafkaNotificationPayload, at time.Time) (*Payload, error) {
+	switch channel.Format {
+	case models.WebhookFormatSlack:
+		body, err := BuildSlackPayload(n)
+		if err != nil {
+			return nil, err
+		}
+		return &Payload{Body: body, Headers: map[string]string{"Content-Type": "application/json"}}, nil
+	case "", models.WebhookFormatGenericJSON:
+		body, err := BuildGenericPayload(n)
+		if err != nil {
+			return nil, err
+		}
+		headers := map[string]string{"Content-Type": "application/json"}
+		if channel.Secret != "" {
+			for key, value := range SignedHeaders(body, channel.Secret, at) {
+				headers[key] = value
+			}
+		}
+		return &Payload{Body: body, Headers: headers}, nil
+	default:
+		return nil, fmt.Errorf("unsupported webhook format: %s", channel.Format)
+	}
+}
+
+// Envelope is the flat JSON body sent to generic webhooks
+type Envelope struct {
+	NotificationID   string                 `json:"notification_id"`
+	NotificationType string                 `json:"notification_type"`
+	UserID           string                 `json:"user_id"`
+	TemplateCode     string                 `json:"template_code"`
+	Subject          string                 `json:"subject,omitempty"`
+	Body             string                 `json:"body"`
+	Priority         string                 `json:"priority"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	CorrelationID    string                 `json:"correlation_id,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// BuildGenericPayload renders n as an Envelope. The plain-text body is
+// preferred over HTML when the notification has one.
+func BuildGenericPayload(n *models.KafkaNotificationPayload) ([]byte, error) {
+	return json.Marshal(Envelope{
+		NotificationID:   n.NotificationID,
+		NotificationType: n.NotificationType,
+		UserID:           n.UserID,
+		TemplateCode:     n.TemplateCode,
+		Subject:          n.Subject,
+		Body:             plainBody(n),
+		Priority:         n.Priority,
+		Metadata:         n.Metadata,
+		CorrelationID:    n.CorrelationID,
+		CreatedAt:        n.CreatedAt,
+	})
+}
+
+// SlackMessage is a Slack incoming webhook body. Text is the fallback shown
+// in notifications and by clients that cannot render blocks.
+type SlackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+// SlackBlock is one Block Kit layout block. Header and section blocks set
+// Text; context blocks set Elements.
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+// SlackText is a Block Kit text object, either "plain_text" or "mrkdwn"
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// BuildSlackPayload renders n as Block Kit JSON: a header with the subject
+// (or the template code when there is none), a section with the body and a
+// context line with the priority and notification ID
+func BuildSlackPayload(n *models.KafkaNotificationPayload) ([]byte, error) {
+	title := n.Subject
+	if title == "" {
+		title = n.TemplateCode
+	}
+	body := plainBody(n)
+
+	footer := fmt.Sprintf("Priority: *%s* | ID: `%s`", n.Priority, n.NotificationID)
+	if !n.CreatedAt.IsZero() {
+		footer += " | " + n.CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return json.Marshal(SlackMessage{
+		Text: title,
+		Blocks: []SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: truncate(title, slackHeaderMaxChars)}},
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: body}},
+			{Type: "context", Elements: []SlackText{{Type: "mrkdwn", Text: footer}}},
+		},
+	})
+}
+
+// plainBody prefers the text body, since neither Slack nor most webhook
+// consumers render HTML
+func plainBody(n *models.KafkaNotificationPayload) string {
+	if n.TextBody != "" {
+		return n.TextBody
+	}
+	return n.Body
+}
+
+// truncate shortens s to at most limit characters, ending in an ellipsis
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() *models.KafkaNotificationPayload {
+	return &models.KafkaNotificationPayload{
+		NotificationID:   "notif-123",
+		NotificationType: "email",
+		UserID:           "usr-1",
+		TemplateCode:     "order_shipped",
+		Subject:          "Your order has shipped",
+		Body:             "<p>Order 42 is on its way</p>",
+		TextBody:         "Order 42 is on its way",
+		Priority:         "high",
+		Metadata:         map[string]interface{}{"order_id": "42"},
+		CreatedAt:        time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestBuildSlackPayload(t *testing.T) {
+	body, err := BuildSlackPayload(testNotification())
+	require.NoError(t, err)
+
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &msg))
+	assert.Equal(t, "Your order has shipped", msg["text"])
+
+	blocks := msg["blocks"].([]interface{})
+	require.Len(t, blocks, 3)
+
+	header := blocks[0].(map[string]interface{})
+	assert.Equal(t, "header", header["type"])
+	assert.Equal(t, map[string]interface{}{"type": "plain_text", "text": "Your order has shipped"}, header["text"])
+
+	section := blocks[1].(map[string]interface{})
+	assert.Equal(t, "section", section["type"])
+	assert.Equal(t, map[string]interface{}{"type": "mrkdwn", "text": "Order 42 is on its way"}, section["text"])
+
+	footer := blocks[2].(map[string]interface{})
+	assert.Equal(t, "context", footer["type"])
+	assert.NotContains(t, footer, "text")
+	elements := footer["elements"].([]interface{})
+	require.Len(t, elements, 1)
+	element := elements[0].(map[string]interface{})
+	assert.Equal(t, "mrkdwn", element["type"])
+	assert.Equal(t, "Priority: *high* | ID: `notif-123` | 2025-03-01T09:30:00Z", element["text"])
+}
+
+func TestBuildSlackPayload_HeaderFallbackAndTruncation(t *testing.T) {
+	n := testNotification()
+	n.Subject = ""
+	body, err := BuildSlackPayload(n)
+	require.NoError(t, err)
+	var msg SlackMessage
+	require.NoError(t, json.Unmarshal(body, &msg))
+	assert.Equal(t, "order_shipped", msg.Blocks[0].Text.Text)
+
+	n.Subject = strings.Repeat("a", 200)
+	body, err = BuildSlackPayload(n)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &msg))
+	assert.Len(t, []rune(msg.Blocks[0].Text.Text), slackHeaderMaxChars)
+	assert.Equal(t, n.Subject, msg.Text)
+}
+
+func TestBuild_GenericSigned(t *testing.T) {
+	channel := models.WebhookChannel{Enabled: true, URL: "https://example.com/hook", Secret: "s3cret"}
+
+	at := time.Date(2025, 3, 1, 9, 31, 0, 0, time.UTC)
+	payload, err := Build(channel, testNotification(), at)
+	require.NoError(t, err)
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(payload.Body, &envelope))
+	assert.Equal(t, "notif-123", envelope.NotificationID)
+	assert.Equal(t, "Order 42 is on its way", envelope.Body)
+	assert.Equal(t, "42", envelope.Metadata["order_id"])
+
+	assert.Equal(t, "1740821460", payload.Headers[HeaderTimestamp])
+	assert.Equal(t, "application/json", payload.Headers["Content-Type"])
+
+	header := http.Header{}
+	for key, value := range payload.Headers {
+		header.Set(key, value)
+	}
+	assert.NoError(t, Verify(payload.Body, "s3cret", header, at, 0))
+}
+
+func TestBuild_GenericWithoutSecret(t *testing.T) {
+	payload, err := Build(models.WebhookChannel{Format: models.WebhookFormatGenericJSON}, testNotification(), time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, payload.Headers, HeaderSignature)
+	assert.NotContains(t, payload.Headers, HeaderTimestamp)
+}
+
+func TestBuild_Slack(t *testing.T) {
+	channel := models.WebhookChannel{Format: models.WebhookFormatSlack, Secret: "s3cret"}
+
+	payload, err := Build(channel, testNotification(), time.Now())
+	require.NoError(t, err)
+
+	expected, err := BuildSlackPayload(testNotification())
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(payload.Body))
+	assert.NotContains(t, payload.Headers, HeaderSignature)
+	assert.NotContains(t, payload.Headers, HeaderTimestamp)
+}
+
+func TestBuild_UnsupportedFormat(t *testing.T) {
+	_, err := Build(models.WebhookChannel{Format: "xml"}, testNotification(), time.Now())
+	assert.EqualError(t, err, "unsupported webhook format: xml")
+}
@@ -31,6 +31,8 @@ type CircuitBreaker struct {
 	successes     uint32
 	lastFailTime  time.Time
 	halfOpenCount uint32
+
+	now func() time.Time
 }
 
 type Config struct {
@@ -38,6 +40,7 @@ type Config struct {
 	MaxFailures uint32
 	Timeout     time.Duration
 	HalfOpenMax uint32
+	Now         func() time.Time // Clock used for the open timeout, defaults to time.Now
 }
 
 func New(cfg Config) *CircuitBreaker {
@@ -50,6 +53,9 @@ func New(cfg Config) *CircuitBreaker {
 	if cfg.HalfOpenMax == 0 {
 		cfg.HalfOpenMax = 1
 	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
 
 	return &CircuitBreaker{
 		name:        cfg.Name,
@@ -57,6 +63,7 @@ func New(cfg Config) *CircuitBreaker {
 		timeout:     cfg.Timeout,
 		halfOpenMax: cfg.HalfOpenMax,
 		state:       StateClosed,
+		now:         cfg.Now,
 	}
 }
 
@@ -76,7 +83,7 @@ func (cb *CircuitBreaker) beforeRequest() error {
 
 	switch cb.state {
 	case StateOpen:
-		if time.Since(cb.lastFailTime) > cb.timeout {
+		if cb.clock().Sub(cb.lastFailTime) > cb.timeout {
 			cb.state = StateHalfOpen
 			cb.halfOpenCount = 0
 			return nil
@@ -121,7 +128,7 @@ func (cb *CircuitBreaker) onSuccess() {
 
 func (cb *CircuitBreaker) onFailure() {
 	cb.failures++
-	cb.lastFailTime = time.Now()
+	cb.lastFailTime = cb.clock()
 
 	switch cb.state {
 	case StateHalfOpen:
@@ -143,3 +150,10 @@ func (cb *CircuitBreaker) State() State {
 func (cb *CircuitBreaker) Name() string {
 	return cb.name
 }
+
+func (cb *CircuitBreaker) clock() time.Time {
+	if cb.now == nil {
+		return time.Now()
+	}
+	return cb.now()
+}
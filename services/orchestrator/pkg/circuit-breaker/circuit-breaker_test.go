@@ -536,3 +536,23 @@ func TestCircuitBreaker_ZeroHalfOpenMax(t *testing.T) {
 	// Should use default value
 	assert.Equal(t, uint32(1), cb.halfOpenMax)
 }
+
+func TestCircuitBreaker_InjectedClock(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	cb := New(Config{
+		Name:        "test",
+		MaxFailures: 1,
+		Timeout:     time.Minute,
+		Now:         func() time.Time { return now },
+	})
+
+	cb.Execute(func() error { return errors.New("failure") })
+	assert.Equal(t, StateOpen, cb.State())
+
+	now = now.Add(59 * time.Second)
+	assert.Equal(t, ErrCircuitOpen, cb.Execute(func() error { return nil }))
+
+	now = now.Add(2 * time.Second)
+	assert.NoError(t, cb.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, cb.State())
+}
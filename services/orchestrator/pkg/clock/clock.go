@@ -0,0 +1,29 @@
+package clock
+
+import "time"
+
+// Clock tells the time. Code that depends on the current time takes a Clock
+// so tests can substitute clocktest.FakeClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real returns a Clock backed by time.Now
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// OrReal returns c, or the real clock when c is nil, for filling in
+// optional Clock config fields
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real()
+	}
+	return c
+}
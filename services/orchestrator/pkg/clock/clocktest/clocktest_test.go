@@ -0,0 +1,25 @@
+package clocktest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ clock.Clock = (*FakeClock)(nil)
+
+func TestFakeClock_AdvanceAndSet(t *testing.T) {
+	start := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	assert.Equal(t, start, c.Now())
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(90 * time.Minute)
+	assert.Equal(t, start.Add(90*time.Minute), c.Now())
+
+	c.Set(start.Add(-time.Hour))
+	assert.Equal(t, start.Add(-time.Hour), c.Now())
+}
@@ -0,0 +1,32 @@
+package correlation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the correlation ID on HTTP requests and Kafka messages
+const Header = "X-Correlation-ID"
+
+type contextKey struct{}
+
+// New returns a fresh correlation ID
+func New() string {
+	return uuid.New().String()
+}
+
+// NewContext returns a copy of ctx carrying id. An empty id leaves ctx as is.
+func NewContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the correlation ID carried by ctx, or "" when there is
+// none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
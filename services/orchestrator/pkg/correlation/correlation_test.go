@@ -0,0 +1,21 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_RoundTrip(t *testing.T) {
+	ctx := NewContext(context.Background(), "corr-1")
+
+	assert.Equal(t, "corr-1", FromContext(ctx))
+	assert.Empty(t, FromContext(context.Background()))
+	assert.Empty(t, FromContext(NewContext(context.Background(), "")))
+}
+
+func TestNew_Unique(t *testing.T) {
+	assert.NotEqual(t, New(), New())
+	assert.NotEmpty(t, New())
+}
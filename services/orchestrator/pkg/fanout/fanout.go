@@ -0,0 +1,95 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultMaxConcurrency is the number of tasks a Pool runs at once when
+// none is configured
+const DefaultMaxConcurrency = 8
+
+// Task is one delivery to fan out. Key identifies it in results and errors,
+// e.g. "push:ios" or "webhook".
+type Task struct {
+	Key string
+	Run func(ctx context.Context) error
+}
+
+// Result is the outcome of one Task. Err is nil when it succeeded, and the
+// context's error when the task never started because ctx was done.
+type Result struct {
+	Key string
+	Err error
+}
+
+// Pool runs tasks concurrently, at most maxConcurrency at a time. It holds no
+// state between runs and is safe for concurrent use.
+type Pool struct {
+	maxConcurrency int
+}
+
+// New returns a Pool running at most maxConcurrency tasks at once,
+// DefaultMaxConcurrency when it is not positive
+func New(maxConcurrency int) *Pool {
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+	return &Pool{maxConcurrency: maxConcurrency}
+}
+
+// Run starts the tasks in order and waits for every started task to return,
+// so no goroutine outlives the call. A failing task does not stop the
+// others; cancel ctx for that. Once ctx is done no further tasks start.
+//
+// Results are in the same order as tasks. The error joins each failure,
+// prefixed with its task's key, and is nil when every task succeeded.
+func (p *Pool) Run(ctx context.Context, tasks ...Task) ([]Result, error) {
+	results := make([]Result, len(tasks))
+	sem := make(chan struct{}, p.maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		results[i].Key = task.Key
+
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, task Task) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Err = runTask(ctx, task)
+		}(i, task)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Key, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// runTask runs task, turning a panic into an error so one bad delivery
+// cannot take down the process
+func runTask(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return task.Run(ctx)
+}
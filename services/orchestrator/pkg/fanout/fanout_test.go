@@ -0,0 +1,130 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_RunsAllTasks(t *testing.T) {
+	var ran atomic.Int32
+	tasks := make([]Task, 20)
+	for i := range tasks {
+		tasks[i] = Task{Key: fmt.Sprintf("device-%d", i), Run: func(ctx context.Context) error {
+			ran.Add(1)
+			return nil
+		}}
+	}
+
+	results, err := New(4).Run(context.Background(), tasks...)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), ran.Load())
+	require.Len(t, results, 20)
+	for i, result := range results {
+		assert.Equal(t, fmt.Sprintf("device-%d", i), result.Key)
+		assert.NoError(t, result.Err)
+	}
+}
+
+func TestPool_RespectsMaxConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	tasks := make([]Task, 12)
+	for i := range tasks {
+		tasks[i] = Task{Key: fmt.Sprint(i), Run: func(ctx context.Context) error {
+			n := running.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		}}
+	}
+
+	_, err := New(3).Run(context.Background(), tasks...)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestPool_AggregatesErrors(t *testing.T) {
+	errBounced := errors.New("bounced")
+	errExpired := errors.New("token expired")
+
+	results, err := New(2).Run(context.Background(),
+		Task{Key: "email", Run: func(ctx context.Context) error { return errBounced }},
+		Task{Key: "push:ios", Run: func(ctx context.Context) error { return nil }},
+		Task{Key: "push:android", Run: func(ctx context.Context) error { return errExpired }},
+		Task{Key: "webhook", Run: func(ctx context.Context) error { panic("boom") }},
+	)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errBounced)
+	assert.ErrorIs(t, err, errExpired)
+	assert.Contains(t, err.Error(), "email: bounced")
+	assert.Contains(t, err.Error(), "push:android: token expired")
+	assert.Contains(t, err.Error(), "webhook: task panicked: boom")
+
+	assert.ErrorIs(t, results[0].Err, errBounced)
+	assert.NoError(t, results[1].Err)
+	assert.ErrorIs(t, results[2].Err, errExpired)
+	assert.Error(t, results[3].Err)
+}
+
+func TestPool_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var returned atomic.Bool
+
+	blocking := func(ctx context.Context) error {
+		defer returned.Store(true)
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	results, err := New(1).Run(ctx,
+		Task{Key: "first", Run: blocking},
+		Task{Key: "second", Run: func(ctx context.Context) error { t.Error("second task should not start"); return nil }},
+	)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+	assert.ErrorIs(t, results[1].Err, context.Canceled)
+	// Run waited for the task it started
+	assert.True(t, returned.Load())
+}
+
+func TestPool_AlreadyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := New(0).Run(ctx, Task{Key: "email", Run: func(ctx context.Context) error {
+		t.Error("task should not start")
+		return nil
+	}})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+}
+
+func TestPool_NoTasks(t *testing.T) {
+	results, err := New(2).Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// healthReporter is implemented by publishers that can signal backpressure
+type healthReporter interface {
+	Healthy() bool
+}
+
+// statsSnapshot holds the writer stats last read through the producer.
+// kafka-go resets its counters on every Stats call, so whoever reads them
+// keeps the snapshot here for Healthy to share.
+type statsSnapshot struct {
+	mu    sync.Mutex
+	stats kafka.WriterStats
+	ok    bool
+}
+
+func (s *statsSnapshot) store(stats kafka.WriterStats) {
+	s.mu.Lock()
+	s.stats, s.ok = stats, true
+	s.mu.Unlock()
+}
+
+func (s *statsSnapshot) load() (kafka.WriterStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats, s.ok
+}
+
+// readStats reads the writer stats and remembers them for Healthy
+func (p *Producer) readStats() kafka.WriterStats {
+	stats := p.writer.Stats()
+	p.lastStats.store(stats)
+	return stats
+}
+
+// QueueDepth returns how many messages have been handed to the writer and
+// not yet acknowledged: async messages awaiting their completion callback,
+// plus messages in synchronous writes that have not returned.
+func (p *Producer) QueueDepth() int {
+	return p.inflight.pending() + int(p.writing.Load())
+}
+
+// Healthy reports whether the producer is keeping up, so callers can slow
+// down or shed load instead of buffering without bound. It is false once
+// QueueDepth exceeds ProducerConfig.MaxQueueDepth, or once the average
+// WriteTime in the writer stats exceeds MaxWriteLatency. The stats are read
+// afresh unless metrics are registered, in which case the snapshot taken by
+// the last metrics refresh is used so Prometheus still sees every count.
+// With neither limit configured it is always true.
+func (p *Producer) Healthy() bool {
+	if p.maxQueueDepth > 0 && p.QueueDepth() > p.maxQueueDepth {
+		return false
+	}
+	if p.maxWriteLatency <= 0 {
+		return true
+	}
+
+	var stats kafka.WriterStats
+	if p.metrics == nil {
+		stats = p.readStats()
+	} else {
+		var ok bool
+		if stats, ok = p.lastStats.load(); !ok {
+			return true
+		}
+	}
+	return stats.WriteTime.Avg <= p.maxWriteLatency
+}
@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducer_Healthy_FlipsWhenQueueBacksUp(t *testing.T) {
+	var enqueued [][]kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				// Accept the messages but never complete them, like a writer
+				// stuck behind a slow broker
+				enqueued = append(enqueued, msgs)
+				return nil
+			},
+		},
+		logger:        logger.Log,
+		async:         true,
+		maxQueueDepth: 2,
+	}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, producer.Publish(context.Background(), "key", "value"))
+	}
+	assert.Equal(t, 2, producer.QueueDepth())
+	assert.True(t, producer.Healthy())
+
+	require.NoError(t, producer.Publish(context.Background(), "key", "value"))
+	assert.Equal(t, 3, producer.QueueDepth())
+	assert.False(t, producer.Healthy())
+
+	// The writer drains once the broker catches up
+	for _, msgs := range enqueued {
+		producer.handleCompletion(msgs, nil)
+	}
+	assert.Equal(t, 0, producer.QueueDepth())
+	assert.True(t, producer.Healthy())
+}
+
+func TestProducer_QueueDepth_CountsSyncWrites(t *testing.T) {
+	release := make(chan struct{})
+	writing := make(chan struct{})
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				writing <- struct{}{}
+				<-release
+				return nil
+			},
+		},
+		logger:        logger.Log,
+		maxQueueDepth: 1,
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- producer.PublishBatch(context.Background(), []Message{{Key: "a", Value: 1}, {Key: "b", Value: 2}})
+	}()
+	<-writing
+
+	assert.Equal(t, 2, producer.QueueDepth())
+	assert.False(t, producer.Healthy())
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, 0, producer.QueueDepth())
+	assert.True(t, producer.Healthy())
+}
+
+func TestProducer_Healthy_WriteLatency(t *testing.T) {
+	latency := 10 * time.Millisecond
+	producer := &Producer{
+		writer: &mockWriter{
+			statsFunc: func() kafka.WriterStats {
+				return kafka.WriterStats{WriteTime: kafka.DurationStats{Avg: latency}}
+			},
+		},
+		logger:          logger.Log,
+		maxWriteLatency: 500 * time.Millisecond,
+	}
+
+	assert.True(t, producer.Healthy())
+
+	latency = 2 * time.Second
+	assert.False(t, producer.Healthy())
+}
+
+func TestProducer_Healthy_UsesMetricsSnapshot(t *testing.T) {
+	reads := 0
+	producer := &Producer{
+		writer: &mockWriter{
+			statsFunc: func() kafka.WriterStats {
+				reads++
+				return kafka.WriterStats{WriteTime: kafka.DurationStats{Avg: 2 * time.Second}}
+			},
+		},
+		logger:          logger.Log,
+		topic:           "backpressure-topic",
+		maxWriteLatency: 500 * time.Millisecond,
+		metricsInterval: time.Hour,
+	}
+	require.NoError(t, producer.RegisterMetrics(prometheus.NewRegistry()))
+	defer producer.Close()
+
+	// Nothing has been sampled yet, and Healthy must not read the stats
+	// itself while they feed Prometheus
+	assert.True(t, producer.Healthy())
+	assert.Equal(t, 0, reads)
+
+	producer.Stats()
+	assert.False(t, producer.Healthy())
+	assert.Equal(t, 1, reads)
+}
+
+func TestProducer_Healthy_WithoutLimits(t *testing.T) {
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log, async: true}
+	producer.inflight.add(1000)
+
+	assert.True(t, producer.Healthy())
+}
+
+func TestManager_Healthy(t *testing.T) {
+	backedUp := &Producer{writer: &mockWriter{}, logger: logger.Log, async: true, maxQueueDepth: 1}
+	backedUp.inflight.add(5)
+
+	manager := NewManagerWithPublishers(NewNoopProducer(logger.Log), NewNoopProducer(logger.Log), logger.Log)
+	assert.True(t, manager.Healthy())
+
+	manager = NewManagerWithPublishers(NewNoopProducer(logger.Log), backedUp, logger.Log)
+	assert.False(t, manager.Healthy())
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Balancer selects how messages are spread across partitions
+type Balancer string
+
+const (
+	// BalancerLeastBytes sends each message to the partition that has
+	// received the least data. It is the default.
+	BalancerLeastBytes Balancer = "least-bytes"
+	// BalancerRoundRobin cycles through partitions
+	BalancerRoundRobin Balancer = "round-robin"
+	// BalancerHash partitions on an FNV-1a hash of the message key, so all
+	// messages for a key keep their order on one partition
+	BalancerHash Balancer = "hash"
+	// BalancerCRC32 matches librdkafka's consistent_random partitioner
+	BalancerCRC32 Balancer = "crc32"
+	// BalancerMurmur2 matches the Java client's default partitioner
+	BalancerMurmur2 Balancer = "murmur2"
+)
+
+// HeaderPartitionKey carries a message's partition key when it differs from
+// the message key
+const HeaderPartitionKey = "x-partition-key"
+
+// partitionKeyBalancer lets a message's partition key take the place of its
+// key when the wrapped balancer picks a partition
+type partitionKeyBalancer struct {
+	kafka.Balancer
+}
+
+// Balance partitions on the HeaderPartitionKey value when the message has
+// one, and on the message key otherwise
+func (b partitionKeyBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	for _, h := range msg.Headers {
+		if h.Key == HeaderPartitionKey {
+			msg.Key = h.Value
+			break
+		}
+	}
+	return b.Balancer.Balance(msg, partitions...)
+}
+
+// withPartitionKey returns headers with HeaderPartitionKey set to
+// partitionKey, leaving the caller's map untouched
+func withPartitionKey(headers map[string]string, partitionKey string) map[string]string {
+	if partitionKey == "" {
+		return headers
+	}
+	merged := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		merged[k] = v
+	}
+	merged[HeaderPartitionKey] = partitionKey
+	return merged
+}
+
+// newBalancer builds the kafka-go balancer for b; the empty value selects
+// least-bytes
+func newBalancer(b Balancer) (kafka.Balancer, error) {
+	switch Balancer(strings.ToLower(string(b))) {
+	case "", BalancerLeastBytes:
+		return &kafka.LeastBytes{}, nil
+	case BalancerRoundRobin:
+		return &kafka.RoundRobin{}, nil
+	case BalancerHash:
+		return &kafka.Hash{}, nil
+	case BalancerCRC32:
+		return kafka.CRC32Balancer{}, nil
+	case BalancerMurmur2:
+		return kafka.Murmur2Balancer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported balancer: %s", b)
+	}
+}
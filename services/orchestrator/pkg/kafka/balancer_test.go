@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProducerWithError_Balancer(t *testing.T) {
+	tests := []struct {
+		balancer Balancer
+		expected kafka.Balancer
+	}{
+		{balancer: "", expected: &kafka.LeastBytes{}},
+		{balancer: BalancerLeastBytes, expected: &kafka.LeastBytes{}},
+		{balancer: BalancerRoundRobin, expected: &kafka.RoundRobin{}},
+		{balancer: BalancerHash, expected: &kafka.Hash{}},
+		{balancer: BalancerCRC32, expected: kafka.CRC32Balancer{}},
+		{balancer: BalancerMurmur2, expected: kafka.Murmur2Balancer{}},
+		{balancer: "HASH", expected: &kafka.Hash{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.balancer), func(t *testing.T) {
+			producer, err := NewProducerWithError(ProducerConfig{
+				Brokers:  []string{"localhost:9092"},
+				Topic:    "test-topic",
+				Logger:   logger.Log,
+				Balancer: tt.balancer,
+			})
+			require.NoError(t, err)
+
+			writer, ok := producer.writer.(*kafka.Writer)
+			require.True(t, ok)
+			require.IsType(t, partitionKeyBalancer{}, writer.Balancer)
+			assert.IsType(t, tt.expected, writer.Balancer.(partitionKeyBalancer).Balancer)
+		})
+	}
+}
+
+func TestNewProducerWithError_UnknownBalancer(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:  []string{"localhost:9092"},
+		Topic:    "test-topic",
+		Logger:   logger.Log,
+		Balancer: "sticky",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, producer)
+	assert.Contains(t, err.Error(), "unsupported balancer")
+}
+
+func TestNewProducer_UnknownBalancerFallsBackToLeastBytes(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:  []string{"localhost:9092"},
+		Topic:    "test-topic",
+		Logger:   logger.Log,
+		Balancer: "sticky",
+	})
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	require.IsType(t, partitionKeyBalancer{}, writer.Balancer)
+	assert.IsType(t, &kafka.LeastBytes{}, writer.Balancer.(partitionKeyBalancer).Balancer)
+}
+
+func TestBalancerHash_SameKeySamePartition(t *testing.T) {
+	balancer, err := newBalancer(BalancerHash)
+	require.NoError(t, err)
+
+	partitions := []int{0, 1, 2, 3, 4, 5}
+	msg := kafka.Message{Key: []byte("user-42")}
+
+	first := balancer.Balance(msg, partitions...)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, balancer.Balance(msg, partitions...))
+	}
+}
+
+func TestPartitionKeyBalancer_SamePartitionKeySamePartition(t *testing.T) {
+	for _, b := range []Balancer{BalancerHash, BalancerCRC32, BalancerMurmur2} {
+		t.Run(string(b), func(t *testing.T) {
+			inner, err := newBalancer(b)
+			require.NoError(t, err)
+			balancer := partitionKeyBalancer{inner}
+			partitions := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+			var messages []kafka.Message
+			for _, key := range []string{"notif-1", "notif-2", "notif-3", "notif-4"} {
+				messages = append(messages, kafka.Message{
+					Key:     []byte(key),
+					Headers: toKafkaHeaders(withPartitionKey(nil, "user-42")),
+				})
+			}
+
+			expected := inner.Balance(kafka.Message{Key: []byte("user-42")}, partitions...)
+			for _, msg := range messages {
+				assert.Equal(t, expected, balancer.Balance(msg, partitions...))
+			}
+		})
+	}
+}
+
+func TestPartitionKeyBalancer_FallsBackToKey(t *testing.T) {
+	inner, err := newBalancer(BalancerHash)
+	require.NoError(t, err)
+	balancer := partitionKeyBalancer{inner}
+	partitions := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+	msg := kafka.Message{Key: []byte("user-42")}
+
+	assert.Equal(t, inner.Balance(msg, partitions...), balancer.Balance(msg, partitions...))
+}
+
+func TestProducer_PublishBatch_PartitionKey(t *testing.T) {
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+	headers := map[string]string{"x-source": "orchestrator"}
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "notif-1", Value: "one", Headers: headers, PartitionKey: "user-42"},
+		{Key: "notif-2", Value: "two"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, written, 2)
+	assert.Equal(t, "notif-1", string(written[0].Key))
+	assert.Equal(t, []kafka.Header{
+		{Key: HeaderPartitionKey, Value: []byte("user-42")},
+		{Key: "x-source", Value: []byte("orchestrator")},
+	}, written[0].Headers)
+	assert.Nil(t, written[1].Headers)
+	// The caller's headers are not modified
+	assert.Equal(t, map[string]string{"x-source": "orchestrator"}, headers)
+}
@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// BatchError is returned by PublishBatch when a batch split into chunks was
+// only partly published. Indexes refer to the messages passed to
+// PublishBatch, so callers can replay just the failed ones. Err joins the
+// error of every failed chunk and matches the same classes as a single
+// write, e.g. ErrBrokerUnavailable.
+type BatchError struct {
+	Succeeded []int
+	Failed    []int
+	Err       error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("published %d of %d batch messages: %v",
+		len(e.Succeeded), len(e.Succeeded)+len(e.Failed), e.Err)
+}
+
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// batchChunk is the half-open range [start, end) of a batch
+type batchChunk struct {
+	start, end int
+}
+
+// splitBatch cuts msgs into consecutive chunks of at most maxCount messages
+// and maxBytes bytes of keys, values and headers; a zero limit is not
+// applied. A message larger than maxBytes on its own gets a chunk to
+// itself.
+func splitBatch(msgs []kafka.Message, maxCount, maxBytes int) []batchChunk {
+	chunks := []batchChunk{}
+	start, size := 0, 0
+	for i, msg := range msgs {
+		msgSize := messageSize(msg)
+		full := maxCount > 0 && i-start >= maxCount
+		tooBig := maxBytes > 0 && size+msgSize > maxBytes
+		if i > start && (full || tooBig) {
+			chunks = append(chunks, batchChunk{start: start, end: i})
+			start, size = i, 0
+		}
+		size += msgSize
+	}
+	if start < len(msgs) || len(chunks) == 0 {
+		chunks = append(chunks, batchChunk{start: start, end: len(msgs)})
+	}
+	return chunks
+}
+
+// messageSize approximates a message's share of a produce request
+func messageSize(msg kafka.Message) int {
+	size := len(msg.Key) + len(msg.Value)
+	for _, h := range msg.Headers {
+		size += len(h.Key) + len(h.Value)
+	}
+	return size
+}
+
+// writeBatch writes msgs in one call, falling back to the DLQ on failure
+func (p *Producer) writeBatch(ctx context.Context, msgs []kafka.Message) error {
+	err := p.write(ctx, msgs...)
+	if err != nil {
+		if p.logger != nil {
+			p.logger.Error("Failed to publish batch",
+				zap.Int("count", len(msgs)),
+				zap.Error(err),
+			)
+		}
+		if p.dlq != nil && p.sendToDLQ(ctx, msgs, err) == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to publish batch: %w", classifyWriteError(err))
+	}
+
+	if p.logger != nil {
+		p.logger.Info("Batch published successfully",
+			zap.Int("count", len(msgs)),
+		)
+	}
+	return nil
+}
+
+// publishChunks writes each chunk of msgs in turn. A failed chunk does not
+// stop the ones after it, but a done context does: the remaining chunks are
+// reported as failed without being written.
+func (p *Producer) publishChunks(ctx context.Context, msgs []kafka.Message, chunks []batchChunk) error {
+	if p.logger != nil {
+		p.logger.Debug("Splitting batch",
+			zap.Int("count", len(msgs)),
+			zap.Int("chunks", len(chunks)),
+		)
+	}
+
+	batchErr := &BatchError{}
+	var errs []error
+	for i, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			batchErr.Failed = appendRange(batchErr.Failed, chunk.start, len(msgs))
+			errs = append(errs, fmt.Errorf("chunks %d to %d of %d: failed to publish batch: %w", i+1, len(chunks), len(chunks), err))
+			break
+		}
+		if err := p.writeBatch(ctx, msgs[chunk.start:chunk.end]); err != nil {
+			batchErr.Failed = appendRange(batchErr.Failed, chunk.start, chunk.end)
+			errs = append(errs, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err))
+			continue
+		}
+		batchErr.Succeeded = appendRange(batchErr.Succeeded, chunk.start, chunk.end)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	batchErr.Err = errors.Join(errs...)
+	return batchErr
+}
+
+// appendRange appends the indexes start to end-1 to indexes
+func appendRange(indexes []int, start, end int) []int {
+	for i := start; i < end; i++ {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
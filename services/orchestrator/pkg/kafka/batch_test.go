@@ -0,0 +1,132 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkRecorder records the keys of each write and fails the writes whose
+// number, counting from 1, is in fail
+type chunkRecorder struct {
+	writes [][]string
+	fail   map[int]error
+}
+
+func (r *chunkRecorder) producer(maxCount, maxBytes int) *Producer {
+	return &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				keys := make([]string, len(msgs))
+				for i, msg := range msgs {
+					keys[i] = string(msg.Key)
+				}
+				r.writes = append(r.writes, keys)
+				return r.fail[len(r.writes)]
+			},
+		},
+		logger:        logger.Log,
+		topic:         "test-topic",
+		maxBatchCount: maxCount,
+		maxBatchBytes: maxBytes,
+	}
+}
+
+func batchOf(keys ...string) []Message {
+	messages := make([]Message, len(keys))
+	for i, key := range keys {
+		messages[i] = Message{Key: key, Value: "v"}
+	}
+	return messages
+}
+
+func TestPublishBatch_SplitsByCount(t *testing.T) {
+	recorder := &chunkRecorder{}
+	producer := recorder.producer(2, 0)
+
+	err := producer.PublishBatch(context.Background(), batchOf("a", "b", "c", "d", "e"))
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, recorder.writes)
+}
+
+func TestPublishBatch_SplitsByBytes(t *testing.T) {
+	recorder := &chunkRecorder{}
+	// Each message is a one byte key and the three byte value "v"
+	producer := recorder.producer(0, 8)
+
+	err := producer.PublishBatch(context.Background(), batchOf("a", "b", "c", "d", "e"))
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, recorder.writes)
+}
+
+func TestPublishBatch_MidChunkFailureReportsPublishedMessages(t *testing.T) {
+	recorder := &chunkRecorder{fail: map[int]error{2: kafka.LeaderNotAvailable}}
+	producer := recorder.producer(2, 0)
+
+	err := producer.PublishBatch(context.Background(), batchOf("a", "b", "c", "d", "e"))
+
+	// The chunks after the failed one are still written
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, recorder.writes)
+
+	var batchErr *BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []int{0, 1, 4}, batchErr.Succeeded)
+	assert.Equal(t, []int{2, 3}, batchErr.Failed)
+	assert.True(t, errors.Is(err, ErrBrokerUnavailable))
+	assert.Contains(t, err.Error(), "published 3 of 5 batch messages")
+	assert.Contains(t, err.Error(), "chunk 2 of 3")
+}
+
+func TestPublishBatch_StopsChunkingWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	recorder := &chunkRecorder{}
+	producer := recorder.producer(2, 0)
+	producer.writer.(*mockWriter).writeMessagesFunc = func(ctx context.Context, msgs ...kafka.Message) error {
+		recorder.writes = append(recorder.writes, []string{string(msgs[0].Key)})
+		cancel()
+		return nil
+	}
+
+	err := producer.PublishBatch(ctx, batchOf("a", "b", "c", "d", "e"))
+
+	assert.Len(t, recorder.writes, 1)
+	var batchErr *BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []int{0, 1}, batchErr.Succeeded)
+	assert.Equal(t, []int{2, 3, 4}, batchErr.Failed)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestPublishBatch_UnderLimitsIsOneWrite(t *testing.T) {
+	recorder := &chunkRecorder{fail: map[int]error{1: kafka.LeaderNotAvailable}}
+	producer := recorder.producer(10, 1024)
+
+	err := producer.PublishBatch(context.Background(), batchOf("a", "b", "c"))
+
+	assert.Equal(t, [][]string{{"a", "b", "c"}}, recorder.writes)
+	// A batch that was not split fails as a whole, as before
+	var batchErr *BatchError
+	assert.False(t, errors.As(err, &batchErr))
+	assert.True(t, errors.Is(err, ErrBrokerUnavailable))
+}
+
+func TestSplitBatch_OversizedMessageGetsOwnChunk(t *testing.T) {
+	msgs := []kafka.Message{
+		{Key: []byte("a"), Value: []byte("1")},
+		{Key: []byte("b"), Value: make([]byte, 100)},
+		{Key: []byte("c"), Value: []byte("3")},
+	}
+
+	assert.Equal(t, []batchChunk{{0, 1}, {1, 2}, {2, 3}}, splitBatch(msgs, 0, 10))
+	assert.Equal(t, []batchChunk{{0, 3}}, splitBatch(msgs, 0, 0))
+	assert.Equal(t, []batchChunk{{0, 0}}, splitBatch(nil, 2, 10))
+}
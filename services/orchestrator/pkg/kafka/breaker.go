@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"time"
+
+	circuitbreaker "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/circuit-breaker"
+)
+
+// ErrCircuitOpen is returned (wrapped) by Publish and PublishBatch while the
+// producer's circuit breaker is open and writes are rejected without
+// contacting Kafka.
+var ErrCircuitOpen = circuitbreaker.ErrCircuitOpen
+
+// newProducerBreaker builds the circuit breaker guarding the writer, or nil
+// when the threshold is zero and the breaker is disabled
+func newProducerBreaker(topic string, threshold uint32, cooldown time.Duration) *circuitbreaker.CircuitBreaker {
+	if threshold == 0 {
+		return nil
+	}
+
+	return circuitbreaker.New(circuitbreaker.Config{
+		Name:        "kafka-producer-" + topic,
+		MaxFailures: threshold,
+		Timeout:     cooldown,
+		// The breaker closes again after `threshold` successful probes, so
+		// allow that many requests through while half-open.
+		HalfOpenMax: threshold,
+	})
+}
+
+// BreakerState returns the current state of the producer's circuit breaker.
+// It reports StateClosed when no breaker is configured.
+func (p *Producer) BreakerState() circuitbreaker.State {
+	if p.breaker == nil {
+		return circuitbreaker.StateClosed
+	}
+	return p.breaker.State()
+}
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/circuit-breaker"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// switchableWriter fails while failing is true and counts write attempts
+type switchableWriter struct {
+	mockWriter
+	failing bool
+	calls   int
+}
+
+func (w *switchableWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.calls++
+	if w.failing {
+		return errors.New("broker down")
+	}
+	return nil
+}
+
+func newBreakerProducer(writer kafkaWriter, threshold uint32, cooldown time.Duration) *Producer {
+	return &Producer{
+		writer:  writer,
+		logger:  logger.Log,
+		topic:   "test-topic",
+		breaker: newProducerBreaker("test-topic", threshold, cooldown),
+	}
+}
+
+func TestProducer_Breaker_Disabled(t *testing.T) {
+	producer := newBreakerProducer(&switchableWriter{failing: true}, 0, 0)
+
+	assert.Nil(t, producer.breaker)
+	assert.Equal(t, circuitbreaker.StateClosed, producer.BreakerState())
+}
+
+func TestProducer_Breaker_OpensAfterThreshold(t *testing.T) {
+	writer := &switchableWriter{failing: true}
+	producer := newBreakerProducer(writer, 2, time.Minute)
+	payload := map[string]interface{}{"key": "value"}
+
+	for i := 0; i < 2; i++ {
+		err := producer.Publish(context.Background(), "test-key", payload)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, producer.BreakerState())
+
+	err := producer.Publish(context.Background(), "test-key", payload)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, writer.calls, "open breaker must not reach the writer")
+}
+
+func TestProducer_Breaker_Transitions(t *testing.T) {
+	writer := &switchableWriter{failing: true}
+	producer := newBreakerProducer(writer, 2, 20*time.Millisecond)
+	payload := map[string]interface{}{"key": "value"}
+
+	// closed -> open
+	_ = producer.Publish(context.Background(), "test-key", payload)
+	_ = producer.Publish(context.Background(), "test-key", payload)
+	assert.Equal(t, circuitbreaker.StateOpen, producer.BreakerState())
+
+	// open -> half-open once the cooldown elapses; a failed probe re-opens
+	time.Sleep(30 * time.Millisecond)
+	err := producer.Publish(context.Background(), "test-key", payload)
+	assert.Error(t, err)
+	assert.Equal(t, circuitbreaker.StateOpen, producer.BreakerState())
+
+	// half-open -> closed after successful probes
+	writer.failing = false
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, producer.Publish(context.Background(), "test-key", payload))
+	assert.Equal(t, circuitbreaker.StateHalfOpen, producer.BreakerState())
+	assert.NoError(t, producer.Publish(context.Background(), "test-key", payload))
+	assert.Equal(t, circuitbreaker.StateClosed, producer.BreakerState())
+}
+
+func TestNewProducer_BreakerConfig(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:          []string{"localhost:9092"},
+		Topic:            "test-topic",
+		Logger:           logger.Log,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Second,
+	})
+
+	assert.NotNil(t, producer.breaker)
+	assert.Equal(t, circuitbreaker.StateClosed, producer.BreakerState())
+}
@@ -0,0 +1,240 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// kafkaReader interface abstracts kafka.Reader for testability
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+	Stats() kafka.ReaderStats
+}
+
+// Consumer reads messages from a topic as part of a consumer group
+type Consumer struct {
+	reader  kafkaReader
+	logger  *zap.Logger
+	topic   string
+	groupID string
+
+	autoCommit bool
+
+	// pending is the message whose handler last failed; the next Consume
+	// hands it over again before fetching anything after it
+	pending *kafka.Message
+}
+
+// ConsumerConfig mirrors ProducerConfig for the read side. The connection
+// fields behave exactly as they do for the producer.
+type ConsumerConfig struct {
+	Brokers  []string
+	Topic    string
+	GroupID  string
+	Logger   *zap.Logger
+	Username string
+	Password string
+	UseTLS   bool
+
+	// SASLMechanism, CACertPEM and InsecureSkipVerify: see ProducerConfig
+	SASLMechanism      string
+	CACertPEM          []byte
+	InsecureSkipVerify bool
+
+	// AutoCommit makes Consume commit each message as soon as the handler
+	// returns nil. It is off by default: the handler acknowledges messages
+	// itself with CommitMessages, and anything not committed when the
+	// consumer stops or crashes is redelivered to the group on restart. This
+	// gives at-least-once delivery, so handlers must tolerate repeats.
+	AutoCommit bool
+
+	// OnAssign and OnRevoke are called with the partitions of Topic gained
+	// or lost in a rebalance, e.g. to flush per-partition state before
+	// ownership moves. Revocation is reported once every partition reader
+	// of the old generation has stopped. Setting either hook switches the
+	// consumer to kafka-go's ConsumerGroup API, which exposes generations.
+	OnAssign func(partitions []int)
+	OnRevoke func(partitions []int)
+}
+
+// connection returns the producer view of the connection settings so the
+// consumer shares the producer's SASL and TLS handling
+func (cfg ConsumerConfig) connection() ProducerConfig {
+	return ProducerConfig{
+		Brokers:            cfg.Brokers,
+		Username:           cfg.Username,
+		Password:           cfg.Password,
+		UseTLS:             cfg.UseTLS,
+		SASLMechanism:      cfg.SASLMechanism,
+		CACertPEM:          cfg.CACertPEM,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+}
+
+// NewConsumer creates a consumer group reader for the configured topic
+func NewConsumer(cfg ConsumerConfig) (*Consumer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one broker is required")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+	if cfg.GroupID == "" {
+		return nil, fmt.Errorf("group ID is required")
+	}
+
+	conn := cfg.connection()
+	if _, err := conn.saslMechanism(); err != nil {
+		return nil, err
+	}
+	if _, err := conn.tlsConfig(); err != nil {
+		return nil, err
+	}
+
+	var reader kafkaReader
+	if cfg.OnAssign != nil || cfg.OnRevoke != nil {
+		groupReader, err := newGroupReader(cfg, newDialer(conn))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consumer group: %w", err)
+		}
+		reader = groupReader
+	} else {
+		reader = kafka.NewReader(kafka.ReaderConfig{
+			Brokers: cfg.Brokers,
+			Topic:   cfg.Topic,
+			GroupID: cfg.GroupID,
+			Dialer:  newDialer(conn),
+		})
+	}
+
+	return &Consumer{
+		reader:  reader,
+		logger:  cfg.Logger,
+		topic:   cfg.Topic,
+		groupID: cfg.GroupID,
+
+		autoCommit: cfg.AutoCommit,
+	}, nil
+}
+
+// Consume fetches messages and passes them to handler until ctx is
+// cancelled. With AutoCommit a message's offset is committed only after
+// handler returns nil; otherwise the handler commits with CommitMessages.
+// If handler fails, Consume stops without committing and returns the error.
+// The failed message is not skipped: the next Consume call on the same
+// Consumer passes it to handler again before fetching later messages, and a
+// new consumer in the group resumes from it as long as nothing after it was
+// committed.
+// Message values are the raw bytes read from Kafka. Wrap handler with Chain
+// to add middleware such as Recover.
+func (c *Consumer) Consume(ctx context.Context, handler HandlerFunc) error {
+	for {
+		var kmsg kafka.Message
+		if c.pending != nil {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			kmsg = *c.pending
+		} else {
+			var err error
+			kmsg, err = c.reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("failed to fetch message: %w", err)
+			}
+		}
+
+		msg := fromKafkaMessage(kmsg)
+		if err := handler(msg); err != nil {
+			if c.logger != nil {
+				c.logger.Error("Message handler failed",
+					zap.String("topic", kmsg.Topic),
+					zap.Int("partition", kmsg.Partition),
+					zap.Int64("offset", kmsg.Offset),
+					zap.String("key", msg.Key),
+					zap.Error(err),
+				)
+			}
+			c.pending = &kmsg
+			return fmt.Errorf("handler failed for message at offset %d: %w", kmsg.Offset, err)
+		}
+		c.pending = nil
+
+		if !c.autoCommit {
+			continue
+		}
+		if err := c.reader.CommitMessages(ctx, kmsg); err != nil {
+			return fmt.Errorf("failed to commit offset %d: %w", kmsg.Offset, err)
+		}
+	}
+}
+
+// CommitMessages marks msgs as processed for the consumer group. Committing
+// a message also acknowledges every earlier message on its partition, so
+// commit in order once processing has really finished.
+func (c *Consumer) CommitMessages(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	kafkaMessages := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		topic := msg.Topic
+		if topic == "" {
+			topic = c.topic
+		}
+		kafkaMessages[i] = kafka.Message{
+			Topic:     topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+		}
+	}
+
+	if err := c.reader.CommitMessages(ctx, kafkaMessages...); err != nil {
+		return fmt.Errorf("failed to commit messages: %w", err)
+	}
+	return nil
+}
+
+// Close leaves the consumer group and closes the reader
+func (c *Consumer) Close() error {
+	if c.logger != nil {
+		c.logger.Info("Closing Kafka consumer",
+			zap.String("topic", c.topic),
+			zap.String("group_id", c.groupID),
+		)
+	}
+	return c.reader.Close()
+}
+
+// Stats returns consumer statistics
+func (c *Consumer) Stats() kafka.ReaderStats {
+	return c.reader.Stats()
+}
+
+func fromKafkaMessage(kmsg kafka.Message) Message {
+	var headers map[string]string
+	if len(kmsg.Headers) > 0 {
+		headers = make(map[string]string, len(kmsg.Headers))
+		for _, h := range kmsg.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+	}
+
+	return Message{
+		Key:          string(kmsg.Key),
+		Value:        kmsg.Value,
+		Headers:      headers,
+		PartitionKey: headers[HeaderPartitionKey],
+		Topic:        kmsg.Topic,
+		Partition:    kmsg.Partition,
+		Offset:       kmsg.Offset,
+	}
+}
@@ -0,0 +1,350 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockReader delivers queued messages and then blocks until the context is
+// cancelled, recording every commit
+type mockReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []kafka.Message
+	fetchErr  error
+	commitErr error
+	closed    bool
+}
+
+func (m *mockReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	m.mu.Lock()
+	if m.fetchErr != nil {
+		err := m.fetchErr
+		m.mu.Unlock()
+		return kafka.Message{}, err
+	}
+	if len(m.messages) > 0 {
+		msg := m.messages[0]
+		m.messages = m.messages[1:]
+		m.mu.Unlock()
+		return msg, nil
+	}
+	m.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (m *mockReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.commitErr != nil {
+		return m.commitErr
+	}
+	m.committed = append(m.committed, msgs...)
+	return nil
+}
+
+func (m *mockReader) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+func (m *mockReader) Stats() kafka.ReaderStats {
+	return kafka.ReaderStats{}
+}
+
+func (m *mockReader) committedOffsets() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	offsets := make([]int64, len(m.committed))
+	for i, msg := range m.committed {
+		offsets[i] = msg.Offset
+	}
+	return offsets
+}
+
+func testMessages(n int) []kafka.Message {
+	msgs := make([]kafka.Message, n)
+	for i := range msgs {
+		msgs[i] = kafka.Message{
+			Topic:     "test-topic",
+			Partition: 0,
+			Offset:    int64(i),
+			Key:       []byte("user-1"),
+			Value:     []byte(`{"n":1}`),
+			Headers:   []kafka.Header{{Key: "source", Value: []byte("test")}},
+		}
+	}
+	return msgs
+}
+
+func newTestConsumer(reader *mockReader) *Consumer {
+	return &Consumer{reader: reader, logger: logger.Log, topic: "test-topic", groupID: "test-group", autoCommit: true}
+}
+
+func TestConsumer_Consume_CommitsAfterHandlerSucceeds(t *testing.T) {
+	reader := &mockReader{messages: testMessages(3)}
+	consumer := newTestConsumer(reader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var received []Message
+	err := consumer.Consume(ctx, func(msg Message) error {
+		received = append(received, msg)
+		if len(received) == 3 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, received, 3)
+	assert.Equal(t, "user-1", received[0].Key)
+	assert.Equal(t, []byte(`{"n":1}`), received[0].Value)
+	assert.Equal(t, "test", received[0].Headers["source"])
+	assert.Equal(t, int64(2), received[2].Offset)
+	assert.Equal(t, []int64{0, 1, 2}, reader.committedOffsets())
+}
+
+func TestConsumer_Consume_HandlerErrorDoesNotCommit(t *testing.T) {
+	reader := &mockReader{messages: testMessages(3)}
+	consumer := newTestConsumer(reader)
+
+	calls := 0
+	err := consumer.Consume(context.Background(), func(msg Message) error {
+		calls++
+		if msg.Offset == 1 {
+			return errors.New("template not found")
+		}
+		return nil
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "handler failed for message at offset 1")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []int64{0}, reader.committedOffsets())
+}
+
+func TestConsumer_Consume_RetriesFailedMessage(t *testing.T) {
+	reader := &mockReader{messages: testMessages(3)}
+	consumer := newTestConsumer(reader)
+
+	failing := true
+	err := consumer.Consume(context.Background(), func(msg Message) error {
+		if msg.Offset == 1 && failing {
+			return errors.New("provider unavailable")
+		}
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, []int64{0}, reader.committedOffsets())
+
+	// Consuming again hands over offset 1 before anything after it
+	failing = false
+	ctx, cancel := context.WithCancel(context.Background())
+	var offsets []int64
+	err = consumer.Consume(ctx, func(msg Message) error {
+		offsets = append(offsets, msg.Offset)
+		if len(offsets) == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int64{1, 2}, offsets)
+	assert.Equal(t, []int64{0, 1, 2}, reader.committedOffsets())
+}
+
+func TestConsumer_Consume_FetchError(t *testing.T) {
+	reader := &mockReader{fetchErr: errors.New("connection reset")}
+	consumer := newTestConsumer(reader)
+
+	err := consumer.Consume(context.Background(), func(msg Message) error { return nil })
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to fetch message")
+}
+
+func TestConsumer_Consume_CommitError(t *testing.T) {
+	reader := &mockReader{messages: testMessages(1), commitErr: errors.New("rebalance in progress")}
+	consumer := newTestConsumer(reader)
+
+	err := consumer.Consume(context.Background(), func(msg Message) error { return nil })
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to commit offset 0")
+}
+
+func TestConsumer_Close(t *testing.T) {
+	reader := &mockReader{}
+	consumer := newTestConsumer(reader)
+
+	require.NoError(t, consumer.Close())
+	assert.True(t, reader.closed)
+}
+
+func TestNewConsumer_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ConsumerConfig
+		want string
+	}{
+		{name: "no brokers", cfg: ConsumerConfig{Topic: "t", GroupID: "g"}, want: "at least one broker"},
+		{name: "no topic", cfg: ConsumerConfig{Brokers: []string{"localhost:9092"}, GroupID: "g"}, want: "topic is required"},
+		{name: "no group", cfg: ConsumerConfig{Brokers: []string{"localhost:9092"}, Topic: "t"}, want: "group ID is required"},
+		{
+			name: "bad sasl",
+			cfg:  ConsumerConfig{Brokers: []string{"localhost:9092"}, Topic: "t", GroupID: "g", SASLMechanism: "gssapi", Username: "u", Password: "p"},
+			want: "unsupported SASL mechanism",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer, err := NewConsumer(tt.cfg)
+			require.Error(t, err)
+			assert.Nil(t, consumer)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestNewConsumer_Success(t *testing.T) {
+	consumer, err := NewConsumer(ConsumerConfig{
+		Brokers:  []string{"localhost:9092"},
+		Topic:    "test-topic",
+		GroupID:  "test-group",
+		Logger:   logger.Log,
+		Username: "user",
+		Password: "pass",
+		UseTLS:   true,
+	})
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	reader, ok := consumer.reader.(*kafka.Reader)
+	require.True(t, ok)
+	assert.Equal(t, "test-group", reader.Config().GroupID)
+	require.NotNil(t, reader.Config().Dialer)
+	assert.NotNil(t, reader.Config().Dialer.SASLMechanism)
+	assert.NotNil(t, reader.Config().Dialer.TLS)
+}
+
+func TestConsumer_Consume_ManualCommitByDefault(t *testing.T) {
+	reader := &mockReader{messages: testMessages(2)}
+	consumer := newTestConsumer(reader)
+	consumer.autoCommit = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := consumer.Consume(ctx, func(msg Message) error {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, reader.committedOffsets())
+}
+
+func TestConsumer_CommitMessages_FromHandler(t *testing.T) {
+	reader := &mockReader{messages: testMessages(3)}
+	consumer := newTestConsumer(reader)
+	consumer.autoCommit = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := consumer.Consume(ctx, func(msg Message) error {
+		if msg.Offset == 2 {
+			cancel()
+			return nil
+		}
+		return consumer.CommitMessages(ctx, msg)
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int64{0, 1}, reader.committedOffsets())
+	assert.Equal(t, "test-topic", reader.committed[0].Topic)
+}
+
+func TestConsumer_CommitMessages_HandlerErrorKeepsOffset(t *testing.T) {
+	reader := &mockReader{messages: testMessages(3)}
+	consumer := newTestConsumer(reader)
+	consumer.autoCommit = false
+
+	err := consumer.Consume(context.Background(), func(msg Message) error {
+		if msg.Offset == 1 {
+			return errors.New("provider unavailable")
+		}
+		return consumer.CommitMessages(context.Background(), msg)
+	})
+
+	require.Error(t, err)
+	// Offset 1 was never acknowledged, so a restarted consumer resumes there
+	assert.Equal(t, []int64{0}, reader.committedOffsets())
+}
+
+func TestConsumer_CommitMessages_DefaultsTopic(t *testing.T) {
+	reader := &mockReader{}
+	consumer := newTestConsumer(reader)
+
+	err := consumer.CommitMessages(context.Background(), Message{Partition: 3, Offset: 42})
+
+	require.NoError(t, err)
+	require.Len(t, reader.committed, 1)
+	assert.Equal(t, "test-topic", reader.committed[0].Topic)
+	assert.Equal(t, 3, reader.committed[0].Partition)
+	assert.Equal(t, int64(42), reader.committed[0].Offset)
+}
+
+func TestConsumer_CommitMessages_Error(t *testing.T) {
+	reader := &mockReader{commitErr: errors.New("not coordinator")}
+	consumer := newTestConsumer(reader)
+
+	err := consumer.CommitMessages(context.Background(), Message{Offset: 1})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to commit messages")
+}
+
+func TestNewConsumer_AutoCommitDefaultsOff(t *testing.T) {
+	consumer, err := NewConsumer(ConsumerConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "test-topic",
+		GroupID: "test-group",
+	})
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	assert.False(t, consumer.autoCommit)
+}
+
+func TestConsumer_Consume_ExposesPartitionKey(t *testing.T) {
+	msgs := testMessages(1)
+	msgs[0].Headers = append(msgs[0].Headers, kafka.Header{Key: HeaderPartitionKey, Value: []byte("user-42")})
+	reader := &mockReader{messages: msgs}
+	consumer := newTestConsumer(reader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var received Message
+	_ = consumer.Consume(ctx, func(msg Message) error {
+		received = msg
+		cancel()
+		return nil
+	})
+
+	assert.Equal(t, "user-1", received.Key)
+	assert.Equal(t, "user-42", received.PartitionKey)
+}
@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Headers stamped on messages forwarded to the dead-letter queue
+const (
+	HeaderDLQError       = "x-dlq-error"
+	HeaderDLQSourceTopic = "x-dlq-source-topic"
+	HeaderDLQFailedAt    = "x-dlq-failed-at"
+)
+
+// sendToDLQ forwards messages that could not be published to the dead-letter
+// producer, keeping the original key, value and headers and adding the failure
+// details as headers. It returns an error if no DLQ is configured or the DLQ
+// write fails too.
+func (p *Producer) sendToDLQ(ctx context.Context, msgs []kafka.Message, cause error) error {
+	if p.dlq == nil {
+		return fmt.Errorf("no dead-letter queue configured")
+	}
+
+	failedAt := p.now().UTC().Format(time.RFC3339)
+	dlqMessages := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		sourceTopic := msg.Topic
+		if sourceTopic == "" {
+			sourceTopic = p.topic
+		}
+
+		headers := make([]kafka.Header, 0, len(msg.Headers)+3)
+		headers = append(headers, msg.Headers...)
+		headers = append(headers,
+			kafka.Header{Key: HeaderDLQError, Value: []byte(cause.Error())},
+			kafka.Header{Key: HeaderDLQSourceTopic, Value: []byte(sourceTopic)},
+			kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(failedAt)},
+		)
+
+		dlqMessages[i] = kafka.Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+			Time:    msg.Time,
+		}
+	}
+
+	// The original context may be what caused the failure, so the DLQ write
+	// only inherits its values and relies on the DLQ writer's own timeouts.
+	if err := p.dlq.write(context.WithoutCancel(ctx), dlqMessages...); err != nil {
+		if p.logger != nil {
+			p.logger.Error("Failed to write messages to dead-letter queue",
+				zap.String("topic", p.topic),
+				zap.String("dlq_topic", p.dlq.topic),
+				zap.Int("count", len(msgs)),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to write to dead-letter queue: %w", err)
+	}
+
+	if p.logger != nil {
+		p.logger.Warn("Messages moved to dead-letter queue",
+			zap.String("topic", p.topic),
+			zap.String("dlq_topic", p.dlq.topic),
+			zap.Int("count", len(msgs)),
+			zap.Error(cause),
+		)
+	}
+
+	return nil
+}
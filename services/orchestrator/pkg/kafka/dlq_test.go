@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headerValue(headers []kafka.Header, key string) (string, bool) {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
+
+func failingWriter(err error) *mockWriter {
+	return &mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			return err
+		},
+	}
+}
+
+func TestProducer_Publish_FailureGoesToDLQ(t *testing.T) {
+	var dlqMessages []kafka.Message
+	dlq := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				dlqMessages = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "failed.queue",
+	}
+
+	producer := &Producer{
+		writer: failingWriter(errors.New("broker unavailable")),
+		logger: logger.Log,
+		topic:  "email.queue",
+		dlq:    dlq,
+	}
+
+	err := producer.PublishWithHeaders(context.Background(), "notif-1", map[string]interface{}{"key": "value"}, map[string]string{"tenant": "acme"})
+	assert.NoError(t, err)
+
+	require.Len(t, dlqMessages, 1)
+	assert.Equal(t, "notif-1", string(dlqMessages[0].Key))
+	assert.JSONEq(t, `{"key":"value"}`, string(dlqMessages[0].Value))
+
+	errHeader, ok := headerValue(dlqMessages[0].Headers, HeaderDLQError)
+	assert.True(t, ok)
+	assert.Equal(t, "broker unavailable", errHeader)
+
+	source, ok := headerValue(dlqMessages[0].Headers, HeaderDLQSourceTopic)
+	assert.True(t, ok)
+	assert.Equal(t, "email.queue", source)
+
+	tenant, ok := headerValue(dlqMessages[0].Headers, "tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestProducer_Publish_DLQFailureReturnsOriginalError(t *testing.T) {
+	dlq := &Producer{
+		writer: failingWriter(errors.New("dlq down")),
+		logger: logger.Log,
+	}
+
+	producer := &Producer{
+		writer: failingWriter(errors.New("broker unavailable")),
+		logger: logger.Log,
+		dlq:    dlq,
+	}
+
+	err := producer.Publish(context.Background(), "notif-1", map[string]interface{}{"key": "value"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to publish message")
+	assert.Contains(t, err.Error(), "broker unavailable")
+}
+
+func TestProducer_PublishBatch_FailureGoesToDLQ(t *testing.T) {
+	var dlqMessages []kafka.Message
+	dlq := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				dlqMessages = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+
+	producer := &Producer{
+		writer: failingWriter(errors.New("batch write failed")),
+		logger: logger.Log,
+		dlq:    dlq,
+	}
+
+	messages := []Message{
+		{Key: "key-1", Value: map[string]interface{}{"msg": "message 1"}},
+		{Key: "key-2", Value: map[string]interface{}{"msg": "message 2"}},
+	}
+
+	err := producer.PublishBatch(context.Background(), messages)
+	assert.NoError(t, err)
+	require.Len(t, dlqMessages, 2)
+	assert.Equal(t, "key-1", string(dlqMessages[0].Key))
+	assert.Equal(t, "key-2", string(dlqMessages[1].Key))
+}
+
+func TestProducer_Publish_MarshalErrorSkipsDLQ(t *testing.T) {
+	dlqCalled := false
+	dlq := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				dlqCalled = true
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+
+	producer := &Producer{
+		writer: &mockWriter{},
+		logger: logger.Log,
+		dlq:    dlq,
+	}
+
+	err := producer.Publish(context.Background(), "notif-1", make(chan int))
+	assert.Error(t, err)
+	assert.False(t, dlqCalled)
+}
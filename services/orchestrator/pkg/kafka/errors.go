@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Publish failure classes. Publish and PublishBatch wrap the underlying
+// error so that errors.Is matches one of these while errors.As still finds
+// the original kafka-go or net error. Errors that fit none of them, e.g.
+// a cancelled context or ErrCircuitOpen, are wrapped unclassified.
+var (
+	// ErrMarshal means the value could not be serialized; retrying the same
+	// value will not help
+	ErrMarshal = errors.New("kafka: marshal failed")
+	// ErrWriteTimeout means the broker did not acknowledge the write in time
+	ErrWriteTimeout = errors.New("kafka: write timed out")
+	// ErrBrokerUnavailable means no usable broker or partition leader could
+	// be reached
+	ErrBrokerUnavailable = errors.New("kafka: broker unavailable")
+	// ErrAuth means the broker rejected the producer's credentials or
+	// permissions
+	ErrAuth = errors.New("kafka: authentication failed")
+)
+
+// publishError attaches a failure class to an error without changing its
+// message
+type publishError struct {
+	class error
+	err   error
+}
+
+func (e *publishError) Error() string { return e.err.Error() }
+
+func (e *publishError) Unwrap() []error { return []error{e.class, e.err} }
+
+// classify wraps err with its failure class, if it has one
+func classify(class, err error) error {
+	if err == nil || class == nil {
+		return err
+	}
+	return &publishError{class: class, err: err}
+}
+
+// classifyWriteError wraps a writer error with the class it belongs to
+func classifyWriteError(err error) error {
+	return classify(writeErrorClass(err), err)
+}
+
+// writeErrorClass maps a writer error to ErrWriteTimeout, ErrBrokerUnavailable
+// or ErrAuth, or nil when it fits none of them
+func writeErrorClass(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrWriteTimeout
+	}
+
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		for _, e := range writeErrs {
+			if class := writeErrorClass(e); class != nil {
+				return class
+			}
+		}
+		return nil
+	}
+
+	var kafkaErr kafka.Error
+	if errors.As(err, &kafkaErr) {
+		switch kafkaErr {
+		case kafka.RequestTimedOut:
+			return ErrWriteTimeout
+		case kafka.LeaderNotAvailable, kafka.NotLeaderForPartition, kafka.BrokerNotAvailable,
+			kafka.ReplicaNotAvailable, kafka.NetworkException, kafka.NotEnoughReplicas,
+			kafka.NotEnoughReplicasAfterAppend, kafka.KafkaStorageError:
+			return ErrBrokerUnavailable
+		case kafka.SASLAuthenticationFailed, kafka.UnsupportedSASLMechanism, kafka.IllegalSASLState,
+			kafka.TopicAuthorizationFailed, kafka.GroupAuthorizationFailed, kafka.ClusterAuthorizationFailed,
+			kafka.TransactionalIDAuthorizationFailed, kafka.DelegationTokenAuthorizationFailed:
+			return ErrAuth
+		}
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrWriteTimeout
+		}
+		return ErrBrokerUnavailable
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrBrokerUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutError is a net.Error that reports a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestProducer_Publish_ClassifiesWriteErrors(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	tests := []struct {
+		name     string
+		writeErr error
+		expected error
+	}{
+		{name: "context deadline", writeErr: context.DeadlineExceeded, expected: ErrWriteTimeout},
+		{name: "request timed out", writeErr: kafka.RequestTimedOut, expected: ErrWriteTimeout},
+		{name: "network timeout", writeErr: timeoutError{}, expected: ErrWriteTimeout},
+		{name: "leader not available", writeErr: kafka.LeaderNotAvailable, expected: ErrBrokerUnavailable},
+		{name: "not enough replicas", writeErr: kafka.NotEnoughReplicas, expected: ErrBrokerUnavailable},
+		{name: "connection refused", writeErr: dialErr, expected: ErrBrokerUnavailable},
+		{name: "connection closed", writeErr: io.EOF, expected: ErrBrokerUnavailable},
+		{name: "sasl failed", writeErr: kafka.SASLAuthenticationFailed, expected: ErrAuth},
+		{name: "topic not authorized", writeErr: kafka.TopicAuthorizationFailed, expected: ErrAuth},
+		{name: "per message errors", writeErr: kafka.WriteErrors{nil, kafka.TopicAuthorizationFailed}, expected: ErrAuth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &Producer{
+				writer: &mockWriter{
+					writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+						return tt.writeErr
+					},
+				},
+				logger: logger.Log,
+			}
+
+			err := producer.Publish(context.Background(), "test-key", map[string]interface{}{"key": "value"})
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.expected), "expected %v, got %v", tt.expected, err)
+			// The original error stays reachable
+			assert.True(t, errors.Is(err, tt.writeErr) || errors.As(err, new(kafka.WriteErrors)))
+			assert.Contains(t, err.Error(), "failed to publish message")
+		})
+	}
+}
+
+func TestProducer_Publish_UnclassifiedWriteError(t *testing.T) {
+	for _, writeErr := range []error{context.Canceled, kafka.UnknownTopicOrPartition, errors.New("boom")} {
+		producer := &Producer{
+			writer: &mockWriter{
+				writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+					return writeErr
+				},
+			},
+			logger: logger.Log,
+		}
+
+		err := producer.Publish(context.Background(), "test-key", "value")
+
+		require.Error(t, err)
+		for _, class := range []error{ErrMarshal, ErrWriteTimeout, ErrBrokerUnavailable, ErrAuth} {
+			assert.False(t, errors.Is(err, class), "%v should not match %v", writeErr, class)
+		}
+		assert.True(t, errors.Is(err, writeErr))
+	}
+}
+
+func TestProducer_PublishBatch_ClassifiesErrors(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				return kafka.BrokerNotAvailable
+			},
+		},
+		logger: logger.Log,
+	}
+
+	err := producer.PublishBatch(context.Background(), []Message{{Key: "a", Value: "one"}})
+	assert.True(t, errors.Is(err, ErrBrokerUnavailable))
+	var kafkaErr kafka.Error
+	require.True(t, errors.As(err, &kafkaErr))
+	assert.Equal(t, kafka.BrokerNotAvailable, kafkaErr)
+
+	err = producer.PublishBatch(context.Background(), []Message{{Key: "a", Value: make(chan int)}})
+	assert.True(t, errors.Is(err, ErrMarshal))
+	assert.Contains(t, err.Error(), "failed to marshal batch message at index 0")
+}
+
+func TestProducer_Publish_MarshalErrorIsTyped(t *testing.T) {
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log}
+
+	err := producer.Publish(context.Background(), "test-key", make(chan int))
+
+	assert.True(t, errors.Is(err, ErrMarshal))
+	assert.False(t, errors.Is(err, ErrBrokerUnavailable))
+}
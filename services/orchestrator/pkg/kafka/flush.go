@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+)
+
+// inflightTracker counts async messages handed to the writer that have not
+// been reported back through the completion callback yet
+type inflightTracker struct {
+	mu      sync.Mutex
+	count   int
+	waiters []chan struct{}
+}
+
+func (t *inflightTracker) add(n int) {
+	t.mu.Lock()
+	t.count += n
+	t.mu.Unlock()
+}
+
+func (t *inflightTracker) done(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count -= n
+	if t.count <= 0 {
+		t.count = 0
+		for _, ch := range t.waiters {
+			close(ch)
+		}
+		t.waiters = nil
+	}
+}
+
+func (t *inflightTracker) pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count
+}
+
+// wait blocks until no messages are in flight or ctx is done
+func (t *inflightTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.count == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	t.waiters = append(t.waiters, ch)
+	t.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush blocks until every message enqueued in async mode has been delivered
+// (successfully or not) or ctx expires. Unlike Close, the producer remains
+// usable afterwards, which lets a rolling restart drain without tearing down
+// the writer. In sync mode there is never anything buffered and Flush returns
+// immediately.
+func (p *Producer) Flush(ctx context.Context) error {
+	if !p.async {
+		return nil
+	}
+	return p.inflight.wait(ctx)
+}
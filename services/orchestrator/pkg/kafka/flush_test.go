@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferingWriter holds async messages until release is called, like the
+// kafka-go writer waiting for its batch timeout
+type bufferingWriter struct {
+	mockWriter
+	producer *Producer
+
+	mu        sync.Mutex
+	buffered  []kafka.Message
+	delivered []kafka.Message
+}
+
+func (w *bufferingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffered = append(w.buffered, msgs...)
+	return nil
+}
+
+func (w *bufferingWriter) release() {
+	w.mu.Lock()
+	msgs := w.buffered
+	w.buffered = nil
+	w.delivered = append(w.delivered, msgs...)
+	w.mu.Unlock()
+
+	w.producer.handleCompletion(msgs, nil)
+}
+
+func (w *bufferingWriter) deliveredCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.delivered)
+}
+
+func TestProducer_Flush_WaitsForPendingMessages(t *testing.T) {
+	producer := &Producer{logger: logger.Log, async: true}
+	writer := &bufferingWriter{producer: producer}
+	producer.writer = writer
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, producer.Publish(context.Background(), "key", map[string]interface{}{"i": i}))
+	}
+	assert.Equal(t, 3, producer.inflight.pending())
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		writer.release()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := producer.Flush(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, writer.deliveredCount())
+	assert.Equal(t, 0, producer.inflight.pending())
+
+	// The producer keeps working after a flush
+	require.NoError(t, producer.Publish(context.Background(), "key", map[string]interface{}{"i": 3}))
+	writer.release()
+	assert.NoError(t, producer.Flush(ctx))
+	assert.Equal(t, 4, writer.deliveredCount())
+}
+
+func TestProducer_Flush_ContextExpires(t *testing.T) {
+	producer := &Producer{logger: logger.Log, async: true}
+	producer.writer = &bufferingWriter{producer: producer}
+
+	require.NoError(t, producer.Publish(context.Background(), "key", map[string]interface{}{"i": 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := producer.Flush(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestProducer_Flush_Sync(t *testing.T) {
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log}
+
+	require.NoError(t, producer.Publish(context.Background(), "key", map[string]interface{}{"i": 1}))
+	assert.NoError(t, producer.Flush(context.Background()))
+}
@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const defaultHealthCheckTimeout = 5 * time.Second
+
+// HealthCheck dials the configured brokers with the producer's SASL/TLS
+// dialer and requests cluster metadata, returning nil as soon as one broker
+// answers. Without a deadline on ctx the check gives up after 5 seconds, so
+// it is safe to call from a readiness probe.
+func (p *Producer) HealthCheck(ctx context.Context) error {
+	if len(p.brokers) == 0 {
+		return fmt.Errorf("kafka health check: no brokers configured")
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultHealthCheckTimeout)
+		defer cancel()
+	}
+
+	dialer := p.dialer
+	if dialer == nil {
+		dialer = &kafka.Dialer{DualStack: true}
+	}
+
+	var errs []error
+	for _, broker := range p.brokers {
+		err := checkBroker(ctx, dialer, broker)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", broker, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return fmt.Errorf("kafka health check failed: %w", errors.Join(errs...))
+}
+
+func checkBroker(ctx context.Context, dialer *kafka.Dialer, broker string) error {
+	conn, err := dialer.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	_, err = conn.Brokers()
+	return err
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachableBroker returns an address nothing is listening on
+func unreachableBroker(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
+
+func TestHealthCheck_UnreachableBroker(t *testing.T) {
+	broker := unreachableBroker(t)
+	producer := NewProducer(ProducerConfig{
+		Brokers: []string{broker},
+		Topic:   "test-topic",
+		Logger:  logger.Log,
+	})
+	defer producer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := producer.HealthCheck(ctx)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), broker)
+	assert.Less(t, time.Since(start), 2*time.Second+500*time.Millisecond)
+}
+
+func TestHealthCheck_HonoursContextDeadline(t *testing.T) {
+	// A listener that accepts but never answers keeps the metadata request
+	// hanging until the deadline.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	producer := &Producer{
+		writer:  &mockWriter{},
+		logger:  logger.Log,
+		brokers: []string{ln.Addr().String()},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = producer.HealthCheck(ctx)
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestHealthCheck_NoBrokers(t *testing.T) {
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log}
+
+	err := producer.HealthCheck(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no brokers configured")
+}
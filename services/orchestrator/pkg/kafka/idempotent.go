@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"go.uber.org/zap"
+)
+
+// HeaderIdempotencyKey carries the caller's idempotency key so consumers can
+// drop repeated deliveries
+const HeaderIdempotencyKey = "X-Idempotency-Key"
+
+// defaultDedupCapacity bounds the number of keys remembered for DedupWindow
+const defaultDedupCapacity = 10000
+
+// dedupCache is a TTL-bounded LRU of recently published idempotency keys
+type dedupCache struct {
+	mu       sync.Mutex
+	window   time.Duration
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	now      func() time.Time
+
+	suppressed atomic.Int64
+}
+
+type dedupEntry struct {
+	key    string
+	seenAt time.Time
+}
+
+// newDedupCache returns a cache expiring keys by clk, or nil when window is
+// not positive
+func newDedupCache(window time.Duration, capacity int, clk clock.Clock) *dedupCache {
+	if window <= 0 {
+		return nil
+	}
+	return &dedupCache{
+		window:   window,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      clock.OrReal(clk).Now,
+	}
+}
+
+// seen reports whether key was recorded within the window, dropping it if
+// it has expired
+func (c *dedupCache) seen(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if c.now().Sub(el.Value.(*dedupEntry).seenAt) >= c.window {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return false
+	}
+	return true
+}
+
+// record remembers key as published now, evicting the least recently
+// published key when the cache is full
+func (c *dedupCache) record(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*dedupEntry).seenAt = c.now()
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&dedupEntry{key: key, seenAt: c.now()})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dedupEntry).key)
+	}
+}
+
+// PublishIdempotent publishes value with idempotencyKey stamped in the
+// X-Idempotency-Key header. When ProducerConfig.DedupWindow is set, a repeat
+// of a key already published within the window is skipped and counted in
+// DuplicatesSuppressed instead of being written again.
+func (p *Producer) PublishIdempotent(ctx context.Context, key string, value interface{}, idempotencyKey string) error {
+	if idempotencyKey == "" {
+		return fmt.Errorf("idempotency key is required")
+	}
+
+	if p.dedup != nil && p.dedup.seen(idempotencyKey) {
+		p.dedup.suppressed.Add(1)
+		if p.logger != nil {
+			p.logger.Debug("Skipping duplicate publish",
+				zap.String("topic", p.topic),
+				zap.String("key", key),
+				zap.String("idempotency_key", idempotencyKey),
+			)
+		}
+		return nil
+	}
+
+	headers := map[string]string{HeaderIdempotencyKey: idempotencyKey}
+	if err := p.PublishWithHeaders(ctx, key, value, headers); err != nil {
+		return err
+	}
+
+	if p.dedup != nil {
+		p.dedup.record(idempotencyKey)
+	}
+	return nil
+}
+
+// DuplicatesSuppressed returns how many PublishIdempotent calls were skipped
+// because their key was seen within the dedup window
+func (p *Producer) DuplicatesSuppressed() int64 {
+	if p.dedup == nil {
+		return 0
+	}
+	return p.dedup.suppressed.Load()
+}
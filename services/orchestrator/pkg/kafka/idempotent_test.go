@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDedupProducer(window time.Duration, written *[]kafka.Message) *Producer {
+	return &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				*written = append(*written, msgs...)
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "test-topic",
+		dedup:  newDedupCache(window, defaultDedupCapacity, nil),
+	}
+}
+
+func TestPublishIdempotent_StampsHeader(t *testing.T) {
+	var written []kafka.Message
+	producer := newDedupProducer(0, &written)
+
+	err := producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123")
+
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	value, ok := headerValue(written[0].Headers, HeaderIdempotencyKey)
+	require.True(t, ok)
+	assert.Equal(t, "req-123", value)
+}
+
+func TestPublishIdempotent_WithoutWindowPublishesRepeats(t *testing.T) {
+	var written []kafka.Message
+	producer := newDedupProducer(0, &written)
+
+	require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+	require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+
+	assert.Len(t, written, 2)
+	assert.Equal(t, int64(0), producer.DuplicatesSuppressed())
+}
+
+func TestPublishIdempotent_SkipsDuplicatesWithinWindow(t *testing.T) {
+	var written []kafka.Message
+	producer := newDedupProducer(time.Minute, &written)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+	}
+	require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-456"))
+
+	assert.Len(t, written, 2)
+	assert.Equal(t, int64(2), producer.DuplicatesSuppressed())
+}
+
+func TestPublishIdempotent_PublishesAgainAfterWindow(t *testing.T) {
+	var written []kafka.Message
+	producer := newDedupProducer(time.Minute, &written)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	producer.dedup.now = func() time.Time { return now }
+
+	require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+	now = now.Add(time.Minute)
+	require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+
+	assert.Len(t, written, 2)
+	assert.Equal(t, int64(0), producer.DuplicatesSuppressed())
+}
+
+func TestPublishIdempotent_FailedPublishIsNotRemembered(t *testing.T) {
+	var written []kafka.Message
+	fail := true
+	producer := newDedupProducer(time.Minute, &written)
+	producer.writer = &mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			if fail {
+				return errors.New("broker down")
+			}
+			written = append(written, msgs...)
+			return nil
+		},
+	}
+
+	require.Error(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+	fail = false
+	require.NoError(t, producer.PublishIdempotent(context.Background(), "user-1", "payload", "req-123"))
+
+	assert.Len(t, written, 1)
+	assert.Equal(t, int64(0), producer.DuplicatesSuppressed())
+}
+
+func TestPublishIdempotent_RequiresKey(t *testing.T) {
+	var written []kafka.Message
+	producer := newDedupProducer(time.Minute, &written)
+
+	err := producer.PublishIdempotent(context.Background(), "user-1", "payload", "")
+
+	assert.Error(t, err)
+	assert.Empty(t, written)
+}
+
+func TestDedupCache_EvictsLeastRecentlyPublished(t *testing.T) {
+	cache := newDedupCache(time.Minute, 2, nil)
+
+	cache.record("a")
+	cache.record("b")
+	cache.record("a")
+	cache.record("c")
+
+	assert.True(t, cache.seen("a"))
+	assert.False(t, cache.seen("b"))
+	assert.True(t, cache.seen("c"))
+}
+
+func TestDedupCache_ExpiresByClock(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	producer := NewProducer(ProducerConfig{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "test-topic",
+		Logger:      logger.Log,
+		DedupWindow: time.Minute,
+		Clock:       clk,
+	})
+	cache := producer.dedup
+
+	cache.record("a")
+	clk.Advance(59 * time.Second)
+	assert.True(t, cache.seen("a"))
+	clk.Advance(time.Second)
+	assert.False(t, cache.seen("a"))
+}
+
+func TestNewProducer_DedupWindow(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "test-topic",
+		Logger:      logger.Log,
+		DedupWindow: time.Minute,
+	})
+	require.NotNil(t, producer.dedup)
+
+	_, err := NewProducerWithError(ProducerConfig{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "test-topic",
+		DedupWindow: -time.Second,
+	})
+	assert.Error(t, err)
+}
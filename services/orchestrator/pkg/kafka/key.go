@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrInvalidKey is returned, before anything is written, for message keys
+// that are not valid UTF-8 and, with ProducerConfig.RejectEmptyKeys, for
+// empty keys
+var ErrInvalidKey = errors.New("kafka: invalid message key")
+
+// hashedKeyBytes is the length of a key hashed by sanitizeKey
+const hashedKeyBytes = sha256.Size * 2
+
+// sanitizeKey checks key against the producer's key rules and returns the
+// key to write. Keys over maxKeyBytes are replaced by their hex SHA-256
+// digest, so a key always maps to the same hashed key and partition.
+func (p *Producer) sanitizeKey(key string) (string, error) {
+	if key == "" {
+		if p.rejectEmptyKeys {
+			return "", fmt.Errorf("%w: key is empty", ErrInvalidKey)
+		}
+		return key, nil
+	}
+	if !utf8.ValidString(key) {
+		return "", fmt.Errorf("%w: key %q is not valid UTF-8", ErrInvalidKey, key)
+	}
+	if p.maxKeyBytes > 0 && len(key) > p.maxKeyBytes {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return key, nil
+}
@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKeyedProducer returns a producer with the given key rules that records
+// the keys it writes
+func newKeyedProducer(rejectEmpty bool, maxKeyBytes int, keys *[]string) *Producer {
+	return &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				for _, msg := range msgs {
+					*keys = append(*keys, string(msg.Key))
+				}
+				return nil
+			},
+		},
+		logger:          logger.Log,
+		topic:           "test-topic",
+		rejectEmptyKeys: rejectEmpty,
+		maxKeyBytes:     maxKeyBytes,
+	}
+}
+
+func TestPublish_ValidKeyUnchanged(t *testing.T) {
+	var keys []string
+	producer := newKeyedProducer(true, 64, &keys)
+
+	require.NoError(t, producer.Publish(context.Background(), "user-1", "value"))
+
+	assert.Equal(t, []string{"user-1"}, keys)
+}
+
+func TestPublish_EmptyKey(t *testing.T) {
+	var keys []string
+	producer := newKeyedProducer(true, 0, &keys)
+
+	err := producer.Publish(context.Background(), "", "value")
+
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	assert.Contains(t, err.Error(), "key is empty")
+	assert.Empty(t, keys)
+
+	// Allowed unless RejectEmptyKeys is set
+	producer.rejectEmptyKeys = false
+	assert.NoError(t, producer.Publish(context.Background(), "", "value"))
+}
+
+func TestPublish_InvalidUTF8KeyRejected(t *testing.T) {
+	var keys []string
+	producer := newKeyedProducer(false, 0, &keys)
+
+	err := producer.Publish(context.Background(), "user-\xff", "value")
+
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	assert.Empty(t, keys)
+}
+
+func TestPublish_OversizedKeyHashed(t *testing.T) {
+	var keys []string
+	producer := newKeyedProducer(false, 64, &keys)
+	long := strings.Repeat("k", 100)
+
+	require.NoError(t, producer.Publish(context.Background(), long, "value"))
+	require.NoError(t, producer.Publish(context.Background(), long, "value"))
+	require.NoError(t, producer.Publish(context.Background(), long+"x", "value"))
+
+	require.Len(t, keys, 3)
+	assert.Len(t, keys[0], 64)
+	assert.Equal(t, keys[0], keys[1], "the same key must hash to the same key")
+	assert.NotEqual(t, keys[0], keys[2])
+}
+
+func TestPublishBatch_InvalidKey(t *testing.T) {
+	var keys []string
+	producer := newKeyedProducer(true, 64, &keys)
+
+	err := producer.PublishBatch(context.Background(), []Message{{Key: "user-1", Value: "one"}, {Value: "two"}})
+
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	assert.Contains(t, err.Error(), "batch message at index 1")
+	assert.Empty(t, keys)
+
+	require.NoError(t, producer.PublishBatch(context.Background(), []Message{{Key: strings.Repeat("k", 65), Value: "one"}}))
+	assert.Len(t, keys[0], 64)
+}
+
+func TestProducerConfig_ValidateMaxKeyBytes(t *testing.T) {
+	for _, limit := range []int{-1, 1, 63} {
+		_, err := NewProducerWithError(ProducerConfig{Brokers: []string{"localhost:9092"}, MaxKeyBytes: limit})
+		assert.Error(t, err, "limit %d", limit)
+	}
+	for _, limit := range []int{0, 64, 256} {
+		_, err := NewProducerWithError(ProducerConfig{Brokers: []string{"localhost:9092"}, MaxKeyBytes: limit})
+		assert.NoError(t, err, "limit %d", limit)
+	}
+}
@@ -2,77 +2,210 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 )
 
 // ProducerInterface defines the interface for Kafka producers
 type ProducerInterface interface {
-	Publish(ctx context.Context, key string, value interface{}) error
-	PublishBatch(ctx context.Context, messages []Message) error
-	Close() error
-	Stats() kafka.WriterStats
+	Publisher
+	PublishWithHeaders(ctx context.Context, key string, value interface{}, headers map[string]string) error
+	Flush(ctx context.Context) error
+}
+
+// flusher is implemented by publishers that buffer messages
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// healthChecker is implemented by publishers that can reach brokers
+type healthChecker interface {
+	HealthCheck(ctx context.Context) error
 }
 
 // Manager handles multiple Kafka producers for different topics
 type Manager struct {
-	emailProducer ProducerInterface
-	pushProducer  ProducerInterface
+	emailProducer Publisher
+	pushProducer  Publisher
 	logger        *zap.Logger
+
+	// emailPriority and pushPriority receive elevated notifications; nil
+	// keeps them on the regular producers
+	emailPriority Publisher
+	pushPriority  Publisher
 }
 
 type ManagerConfig struct {
-	Brokers    []string
-	EmailTopic string
-	PushTopic  string
-	Logger     *zap.Logger
-	Username   string
-	Password   string
-	UseTLS     bool
+	Brokers     []string
+	EmailTopic  string
+	PushTopic   string
+	Logger      *zap.Logger
+	Username    string
+	Password    string
+	UseTLS      bool
+	Compression string
+
+	SASLMechanism string
+	Balancer      Balancer
+
+	// BatchSize, BatchBytes and BatchTimeout are passed to both producers;
+	// see ProducerConfig
+	BatchSize    int
+	BatchBytes   int64
+	BatchTimeout time.Duration
+
+	// LogValues makes the producers log each value, masked with
+	// DefaultRedactor, at debug level
+	LogValues bool
+
+	// EmailPriorityTopic and PushPriorityTopic receive notifications
+	// published with a high or critical WithPriority hint, so consumers can
+	// serve them ahead of bulk traffic. Empty keeps every notification on
+	// EmailTopic and PushTopic.
+	EmailPriorityTopic string
+	PushPriorityTopic  string
+
+	// DryRun replaces both producers with NoopProducers that only log what
+	// would be published. No brokers are contacted or required.
+	DryRun bool
+
+	// Bus, when set, replaces every producer with an InMemoryPublisher on
+	// the bus, so notifications reach in-process subscribers of the
+	// configured topics without a broker. It takes precedence over DryRun.
+	Bus *InMemoryBus
 }
 
 func NewManager(cfg ManagerConfig) (*Manager, error) {
+	if cfg.Bus != nil {
+		manager := NewManagerWithPublishers(cfg.Bus.Publisher(cfg.EmailTopic), cfg.Bus.Publisher(cfg.PushTopic), cfg.Logger)
+		var emailPriority, pushPriority Publisher
+		if cfg.EmailPriorityTopic != "" {
+			emailPriority = cfg.Bus.Publisher(cfg.EmailPriorityTopic)
+		}
+		if cfg.PushPriorityTopic != "" {
+			pushPriority = cfg.Bus.Publisher(cfg.PushPriorityTopic)
+		}
+		manager.SetPriorityPublishers(emailPriority, pushPriority)
+		return manager, nil
+	}
+	if cfg.DryRun {
+		manager := NewManagerWithPublishers(
+			NewNoopProducer(cfg.Logger.With(zap.String("topic", cfg.EmailTopic))),
+			NewNoopProducer(cfg.Logger.With(zap.String("topic", cfg.PushTopic))),
+			cfg.Logger,
+		)
+		var emailPriority, pushPriority Publisher
+		if cfg.EmailPriorityTopic != "" {
+			emailPriority = NewNoopProducer(cfg.Logger.With(zap.String("topic", cfg.EmailPriorityTopic)))
+		}
+		if cfg.PushPriorityTopic != "" {
+			pushPriority = NewNoopProducer(cfg.Logger.With(zap.String("topic", cfg.PushPriorityTopic)))
+		}
+		manager.SetPriorityPublishers(emailPriority, pushPriority)
+		return manager, nil
+	}
 	if len(cfg.Brokers) == 0 {
 		return nil, fmt.Errorf("at least one broker is required")
 	}
 
-	emailProducer := NewProducer(ProducerConfig{
-		Brokers:  cfg.Brokers,
-		Topic:    cfg.EmailTopic,
-		Logger:   cfg.Logger,
-		Username: cfg.Username,
-		Password: cfg.Password,
-		UseTLS:   cfg.UseTLS,
-	})
-
-	pushProducer := NewProducer(ProducerConfig{
-		Brokers:  cfg.Brokers,
-		Topic:    cfg.PushTopic,
-		Logger:   cfg.Logger,
-		Username: cfg.Username,
-		Password: cfg.Password,
-		UseTLS:   cfg.UseTLS,
-	})
+	emailProducer, err := NewProducerWithError(cfg.producerConfig(cfg.EmailTopic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email producer: %w", err)
+	}
+
+	pushProducer, err := NewProducerWithError(cfg.producerConfig(cfg.PushTopic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create push producer: %w", err)
+	}
+
+	manager := NewManagerWithPublishers(emailProducer, pushProducer, cfg.Logger)
+
+	var emailPriority, pushPriority Publisher
+	if cfg.EmailPriorityTopic != "" {
+		if emailPriority, err = NewProducerWithError(cfg.producerConfig(cfg.EmailPriorityTopic)); err != nil {
+			return nil, fmt.Errorf("failed to create email priority producer: %w", err)
+		}
+	}
+	if cfg.PushPriorityTopic != "" {
+		if pushPriority, err = NewProducerWithError(cfg.producerConfig(cfg.PushPriorityTopic)); err != nil {
+			return nil, fmt.Errorf("failed to create push priority producer: %w", err)
+		}
+	}
+	manager.SetPriorityPublishers(emailPriority, pushPriority)
 
+	return manager, nil
+}
+
+// producerConfig returns the settings shared by every producer the manager
+// creates, writing to topic
+func (cfg ManagerConfig) producerConfig(topic string) ProducerConfig {
+	return ProducerConfig{
+		Brokers:     cfg.Brokers,
+		Topic:       topic,
+		Logger:      cfg.Logger,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		UseTLS:      cfg.UseTLS,
+		Compression: cfg.Compression,
+
+		SASLMechanism: cfg.SASLMechanism,
+		Balancer:      cfg.Balancer,
+
+		BatchSize:    cfg.BatchSize,
+		BatchBytes:   cfg.BatchBytes,
+		BatchTimeout: cfg.BatchTimeout,
+
+		LogValues: cfg.LogValues,
+	}
+}
+
+// NewManagerWithPublishers builds a manager around existing publishers, e.g.
+// NoopProducers or test fakes, instead of creating producers from config
+func NewManagerWithPublishers(email, push Publisher, logger *zap.Logger) *Manager {
 	return &Manager{
-		emailProducer: emailProducer,
-		pushProducer:  pushProducer,
-		logger:        cfg.Logger,
-	}, nil
+		emailProducer: email,
+		pushProducer:  push,
+		logger:        logger,
+	}
 }
 
-// PublishEmail publishes a message to the email queue
+// SetPriorityPublishers sends elevated notifications to email and push
+// instead of the regular producers. A nil publisher keeps that channel's
+// elevated notifications on its regular producer.
+func (m *Manager) SetPriorityPublishers(email, push Publisher) {
+	m.emailPriority = email
+	m.pushPriority = push
+}
+
+// PublishEmail publishes a message to the email queue, or to the email
+// priority queue when ctx carries an elevated priority
 func (m *Manager) PublishEmail(ctx context.Context, notificationID string, payload interface{}) error {
+	if m.emailPriority != nil && PriorityFromContext(ctx).Elevated() {
+		m.logger.Info("Publishing to email priority queue",
+			zap.String("notification_id", notificationID),
+			zap.String("priority", string(PriorityFromContext(ctx))),
+		)
+		return m.emailPriority.Publish(ctx, notificationID, payload)
+	}
 	m.logger.Info("Publishing to email queue",
 		zap.String("notification_id", notificationID),
 	)
 	return m.emailProducer.Publish(ctx, notificationID, payload)
 }
 
-// PublishPush publishes a message to the push notification queue
+// PublishPush publishes a message to the push notification queue, or to
+// the push priority queue when ctx carries an elevated priority
 func (m *Manager) PublishPush(ctx context.Context, notificationID string, payload interface{}) error {
+	if m.pushPriority != nil && PriorityFromContext(ctx).Elevated() {
+		m.logger.Info("Publishing to push priority queue",
+			zap.String("notification_id", notificationID),
+			zap.String("priority", string(PriorityFromContext(ctx))),
+		)
+		return m.pushPriority.Publish(ctx, notificationID, payload)
+	}
 	m.logger.Info("Publishing to push queue",
 		zap.String("notification_id", notificationID),
 	)
@@ -91,6 +224,46 @@ func (m *Manager) PublishByType(ctx context.Context, notificationType, notificat
 	}
 }
 
+// Flush waits for messages the producers have buffered in async mode to be
+// delivered, or for ctx to expire. Publishers without a Flush method are
+// skipped.
+func (m *Manager) Flush(ctx context.Context) error {
+	if err := flush(ctx, m.emailProducer); err != nil {
+		return fmt.Errorf("flush email producer: %w", err)
+	}
+	if err := flush(ctx, m.pushProducer); err != nil {
+		return fmt.Errorf("flush push producer: %w", err)
+	}
+	if err := flush(ctx, m.emailPriority); err != nil {
+		return fmt.Errorf("flush email priority producer: %w", err)
+	}
+	if err := flush(ctx, m.pushPriority); err != nil {
+		return fmt.Errorf("flush push priority producer: %w", err)
+	}
+	return nil
+}
+
+func flush(ctx context.Context, p Publisher) error {
+	if f, ok := p.(flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Healthy reports whether every producer is keeping up; see
+// Producer.Healthy. Publishers that cannot tell are assumed healthy.
+func (m *Manager) Healthy() bool {
+	return healthy(m.emailProducer) && healthy(m.pushProducer) &&
+		healthy(m.emailPriority) && healthy(m.pushPriority)
+}
+
+func healthy(p Publisher) bool {
+	if h, ok := p.(healthReporter); ok {
+		return h.Healthy()
+	}
+	return true
+}
+
 // Close closes all producers
 func (m *Manager) Close() error {
 	m.logger.Info("Closing Kafka manager")
@@ -110,11 +283,33 @@ func (m *Manager) Close() error {
 		}
 	}
 
+	if m.emailPriority != nil {
+		if err := m.emailPriority.Close(); err != nil {
+			m.logger.Error("Failed to close email priority producer", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if m.pushPriority != nil {
+		if err := m.pushPriority.Close(); err != nil {
+			m.logger.Error("Failed to close push priority producer", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
 	return firstErr
 }
 
-// HealthCheck verifies connectivity to Kafka brokers
-func (m *Manager) HealthCheck() error {
+// HealthCheck verifies connectivity to Kafka brokers by running the
+// HealthCheck of every producer, including the priority ones, and joins the
+// errors of those that fail. Publishers without a HealthCheck method, such
+// as NoopProducer, are skipped. Producers whose stats show write errors are
+// logged but not reported as failing.
+func (m *Manager) HealthCheck(ctx context.Context) error {
 	// Check email producer stats
 	emailStats := m.emailProducer.Stats()
 	if emailStats.Errors > 0 {
@@ -131,5 +326,25 @@ func (m *Manager) HealthCheck() error {
 		)
 	}
 
+	var errs []error
+	if err := healthCheck(ctx, m.emailProducer); err != nil {
+		errs = append(errs, fmt.Errorf("email producer: %w", err))
+	}
+	if err := healthCheck(ctx, m.pushProducer); err != nil {
+		errs = append(errs, fmt.Errorf("push producer: %w", err))
+	}
+	if err := healthCheck(ctx, m.emailPriority); err != nil {
+		errs = append(errs, fmt.Errorf("email priority producer: %w", err))
+	}
+	if err := healthCheck(ctx, m.pushPriority); err != nil {
+		errs = append(errs, fmt.Errorf("push priority producer: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func healthCheck(ctx context.Context, p Publisher) error {
+	if h, ok := p.(healthChecker); ok {
+		return h.HealthCheck(ctx)
+	}
 	return nil
 }
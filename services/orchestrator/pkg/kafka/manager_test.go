@@ -5,11 +5,13 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestMain initializes the logger before running tests
@@ -32,11 +34,21 @@ func (m *MockProducer) Publish(ctx context.Context, key string, value interface{
 	return args.Error(0)
 }
 
+func (m *MockProducer) PublishWithHeaders(ctx context.Context, key string, value interface{}, headers map[string]string) error {
+	args := m.Called(ctx, key, value, headers)
+	return args.Error(0)
+}
+
 func (m *MockProducer) PublishBatch(ctx context.Context, messages []Message) error {
 	args := m.Called(ctx, messages)
 	return args.Error(0)
 }
 
+func (m *MockProducer) Flush(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 func (m *MockProducer) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -111,6 +123,51 @@ func TestNewManager_EmptyBrokers(t *testing.T) {
 	assert.Contains(t, err.Error(), "at least one broker is required")
 }
 
+func TestNewManager_DryRun(t *testing.T) {
+	manager, err := NewManager(ManagerConfig{
+		EmailTopic: "email.queue",
+		PushTopic:  "push.queue",
+		Logger:     logger.Log,
+		DryRun:     true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.PublishByType(context.Background(), "email", "notif-1", map[string]string{"to": "a@example.com"}))
+	require.NoError(t, manager.PublishByType(context.Background(), "push", "notif-2", map[string]string{"title": "Hi"}))
+
+	email := manager.emailProducer.(*NoopProducer)
+	push := manager.pushProducer.(*NoopProducer)
+	if assert.Len(t, email.Sent(), 1) {
+		assert.Equal(t, "notif-1", email.Sent()[0].Key)
+	}
+	if assert.Len(t, push.Sent(), 1) {
+		assert.Equal(t, "notif-2", push.Sent()[0].Key)
+	}
+	assert.NoError(t, manager.Close())
+}
+
+func TestNewManager_DryRunOnePriorityTopic(t *testing.T) {
+	manager, err := NewManager(ManagerConfig{
+		EmailTopic:         "email.queue",
+		PushTopic:          "push.queue",
+		EmailPriorityTopic: "email.priority",
+		Logger:             logger.Log,
+		DryRun:             true,
+	})
+	require.NoError(t, err)
+
+	ctx := WithPriority(context.Background(), PriorityHigh)
+	require.NoError(t, manager.PublishByType(ctx, "email", "notif-1", map[string]string{"to": "a@example.com"}))
+	require.NoError(t, manager.PublishByType(ctx, "push", "notif-2", map[string]string{"title": "Hi"}))
+
+	// Push has no priority topic, so its priority traffic stays on the
+	// normal topic
+	assert.Nil(t, manager.pushPriority)
+	assert.Len(t, manager.emailPriority.(*NoopProducer).Sent(), 1)
+	assert.Empty(t, manager.emailProducer.(*NoopProducer).Sent())
+	assert.Len(t, manager.pushProducer.(*NoopProducer).Sent(), 1)
+}
+
 func TestManager_PublishEmail_Success(t *testing.T) {
 	mockEmailProducer := new(MockProducer)
 	mockPushProducer := new(MockProducer)
@@ -230,6 +287,41 @@ func TestManager_PublishByType_Email(t *testing.T) {
 	mockPushProducer.AssertNotCalled(t, "Publish")
 }
 
+func TestManager_PublishByType_PriorityTopic(t *testing.T) {
+	email, push := new(MockProducer), new(MockProducer)
+	emailPriority, pushPriority := new(MockProducer), new(MockProducer)
+	manager := &Manager{emailProducer: email, pushProducer: push, logger: logger.Log}
+	manager.SetPriorityPublishers(emailPriority, pushPriority)
+
+	critical := WithPriority(context.Background(), PriorityCritical)
+	normal := WithPriority(context.Background(), PriorityNormal)
+	emailPriority.On("Publish", critical, "notif-otp", "otp").Return(nil)
+	pushPriority.On("Publish", critical, "notif-alert", "alert").Return(nil)
+	email.On("Publish", normal, "notif-news", "news").Return(nil)
+	email.On("Publish", context.Background(), "notif-plain", "plain").Return(nil)
+
+	require.NoError(t, manager.PublishByType(critical, "email", "notif-otp", "otp"))
+	require.NoError(t, manager.PublishByType(critical, "push", "notif-alert", "alert"))
+	require.NoError(t, manager.PublishByType(normal, "email", "notif-news", "news"))
+	require.NoError(t, manager.PublishByType(context.Background(), "email", "notif-plain", "plain"))
+
+	emailPriority.AssertExpectations(t)
+	pushPriority.AssertExpectations(t)
+	email.AssertExpectations(t)
+	push.AssertNotCalled(t, "Publish")
+}
+
+func TestManager_PublishByType_PriorityWithoutTopic(t *testing.T) {
+	email, push := new(MockProducer), new(MockProducer)
+	manager := &Manager{emailProducer: email, pushProducer: push, logger: logger.Log}
+
+	high := WithPriority(context.Background(), PriorityHigh)
+	email.On("Publish", high, "notif-1", "payload").Return(nil)
+
+	require.NoError(t, manager.PublishByType(high, "email", "notif-1", "payload"))
+	email.AssertExpectations(t)
+}
+
 func TestManager_PublishByType_Push(t *testing.T) {
 	mockEmailProducer := new(MockProducer)
 	mockPushProducer := new(MockProducer)
@@ -406,7 +498,7 @@ func TestManager_HealthCheck_Success(t *testing.T) {
 	mockEmailProducer.On("Stats").Return(emailStats)
 	mockPushProducer.On("Stats").Return(pushStats)
 
-	err := manager.HealthCheck()
+	err := manager.HealthCheck(context.Background())
 
 	assert.NoError(t, err)
 	mockEmailProducer.AssertExpectations(t)
@@ -437,7 +529,7 @@ func TestManager_HealthCheck_EmailProducerHasErrors(t *testing.T) {
 
 	// HealthCheck should not return error even if stats show errors
 	// It only logs warnings
-	err := manager.HealthCheck()
+	err := manager.HealthCheck(context.Background())
 
 	assert.NoError(t, err)
 	mockEmailProducer.AssertExpectations(t)
@@ -466,7 +558,7 @@ func TestManager_HealthCheck_PushProducerHasErrors(t *testing.T) {
 	mockEmailProducer.On("Stats").Return(emailStats)
 	mockPushProducer.On("Stats").Return(pushStats)
 
-	err := manager.HealthCheck()
+	err := manager.HealthCheck(context.Background())
 
 	assert.NoError(t, err)
 	mockEmailProducer.AssertExpectations(t)
@@ -495,13 +587,66 @@ func TestManager_HealthCheck_BothProducersHaveErrors(t *testing.T) {
 	mockEmailProducer.On("Stats").Return(emailStats)
 	mockPushProducer.On("Stats").Return(pushStats)
 
-	err := manager.HealthCheck()
+	err := manager.HealthCheck(context.Background())
 
 	assert.NoError(t, err)
 	mockEmailProducer.AssertExpectations(t)
 	mockPushProducer.AssertExpectations(t)
 }
 
+// checkedProducer is a MockProducer whose broker check returns err
+type checkedProducer struct {
+	*MockProducer
+	err error
+}
+
+func (p *checkedProducer) HealthCheck(ctx context.Context) error {
+	return p.err
+}
+
+func TestManager_HealthCheck_JoinsProducerErrors(t *testing.T) {
+	quiet := new(MockProducer)
+	quiet.On("Stats").Return(kafka.WriterStats{})
+	emailErr := errors.New("email broker unreachable")
+	pushPriorityErr := errors.New("push priority broker unreachable")
+
+	manager := &Manager{
+		emailProducer: &checkedProducer{MockProducer: quiet, err: emailErr},
+		pushProducer:  &checkedProducer{MockProducer: quiet},
+		emailPriority: NewNoopProducer(logger.Log),
+		pushPriority:  &checkedProducer{MockProducer: quiet, err: pushPriorityErr},
+		logger:        logger.Log,
+	}
+
+	err := manager.HealthCheck(context.Background())
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, emailErr)
+	assert.ErrorIs(t, err, pushPriorityErr)
+	assert.Contains(t, err.Error(), "email producer: email broker unreachable")
+	assert.Contains(t, err.Error(), "push priority producer: push priority broker unreachable")
+	assert.NotContains(t, err.Error(), "push producer:")
+}
+
+func TestManager_HealthCheck_UnreachableBrokers(t *testing.T) {
+	manager, err := NewManager(ManagerConfig{
+		Brokers:    []string{"127.0.0.1:1"},
+		EmailTopic: "email.queue",
+		PushTopic:  "push.queue",
+		Logger:     logger.Log,
+	})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = manager.HealthCheck(ctx)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "email producer:")
+	assert.Contains(t, err.Error(), "push producer:")
+}
+
 func TestManager_PublishEmail_ContextCancellation(t *testing.T) {
 	mockEmailProducer := new(MockProducer)
 	mockPushProducer := new(MockProducer)
@@ -551,3 +696,34 @@ func TestManager_PublishByType_CaseSensitive(t *testing.T) {
 	mockEmailProducer.AssertNotCalled(t, "Publish")
 	mockPushProducer.AssertNotCalled(t, "Publish")
 }
+
+func TestNewManager_InvalidCompression(t *testing.T) {
+	cfg := ManagerConfig{
+		Brokers:     []string{"localhost:9092"},
+		EmailTopic:  "email.queue",
+		PushTopic:   "push.queue",
+		Logger:      logger.Log,
+		Compression: "brotli",
+	}
+
+	manager, err := NewManager(cfg)
+
+	assert.Error(t, err)
+	assert.Nil(t, manager)
+}
+
+func TestManager_Flush(t *testing.T) {
+	emailProducer := new(MockProducer)
+	pushProducer := new(MockProducer)
+	manager := &Manager{emailProducer: emailProducer, pushProducer: pushProducer, logger: logger.Log}
+
+	emailProducer.On("Flush", mock.Anything).Return(nil)
+	pushProducer.On("Flush", mock.Anything).Return(errors.New("context deadline exceeded"))
+
+	err := manager.Flush(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "push producer")
+	emailProducer.AssertExpectations(t)
+	pushProducer.AssertExpectations(t)
+}
@@ -0,0 +1,182 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// InMemoryBus delivers messages between in-process publishers and
+// subscribers by topic, standing in for a broker in local development,
+// single-node deployments and hermetic end-to-end tests. Nothing is
+// persisted: messages published while a topic has no subscribers are
+// dropped. It is safe for concurrent use.
+type InMemoryBus struct {
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	subscribers map[string][]HandlerFunc
+	offsets     map[string]int64
+}
+
+// NewInMemoryBus returns a bus without subscribers. logger may be nil.
+func NewInMemoryBus(logger *zap.Logger) *InMemoryBus {
+	return &InMemoryBus{
+		logger:      logger,
+		subscribers: make(map[string][]HandlerFunc),
+		offsets:     make(map[string]int64),
+	}
+}
+
+// Subscribe calls handler with every message later published to topic.
+// Handlers receive messages as a Consumer would: values are the serialized
+// bytes, and headers include the correlation ID and priority from the
+// publishing context. Every subscriber of a topic gets every message.
+func (b *InMemoryBus) Subscribe(topic string, handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[topic] = append(b.subscribers[topic], handler)
+}
+
+// Publisher returns a publisher writing to topic on this bus
+func (b *InMemoryBus) Publisher(topic string) *InMemoryPublisher {
+	return &InMemoryPublisher{bus: b, topic: topic}
+}
+
+// deliver assigns the next offset on msg's topic and passes msg to each of
+// its subscribers in turn. Handlers run without the lock held so they may
+// publish themselves. It returns how many handlers failed.
+func (b *InMemoryBus) deliver(msg Message) int {
+	b.mu.Lock()
+	handlers := b.subscribers[msg.Topic]
+	msg.Offset = b.offsets[msg.Topic]
+	b.offsets[msg.Topic]++
+	b.mu.Unlock()
+
+	if len(handlers) == 0 && b.logger != nil {
+		b.logger.Debug("No in-memory subscribers, message dropped",
+			zap.String("topic", msg.Topic),
+			zap.String("key", msg.Key),
+		)
+	}
+
+	failed := 0
+	for _, handler := range handlers {
+		if err := handler(msg); err != nil {
+			failed++
+			if b.logger != nil {
+				b.logger.Error("In-memory subscriber failed",
+					zap.String("topic", msg.Topic),
+					zap.Int64("offset", msg.Offset),
+					zap.String("key", msg.Key),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+	return failed
+}
+
+// InMemoryPublisher publishes to an InMemoryBus topic. Delivery is
+// synchronous: Publish returns once every subscriber has handled the
+// message. As with Kafka, a failing subscriber does not fail the publish;
+// it is logged and counted in Stats().Errors.
+type InMemoryPublisher struct {
+	bus   *InMemoryBus
+	topic string
+
+	mu     sync.RWMutex
+	closed bool
+
+	messages atomic.Int64
+	errors   atomic.Int64
+}
+
+// Publish serializes value as JSON and delivers it to the topic's
+// subscribers
+func (p *InMemoryPublisher) Publish(ctx context.Context, key string, value interface{}) error {
+	return p.PublishWithHeaders(ctx, key, value, nil)
+}
+
+// PublishWithHeaders delivers a message with headers
+func (p *InMemoryPublisher) PublishWithHeaders(ctx context.Context, key string, value interface{}, headers map[string]string) error {
+	return p.PublishBatch(ctx, []Message{{Key: key, Value: value, Headers: headers}})
+}
+
+// PublishBatch serializes every message before delivering any, so a batch
+// with an unserializable value or invalid metadata delivers nothing. Messages without a Topic
+// go to the publisher's topic. Like a closed writer it fails with
+// io.ErrClosedPipe after Close.
+func (p *InMemoryPublisher) PublishBatch(ctx context.Context, messages []Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to publish batch: %w", err)
+	}
+
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return io.ErrClosedPipe
+	}
+
+	kafkaMessages := make([]kafka.Message, len(messages))
+	for i, msg := range messages {
+		value, err := JSONSerializer{}.Marshal(msg.Value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal batch message at index %d: %w", i, classify(ErrMarshal, err))
+		}
+		headers, err := withMetadata(msg.Headers, msg.Value)
+		if err != nil {
+			return fmt.Errorf("batch message at index %d: %w", i, err)
+		}
+		topic := msg.Topic
+		if topic == "" {
+			topic = p.topic
+		}
+		kafkaMessages[i] = kafka.Message{
+			Key:     []byte(msg.Key),
+			Value:   value,
+			Headers: toKafkaHeaders(withPartitionKey(headers, msg.PartitionKey)),
+			Topic:   topic,
+		}
+	}
+	stampCorrelationID(ctx, kafkaMessages)
+	stampPriority(ctx, kafkaMessages)
+
+	for _, kmsg := range kafkaMessages {
+		failed := p.bus.deliver(fromKafkaMessage(kmsg))
+		p.messages.Add(1)
+		p.errors.Add(int64(failed))
+	}
+	return nil
+}
+
+// Flush returns immediately; delivery is synchronous
+func (p *InMemoryPublisher) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Close stops the publisher accepting messages. Subscribers stay registered
+// on the bus.
+func (p *InMemoryPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	return nil
+}
+
+// Stats reports the messages delivered and the subscriber failures so far
+func (p *InMemoryPublisher) Stats() kafka.WriterStats {
+	return kafka.WriterStats{
+		Topic:    p.topic,
+		Messages: p.messages.Load(),
+		Errors:   p.errors.Load(),
+	}
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryPublisher_DeliversToSubscriber(t *testing.T) {
+	bus := NewInMemoryBus(logger.Log)
+	var received []Message
+	bus.Subscribe("email.queue", func(msg Message) error {
+		received = append(received, msg)
+		return nil
+	})
+
+	publisher := bus.Publisher("email.queue")
+	ctx := WithPriority(correlation.NewContext(context.Background(), "corr-1"), PriorityHigh)
+	require.NoError(t, publisher.PublishWithHeaders(ctx, "notif-1", map[string]string{"to": "a@example.com"}, map[string]string{"X-Source": "test"}))
+	require.NoError(t, publisher.Publish(context.Background(), "notif-2", map[string]string{"to": "b@example.com"}))
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "notif-1", received[0].Key)
+	assert.Equal(t, "email.queue", received[0].Topic)
+	assert.Equal(t, int64(0), received[0].Offset)
+	assert.JSONEq(t, `{"to": "a@example.com"}`, string(received[0].Value.([]byte)))
+	assert.Equal(t, "test", received[0].Headers["X-Source"])
+	assert.Equal(t, "corr-1", received[0].Headers[correlation.Header])
+	assert.Equal(t, "high", received[0].Headers[HeaderPriority])
+	assert.Equal(t, int64(1), received[1].Offset)
+	assert.Equal(t, int64(2), publisher.Stats().Messages)
+}
+
+func TestInMemoryPublisher_MultipleSubscribers(t *testing.T) {
+	bus := NewInMemoryBus(nil)
+	var mu sync.Mutex
+	counts := map[string]int{}
+	record := func(name string) func(Message) error {
+		return func(msg Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[name]++
+			return nil
+		}
+	}
+	bus.Subscribe("push.queue", record("push-a"))
+	bus.Subscribe("push.queue", record("push-b"))
+	bus.Subscribe("email.queue", record("email"))
+
+	err := bus.Publisher("push.queue").PublishBatch(context.Background(), []Message{
+		{Key: "notif-1", Value: "one"},
+		{Key: "notif-2", Value: "two"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"push-a": 2, "push-b": 2}, counts)
+}
+
+func TestInMemoryPublisher_SubscriberFailureDoesNotFailPublish(t *testing.T) {
+	bus := NewInMemoryBus(logger.Log)
+	var delivered int
+	bus.Subscribe("email.queue", func(msg Message) error { return errors.New("handler down") })
+	bus.Subscribe("email.queue", func(msg Message) error { delivered++; return nil })
+
+	publisher := bus.Publisher("email.queue")
+	require.NoError(t, publisher.Publish(context.Background(), "notif-1", "value"))
+
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, int64(1), publisher.Stats().Errors)
+}
+
+func TestInMemoryPublisher_NoSubscribers(t *testing.T) {
+	publisher := NewInMemoryBus(logger.Log).Publisher("email.queue")
+
+	assert.NoError(t, publisher.Publish(context.Background(), "notif-1", "value"))
+	assert.Equal(t, int64(1), publisher.Stats().Messages)
+}
+
+func TestInMemoryPublisher_Errors(t *testing.T) {
+	bus := NewInMemoryBus(nil)
+	var delivered int
+	bus.Subscribe("email.queue", func(msg Message) error { delivered++; return nil })
+	publisher := bus.Publisher("email.queue")
+
+	err := publisher.PublishBatch(context.Background(), []Message{{Key: "a", Value: "ok"}, {Key: "b", Value: make(chan int)}})
+	assert.True(t, errors.Is(err, ErrMarshal))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, publisher.Publish(ctx, "c", "value"), context.Canceled)
+
+	require.NoError(t, publisher.Close())
+	assert.ErrorIs(t, publisher.Publish(context.Background(), "d", "value"), io.ErrClosedPipe)
+	assert.Zero(t, delivered)
+}
+
+func TestNewManager_InMemory(t *testing.T) {
+	bus := NewInMemoryBus(logger.Log)
+	topics := map[string][]string{}
+	for _, topic := range []string{"email.queue", "push.queue", "push.priority"} {
+		topic := topic
+		bus.Subscribe(topic, func(msg Message) error {
+			topics[topic] = append(topics[topic], msg.Key)
+			return nil
+		})
+	}
+
+	manager, err := NewManager(ManagerConfig{
+		EmailTopic:        "email.queue",
+		PushTopic:         "push.queue",
+		PushPriorityTopic: "push.priority",
+		Logger:            logger.Log,
+		Bus:               bus,
+		DryRun:            true,
+	})
+	require.NoError(t, err)
+
+	critical := WithPriority(context.Background(), PriorityCritical)
+	require.NoError(t, manager.PublishByType(context.Background(), "email", "notif-1", "email"))
+	require.NoError(t, manager.PublishByType(context.Background(), "push", "notif-2", "push"))
+	require.NoError(t, manager.PublishByType(critical, "push", "notif-3", "alert"))
+	require.NoError(t, manager.PublishByType(critical, "email", "notif-4", "otp"))
+
+	assert.Equal(t, map[string][]string{
+		"email.queue":   {"notif-1", "notif-4"},
+		"push.queue":    {"notif-2"},
+		"push.priority": {"notif-3"},
+	}, topics)
+	assert.NoError(t, manager.Close())
+}
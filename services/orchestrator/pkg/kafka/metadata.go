@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HeaderMetadataPrefix starts the header carrying each metadata entry, so
+// campaign_id travels as X-Metadata-campaign_id and consumers can filter on
+// it without decoding the value
+const HeaderMetadataPrefix = "X-Metadata-"
+
+// Limits on the metadata a single message may carry. Headers are read by
+// every consumer of a topic, so they are kept much smaller than values.
+const (
+	MaxMetadataEntries = 16
+	MaxMetadataBytes   = 4096
+)
+
+// ErrMetadataInvalid is returned when a value's metadata has a malformed
+// key or exceeds MaxMetadataEntries or MaxMetadataBytes. Retrying the same
+// value will not help.
+var ErrMetadataInvalid = errors.New("kafka: invalid message metadata")
+
+// MetadataCarrier is implemented by values that carry metadata for the
+// message headers rather than the serialized value. Publishers add each
+// entry as a HeaderMetadataPrefix header.
+type MetadataCarrier interface {
+	KafkaMetadata() map[string]string
+}
+
+// ValidateMetadata checks metadata against the key rules and limits. Keys
+// may hold letters, digits, '-', '_' and '.'; the size counts every key,
+// with its header prefix, and value.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataEntries {
+		return fmt.Errorf("%w: %d entries, exceeding the limit of %d", ErrMetadataInvalid, len(metadata), MaxMetadataEntries)
+	}
+
+	size := 0
+	for key, value := range metadata {
+		if !validMetadataKey(key) {
+			return fmt.Errorf("%w: key %q must be non-empty and hold only letters, digits, '-', '_' or '.'", ErrMetadataInvalid, key)
+		}
+		size += len(HeaderMetadataPrefix) + len(key) + len(value)
+	}
+	if size > MaxMetadataBytes {
+		return fmt.Errorf("%w: %d bytes, exceeding the %d byte limit", ErrMetadataInvalid, size, MaxMetadataBytes)
+	}
+	return nil
+}
+
+func validMetadataKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// withMetadata adds the metadata of a MetadataCarrier value to headers.
+// Explicit headers win over metadata with the same header name. headers is
+// returned as is when value carries no metadata.
+func withMetadata(headers map[string]string, value interface{}) (map[string]string, error) {
+	carrier, ok := value.(MetadataCarrier)
+	if !ok {
+		return headers, nil
+	}
+	metadata := carrier.KafkaMetadata()
+	if len(metadata) == 0 {
+		return headers, nil
+	}
+	if err := ValidateMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]string, len(headers)+len(metadata))
+	for key, v := range metadata {
+		merged[HeaderMetadataPrefix+key] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return merged, nil
+}
@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// taggedValue is a value carrying metadata for the headers
+type taggedValue struct {
+	Body     string            `json:"body"`
+	Metadata map[string]string `json:"-"`
+}
+
+func (v taggedValue) KafkaMetadata() map[string]string { return v.Metadata }
+
+func TestProducer_PublishesMetadataAsHeaders(t *testing.T) {
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = append(written, msgs...)
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+	value := taggedValue{Body: "hello", Metadata: map[string]string{"campaign_id": "spring-sale", "variant": "b"}}
+
+	require.NoError(t, producer.Publish(context.Background(), "user-1", value))
+	require.NoError(t, producer.PublishBatch(context.Background(), []Message{{Key: "user-2", Value: value}}))
+	require.NoError(t, producer.PublishWithHeaders(context.Background(), "user-3", value, map[string]string{"X-Metadata-variant": "a"}))
+
+	require.Len(t, written, 3)
+	for i, variant := range []string{"b", "b", "a"} {
+		campaign, ok := headerValue(written[i].Headers, "X-Metadata-campaign_id")
+		assert.True(t, ok)
+		assert.Equal(t, "spring-sale", campaign)
+		got, _ := headerValue(written[i].Headers, "X-Metadata-variant")
+		assert.Equal(t, variant, got)
+
+		// Metadata stays out of the value
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(written[i].Value, &body))
+		assert.Equal(t, map[string]interface{}{"body": "hello"}, body)
+	}
+}
+
+func TestProducer_RejectsInvalidMetadata(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxMetadataEntries; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		message  string
+	}{
+		{name: "too many entries", metadata: tooMany, message: "exceeding the limit of 16"},
+		{name: "too large", metadata: map[string]string{"blob": strings.Repeat("x", MaxMetadataBytes)}, message: "byte limit"},
+		{name: "malformed key", metadata: map[string]string{"campaign id": "x"}, message: `key "campaign id"`},
+		{name: "empty key", metadata: map[string]string{"": "x"}, message: `key ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writes := 0
+			producer := &Producer{
+				writer: &mockWriter{
+					writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+						writes++
+						return nil
+					},
+				},
+				logger: logger.Log,
+			}
+			value := taggedValue{Body: "hello", Metadata: tt.metadata}
+
+			err := producer.Publish(context.Background(), "user-1", value)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrMetadataInvalid))
+			assert.Contains(t, err.Error(), tt.message)
+
+			err = producer.PublishBatch(context.Background(), []Message{{Key: "user-2", Value: value}})
+			assert.True(t, errors.Is(err, ErrMetadataInvalid))
+			assert.Contains(t, err.Error(), "batch message at index 0")
+			assert.Zero(t, writes)
+		})
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	assert.NoError(t, ValidateMetadata(nil))
+	assert.NoError(t, ValidateMetadata(map[string]string{"campaign-id": "1", "ab.variant": "b", "Source_2": ""}))
+	assert.ErrorIs(t, ValidateMetadata(map[string]string{"ünicode": "x"}), ErrMetadataInvalid)
+}
+
+func TestInMemoryPublisher_PublishesMetadataAsHeaders(t *testing.T) {
+	bus := NewInMemoryBus(nil)
+	var received []Message
+	bus.Subscribe("email.queue", func(msg Message) error {
+		received = append(received, msg)
+		return nil
+	})
+	publisher := bus.Publisher("email.queue")
+
+	require.NoError(t, publisher.Publish(context.Background(), "user-1", taggedValue{Metadata: map[string]string{"variant": "b"}}))
+	err := publisher.Publish(context.Background(), "user-2", taggedValue{Metadata: map[string]string{"bad key": "b"}})
+	assert.ErrorIs(t, err, ErrMetadataInvalid)
+
+	require.Len(t, received, 1)
+	assert.Equal(t, "b", received[0].Headers["X-Metadata-variant"])
+}
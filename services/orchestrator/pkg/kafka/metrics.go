@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+)
+
+const defaultMetricsInterval = 15 * time.Second
+
+// producerMetrics mirrors the writer stats into Prometheus collectors
+type producerMetrics struct {
+	published prometheus.Counter
+	errors    prometheus.Counter
+	batchSize prometheus.Gauge
+	latency   prometheus.Gauge
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newProducerMetrics(topic string) *producerMetrics {
+	labels := prometheus.Labels{"topic": topic}
+
+	return &producerMetrics{
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "kafka_producer_messages_published_total",
+			Help:        "Total number of messages written to Kafka.",
+			ConstLabels: labels,
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "kafka_producer_publish_errors_total",
+			Help:        "Total number of failed Kafka write attempts.",
+			ConstLabels: labels,
+		}),
+		batchSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "kafka_producer_batch_size",
+			Help:        "Average number of messages per batch over the last interval.",
+			ConstLabels: labels,
+		}),
+		latency: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "kafka_producer_write_latency_seconds",
+			Help:        "Average write latency over the last interval.",
+			ConstLabels: labels,
+		}),
+		done: make(chan struct{}),
+	}
+}
+
+func (m *producerMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.published, m.errors, m.batchSize, m.latency}
+}
+
+// observe applies a stats snapshot. kafka-go resets its counters on every
+// Stats call, so the counter values are deltas since the previous snapshot.
+func (m *producerMetrics) observe(stats kafka.WriterStats) {
+	m.published.Add(float64(stats.Messages))
+	m.errors.Add(float64(stats.Errors))
+	m.batchSize.Set(float64(stats.BatchSize.Avg))
+	m.latency.Set(stats.WriteTime.Avg.Seconds())
+}
+
+func (m *producerMetrics) stop() {
+	m.stopOnce.Do(func() { close(m.done) })
+}
+
+// RegisterMetrics registers the producer's Prometheus collectors on reg and
+// starts refreshing them from the writer stats. Because the writer resets
+// its stats on every read, calling Stats directly while metrics are
+// registered will hide those counts from Prometheus. The refresh loop stops
+// when the producer is closed.
+func (p *Producer) RegisterMetrics(reg prometheus.Registerer) error {
+	if p.metrics != nil {
+		return fmt.Errorf("metrics already registered for topic %s", p.topic)
+	}
+
+	metrics := newProducerMetrics(p.topic)
+	for _, c := range metrics.collectors() {
+		if err := reg.Register(c); err != nil {
+			for _, registered := range metrics.collectors() {
+				reg.Unregister(registered)
+			}
+			return fmt.Errorf("failed to register producer metrics: %w", err)
+		}
+	}
+
+	p.metrics = metrics
+	go p.refreshMetrics(metrics)
+
+	return nil
+}
+
+func (p *Producer) refreshMetrics(metrics *producerMetrics) {
+	interval := p.metricsInterval
+	if interval <= 0 {
+		interval = defaultMetricsInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			metrics.observe(p.readStats())
+		case <-metrics.done:
+			return
+		}
+	}
+}
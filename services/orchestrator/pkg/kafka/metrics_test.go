@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsWriter reports one message per successful write through Stats,
+// resetting like the real writer does
+type statsWriter struct {
+	mockWriter
+	stats chan kafka.WriterStats
+}
+
+func newStatsWriter() *statsWriter {
+	return &statsWriter{stats: make(chan kafka.WriterStats, 16)}
+}
+
+func (w *statsWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.stats <- kafka.WriterStats{
+		Messages:  int64(len(msgs)),
+		WriteTime: kafka.DurationStats{Avg: 20 * time.Millisecond},
+		BatchSize: kafka.SummaryStats{Avg: int64(len(msgs))},
+	}
+	return nil
+}
+
+func (w *statsWriter) Stats() kafka.WriterStats {
+	select {
+	case s := <-w.stats:
+		return s
+	default:
+		return kafka.WriterStats{}
+	}
+}
+
+func TestRegisterMetrics_ExposesMetricNames(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log, topic: "test-topic"}
+	defer producer.Close()
+
+	require.NoError(t, producer.RegisterMetrics(reg))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.ElementsMatch(t, []string{
+		"kafka_producer_messages_published_total",
+		"kafka_producer_publish_errors_total",
+		"kafka_producer_batch_size",
+		"kafka_producer_write_latency_seconds",
+	}, names)
+}
+
+func TestRegisterMetrics_CountersIncrementAfterPublish(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	producer := &Producer{
+		writer:          newStatsWriter(),
+		logger:          logger.Log,
+		topic:           "test-topic",
+		metricsInterval: 5 * time.Millisecond,
+	}
+	defer producer.Close()
+
+	require.NoError(t, producer.RegisterMetrics(reg))
+	require.NoError(t, producer.Publish(context.Background(), "key", "value"))
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(producer.metrics.published) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(0), testutil.ToFloat64(producer.metrics.errors))
+	assert.InDelta(t, 0.02, testutil.ToFloat64(producer.metrics.latency), 1e-9)
+}
+
+func TestRegisterMetrics_RejectsSecondRegistration(t *testing.T) {
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log, topic: "test-topic"}
+	defer producer.Close()
+
+	require.NoError(t, producer.RegisterMetrics(prometheus.NewRegistry()))
+	assert.Error(t, producer.RegisterMetrics(prometheus.NewRegistry()))
+}
+
+func TestRegisterMetrics_DuplicateCollectorsFail(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first := &Producer{writer: &mockWriter{}, logger: logger.Log, topic: "test-topic"}
+	second := &Producer{writer: &mockWriter{}, logger: logger.Log, topic: "test-topic"}
+	defer first.Close()
+
+	require.NoError(t, first.RegisterMetrics(reg))
+	err := second.RegisterMetrics(reg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to register producer metrics")
+	assert.Nil(t, second.metrics)
+}
+
+func TestClose_StopsMetricsRefresh(t *testing.T) {
+	producer := &Producer{writer: &mockWriter{}, logger: logger.Log, topic: "test-topic"}
+	require.NoError(t, producer.RegisterMetrics(prometheus.NewRegistry()))
+
+	require.NoError(t, producer.Close())
+
+	select {
+	case <-producer.metrics.done:
+	default:
+		t.Fatal("expected metrics refresh to be stopped")
+	}
+	assert.NotPanics(t, func() { producer.metrics.stop() })
+}
@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// ErrHandlerPanic is matched by the error Recover returns for a handler
+// that panicked
+var ErrHandlerPanic = errors.New("kafka: handler panicked")
+
+// HandlerFunc processes one consumed message. Returning an error leaves the
+// message uncommitted; see Consumer.Consume.
+type HandlerFunc func(Message) error
+
+// Middleware wraps a handler with behaviour shared by every consumer, such
+// as recovery, logging or metrics
+type Middleware func(HandlerFunc) HandlerFunc
+
+// Chain composes middleware into one. The first middleware is the
+// outermost: Chain(a, b)(h) runs a, then b, then h, and sees results in
+// the opposite order.
+func Chain(middleware ...Middleware) Middleware {
+	return func(handler HandlerFunc) HandlerFunc {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			handler = middleware[i](handler)
+		}
+		return handler
+	}
+}
+
+// Recover turns a panicking handler into an ErrHandlerPanic error, so the
+// consumer stops without committing and the message is redelivered
+// instead of the process crashing. The panic and its stack are logged
+// when logger is not nil. Put it first in a Chain to cover the other
+// middleware too.
+func Recover(logger *zap.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if logger != nil {
+						logger.Error("Message handler panicked",
+							zap.String("topic", msg.Topic),
+							zap.Int("partition", msg.Partition),
+							zap.Int64("offset", msg.Offset),
+							zap.String("key", msg.Key),
+							zap.Any("panic", r),
+							zap.ByteString("stack", debug.Stack()),
+						)
+					}
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+			return next(msg)
+		}
+	}
+}
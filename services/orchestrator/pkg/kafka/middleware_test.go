@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMiddleware appends its name to calls before and after the
+// handler it wraps
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg Message) error {
+			*calls = append(*calls, name+" before")
+			err := next(msg)
+			*calls = append(*calls, name+" after")
+			return err
+		}
+	}
+}
+
+func TestChain_RunsInOrder(t *testing.T) {
+	var calls []string
+	handler := Chain(
+		recordingMiddleware("logging", &calls),
+		recordingMiddleware("metrics", &calls),
+	)(func(msg Message) error {
+		calls = append(calls, "handler "+msg.Key)
+		return nil
+	})
+
+	require.NoError(t, handler(Message{Key: "user-1"}))
+	assert.Equal(t, []string{
+		"logging before",
+		"metrics before",
+		"handler user-1",
+		"metrics after",
+		"logging after",
+	}, calls)
+}
+
+func TestChain_Empty(t *testing.T) {
+	handlerErr := errors.New("template not found")
+	handler := Chain()(func(msg Message) error { return handlerErr })
+
+	assert.Equal(t, handlerErr, handler(Message{}))
+}
+
+func TestRecover_PanicBecomesError(t *testing.T) {
+	var calls []string
+	handler := Chain(Recover(logger.Log), recordingMiddleware("logging", &calls))(func(msg Message) error {
+		panic("nil template")
+	})
+
+	err := handler(Message{Key: "user-1", Offset: 7})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrHandlerPanic)
+	assert.Contains(t, err.Error(), "nil template")
+	// The panic unwound through the inner middleware
+	assert.Equal(t, []string{"logging before"}, calls)
+}
+
+func TestRecover_PassesThrough(t *testing.T) {
+	handlerErr := errors.New("template not found")
+	recovered := Recover(nil)
+
+	assert.NoError(t, recovered(func(msg Message) error { return nil })(Message{}))
+	assert.Equal(t, handlerErr, recovered(func(msg Message) error { return handlerErr })(Message{}))
+}
+
+func TestConsumer_Consume_RecoveredPanicRequeues(t *testing.T) {
+	reader := &mockReader{messages: testMessages(3)}
+	consumer := newTestConsumer(reader)
+
+	err := consumer.Consume(context.Background(), Chain(Recover(logger.Log))(func(msg Message) error {
+		if msg.Offset == 1 {
+			var payload map[string]string
+			payload["n"] = "1"
+		}
+		return nil
+	}))
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrHandlerPanic)
+	assert.Contains(t, err.Error(), "handler failed for message at offset 1")
+	// The panicking message stays uncommitted and is redelivered
+	assert.Equal(t, []int64{0}, reader.committedOffsets())
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoopProducer_RecordsSentMessages(t *testing.T) {
+	producer := NewNoopProducer(logger.Log)
+	ctx := context.Background()
+
+	require.NoError(t, producer.Publish(ctx, "user-1", map[string]string{"subject": "Welcome"}))
+	require.NoError(t, producer.PublishWithHeaders(ctx, "user-2", "hello", map[string]string{"X-Trace": "abc"}))
+	require.NoError(t, producer.PublishBatch(ctx, []Message{{Key: "user-3", Value: 1}, {Key: "user-4", Value: 2}}))
+
+	sent := producer.Sent()
+	require.Len(t, sent, 4)
+	assert.Equal(t, "user-1", sent[0].Key)
+	assert.Equal(t, map[string]string{"subject": "Welcome"}, sent[0].Value)
+	assert.Equal(t, "abc", sent[1].Headers["X-Trace"])
+	assert.Equal(t, []string{"user-3", "user-4"}, []string{sent[2].Key, sent[3].Key})
+	assert.Equal(t, int64(4), producer.Stats().Messages)
+
+	// Sent returns a copy
+	sent[0].Key = "changed"
+	assert.Equal(t, "user-1", producer.Sent()[0].Key)
+
+	producer.Reset()
+	assert.Empty(t, producer.Sent())
+}
+
+func TestNoopProducer_NilLogger(t *testing.T) {
+	producer := NewNoopProducer(nil)
+
+	assert.NoError(t, producer.Publish(context.Background(), "user-1", "hello"))
+	assert.Len(t, producer.Sent(), 1)
+}
+
+func TestNoopProducer_RejectsAfterClose(t *testing.T) {
+	producer := NewNoopProducer(logger.Log)
+	require.NoError(t, producer.Publish(context.Background(), "user-1", "hello"))
+	require.NoError(t, producer.Close())
+
+	err := producer.Publish(context.Background(), "user-2", "hello")
+
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Len(t, producer.Sent(), 1)
+}
+
+func TestNoopProducer_CancelledContext(t *testing.T) {
+	producer := NewNoopProducer(logger.Log)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := producer.Publish(ctx, "user-1", "hello")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, producer.Sent())
+}
@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Priority is a hint about how urgently a message must be delivered. It
+// travels in the context so callers can set it without changing how they
+// publish.
+type Priority string
+
+const (
+	PriorityLow      Priority = "low"
+	PriorityNormal   Priority = "normal"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
+// HeaderPriority carries a message's priority so consumers can serve
+// elevated messages first
+const HeaderPriority = "X-Priority"
+
+// Elevated reports whether p is high or critical. Elevated notifications
+// go to the priority topics when the Manager has them.
+func (p Priority) Elevated() bool {
+	return p == PriorityHigh || p == PriorityCritical
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx carrying p. An empty p leaves ctx as
+// is.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	if p == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority carried by ctx, or "" when there
+// is none
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// stampPriority adds the priority carried by ctx, if any, to each message
+// that does not already have a priority header
+func stampPriority(ctx context.Context, msgs []kafka.Message) {
+	p := PriorityFromContext(ctx)
+	if p == "" {
+		return
+	}
+	for i := range msgs {
+		if !hasHeader(msgs[i].Headers, HeaderPriority) {
+			msgs[i].Headers = append(msgs[i].Headers, kafka.Header{Key: HeaderPriority, Value: []byte(p)})
+		}
+	}
+}
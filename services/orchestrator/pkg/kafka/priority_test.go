@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriority_Elevated(t *testing.T) {
+	assert.True(t, PriorityCritical.Elevated())
+	assert.True(t, PriorityHigh.Elevated())
+	assert.False(t, PriorityNormal.Elevated())
+	assert.False(t, PriorityLow.Elevated())
+	assert.False(t, Priority("").Elevated())
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	assert.Equal(t, PriorityHigh, PriorityFromContext(WithPriority(context.Background(), PriorityHigh)))
+	assert.Empty(t, PriorityFromContext(context.Background()))
+	assert.Empty(t, PriorityFromContext(WithPriority(context.Background(), "")))
+}
+
+func TestProducer_StampsPriorityHeader(t *testing.T) {
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = append(written, msgs...)
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+	ctx := WithPriority(context.Background(), PriorityCritical)
+
+	require.NoError(t, producer.Publish(ctx, "user-1", "otp"))
+	require.NoError(t, producer.PublishBatch(ctx, []Message{{Key: "user-2", Value: "otp"}}))
+	require.NoError(t, producer.PublishWithHeaders(ctx, "user-3", "otp", map[string]string{HeaderPriority: "low"}))
+	require.NoError(t, producer.Publish(context.Background(), "user-4", "news"))
+
+	require.Len(t, written, 4)
+	for i, want := range []string{"critical", "critical", "low"} {
+		value, ok := headerValue(written[i].Headers, HeaderPriority)
+		assert.True(t, ok)
+		assert.Equal(t, want, value)
+	}
+	_, ok := headerValue(written[3].Headers, HeaderPriority)
+	assert.False(t, ok)
+}
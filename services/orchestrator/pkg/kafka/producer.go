@@ -2,14 +2,18 @@ package kafka
 
 import (
 	"context"
-	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	circuitbreaker "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/circuit-breaker"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
 	"github.com/segmentio/kafka-go"
-	"github.com/segmentio/kafka-go/sasl/plain"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -22,8 +26,48 @@ type kafkaWriter interface {
 
 type Producer struct {
 	writer kafkaWriter
+	dialer *kafka.Dialer
 	logger *zap.Logger
 	topic  string // Store topic separately for logging
+	async  bool
+
+	serializer Serializer
+	dlq        *Producer
+
+	retryPolicy RetryPolicy
+	breaker     *circuitbreaker.CircuitBreaker
+
+	completion func(msgs []kafka.Message, err error)
+	results    resultWaiters
+	inflight   inflightTracker
+	writing    atomic.Int64
+
+	maxQueueDepth   int
+	maxWriteLatency time.Duration
+	lastStats       statsSnapshot
+
+	tracer trace.Tracer
+
+	metrics         *producerMetrics
+	metricsInterval time.Duration
+
+	maxMessageBytes int
+	maxBatchCount   int
+	maxBatchBytes   int
+
+	rejectEmptyKeys bool
+	maxKeyBytes     int
+
+	schema SchemaValidator
+
+	// redactor is set when values are logged
+	redactor Redactor
+
+	brokers []string
+
+	dedup *dedupCache
+
+	clock clock.Clock
 }
 
 type ProducerConfig struct {
@@ -33,69 +77,404 @@ type ProducerConfig struct {
 	Username string
 	Password string
 	UseTLS   bool
+
+	// SASLMechanism selects the SASL mechanism: "plain", "scram-sha-256" or
+	// "scram-sha-512". When empty, PLAIN is used if UseTLS is set and
+	// credentials are present. All mechanisms require Username and Password.
+	SASLMechanism string
+
+	// CACertPEM is a PEM-encoded CA bundle used instead of the system roots
+	// to verify brokers, e.g. for self-signed internal clusters.
+	// InsecureSkipVerify disables certificate verification altogether and
+	// must only be used in development. Both require UseTLS.
+	CACertPEM          []byte
+	InsecureSkipVerify bool
+
+	// Async makes Publish return as soon as messages are enqueued instead of
+	// waiting for the broker to acknowledge them. Delivery errors are then only
+	// reported through CompletionFunc.
+	Async bool
+	// CompletionFunc is called by the writer once a batch has been delivered
+	// (or failed). It is mostly useful together with Async.
+	CompletionFunc func(msgs []kafka.Message, err error)
+
+	// Compression is the codec used for message batches: "gzip", "snappy",
+	// "lz4", "zstd" or "none". Empty means no compression.
+	Compression string
+
+	// Balancer picks the partitioning strategy. Use BalancerHash (or
+	// BalancerMurmur2 for Java client compatibility) to keep all events for
+	// a key, e.g. a user ID, in order on a single partition. Defaults to
+	// BalancerLeastBytes; unknown values fall back to it too.
+	Balancer Balancer
+
+	// RequiredAcks is the acknowledgement level the writer waits for. It is a
+	// pointer because kafka.RequireNone is the zero value; nil means
+	// kafka.RequireOne. Note that kafka.RequireNone disables delivery error
+	// detection: the broker never reports failed writes back to the producer.
+	RequiredAcks *kafka.RequiredAcks
+
+	// WriteTimeout and ReadTimeout bound each broker round trip and
+	// MaxAttempts caps delivery attempts per batch. Zero values fall back to
+	// the defaults below.
+	WriteTimeout time.Duration
+	ReadTimeout  time.Duration
+	MaxAttempts  int
+
+	// BatchSize, BatchBytes and BatchTimeout control how the writer groups
+	// messages per partition: a batch is sent once it holds BatchSize
+	// messages or BatchBytes bytes, or BatchTimeout after its first message.
+	// Zero values keep kafka-go's defaults of 100 messages, 1 MiB and 1s.
+	// A synchronous Publish waits for its batch to be sent, so a long
+	// BatchTimeout adds latency to every call that does not fill a batch;
+	// raise it together with Async on high-volume topics.
+	BatchSize    int
+	BatchBytes   int64
+	BatchTimeout time.Duration
+
+	// Serializer encodes message values. Defaults to JSONSerializer.
+	Serializer Serializer
+
+	// DLQProducer receives messages whose publish ultimately failed, together
+	// with the error in a header. When the DLQ write succeeds the publish is
+	// treated as handled and no error is returned.
+	DLQProducer *Producer
+
+	// RetryPolicy adds backoff retries for retryable write errors. Marshal
+	// failures and cancelled contexts are never retried.
+	RetryPolicy RetryPolicy
+
+	// BreakerThreshold is the number of consecutive write failures that opens
+	// the circuit breaker; 0 disables it. While open, publishes fail fast with
+	// ErrCircuitOpen until BreakerCooldown has elapsed and probes succeed.
+	BreakerThreshold uint32
+	BreakerCooldown  time.Duration
+
+	// TracerProvider creates the kafka.publish spans. Span context is
+	// propagated to consumers through W3C traceparent headers. When nil,
+	// tracing is a no-op.
+	TracerProvider trace.TracerProvider
+
+	// MaxMessageBytes rejects any message whose marshaled value is larger
+	// than this with ErrMessageTooLarge, before anything is written. Keep it
+	// at or below the broker's message.max.bytes; 0 disables the check.
+	MaxMessageBytes int
+
+	// RejectEmptyKeys fails messages without a key with ErrInvalidKey
+	// instead of letting the balancer place them anywhere, for topics that
+	// must keep each user's messages on one partition. Keys that are not
+	// valid UTF-8 are always rejected.
+	RejectEmptyKeys bool
+	// MaxKeyBytes replaces longer keys with their hex SHA-256 digest, which
+	// keeps partitioning deterministic while bounding key size. It must be
+	// 0, which leaves keys as they are, or at least 64, the digest length.
+	MaxKeyBytes int
+
+	// SchemaValidator, when set, checks every serialized value before it is
+	// published and fails nonconforming messages with a *SchemaError
+	// matching ErrSchemaInvalid, so event shape regressions surface at the
+	// producer instead of in consumers. JSONSchema implements it.
+	SchemaValidator SchemaValidator
+
+	// LogValues adds each message value to the debug logs, after passing it
+	// through Redactor, for troubleshooting what is being published. Values
+	// are never logged otherwise.
+	LogValues bool
+	// Redactor masks sensitive data in logged values. Defaults to
+	// DefaultRedactor.
+	Redactor Redactor
+
+	// MaxBatchCount and MaxBatchBytes split a PublishBatch into chunks of
+	// at most that many messages and bytes of keys, values and headers,
+	// written one after another, so one call cannot exceed the broker's
+	// request size. Keep MaxBatchBytes below the broker's
+	// message.max.bytes. 0 leaves the batch whole.
+	MaxBatchCount int
+	MaxBatchBytes int
+
+	// MaxQueueDepth and MaxWriteLatency are the thresholds behind Healthy:
+	// more than MaxQueueDepth messages awaiting acknowledgement, or an
+	// average WriteTime above MaxWriteLatency in the writer stats, mark the
+	// producer as backed up. 0 disables either check.
+	MaxQueueDepth   int
+	MaxWriteLatency time.Duration
+
+	// TransactionalID identifies a TransactionalProducer and prefixes the
+	// HeaderTransactionID of every message it commits. It is required by
+	// NewTransactionalProducer and ignored by plain producers.
+	TransactionalID string
+
+	// DedupWindow makes PublishIdempotent skip keys already published
+	// within this window by this producer. Only the most recent 10000 keys
+	// are remembered; 0 disables in-process deduplication and only the
+	// X-Idempotency-Key header is set.
+	DedupWindow time.Duration
+
+	// Clock stamps message times. Defaults to the real clock.
+	Clock clock.Clock
 }
 
+const (
+	defaultWriteTimeout = 10 * time.Second
+	defaultReadTimeout  = 10 * time.Second
+	defaultMaxAttempts  = 3
+)
+
 type Message struct {
-	Key   string
-	Value interface{}
+	Key     string
+	Value   interface{}
+	Headers map[string]string
+
+	// PartitionKey, when set, is what the balancer partitions on instead of
+	// Key, e.g. a user ID to keep a user's notifications in order while Key
+	// stays the notification ID. It travels in the HeaderPartitionKey
+	// header, which takes precedence over Key for every message that has
+	// it, including ones published with PublishWithHeaders. Only key-based
+	// balancers (hash, crc32, murmur2) look at either.
+	PartitionKey string
+
+	// Topic routes this message to a topic other than the producer's. Kafka
+	// only allows per-message topics when the writer has none, so batches
+	// that set it require ProducerConfig.Topic to be left empty, and every
+	// message in them must then name its topic.
+	Topic string
+
+	// Partition and Offset are set on messages delivered by a Consumer and
+	// ignored when publishing
+	Partition int
+	Offset    int64
 }
 
-func NewProducer(cfg ProducerConfig) *Producer {
+// compressionCodecs maps ProducerConfig.Compression values to kafka-go codecs
+var compressionCodecs = map[string]kafka.Compression{
+	"":       0,
+	"none":   0,
+	"gzip":   kafka.Gzip,
+	"snappy": kafka.Snappy,
+	"lz4":    kafka.Lz4,
+	"zstd":   kafka.Zstd,
+}
+
+// validate checks the configuration for values NewProducer cannot honour
+func (cfg ProducerConfig) validate() error {
+	if _, ok := compressionCodecs[strings.ToLower(cfg.Compression)]; !ok {
+		return fmt.Errorf("unsupported compression codec: %s", cfg.Compression)
+	}
+	if _, err := newBalancer(cfg.Balancer); err != nil {
+		return err
+	}
+	if cfg.RequiredAcks != nil {
+		switch *cfg.RequiredAcks {
+		case kafka.RequireNone, kafka.RequireOne, kafka.RequireAll:
+		default:
+			return fmt.Errorf("unsupported required acks level: %d", *cfg.RequiredAcks)
+		}
+	}
+	if cfg.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must be at least 1, got %d", cfg.MaxAttempts)
+	}
+	if cfg.WriteTimeout < 0 || cfg.ReadTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if cfg.BatchSize < 0 || cfg.BatchBytes < 0 || cfg.BatchTimeout < 0 {
+		return fmt.Errorf("batch size, bytes and timeout must not be negative")
+	}
+	if cfg.RetryPolicy.MaxRetries < 0 {
+		return fmt.Errorf("retry policy max retries must not be negative")
+	}
+	if cfg.MaxMessageBytes < 0 {
+		return fmt.Errorf("max message bytes must not be negative")
+	}
+	if cfg.MaxKeyBytes < 0 || (cfg.MaxKeyBytes > 0 && cfg.MaxKeyBytes < hashedKeyBytes) {
+		return fmt.Errorf("max key bytes must be 0 or at least %d, got %d", hashedKeyBytes, cfg.MaxKeyBytes)
+	}
+	if cfg.MaxBatchCount < 0 || cfg.MaxBatchBytes < 0 {
+		return fmt.Errorf("max batch count and bytes must not be negative")
+	}
+	if cfg.MaxQueueDepth < 0 || cfg.MaxWriteLatency < 0 {
+		return fmt.Errorf("max queue depth and write latency must not be negative")
+	}
+	if cfg.DedupWindow < 0 {
+		return fmt.Errorf("dedup window must not be negative")
+	}
+	if _, err := cfg.saslMechanism(); err != nil {
+		return err
+	}
+	if _, err := cfg.tlsConfig(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// newDialer builds the broker dialer with the configured SASL and TLS
+// settings. Only the connection fields of cfg are used.
+func newDialer(cfg ProducerConfig) *kafka.Dialer {
 	dialer := &kafka.Dialer{
 		Timeout:   10 * time.Second,
 		DualStack: true,
 	}
 
 	// Configuration SASL/SSL for Confluent Cloud
-	if cfg.UseTLS && cfg.Username != "" && cfg.Password != "" {
-		dialer.SASLMechanism = plain.Mechanism{
-			Username: cfg.Username,
-			Password: cfg.Password,
-		}
-		dialer.TLS = &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		}
+	// Invalid SASL settings are rejected by validate; here they disable SASL.
+	mechanism, _ := cfg.saslMechanism()
+	if mechanism != nil {
+		dialer.SASLMechanism = mechanism
+	}
+	// TLS is used together with SASL, or on its own when a custom CA or
+	// verification setting is configured
+	if cfg.UseTLS && (mechanism != nil || cfg.customTLS()) {
+		dialer.TLS, _ = cfg.tlsConfig()
 	}
 
-	// Create transport with dialer if TLS is enabled
-	var transport *kafka.Transport
-	if cfg.UseTLS && cfg.Username != "" && cfg.Password != "" {
-		transport = &kafka.Transport{
-			Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				conn, err := dialer.DialContext(ctx, network, addr)
-				if err != nil {
-					return nil, err
-				}
-				return conn, nil
-			},
-		}
+	return dialer
+}
+
+// newTransport returns a transport dialing through dialer when it uses SASL
+// or TLS, or nil to keep kafka-go's default transport
+func newTransport(dialer *kafka.Dialer) *kafka.Transport {
+	if dialer.SASLMechanism == nil && dialer.TLS == nil {
+		return nil
+	}
+	return &kafka.Transport{
+		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return conn, nil
+		},
+	}
+}
+
+// NewProducerWithError validates the configuration before creating the producer
+func NewProducerWithError(cfg ProducerConfig) (*Producer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return NewProducer(cfg), nil
+}
+
+// NewProducer creates a producer without validating the configuration.
+// Invalid options fall back to their defaults; use NewProducerWithError to
+// reject them instead.
+func NewProducer(cfg ProducerConfig) *Producer {
+	dialer := newDialer(cfg)
+	transport := newTransport(dialer)
+
+	requiredAcks := kafka.RequireOne
+	if cfg.RequiredAcks != nil {
+		requiredAcks = *cfg.RequiredAcks
+	}
+
+	writeTimeout := defaultWriteTimeout
+	if cfg.WriteTimeout > 0 {
+		writeTimeout = cfg.WriteTimeout
+	}
+	readTimeout := defaultReadTimeout
+	if cfg.ReadTimeout > 0 {
+		readTimeout = cfg.ReadTimeout
+	}
+	maxAttempts := defaultMaxAttempts
+	if cfg.MaxAttempts > 0 {
+		maxAttempts = cfg.MaxAttempts
+	}
+	balancer, err := newBalancer(cfg.Balancer)
+	if err != nil {
+		balancer = &kafka.LeastBytes{}
 	}
 
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(cfg.Brokers...),
 		Topic:        cfg.Topic,
-		Balancer:     &kafka.LeastBytes{},
-		MaxAttempts:  3,
-		WriteTimeout: 10 * time.Second,
-		ReadTimeout:  10 * time.Second,
-		RequiredAcks: kafka.RequireOne,
-		Async:        false,
+		Balancer:     partitionKeyBalancer{balancer},
+		MaxAttempts:  maxAttempts,
+		WriteTimeout: writeTimeout,
+		ReadTimeout:  readTimeout,
+		RequiredAcks: requiredAcks,
+		Async:        cfg.Async,
+		Compression:  compressionCodecs[strings.ToLower(cfg.Compression)],
+		BatchSize:    cfg.BatchSize,
+		BatchBytes:   cfg.BatchBytes,
+		BatchTimeout: cfg.BatchTimeout,
 	}
 
 	if transport != nil {
 		writer.Transport = transport
 	}
 
-	return &Producer{
+	producer := &Producer{
 		writer: writer,
+		dialer: dialer,
 		logger: cfg.Logger,
 		topic:  cfg.Topic,
+		async:  cfg.Async,
+
+		serializer: cfg.Serializer,
+		dlq:        cfg.DLQProducer,
+
+		retryPolicy: cfg.RetryPolicy,
+		breaker:     newProducerBreaker(cfg.Topic, cfg.BreakerThreshold, cfg.BreakerCooldown),
+
+		completion: cfg.CompletionFunc,
+		tracer:     tracerFor(cfg.TracerProvider),
+
+		maxMessageBytes: cfg.MaxMessageBytes,
+		maxBatchCount:   cfg.MaxBatchCount,
+		maxBatchBytes:   cfg.MaxBatchBytes,
+		rejectEmptyKeys: cfg.RejectEmptyKeys,
+		maxKeyBytes:     cfg.MaxKeyBytes,
+		maxQueueDepth:   cfg.MaxQueueDepth,
+		maxWriteLatency: cfg.MaxWriteLatency,
+
+		schema:   cfg.SchemaValidator,
+		redactor: logRedactor(cfg.LogValues, cfg.Redactor),
+
+		brokers: cfg.Brokers,
+
+		dedup: newDedupCache(cfg.DedupWindow, defaultDedupCapacity, cfg.Clock),
+
+		clock: clock.OrReal(cfg.Clock),
 	}
+	writer.Completion = producer.handleCompletion
+
+	return producer
 }
 
-// Publish sends a message to Kafka with retries
+// now reads the producer's clock, falling back to the real time for
+// producers not built by NewProducer
+func (p *Producer) now() time.Time {
+	if p.clock == nil {
+		return time.Now()
+	}
+	return p.clock.Now()
+}
+
+// Publish sends a message to Kafka with retries.
+// In async mode it returns once the message is enqueued; delivery errors are
+// surfaced through ProducerConfig.CompletionFunc. Failures match ErrMarshal,
+// ErrWriteTimeout, ErrBrokerUnavailable or ErrAuth with errors.Is when they
+// fall into one of those classes.
 func (p *Producer) Publish(ctx context.Context, key string, value interface{}) error {
-	valueBytes, err := json.Marshal(value)
+	return p.PublishWithHeaders(ctx, key, value, nil)
+}
+
+// PublishWithHeaders sends a message to Kafka with the given headers attached.
+// A nil or empty headers map behaves exactly like Publish.
+func (p *Producer) PublishWithHeaders(ctx context.Context, key string, value interface{}, headers map[string]string) error {
+	msg, err := p.buildMessage(key, value, headers)
+	if err != nil {
+		return err
+	}
+	return p.publishMessage(ctx, msg)
+}
+
+// buildMessage marshals a value into a kafka message ready to be written
+func (p *Producer) buildMessage(key string, value interface{}, headers map[string]string) (kafka.Message, error) {
+	key, err := p.sanitizeKey(key)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	valueBytes, err := p.marshal(value)
 	if err != nil {
 		if p.logger != nil {
 			p.logger.Error("Failed to marshal message",
@@ -103,14 +482,38 @@ func (p *Producer) Publish(ctx context.Context, key string, value interface{}) e
 				zap.Error(err),
 			)
 		}
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return kafka.Message{}, fmt.Errorf("failed to marshal message: %w", classify(ErrMarshal, err))
 	}
-
-	msg := kafka.Message{
-		Key:   []byte(key),
-		Value: valueBytes,
-		Time:  time.Now(),
+	if err := p.checkSize(key, valueBytes); err != nil {
+		return kafka.Message{}, err
+	}
+	if err := p.checkSchema(key, valueBytes); err != nil {
+		return kafka.Message{}, err
+	}
+	headers, err = withMetadata(headers, value)
+	if err != nil {
+		return kafka.Message{}, err
 	}
+	p.logValue(key, value)
+
+	return kafka.Message{
+		Key:     []byte(key),
+		Value:   valueBytes,
+		Headers: p.stampContentEncoding(toKafkaHeaders(headers)),
+		Time:    p.now(),
+	}, nil
+}
+
+// publishMessage writes a single message, falling back to the DLQ on failure
+func (p *Producer) publishMessage(ctx context.Context, msg kafka.Message) (err error) {
+	key := string(msg.Key)
+
+	msgs := []kafka.Message{msg}
+	stampCorrelationID(ctx, msgs)
+	stampPriority(ctx, msgs)
+	ctx, span := p.startPublishSpan(ctx, msgs)
+	defer func() { endPublishSpan(span, err) }()
+	msg = msgs[0]
 
 	if p.logger != nil {
 		p.logger.Debug("Publishing message to Kafka",
@@ -119,7 +522,7 @@ func (p *Producer) Publish(ctx context.Context, key string, value interface{}) e
 		)
 	}
 
-	err = p.writer.WriteMessages(ctx, msg)
+	err = p.write(ctx, msg)
 	if err != nil {
 		if p.logger != nil {
 			p.logger.Error("Failed to publish message",
@@ -128,25 +531,47 @@ func (p *Producer) Publish(ctx context.Context, key string, value interface{}) e
 				zap.Error(err),
 			)
 		}
-		return fmt.Errorf("failed to publish message: %w", err)
+		if p.dlq != nil && p.sendToDLQ(ctx, []kafka.Message{msg}, err) == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to publish message: %w", classifyWriteError(err))
 	}
 
 	if p.logger != nil {
-		p.logger.Info("Message published successfully",
-			zap.String("topic", p.topic),
-			zap.String("key", key),
-		)
+		if p.async {
+			p.logger.Debug("Message enqueued for async publishing",
+				zap.String("topic", p.topic),
+				zap.String("key", key),
+			)
+		} else {
+			p.logger.Info("Message published successfully",
+				zap.String("topic", p.topic),
+				zap.String("key", key),
+			)
+		}
 	}
 
 	return nil
 }
 
-// PublishBatch sends multiple messages in a batch
-func (p *Producer) PublishBatch(ctx context.Context, messages []Message) error {
+// PublishBatch sends multiple messages in a batch. A context that is done
+// before the write returns its error without marshaling or writing. Batches
+// over ProducerConfig.MaxBatchCount or MaxBatchBytes are written in chunks;
+// when some chunks fail the error is a *BatchError listing the messages
+// that were published.
+func (p *Producer) PublishBatch(ctx context.Context, messages []Message) (err error) {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to publish batch: %w", err)
+	}
+
 	kafkaMessages := make([]kafka.Message, len(messages))
 
 	for i, msg := range messages {
-		valueBytes, err := json.Marshal(msg.Value)
+		key, err := p.sanitizeKey(msg.Key)
+		if err != nil {
+			return fmt.Errorf("batch message at index %d: %w", i, err)
+		}
+		valueBytes, err := p.marshal(msg.Value)
 		if err != nil {
 			if p.logger != nil {
 				p.logger.Error("Failed to marshal batch message",
@@ -154,45 +579,122 @@ func (p *Producer) PublishBatch(ctx context.Context, messages []Message) error {
 					zap.Error(err),
 				)
 			}
-			return fmt.Errorf("failed to marshal batch message at index %d: %w", i, err)
+			return fmt.Errorf("failed to marshal batch message at index %d: %w", i, classify(ErrMarshal, err))
+		}
+		if err := p.checkSize(key, valueBytes); err != nil {
+			return fmt.Errorf("batch message at index %d: %w", i, err)
+		}
+		if err := p.checkSchema(key, valueBytes); err != nil {
+			return fmt.Errorf("batch message at index %d: %w", i, err)
+		}
+		if err := p.checkTopic(msg.Topic); err != nil {
+			return fmt.Errorf("batch message at index %d: %w", i, err)
+		}
+		headers, err := withMetadata(msg.Headers, msg.Value)
+		if err != nil {
+			return fmt.Errorf("batch message at index %d: %w", i, err)
 		}
+		p.logValue(key, msg.Value)
 
 		kafkaMessages[i] = kafka.Message{
-			Key:   []byte(msg.Key),
-			Value: valueBytes,
-			Time:  time.Now(),
+			Key:     []byte(key),
+			Value:   valueBytes,
+			Headers: p.stampContentEncoding(toKafkaHeaders(withPartitionKey(headers, msg.PartitionKey))),
+			Time:    p.now(),
+			Topic:   msg.Topic,
 		}
 	}
 
-	err := p.writer.WriteMessages(ctx, kafkaMessages...)
-	if err != nil {
-		if p.logger != nil {
-			p.logger.Error("Failed to publish batch",
-				zap.Int("count", len(messages)),
-				zap.Error(err),
-			)
-		}
+	// Marshaling a large batch can outlast the request
+	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("failed to publish batch: %w", err)
 	}
 
-	if p.logger != nil {
-		p.logger.Info("Batch published successfully",
-			zap.Int("count", len(messages)),
-		)
+	stampCorrelationID(ctx, kafkaMessages)
+	stampPriority(ctx, kafkaMessages)
+	ctx, span := p.startPublishSpan(ctx, kafkaMessages)
+	defer func() { endPublishSpan(span, err) }()
+
+	chunks := splitBatch(kafkaMessages, p.maxBatchCount, p.maxBatchBytes)
+	if len(chunks) > 1 {
+		return p.publishChunks(ctx, kafkaMessages, chunks)
 	}
+	return p.writeBatch(ctx, kafkaMessages)
+}
 
+// checkTopic ensures a message topic is compatible with the writer, which
+// rejects messages that set a topic when it has one of its own
+func (p *Producer) checkTopic(topic string) error {
+	if topic != "" && p.topic != "" {
+		return fmt.Errorf("per-message topic %s requires a producer without a default topic, got %s", topic, p.topic)
+	}
 	return nil
 }
 
-// Close gracefully shuts down the producer
+// marshal encodes a value with the configured serializer, defaulting to JSON
+func (p *Producer) marshal(value interface{}) ([]byte, error) {
+	if p.serializer == nil {
+		return JSONSerializer{}.Marshal(value)
+	}
+	return p.serializer.Marshal(value)
+}
+
+// toKafkaHeaders converts a header map into kafka headers sorted by key.
+// It returns nil for an empty map so messages without headers are unchanged.
+func toKafkaHeaders(headers map[string]string) []kafka.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kafkaHeaders := make([]kafka.Header, 0, len(headers))
+	for _, k := range keys {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: k, Value: []byte(headers[k])})
+	}
+	return kafkaHeaders
+}
+
+// stampCorrelationID adds the correlation ID carried by ctx, if any, to each
+// message that does not already have a correlation header
+func stampCorrelationID(ctx context.Context, msgs []kafka.Message) {
+	id := correlation.FromContext(ctx)
+	if id == "" {
+		return
+	}
+	for i := range msgs {
+		if !hasHeader(msgs[i].Headers, correlation.Header) {
+			msgs[i].Headers = append(msgs[i].Headers, kafka.Header{Key: correlation.Header, Value: []byte(id)})
+		}
+	}
+}
+
+func hasHeader(headers []kafka.Header, key string) bool {
+	for _, h := range headers {
+		if h.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Close gracefully shuts down the producer.
+// Any messages still buffered in async mode are flushed before it returns.
 func (p *Producer) Close() error {
 	if p.logger != nil {
 		p.logger.Info("Closing Kafka producer")
 	}
+	if p.metrics != nil {
+		p.metrics.stop()
+	}
 	return p.writer.Close()
 }
 
 // Stats returns producer statistics
 func (p *Producer) Stats() kafka.WriterStats {
-	return p.writer.Stats()
+	return p.readStats()
 }
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/correlation"
 	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
@@ -396,3 +398,451 @@ func TestProducer_Publish_TimeStamp(t *testing.T) {
 	assert.False(t, messageTime.IsZero())
 	assert.WithinDuration(t, time.Now(), messageTime, 1*time.Second)
 }
+
+func TestProducer_Publish_TimeStampFromClock(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	var times []time.Time
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				for _, msg := range msgs {
+					times = append(times, msg.Time)
+				}
+				return nil
+			},
+		},
+		logger: logger.Log,
+		clock:  clk,
+	}
+
+	require.NoError(t, producer.Publish(context.Background(), "k1", map[string]string{"n": "1"}))
+	clk.Advance(time.Minute)
+	require.NoError(t, producer.PublishBatch(context.Background(), []Message{{Key: "k2", Value: "v"}}))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 15, 12, 1, 0, 0, time.UTC),
+	}, times)
+}
+
+func TestNewProducer_AsyncConfig(t *testing.T) {
+	var called bool
+	cfg := ProducerConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "test-topic",
+		Logger:  logger.Log,
+		Async:   true,
+		CompletionFunc: func(msgs []kafka.Message, err error) {
+			called = true
+		},
+	}
+
+	producer := NewProducer(cfg)
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	assert.True(t, writer.Async)
+	assert.True(t, producer.async)
+	require.NotNil(t, writer.Completion)
+
+	writer.Completion(nil, nil)
+	assert.True(t, called)
+}
+
+func TestNewProducer_SyncByDefault(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "test-topic",
+		Logger:  logger.Log,
+	})
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	assert.False(t, writer.Async)
+	// The producer always installs its own completion hook; it must be safe
+	// to call without a CompletionFunc configured
+	require.NotNil(t, writer.Completion)
+	writer.Completion(nil, nil)
+}
+
+func TestProducer_Publish_Async(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				assert.Len(t, msgs, 1)
+				return nil
+			},
+		},
+		logger: logger.Log,
+		async:  true,
+	}
+
+	err := producer.Publish(context.Background(), "test-key", map[string]interface{}{"key": "value"})
+	assert.NoError(t, err)
+}
+
+func TestNewProducerWithError_Compression(t *testing.T) {
+	tests := []struct {
+		codec    string
+		expected kafka.Compression
+	}{
+		{codec: "", expected: 0},
+		{codec: "none", expected: 0},
+		{codec: "gzip", expected: kafka.Gzip},
+		{codec: "snappy", expected: kafka.Snappy},
+		{codec: "lz4", expected: kafka.Lz4},
+		{codec: "zstd", expected: kafka.Zstd},
+		{codec: "ZSTD", expected: kafka.Zstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			producer, err := NewProducerWithError(ProducerConfig{
+				Brokers:     []string{"localhost:9092"},
+				Topic:       "test-topic",
+				Logger:      logger.Log,
+				Compression: tt.codec,
+			})
+			require.NoError(t, err)
+
+			writer, ok := producer.writer.(*kafka.Writer)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, writer.Compression)
+		})
+	}
+}
+
+func TestNewProducerWithError_UnknownCompression(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "test-topic",
+		Logger:      logger.Log,
+		Compression: "brotli",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, producer)
+	assert.Contains(t, err.Error(), "unsupported compression codec")
+}
+
+func TestProducer_PublishBatch_LargeBatchCompressed(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "test-topic",
+		Logger:      logger.Log,
+		Compression: "snappy",
+	})
+	require.NoError(t, err)
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	assert.Equal(t, kafka.Snappy, writer.Compression)
+
+	// Swap in a mock writer so the batch can be published without a broker
+	var written int
+	producer.writer = &mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			written = len(msgs)
+			return nil
+		},
+	}
+
+	messages := make([]Message, 500)
+	for i := range messages {
+		messages[i] = Message{Key: "key", Value: map[string]interface{}{"index": i}}
+	}
+
+	err = producer.PublishBatch(context.Background(), messages)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, written)
+}
+
+func TestNewProducer_RequiredAcks(t *testing.T) {
+	tests := []struct {
+		name     string
+		acks     *kafka.RequiredAcks
+		expected kafka.RequiredAcks
+	}{
+		{name: "default", acks: nil, expected: kafka.RequireOne},
+		{name: "none", acks: requiredAcks(kafka.RequireNone), expected: kafka.RequireNone},
+		{name: "one", acks: requiredAcks(kafka.RequireOne), expected: kafka.RequireOne},
+		{name: "all", acks: requiredAcks(kafka.RequireAll), expected: kafka.RequireAll},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer, err := NewProducerWithError(ProducerConfig{
+				Brokers:      []string{"localhost:9092"},
+				Topic:        "test-topic",
+				Logger:       logger.Log,
+				RequiredAcks: tt.acks,
+			})
+			require.NoError(t, err)
+
+			writer, ok := producer.writer.(*kafka.Writer)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, writer.RequiredAcks)
+		})
+	}
+}
+
+func TestNewProducerWithError_InvalidRequiredAcks(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:      []string{"localhost:9092"},
+		Topic:        "test-topic",
+		Logger:       logger.Log,
+		RequiredAcks: requiredAcks(kafka.RequiredAcks(2)),
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, producer)
+}
+
+func requiredAcks(acks kafka.RequiredAcks) *kafka.RequiredAcks {
+	return &acks
+}
+
+func TestProducer_PublishWithHeaders(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				require.Len(t, msgs, 1)
+				assert.Equal(t, []kafka.Header{
+					{Key: "notification_type", Value: []byte("email")},
+					{Key: "tenant", Value: []byte("acme")},
+				}, msgs[0].Headers)
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+
+	headers := map[string]string{
+		"tenant":            "acme",
+		"notification_type": "email",
+	}
+
+	err := producer.PublishWithHeaders(context.Background(), "test-key", map[string]interface{}{"key": "value"}, headers)
+	assert.NoError(t, err)
+}
+
+func TestProducer_PublishWithHeaders_EmptyHeaders(t *testing.T) {
+	for _, headers := range []map[string]string{nil, {}} {
+		producer := &Producer{
+			writer: &mockWriter{
+				writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+					require.Len(t, msgs, 1)
+					assert.Nil(t, msgs[0].Headers)
+					return nil
+				},
+			},
+			logger: logger.Log,
+		}
+
+		err := producer.PublishWithHeaders(context.Background(), "test-key", map[string]interface{}{"key": "value"}, headers)
+		assert.NoError(t, err)
+	}
+}
+
+func TestProducer_PublishBatch_Headers(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				require.Len(t, msgs, 2)
+				assert.Equal(t, []kafka.Header{{Key: "tenant", Value: []byte("acme")}}, msgs[0].Headers)
+				assert.Nil(t, msgs[1].Headers)
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+
+	messages := []Message{
+		{Key: "key-1", Value: map[string]interface{}{"msg": "message 1"}, Headers: map[string]string{"tenant": "acme"}},
+		{Key: "key-2", Value: map[string]interface{}{"msg": "message 2"}},
+	}
+
+	err := producer.PublishBatch(context.Background(), messages)
+	assert.NoError(t, err)
+}
+
+func TestNewProducer_DefaultTimeouts(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "test-topic",
+		Logger:  logger.Log,
+	})
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, writer.WriteTimeout)
+	assert.Equal(t, 10*time.Second, writer.ReadTimeout)
+	assert.Equal(t, 3, writer.MaxAttempts)
+}
+
+func TestNewProducer_CustomTimeouts(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:      []string{"localhost:9092"},
+		Topic:        "test-topic",
+		Logger:       logger.Log,
+		WriteTimeout: 2 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		MaxAttempts:  7,
+	})
+	require.NoError(t, err)
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, writer.WriteTimeout)
+	assert.Equal(t, 3*time.Second, writer.ReadTimeout)
+	assert.Equal(t, 7, writer.MaxAttempts)
+}
+
+func TestNewProducerWithError_InvalidMaxAttempts(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "test-topic",
+		Logger:      logger.Log,
+		MaxAttempts: -1,
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, producer)
+	assert.Contains(t, err.Error(), "max attempts must be at least 1")
+}
+
+func TestNewProducer_DefaultBatching(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "test-topic",
+		Logger:  logger.Log,
+	})
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	// Zero values leave kafka-go to apply its own defaults
+	assert.Zero(t, writer.BatchSize)
+	assert.Zero(t, writer.BatchBytes)
+	assert.Zero(t, writer.BatchTimeout)
+}
+
+func TestNewProducer_CustomBatching(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:      []string{"localhost:9092"},
+		Topic:        "test-topic",
+		Logger:       logger.Log,
+		BatchSize:    500,
+		BatchBytes:   4 << 20,
+		BatchTimeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	writer, ok := producer.writer.(*kafka.Writer)
+	require.True(t, ok)
+	assert.Equal(t, 500, writer.BatchSize)
+	assert.Equal(t, int64(4<<20), writer.BatchBytes)
+	assert.Equal(t, 50*time.Millisecond, writer.BatchTimeout)
+}
+
+func TestNewProducerWithError_InvalidBatching(t *testing.T) {
+	for _, cfg := range []ProducerConfig{
+		{BatchSize: -1},
+		{BatchBytes: -1},
+		{BatchTimeout: -time.Second},
+		{MaxBatchCount: -1},
+		{MaxBatchBytes: -1},
+		{MaxQueueDepth: -1},
+		{MaxWriteLatency: -time.Second},
+	} {
+		cfg.Brokers = []string{"localhost:9092"}
+		cfg.Topic = "test-topic"
+
+		producer, err := NewProducerWithError(cfg)
+
+		assert.Error(t, err)
+		assert.Nil(t, producer)
+	}
+}
+
+func TestProducer_PublishBatch_CancelledContext(t *testing.T) {
+	writes := 0
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				writes++
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := producer.PublishBatch(ctx, []Message{{Key: "a", Value: "one"}, {Key: "b", Value: "two"}})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, writes)
+}
+
+// serializerFunc adapts a function to the Serializer interface
+type serializerFunc func(v interface{}) ([]byte, error)
+
+func (f serializerFunc) Marshal(v interface{}) ([]byte, error) { return f(v) }
+
+func TestProducer_PublishBatch_CancelledWhileMarshaling(t *testing.T) {
+	writes := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				writes++
+				return nil
+			},
+		},
+		logger: logger.Log,
+		serializer: serializerFunc(func(v interface{}) ([]byte, error) {
+			cancel()
+			return json.Marshal(v)
+		}),
+	}
+
+	err := producer.PublishBatch(ctx, []Message{{Key: "a", Value: "one"}})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, writes)
+}
+
+func TestProducer_StampsCorrelationID(t *testing.T) {
+	var written [][]kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = append(written, msgs)
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+	ctx := correlation.NewContext(context.Background(), "corr-123")
+
+	require.NoError(t, producer.Publish(ctx, "notif-1", "hello"))
+	require.NoError(t, producer.PublishWithHeaders(ctx, "notif-2", "hello", map[string]string{correlation.Header: "explicit"}))
+	require.NoError(t, producer.PublishBatch(ctx, []Message{{Key: "notif-3", Value: "one"}, {Key: "notif-4", Value: "two"}}))
+	require.NoError(t, producer.Publish(context.Background(), "notif-5", "hello"))
+
+	headerValue := func(msg kafka.Message) string {
+		for _, h := range msg.Headers {
+			if h.Key == correlation.Header {
+				return string(h.Value)
+			}
+		}
+		return ""
+	}
+	require.Len(t, written, 4)
+	assert.Equal(t, "corr-123", headerValue(written[0][0]))
+	// An explicit header is kept
+	assert.Equal(t, "explicit", headerValue(written[1][0]))
+	assert.Equal(t, "corr-123", headerValue(written[2][0]))
+	assert.Equal(t, "corr-123", headerValue(written[2][1]))
+	assert.Empty(t, headerValue(written[3][0]))
+}
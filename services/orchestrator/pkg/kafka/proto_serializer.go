@@ -0,0 +1,21 @@
+package kafka
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoSerializer encodes proto.Message values in protobuf wire format.
+// Register it with ProducerConfig{Serializer: ProtoSerializer{}} for topics
+// shared with consumers that expect Protobuf rather than JSON.
+type ProtoSerializer struct{}
+
+// Marshal encodes v, which must implement proto.Message
+func (ProtoSerializer) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("proto serializer: value of type %T is not a proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProtoSerializer_Marshal(t *testing.T) {
+	msg, err := structpb.NewStruct(map[string]interface{}{"notification_id": "notif-123"})
+	require.NoError(t, err)
+
+	data, err := ProtoSerializer{}.Marshal(msg)
+	require.NoError(t, err)
+
+	var decoded structpb.Struct
+	require.NoError(t, proto.Unmarshal(data, &decoded))
+	assert.Equal(t, "notif-123", decoded.Fields["notification_id"].GetStringValue())
+}
+
+func TestProtoSerializer_RejectsNonProto(t *testing.T) {
+	data, err := ProtoSerializer{}.Marshal(map[string]interface{}{"key": "value"})
+
+	assert.Error(t, err)
+	assert.Nil(t, data)
+	assert.Contains(t, err.Error(), "is not a proto.Message")
+}
+
+func TestProducer_Publish_ProtoSerializer(t *testing.T) {
+	var written []byte
+	producer := NewProducer(ProducerConfig{
+		Brokers:    []string{"localhost:9092"},
+		Topic:      "test-topic",
+		Logger:     logger.Log,
+		Serializer: ProtoSerializer{},
+	})
+	producer.writer = &mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			require.Len(t, msgs, 1)
+			written = msgs[0].Value
+			return nil
+		},
+	}
+
+	msg, err := structpb.NewStruct(map[string]interface{}{"user_id": "usr_123", "priority": "high"})
+	require.NoError(t, err)
+
+	err = producer.Publish(context.Background(), "test-key", msg)
+	require.NoError(t, err)
+
+	var decoded structpb.Struct
+	require.NoError(t, proto.Unmarshal(written, &decoded))
+	assert.True(t, proto.Equal(msg, &decoded))
+}
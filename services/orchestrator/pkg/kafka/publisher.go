@@ -0,0 +1,24 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Publisher is the publishing surface shared by Producer and its stand-ins,
+// such as NoopProducer. Code that only sends messages should depend on it
+// rather than on *Producer so implementations can be swapped.
+type Publisher interface {
+	Publish(ctx context.Context, key string, value interface{}) error
+	PublishBatch(ctx context.Context, messages []Message) error
+	Close() error
+	Stats() kafka.WriterStats
+}
+
+var (
+	_ Publisher         = (*Producer)(nil)
+	_ ProducerInterface = (*Producer)(nil)
+	_ ProducerInterface = (*NoopProducer)(nil)
+	_ ProducerInterface = (*InMemoryPublisher)(nil)
+)
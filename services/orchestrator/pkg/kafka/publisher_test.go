@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePublisher implements only Publisher, with no Flush or headers support
+type fakePublisher struct {
+	keys   []string
+	closed bool
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, key string, value interface{}) error {
+	f.keys = append(f.keys, key)
+	return nil
+}
+
+func (f *fakePublisher) PublishBatch(ctx context.Context, messages []Message) error {
+	for _, msg := range messages {
+		f.keys = append(f.keys, msg.Key)
+	}
+	return nil
+}
+
+func (f *fakePublisher) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakePublisher) Stats() kafka.WriterStats {
+	return kafka.WriterStats{Messages: int64(len(f.keys))}
+}
+
+func TestNewManagerWithPublishers_UsesFakes(t *testing.T) {
+	email, push := &fakePublisher{}, &fakePublisher{}
+	manager := NewManagerWithPublishers(email, push, logger.Log)
+	ctx := context.Background()
+
+	require.NoError(t, manager.PublishByType(ctx, "email", "notif-1", "hello"))
+	require.NoError(t, manager.PublishByType(ctx, "push", "notif-2", "hello"))
+	require.NoError(t, manager.PublishByType(ctx, "email", "notif-3", "hello"))
+
+	assert.Equal(t, []string{"notif-1", "notif-3"}, email.keys)
+	assert.Equal(t, []string{"notif-2"}, push.keys)
+
+	// Publishers without Flush are skipped rather than failing
+	assert.NoError(t, manager.Flush(ctx))
+	assert.NoError(t, manager.Close())
+	assert.True(t, email.closed)
+	assert.True(t, push.closed)
+}
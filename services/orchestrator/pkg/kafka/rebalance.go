@@ -0,0 +1,237 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// rebalanceHooks reports partition ownership changes to the configured
+// callbacks. kafka-go rebalances eagerly, so every generation revokes all
+// partitions of the previous one before assigning the new set.
+type rebalanceHooks struct {
+	onAssign func([]int)
+	onRevoke func([]int)
+
+	mu    sync.Mutex
+	owned []int
+}
+
+func (h *rebalanceHooks) assign(partitions []int) {
+	h.mu.Lock()
+	h.owned = partitions
+	h.mu.Unlock()
+
+	if h.onAssign != nil {
+		h.onAssign(partitions)
+	}
+}
+
+func (h *rebalanceHooks) revoke() {
+	h.mu.Lock()
+	revoked := h.owned
+	h.owned = nil
+	h.mu.Unlock()
+
+	if h.onRevoke != nil && len(revoked) > 0 {
+		h.onRevoke(revoked)
+	}
+}
+
+// assignedPartitions returns the sorted partition IDs assigned for topic
+func assignedPartitions(assignments map[string][]kafka.PartitionAssignment, topic string) []int {
+	partitions := make([]int, 0, len(assignments[topic]))
+	for _, a := range assignments[topic] {
+		partitions = append(partitions, a.ID)
+	}
+	sort.Ints(partitions)
+	return partitions
+}
+
+// groupReader is a kafkaReader driven by kafka-go's ConsumerGroup instead of
+// kafka.Reader, which hides group generations from its callers. It reads
+// each assigned partition with its own reader so rebalances can be reported
+// through rebalanceHooks.
+type groupReader struct {
+	topic   string
+	brokers []string
+	dialer  *kafka.Dialer
+	group   *kafka.ConsumerGroup
+	hooks   *rebalanceHooks
+	logger  *zap.Logger
+
+	msgs   chan kafka.Message
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	gen *kafka.Generation
+}
+
+func newGroupReader(cfg ConsumerConfig, dialer *kafka.Dialer) (*groupReader, error) {
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:      cfg.GroupID,
+		Brokers: cfg.Brokers,
+		Dialer:  dialer,
+		Topics:  []string{cfg.Topic},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &groupReader{
+		topic:   cfg.Topic,
+		brokers: cfg.Brokers,
+		dialer:  dialer,
+		group:   group,
+		hooks:   &rebalanceHooks{onAssign: cfg.OnAssign, onRevoke: cfg.OnRevoke},
+		logger:  cfg.Logger,
+		msgs:    make(chan kafka.Message),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go r.run()
+
+	return r, nil
+}
+
+// run joins each new generation in turn until the reader is closed
+func (r *groupReader) run() {
+	defer close(r.done)
+
+	for {
+		gen, err := r.group.Next(r.ctx)
+		if err != nil {
+			if r.ctx.Err() != nil || errors.Is(err, kafka.ErrGroupClosed) {
+				return
+			}
+			if r.logger != nil {
+				r.logger.Warn("Failed to join consumer group generation",
+					zap.String("topic", r.topic),
+					zap.Error(err),
+				)
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		r.gen = gen
+		r.mu.Unlock()
+
+		r.hooks.assign(assignedPartitions(gen.Assignments, r.topic))
+
+		var wg sync.WaitGroup
+		for _, assignment := range gen.Assignments[r.topic] {
+			wg.Add(1)
+			gen.Start(func(ctx context.Context) {
+				defer wg.Done()
+				r.readPartition(ctx, assignment)
+			})
+		}
+		gen.Start(func(ctx context.Context) {
+			<-ctx.Done()
+			wg.Wait()
+			r.hooks.revoke()
+		})
+	}
+}
+
+// readPartition forwards messages from one assigned partition until the
+// generation ends
+func (r *groupReader) readPartition(ctx context.Context, assignment kafka.PartitionAssignment) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     r.topic,
+		Partition: assignment.ID,
+		Dialer:    r.dialer,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(assignment.Offset); err != nil {
+		if r.logger != nil {
+			r.logger.Error("Failed to seek partition",
+				zap.String("topic", r.topic),
+				zap.Int("partition", assignment.ID),
+				zap.Int64("offset", assignment.Offset),
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil && r.logger != nil {
+				r.logger.Error("Failed to read partition",
+					zap.String("topic", r.topic),
+					zap.Int("partition", assignment.ID),
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		select {
+		case r.msgs <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *groupReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case <-r.ctx.Done():
+		return kafka.Message{}, io.EOF
+	}
+}
+
+// CommitMessages commits through the current generation. As with
+// kafka.Reader, the committed offset is the one after each message.
+func (r *groupReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	gen := r.gen
+	r.mu.Unlock()
+
+	if gen == nil {
+		return errors.New("consumer group has no active generation")
+	}
+
+	offsets := make(map[string]map[int]int64)
+	for _, msg := range msgs {
+		partitions, ok := offsets[msg.Topic]
+		if !ok {
+			partitions = make(map[int]int64)
+			offsets[msg.Topic] = partitions
+		}
+		if next := msg.Offset + 1; next > partitions[msg.Partition] {
+			partitions[msg.Partition] = next
+		}
+	}
+
+	return gen.CommitOffsets(offsets)
+}
+
+func (r *groupReader) Close() error {
+	r.cancel()
+	err := r.group.Close()
+	<-r.done
+	return err
+}
+
+func (r *groupReader) Stats() kafka.ReaderStats {
+	return kafka.ReaderStats{Topic: r.topic}
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebalanceHooks_SimulatedRebalance(t *testing.T) {
+	var assigned, revoked [][]int
+	hooks := &rebalanceHooks{
+		onAssign: func(p []int) { assigned = append(assigned, p) },
+		onRevoke: func(p []int) { revoked = append(revoked, p) },
+	}
+
+	// First generation: this member owns every partition
+	hooks.assign(assignedPartitions(map[string][]kafka.PartitionAssignment{
+		"notifications": {{ID: 2}, {ID: 0}, {ID: 1}, {ID: 3}},
+	}, "notifications"))
+
+	// A second member joins: eager rebalancing revokes everything, then
+	// hands back half
+	hooks.revoke()
+	hooks.assign(assignedPartitions(map[string][]kafka.PartitionAssignment{
+		"notifications": {{ID: 1}, {ID: 0}},
+	}, "notifications"))
+
+	assert.Equal(t, [][]int{{0, 1, 2, 3}, {0, 1}}, assigned)
+	assert.Equal(t, [][]int{{0, 1, 2, 3}}, revoked)
+}
+
+func TestRebalanceHooks_RevokeWithoutPartitionsIsSilent(t *testing.T) {
+	calls := 0
+	hooks := &rebalanceHooks{onRevoke: func([]int) { calls++ }}
+
+	hooks.revoke()
+	hooks.assign([]int{})
+	hooks.revoke()
+
+	assert.Equal(t, 0, calls)
+}
+
+func TestRebalanceHooks_NilCallbacks(t *testing.T) {
+	hooks := &rebalanceHooks{}
+
+	assert.NotPanics(t, func() {
+		hooks.assign([]int{0})
+		hooks.revoke()
+	})
+}
+
+func TestAssignedPartitions_IgnoresOtherTopics(t *testing.T) {
+	partitions := assignedPartitions(map[string][]kafka.PartitionAssignment{
+		"notifications": {{ID: 4}},
+		"other":         {{ID: 0}, {ID: 1}},
+	}, "notifications")
+
+	assert.Equal(t, []int{4}, partitions)
+}
+
+func TestNewConsumer_HooksUseConsumerGroup(t *testing.T) {
+	consumer, err := NewConsumer(ConsumerConfig{
+		Brokers:  []string{unreachableBroker(t)},
+		Topic:    "test-topic",
+		GroupID:  "test-group",
+		Logger:   logger.Log,
+		OnAssign: func([]int) {},
+	})
+	require.NoError(t, err)
+
+	reader, ok := consumer.reader.(*groupReader)
+	require.True(t, ok)
+
+	err = reader.CommitMessages(context.Background(), kafka.Message{Topic: "test-topic", Offset: 1})
+	assert.Error(t, err)
+
+	closed := make(chan error, 1)
+	go func() { closed <- consumer.Close() }()
+	select {
+	case err := <-closed:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not close")
+	}
+
+	_, err = reader.FetchMessage(context.Background())
+	assert.ErrorIs(t, err, io.EOF)
+}
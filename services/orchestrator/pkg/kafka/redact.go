@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Redacted replaces values that are never logged, not even in part
+const Redacted = "[REDACTED]"
+
+// Redactor returns a copy of a message value that is safe to log. It must
+// not modify value, which is still to be published.
+type Redactor func(value interface{}) interface{}
+
+// sensitiveKeyParts mark fields whose whole value is a credential. Keys
+// are matched case-insensitively on any part, so device_tokens and
+// X-Api-Key are caught too.
+var sensitiveKeyParts = []string{"token", "password", "secret", "authorization", "api_key", "apikey", "credential"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// phonePattern matches international numbers and Kenyan-style local
+	// ones such as 0712345678
+	phonePattern = regexp.MustCompile(`\+\d[\d\s-]{6,}\d|\b0\d{9}\b`)
+	// tokenPattern matches JWTs and bearer credentials inside free text
+	tokenPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+|(?i:bearer)\s+[A-Za-z0-9._~+/=-]+`)
+)
+
+// DefaultRedactor masks personal data and credentials in a value. The
+// value is read through its JSON form, so structs are handled by their
+// field names. Fields named like tokens, passwords and secrets become
+// Redacted; in every other string, email addresses keep only their first
+// character and domain (j***@example.com), phone numbers only their last
+// four digits (***5678) and JWT or bearer tokens are replaced by Redacted.
+// Serialized values ([]byte) are redacted as JSON when they are JSON.
+func DefaultRedactor(value interface{}) interface{} {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return redactString(v)
+	case []byte:
+		if !json.Valid(v) {
+			return redactString(string(v))
+		}
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return Redacted
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return Redacted
+	}
+	return redactValue(generic)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if sensitiveKey(key) {
+				out[key] = Redacted
+				continue
+			}
+			out[key] = redactValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	case string:
+		return redactString(v)
+	default:
+		return v
+	}
+}
+
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactString(s string) string {
+	s = tokenPattern.ReplaceAllString(s, Redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		at := strings.LastIndex(email, "@")
+		return email[:1] + "***" + email[at:]
+	})
+	return phonePattern.ReplaceAllStringFunc(s, func(phone string) string {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, phone)
+		return "***" + digits[len(digits)-4:]
+	})
+}
+
+// logRedactor returns the redactor for logged values, or nil when values
+// are not logged
+func logRedactor(logValues bool, redactor Redactor) Redactor {
+	if !logValues {
+		return nil
+	}
+	if redactor == nil {
+		return DefaultRedactor
+	}
+	return redactor
+}
+
+// logValue logs a message value at debug level, redacted, when the
+// producer is configured to log values
+func (p *Producer) logValue(key string, value interface{}) {
+	if p.redactor == nil || p.logger == nil || !p.logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+	p.logger.Debug("Message value",
+		zap.String("topic", p.topic),
+		zap.String("key", key),
+		zap.Any("value", p.redactor(value)),
+	)
+}
@@ -0,0 +1,228 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Replay defaults
+const (
+	DefaultReplayGroupID     = "dlq-replay"
+	DefaultReplayIdleTimeout = 5 * time.Second
+)
+
+// ReplayConfig configures a Replayer. The connection fields behave exactly
+// as they do for the producer.
+type ReplayConfig struct {
+	Brokers  []string
+	Logger   *zap.Logger
+	Username string
+	Password string
+	UseTLS   bool
+
+	// SASLMechanism, CACertPEM and InsecureSkipVerify: see ProducerConfig
+	SASLMechanism      string
+	CACertPEM          []byte
+	InsecureSkipVerify bool
+
+	// GroupID is the consumer group the dead-letter topic is read with.
+	// Replayed messages are committed, so running the replay again picks up
+	// where the last one stopped. Defaults to DefaultReplayGroupID.
+	GroupID string
+
+	// Balancer picks the partition of replayed messages: see ProducerConfig
+	Balancer Balancer
+
+	// DryRun counts the messages that would be replayed without writing or
+	// committing anything
+	DryRun bool
+
+	// IdleTimeout is how long the replay waits for another message before
+	// deciding it has caught up with the dead-letter topic. Defaults to
+	// DefaultReplayIdleTimeout.
+	IdleTimeout time.Duration
+}
+
+// connection returns the producer view of the connection settings
+func (cfg ReplayConfig) connection() ProducerConfig {
+	return ProducerConfig{
+		Brokers:            cfg.Brokers,
+		Username:           cfg.Username,
+		Password:           cfg.Password,
+		UseTLS:             cfg.UseTLS,
+		SASLMechanism:      cfg.SASLMechanism,
+		CACertPEM:          cfg.CACertPEM,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+}
+
+// Replayer re-publishes messages from a dead-letter topic once whatever
+// made them fail has been fixed
+type Replayer struct {
+	newReader func(topic string) kafkaReader
+	writer    kafkaWriter
+	logger    *zap.Logger
+
+	dryRun      bool
+	idleTimeout time.Duration
+}
+
+// NewReplayer creates a replayer for the configured cluster
+func NewReplayer(cfg ReplayConfig) (*Replayer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one broker is required")
+	}
+	conn := cfg.connection()
+	if _, err := conn.saslMechanism(); err != nil {
+		return nil, err
+	}
+	if _, err := conn.tlsConfig(); err != nil {
+		return nil, err
+	}
+	balancer, err := newBalancer(cfg.Balancer)
+	if err != nil {
+		return nil, err
+	}
+
+	groupID := cfg.GroupID
+	if groupID == "" {
+		groupID = DefaultReplayGroupID
+	}
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultReplayIdleTimeout
+	}
+
+	dialer := newDialer(conn)
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     partitionKeyBalancer{balancer},
+		WriteTimeout: defaultWriteTimeout,
+		ReadTimeout:  defaultReadTimeout,
+		MaxAttempts:  defaultMaxAttempts,
+		RequiredAcks: kafka.RequireOne,
+	}
+	if transport := newTransport(dialer); transport != nil {
+		writer.Transport = transport
+	}
+
+	return &Replayer{
+		newReader: func(topic string) kafkaReader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers: cfg.Brokers,
+				Topic:   topic,
+				GroupID: groupID,
+				Dialer:  dialer,
+			})
+		},
+		writer: writer,
+		logger: cfg.Logger,
+
+		dryRun:      cfg.DryRun,
+		idleTimeout: idleTimeout,
+	}, nil
+}
+
+// ReplayDLQ reads the dead-letter topic from and re-publishes each message
+// that filter accepts to the topic to, or to its x-dlq-source-topic when to
+// is empty, and returns how many were replayed. A nil filter accepts every
+// message. Filters see the DLQ headers, so they can select by error or
+// source topic; replayed messages keep their key, value and original
+// headers without them. It stops once no message has arrived for the idle
+// timeout. Messages are committed as they are read, including those the
+// filter skips, so use a new GroupID to replay them later. In dry-run mode
+// nothing is written or committed and the count is what would be replayed.
+func (r *Replayer) ReplayDLQ(ctx context.Context, from, to string, filter func(Message) bool) (int, error) {
+	if from == "" {
+		return 0, fmt.Errorf("dead-letter topic is required")
+	}
+
+	reader := r.newReader(from)
+	defer reader.Close()
+
+	replayed := 0
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, r.idleTimeout)
+		kmsg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return replayed, ctx.Err()
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				r.logDone(from, replayed)
+				return replayed, nil
+			}
+			return replayed, fmt.Errorf("failed to fetch message: %w", err)
+		}
+
+		msg := fromKafkaMessage(kmsg)
+		if filter == nil || filter(msg) {
+			if err := r.replay(ctx, kmsg, to); err != nil {
+				return replayed, fmt.Errorf("failed to replay message at offset %d: %w", kmsg.Offset, err)
+			}
+			replayed++
+		}
+
+		if r.dryRun {
+			continue
+		}
+		if err := reader.CommitMessages(ctx, kmsg); err != nil {
+			return replayed, fmt.Errorf("failed to commit offset %d: %w", kmsg.Offset, err)
+		}
+	}
+}
+
+// Close closes the writer used for replayed messages
+func (r *Replayer) Close() error {
+	return r.writer.Close()
+}
+
+// replay writes a dead-lettered message back to its topic
+func (r *Replayer) replay(ctx context.Context, kmsg kafka.Message, to string) error {
+	headers := make([]kafka.Header, 0, len(kmsg.Headers))
+	topic := to
+	for _, h := range kmsg.Headers {
+		if h.Key == HeaderDLQSourceTopic && topic == "" {
+			topic = string(h.Value)
+		}
+		switch h.Key {
+		case HeaderDLQError, HeaderDLQSourceTopic, HeaderDLQFailedAt:
+		default:
+			headers = append(headers, h)
+		}
+	}
+	if topic == "" {
+		return fmt.Errorf("no target topic and no %s header", HeaderDLQSourceTopic)
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	err := r.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Key:     kmsg.Key,
+		Value:   kmsg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return classifyWriteError(err)
+	}
+	return nil
+}
+
+func (r *Replayer) logDone(from string, replayed int) {
+	if r.logger == nil {
+		return
+	}
+	r.logger.Info("Dead-letter replay caught up",
+		zap.String("dlq_topic", from),
+		zap.Int("replayed", replayed),
+		zap.Bool("dry_run", r.dryRun),
+	)
+}
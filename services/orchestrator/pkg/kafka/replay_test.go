@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dlqMessage(offset int64, key, sourceTopic, cause string) kafka.Message {
+	return kafka.Message{
+		Topic:  "notifications.dlq",
+		Offset: offset,
+		Key:    []byte(key),
+		Value:  []byte(`{"notification_id":"` + key + `"}`),
+		Headers: []kafka.Header{
+			{Key: "X-Correlation-ID", Value: []byte("corr-" + key)},
+			{Key: HeaderDLQError, Value: []byte(cause)},
+			{Key: HeaderDLQSourceTopic, Value: []byte(sourceTopic)},
+			{Key: HeaderDLQFailedAt, Value: []byte("2025-01-10T09:00:00Z")},
+		},
+	}
+}
+
+func newTestReplayer(reader *mockReader, writer *mockWriter, dryRun bool) (*Replayer, *[]string) {
+	var topics []string
+	return &Replayer{
+		newReader: func(topic string) kafkaReader {
+			topics = append(topics, topic)
+			return reader
+		},
+		writer:      writer,
+		logger:      logger.Log,
+		dryRun:      dryRun,
+		idleTimeout: 10 * time.Millisecond,
+	}, &topics
+}
+
+func TestReplayer_ReplayDLQ_FilteredSubset(t *testing.T) {
+	reader := &mockReader{messages: []kafka.Message{
+		dlqMessage(0, "n-1", "email.queue", "kafka: broker unavailable"),
+		dlqMessage(1, "n-2", "push.queue", "kafka: marshal failed"),
+		dlqMessage(2, "n-3", "push.queue", "kafka: broker unavailable"),
+	}}
+	var written []kafka.Message
+	writer := &mockWriter{writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+		written = append(written, msgs...)
+		return nil
+	}}
+	replayer, topics := newTestReplayer(reader, writer, false)
+
+	replayed, err := replayer.ReplayDLQ(context.Background(), "notifications.dlq", "", func(msg Message) bool {
+		return msg.Headers[HeaderDLQError] == "kafka: broker unavailable"
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, []string{"notifications.dlq"}, *topics)
+	require.Len(t, written, 2)
+	assert.Equal(t, "email.queue", written[0].Topic)
+	assert.Equal(t, "n-1", string(written[0].Key))
+	assert.Equal(t, "push.queue", written[1].Topic)
+	assert.Equal(t, "n-3", string(written[1].Key))
+	assert.JSONEq(t, `{"notification_id":"n-3"}`, string(written[1].Value))
+
+	// The original headers survive, the DLQ ones are dropped
+	assert.Equal(t, []kafka.Header{{Key: "X-Correlation-ID", Value: []byte("corr-n-3")}}, written[1].Headers)
+	// Skipped messages are committed too
+	assert.Equal(t, []int64{0, 1, 2}, reader.committedOffsets())
+	assert.True(t, reader.closed)
+}
+
+func TestReplayer_ReplayDLQ_ExplicitTarget(t *testing.T) {
+	reader := &mockReader{messages: []kafka.Message{dlqMessage(0, "n-1", "email.queue", "boom")}}
+	var written []kafka.Message
+	writer := &mockWriter{writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+		written = append(written, msgs...)
+		return nil
+	}}
+	replayer, _ := newTestReplayer(reader, writer, false)
+
+	replayed, err := replayer.ReplayDLQ(context.Background(), "notifications.dlq", "email.retry", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	require.Len(t, written, 1)
+	assert.Equal(t, "email.retry", written[0].Topic)
+}
+
+func TestReplayer_ReplayDLQ_DryRun(t *testing.T) {
+	reader := &mockReader{messages: []kafka.Message{
+		dlqMessage(0, "n-1", "email.queue", "boom"),
+		dlqMessage(1, "n-2", "email.queue", "boom"),
+	}}
+	writes := 0
+	writer := &mockWriter{writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+		writes++
+		return nil
+	}}
+	replayer, _ := newTestReplayer(reader, writer, true)
+
+	replayed, err := replayer.ReplayDLQ(context.Background(), "notifications.dlq", "", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Zero(t, writes)
+	assert.Empty(t, reader.committedOffsets())
+}
+
+func TestReplayer_ReplayDLQ_WriteFailure(t *testing.T) {
+	reader := &mockReader{messages: []kafka.Message{
+		dlqMessage(0, "n-1", "email.queue", "boom"),
+		dlqMessage(1, "n-2", "email.queue", "boom"),
+	}}
+	calls := 0
+	writer := &mockWriter{writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+		calls++
+		if calls == 2 {
+			return kafka.LeaderNotAvailable
+		}
+		return nil
+	}}
+	replayer, _ := newTestReplayer(reader, writer, false)
+
+	replayed, err := replayer.ReplayDLQ(context.Background(), "notifications.dlq", "", nil)
+
+	require.Error(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.True(t, errors.Is(err, ErrBrokerUnavailable))
+	assert.Contains(t, err.Error(), "failed to replay message at offset 1")
+	// The failed message stays uncommitted so the next replay retries it
+	assert.Equal(t, []int64{0}, reader.committedOffsets())
+}
+
+func TestReplayer_ReplayDLQ_MissingTarget(t *testing.T) {
+	msg := dlqMessage(0, "n-1", "", "boom")
+	reader := &mockReader{messages: []kafka.Message{msg}}
+	replayer, _ := newTestReplayer(reader, &mockWriter{}, false)
+
+	_, err := replayer.ReplayDLQ(context.Background(), "notifications.dlq", "", nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no target topic")
+	assert.Empty(t, reader.committedOffsets())
+}
+
+func TestReplayer_ReplayDLQ_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	replayer, _ := newTestReplayer(&mockReader{}, &mockWriter{}, false)
+
+	_, err := replayer.ReplayDLQ(ctx, "notifications.dlq", "", nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewReplayer_Validation(t *testing.T) {
+	_, err := NewReplayer(ReplayConfig{})
+	assert.Error(t, err)
+
+	_, err = NewReplayer(ReplayConfig{Brokers: []string{"localhost:9092"}, Balancer: "random"})
+	assert.Error(t, err)
+
+	replayer, err := NewReplayer(ReplayConfig{Brokers: []string{"localhost:9092"}})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultReplayIdleTimeout, replayer.idleTimeout)
+	assert.False(t, replayer.dryRun)
+	assert.NoError(t, replayer.Close())
+}
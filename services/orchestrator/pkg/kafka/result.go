@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PublishResult is the Kafka location a message was written to
+type PublishResult struct {
+	Topic     string
+	Partition int
+	Offset    int64
+}
+
+// publishOutcome is what the writer reports back for a single message
+type publishOutcome struct {
+	result PublishResult
+	err    error
+}
+
+// resultKey identifies a message across the copies kafka-go makes of it
+type resultKey struct {
+	key   string
+	nanos int64
+}
+
+// resultWaiters tracks PublishWithResult calls waiting for the writer to
+// report where their message landed
+type resultWaiters struct {
+	mu        sync.Mutex
+	pending   map[resultKey]chan publishOutcome
+	lastNanos atomic.Int64
+}
+
+// stamp returns now, or a timestamp strictly later than any previously
+// returned one when now is not, so that key and time uniquely identify a
+// message within the producer
+func (w *resultWaiters) stamp(now time.Time) time.Time {
+	for {
+		last := w.lastNanos.Load()
+		nanos := now.UnixNano()
+		if nanos <= last {
+			nanos = last + 1
+		}
+		if w.lastNanos.CompareAndSwap(last, nanos) {
+			return time.Unix(0, nanos)
+		}
+	}
+}
+
+func (w *resultWaiters) add(msg kafka.Message) chan publishOutcome {
+	ch := make(chan publishOutcome, 1)
+	w.mu.Lock()
+	if w.pending == nil {
+		w.pending = make(map[resultKey]chan publishOutcome)
+	}
+	w.pending[keyOf(msg)] = ch
+	w.mu.Unlock()
+	return ch
+}
+
+func (w *resultWaiters) remove(msg kafka.Message) {
+	w.mu.Lock()
+	delete(w.pending, keyOf(msg))
+	w.mu.Unlock()
+}
+
+func (w *resultWaiters) resolve(msgs []kafka.Message, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return
+	}
+	for _, msg := range msgs {
+		if ch, ok := w.pending[keyOf(msg)]; ok {
+			delete(w.pending, keyOf(msg))
+			ch <- publishOutcome{
+				result: PublishResult{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset},
+				err:    err,
+			}
+		}
+	}
+}
+
+func keyOf(msg kafka.Message) resultKey {
+	return resultKey{key: string(msg.Key), nanos: msg.Time.UnixNano()}
+}
+
+// handleCompletion is installed as the writer's completion callback. It
+// resolves pending PublishWithResult calls and forwards to the configured
+// CompletionFunc.
+func (p *Producer) handleCompletion(msgs []kafka.Message, err error) {
+	// Sync writes report failures through WriteMessages, where retries and the
+	// DLQ are applied, so only successes are of interest here
+	if err == nil || p.async {
+		p.results.resolve(msgs, err)
+	}
+	if p.completion != nil {
+		p.completion(msgs, err)
+	}
+	if p.async {
+		p.inflight.done(len(msgs))
+	}
+}
+
+// PublishWithResult sends a message and returns the topic, partition and
+// offset it was written to.
+//
+// The location is reported by the writer once the broker acknowledges the
+// batch, so this call always waits for delivery, even when the producer is in
+// async mode. Partition and Offset are -1 when the writer did not report them,
+// e.g. when the message was diverted to the dead-letter queue. With
+// kafka.RequireNone the broker does not return offsets and the values must
+// not be relied on.
+func (p *Producer) PublishWithResult(ctx context.Context, key string, value interface{}) (*PublishResult, error) {
+	msg, err := p.buildMessage(key, value, nil)
+	if err != nil {
+		return nil, err
+	}
+	msg.Time = p.results.stamp(p.now())
+
+	ch := p.results.add(msg)
+	defer p.results.remove(msg)
+
+	if err := p.publishMessage(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	notReported := &PublishResult{Topic: p.topic, Partition: -1, Offset: -1}
+
+	if !p.async {
+		// Sync writers report completion before WriteMessages returns
+		select {
+		case outcome := <-ch:
+			return outcomeResult(outcome, notReported)
+		default:
+			return notReported, nil
+		}
+	}
+
+	select {
+	case outcome := <-ch:
+		return outcomeResult(outcome, notReported)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func outcomeResult(outcome publishOutcome, notReported *PublishResult) (*PublishResult, error) {
+	if outcome.err != nil {
+		return nil, fmt.Errorf("failed to publish message: %w", outcome.err)
+	}
+	if outcome.result.Topic == "" {
+		outcome.result.Topic = notReported.Topic
+	}
+	return &outcome.result, nil
+}
@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/clock/clocktest"
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportingWriter mimics kafka.Writer by invoking the completion callback
+// with the location assigned to each message
+type reportingWriter struct {
+	mockWriter
+	producer  *Producer
+	partition int
+	offset    int64
+	err       error
+	async     bool
+}
+
+func (w *reportingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	written := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		msg.Topic = "email.queue"
+		msg.Partition = w.partition
+		msg.Offset = w.offset + int64(i)
+		written[i] = msg
+	}
+
+	if w.async {
+		go w.producer.handleCompletion(written, w.err)
+		return nil
+	}
+
+	w.producer.handleCompletion(written, w.err)
+	return w.err
+}
+
+func TestProducer_PublishWithResult_Sync(t *testing.T) {
+	producer := &Producer{logger: logger.Log, topic: "email.queue"}
+	producer.writer = &reportingWriter{producer: producer, partition: 3, offset: 42}
+
+	result, err := producer.PublishWithResult(context.Background(), "notif-1", map[string]interface{}{"key": "value"})
+	require.NoError(t, err)
+	assert.Equal(t, &PublishResult{Topic: "email.queue", Partition: 3, Offset: 42}, result)
+}
+
+func TestProducer_PublishWithResult_Async(t *testing.T) {
+	producer := &Producer{logger: logger.Log, topic: "email.queue", async: true}
+	producer.writer = &reportingWriter{producer: producer, partition: 1, offset: 7, async: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result, err := producer.PublishWithResult(ctx, "notif-1", map[string]interface{}{"key": "value"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Partition)
+	assert.Equal(t, int64(7), result.Offset)
+}
+
+func TestProducer_PublishWithResult_AsyncError(t *testing.T) {
+	producer := &Producer{logger: logger.Log, topic: "email.queue", async: true}
+	producer.writer = &reportingWriter{producer: producer, err: errors.New("delivery failed"), async: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result, err := producer.PublishWithResult(ctx, "notif-1", map[string]interface{}{"key": "value"})
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "delivery failed")
+}
+
+func TestProducer_PublishWithResult_TimeFromClock(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = append(written, msgs...)
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "email.queue",
+		clock:  clk,
+	}
+
+	_, err := producer.PublishWithResult(context.Background(), "notif-1", map[string]interface{}{"key": "value"})
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	assert.True(t, written[0].Time.Equal(clk.Now()))
+}
+
+func TestProducer_PublishWithResult_NotReported(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{},
+		logger: logger.Log,
+		topic:  "email.queue",
+	}
+
+	result, err := producer.PublishWithResult(context.Background(), "notif-1", map[string]interface{}{"key": "value"})
+	require.NoError(t, err)
+	assert.Equal(t, &PublishResult{Topic: "email.queue", Partition: -1, Offset: -1}, result)
+}
+
+func TestProducer_PublishWithResult_WriteError(t *testing.T) {
+	producer := &Producer{
+		writer: failingWriter(errors.New("broker unavailable")),
+		logger: logger.Log,
+	}
+
+	result, err := producer.PublishWithResult(context.Background(), "notif-1", map[string]interface{}{"key": "value"})
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestProducer_HandleCompletion_ForwardsToCallback(t *testing.T) {
+	var got []kafka.Message
+	producer := &Producer{
+		completion: func(msgs []kafka.Message, err error) {
+			got = msgs
+		},
+	}
+
+	producer.handleCompletion([]kafka.Message{{Key: []byte("k")}}, nil)
+	assert.Len(t, got, 1)
+}
+
+func TestResultWaiters_StampIsUnique(t *testing.T) {
+	var w resultWaiters
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	seen := make(map[int64]bool)
+	for i := 0; i < 1000; i++ {
+		// A stopped clock still yields distinct stamps
+		nanos := w.stamp(now).UnixNano()
+		assert.False(t, seen[nanos])
+		seen[nanos] = true
+	}
+}
@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	circuitbreaker "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/circuit-breaker"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// RetryPolicy configures retries around a writer call on top of the writer's
+// own MaxAttempts. kafka-go retries immediately; the policy adds exponential
+// backoff between attempts so short broker flaps can recover.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt; 0 disables retrying
+	BaseDelay  time.Duration // Delay before the first retry
+	MaxDelay   time.Duration // Upper bound for a single delay
+	Jitter     bool          // Randomise each delay to avoid synchronised retries
+}
+
+const (
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// delay returns the backoff before the given retry (1-based)
+func (rp RetryPolicy) delay(retry int) time.Duration {
+	base := rp.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := rp.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	d := base
+	for i := 1; i < retry && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	if rp.Jitter {
+		// Equal jitter: keep half the delay and randomise the rest
+		half := d / 2
+		d = half + time.Duration(rand.Int63n(int64(half)+1))
+	}
+	return d
+}
+
+// write sends messages to the writer through the circuit breaker, if any
+func (p *Producer) write(ctx context.Context, msgs ...kafka.Message) error {
+	if p.breaker == nil {
+		return p.writeWithRetry(ctx, msgs...)
+	}
+
+	err := p.breaker.Execute(func() error {
+		return p.writeWithRetry(ctx, msgs...)
+	})
+	if errors.Is(err, circuitbreaker.ErrTooManyRequests) {
+		// Still probing the broker after a cooldown: fail fast like an open breaker
+		return fmt.Errorf("%w: %v", ErrCircuitOpen, err)
+	}
+	return err
+}
+
+// writeWithRetry sends messages to the writer, retrying retryable errors
+// according to the producer's retry policy
+func (p *Producer) writeWithRetry(ctx context.Context, msgs ...kafka.Message) error {
+	err := p.writeOnce(ctx, msgs...)
+
+	for retry := 1; err != nil && retry <= p.retryPolicy.MaxRetries && isRetryableWriteError(err); retry++ {
+		delay := p.retryPolicy.delay(retry)
+
+		if p.logger != nil {
+			p.logger.Warn("Retrying Kafka write",
+				zap.String("topic", p.topic),
+				zap.Int("retry", retry),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		err = p.writeOnce(ctx, msgs...)
+	}
+
+	return err
+}
+
+// writeOnce performs a single writer call, tracking async messages until the
+// writer reports them completed and sync ones until the call returns
+func (p *Producer) writeOnce(ctx context.Context, msgs ...kafka.Message) error {
+	if !p.async {
+		p.writing.Add(int64(len(msgs)))
+		defer p.writing.Add(-int64(len(msgs)))
+		return p.writer.WriteMessages(ctx, msgs...)
+	}
+
+	p.inflight.add(len(msgs))
+	err := p.writer.WriteMessages(ctx, msgs...)
+	if err != nil {
+		// Nothing was enqueued, so no completion will follow
+		p.inflight.done(len(msgs))
+	}
+	return err
+}
+
+// isRetryableWriteError reports whether a writer error is worth retrying.
+// Context cancellation is never retried; broker errors are retried when Kafka
+// flags them as temporary, and network errors always are.
+func isRetryableWriteError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var kafkaErr kafka.Error
+	if errors.As(err, &kafkaErr) {
+		return kafkaErr.Temporary()
+	}
+
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		for _, e := range writeErrs {
+			if e != nil && !isRetryableWriteError(e) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
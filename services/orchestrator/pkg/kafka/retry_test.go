@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyWriter returns err for the first failures calls and succeeds afterwards
+func flakyWriter(failures int, err error, calls *int) *mockWriter {
+	return &mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			*calls++
+			if *calls <= failures {
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+func TestProducer_Publish_RetriesRetryableErrors(t *testing.T) {
+	calls := 0
+	producer := &Producer{
+		writer: flakyWriter(2, kafka.LeaderNotAvailable, &calls),
+		logger: logger.Log,
+		retryPolicy: RetryPolicy{
+			MaxRetries: 3,
+			BaseDelay:  time.Millisecond,
+			MaxDelay:   5 * time.Millisecond,
+		},
+	}
+
+	err := producer.Publish(context.Background(), "test-key", map[string]interface{}{"key": "value"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestProducer_Publish_RetriesExhausted(t *testing.T) {
+	calls := 0
+	producer := &Producer{
+		writer: flakyWriter(10, &net.OpError{Op: "dial", Err: errors.New("connection refused")}, &calls),
+		logger: logger.Log,
+		retryPolicy: RetryPolicy{
+			MaxRetries: 2,
+			BaseDelay:  time.Millisecond,
+			Jitter:     true,
+		},
+	}
+
+	err := producer.Publish(context.Background(), "test-key", map[string]interface{}{"key": "value"})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestProducer_Publish_NonRetryableErrorNotRetried(t *testing.T) {
+	calls := 0
+	producer := &Producer{
+		writer: flakyWriter(10, kafka.TopicAuthorizationFailed, &calls),
+		logger: logger.Log,
+		retryPolicy: RetryPolicy{
+			MaxRetries: 3,
+			BaseDelay:  time.Millisecond,
+		},
+	}
+
+	err := producer.Publish(context.Background(), "test-key", map[string]interface{}{"key": "value"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProducer_Publish_MarshalErrorNotRetried(t *testing.T) {
+	calls := 0
+	producer := &Producer{
+		writer: flakyWriter(0, nil, &calls),
+		logger: logger.Log,
+		retryPolicy: RetryPolicy{
+			MaxRetries: 3,
+			BaseDelay:  time.Millisecond,
+		},
+	}
+
+	err := producer.Publish(context.Background(), "test-key", make(chan int))
+	assert.Error(t, err)
+	assert.Equal(t, 0, calls)
+}
+
+func TestProducer_Publish_RetryRespectsContext(t *testing.T) {
+	calls := 0
+	producer := &Producer{
+		writer: flakyWriter(10, kafka.LeaderNotAvailable, &calls),
+		logger: logger.Log,
+		retryPolicy: RetryPolicy{
+			MaxRetries: 5,
+			BaseDelay:  time.Second,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := producer.Publish(ctx, "test-key", map[string]interface{}{"key": "value"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	assert.Equal(t, 10*time.Millisecond, policy.delay(1))
+	assert.Equal(t, 20*time.Millisecond, policy.delay(2))
+	assert.Equal(t, 40*time.Millisecond, policy.delay(3))
+	assert.Equal(t, 50*time.Millisecond, policy.delay(4))
+
+	policy.Jitter = true
+	for i := 0; i < 20; i++ {
+		d := policy.delay(2)
+		assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+		assert.LessOrEqual(t, d, 20*time.Millisecond)
+	}
+}
+
+func TestIsRetryableWriteError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "context cancelled", err: context.Canceled, expected: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, expected: false},
+		{name: "temporary kafka error", err: kafka.NotLeaderForPartition, expected: true},
+		{name: "permanent kafka error", err: kafka.MessageSizeTooLarge, expected: false},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, expected: true},
+		{name: "write errors", err: kafka.WriteErrors{kafka.LeaderNotAvailable, nil}, expected: true},
+		{name: "unknown error", err: errors.New("something else"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isRetryableWriteError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Supported values for ProducerConfig.SASLMechanism
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// saslMechanism builds the SASL mechanism for the configuration, or nil when
+// SASL is not used. Without an explicit mechanism, PLAIN is used for TLS
+// connections with credentials, which is what Confluent Cloud expects.
+func (cfg ProducerConfig) saslMechanism() (sasl.Mechanism, error) {
+	hasCredentials := cfg.Username != "" && cfg.Password != ""
+	mechanism := strings.ToLower(cfg.SASLMechanism)
+
+	if mechanism == "" {
+		if cfg.UseTLS && hasCredentials {
+			mechanism = SASLPlain
+		} else {
+			return nil, nil
+		}
+	}
+
+	if !hasCredentials {
+		return nil, fmt.Errorf("SASL mechanism %s requires a username and password", mechanism)
+	}
+
+	switch mechanism {
+	case SASLPlain:
+		return plain.Mechanism{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %s", cfg.SASLMechanism)
+	}
+}
@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProducer_SASLMechanism(t *testing.T) {
+	tests := []struct {
+		name      string
+		mechanism string
+		expected  string
+	}{
+		{name: "default with TLS", mechanism: "", expected: "PLAIN"},
+		{name: "plain", mechanism: SASLPlain, expected: "PLAIN"},
+		{name: "scram-sha-256", mechanism: SASLScramSHA256, expected: "SCRAM-SHA-256"},
+		{name: "scram-sha-512", mechanism: SASLScramSHA512, expected: "SCRAM-SHA-512"},
+		{name: "case insensitive", mechanism: "SCRAM-SHA-512", expected: "SCRAM-SHA-512"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer, err := NewProducerWithError(ProducerConfig{
+				Brokers:       []string{"localhost:9092"},
+				Topic:         "test-topic",
+				Logger:        logger.Log,
+				Username:      "user",
+				Password:      "secret",
+				UseTLS:        true,
+				SASLMechanism: tt.mechanism,
+			})
+			require.NoError(t, err)
+
+			require.NotNil(t, producer.dialer.SASLMechanism)
+			assert.Equal(t, tt.expected, producer.dialer.SASLMechanism.Name())
+			assert.NotNil(t, producer.dialer.TLS)
+		})
+	}
+}
+
+func TestNewProducer_PlainMechanismCredentials(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:       []string{"localhost:9092"},
+		Topic:         "test-topic",
+		Logger:        logger.Log,
+		Username:      "user",
+		Password:      "secret",
+		SASLMechanism: SASLPlain,
+	})
+
+	mechanism, ok := producer.dialer.SASLMechanism.(plain.Mechanism)
+	require.True(t, ok)
+	assert.Equal(t, "user", mechanism.Username)
+	assert.Equal(t, "secret", mechanism.Password)
+	assert.Nil(t, producer.dialer.TLS)
+}
+
+func TestNewProducer_NoSASLWithoutTLS(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:  []string{"localhost:9092"},
+		Topic:    "test-topic",
+		Logger:   logger.Log,
+		Username: "user",
+		Password: "secret",
+	})
+
+	assert.Nil(t, producer.dialer.SASLMechanism)
+	assert.Nil(t, producer.dialer.TLS)
+}
+
+func TestNewProducerWithError_SASLMissingCredentials(t *testing.T) {
+	for _, mechanism := range []string{SASLPlain, SASLScramSHA256, SASLScramSHA512} {
+		t.Run(mechanism, func(t *testing.T) {
+			producer, err := NewProducerWithError(ProducerConfig{
+				Brokers:       []string{"localhost:9092"},
+				Topic:         "test-topic",
+				Logger:        logger.Log,
+				Username:      "user",
+				SASLMechanism: mechanism,
+			})
+
+			assert.Error(t, err)
+			assert.Nil(t, producer)
+			assert.Contains(t, err.Error(), "requires a username and password")
+		})
+	}
+}
+
+func TestNewProducerWithError_UnknownSASLMechanism(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:       []string{"localhost:9092"},
+		Topic:         "test-topic",
+		Logger:        logger.Log,
+		Username:      "user",
+		Password:      "secret",
+		SASLMechanism: "gssapi",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, producer)
+	assert.Contains(t, err.Error(), "unsupported SASL mechanism")
+}
@@ -0,0 +1,357 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// ErrSchemaInvalid is matched by the *SchemaError returned when a value
+// does not conform to ProducerConfig.SchemaValidator. Retrying the same
+// value will not help.
+var ErrSchemaInvalid = errors.New("kafka: value does not match schema")
+
+// SchemaError lists why a message value was rejected by the schema
+// validator. Callers can match it with errors.Is(err, ErrSchemaInvalid) or
+// inspect the violations with errors.As.
+type SchemaError struct {
+	Key        string
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("message %q does not match schema: %s", e.Key, strings.Join(e.Violations, "; "))
+}
+
+func (e *SchemaError) Unwrap() error { return ErrSchemaInvalid }
+
+// SchemaValidator checks a serialized message value before it is
+// published. Validate returns one entry per violation, or none when the
+// value conforms. Values are passed after decoding any content encoding,
+// so JSONGzipSerializer output is validated as plain JSON.
+type SchemaValidator interface {
+	Validate(value []byte) []string
+}
+
+// JSONSchema validates JSON values against a JSON Schema document. It
+// supports the subset of keywords event contracts need: type, properties,
+// required, additionalProperties (as a boolean), items, enum, const,
+// minLength, maxLength, pattern, minimum, maximum, minItems and maxItems.
+// Annotations such as title and description are ignored; any other keyword
+// is rejected by NewJSONSchema rather than silently not enforced.
+type JSONSchema struct {
+	root *schemaNode
+}
+
+// schemaNode is one compiled (sub)schema. Pointer fields are nil when the
+// keyword is absent.
+type schemaNode struct {
+	types                []string
+	properties           map[string]*schemaNode
+	required             []string
+	additionalProperties *bool
+	items                *schemaNode
+	enum                 []interface{}
+	constant             *interface{}
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+	minItems, maxItems   *int
+}
+
+// annotationKeywords carry documentation only and are accepted anywhere
+var annotationKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "examples": true, "default": true,
+}
+
+// NewJSONSchema compiles a JSON Schema document
+func NewJSONSchema(document []byte) (*JSONSchema, error) {
+	root, err := compileSchema(document, "$")
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchema{root: root}, nil
+}
+
+// Validate reports where value departs from the schema, e.g.
+// `$.user_id: required property missing`
+func (s *JSONSchema) Validate(value []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return []string{fmt.Sprintf("$: invalid JSON: %v", err)}
+	}
+
+	var violations []string
+	s.root.validate(v, "$", &violations)
+	return violations
+}
+
+func compileSchema(raw json.RawMessage, path string) (*schemaNode, error) {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keywords); err != nil {
+		return nil, fmt.Errorf("%s: schema must be an object: %w", path, err)
+	}
+
+	node := &schemaNode{}
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := keywords[name]
+		var err error
+		switch name {
+		case "type":
+			node.types, err = compileTypes(value)
+		case "properties":
+			var props map[string]json.RawMessage
+			if err = json.Unmarshal(value, &props); err == nil {
+				node.properties = make(map[string]*schemaNode, len(props))
+				for prop, sub := range props {
+					if node.properties[prop], err = compileSchema(sub, path+"."+prop); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case "required":
+			err = json.Unmarshal(value, &node.required)
+		case "additionalProperties":
+			err = json.Unmarshal(value, &node.additionalProperties)
+		case "items":
+			if node.items, err = compileSchema(value, path+"[]"); err != nil {
+				return nil, err
+			}
+		case "enum":
+			err = json.Unmarshal(value, &node.enum)
+		case "const":
+			var constant interface{}
+			err = json.Unmarshal(value, &constant)
+			node.constant = &constant
+		case "minLength":
+			err = json.Unmarshal(value, &node.minLength)
+		case "maxLength":
+			err = json.Unmarshal(value, &node.maxLength)
+		case "pattern":
+			var pattern string
+			if err = json.Unmarshal(value, &pattern); err == nil {
+				node.pattern, err = regexp.Compile(pattern)
+			}
+		case "minimum":
+			err = json.Unmarshal(value, &node.minimum)
+		case "maximum":
+			err = json.Unmarshal(value, &node.maximum)
+		case "minItems":
+			err = json.Unmarshal(value, &node.minItems)
+		case "maxItems":
+			err = json.Unmarshal(value, &node.maxItems)
+		default:
+			if !annotationKeywords[name] {
+				return nil, fmt.Errorf("%s: unsupported schema keyword %q", path, name)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %q: %w", path, name, err)
+		}
+	}
+	return node, nil
+}
+
+// compileTypes accepts "type" as either one name or a list of names
+func compileTypes(raw json.RawMessage) ([]string, error) {
+	var types []string
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		types = []string{single}
+	} else if err := json.Unmarshal(raw, &types); err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+	}
+	return types, nil
+}
+
+func (n *schemaNode) validate(v interface{}, path string, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(n.types) > 0 && !matchesAnyType(v, n.types) {
+		fail("expected %s, got %s", strings.Join(n.types, " or "), jsonType(v))
+		return
+	}
+	if n.enum != nil && !containsValue(n.enum, v) {
+		fail("value is not one of the allowed values")
+	}
+	if n.constant != nil && !reflect.DeepEqual(normalize(v), *n.constant) {
+		fail("value does not equal the required constant")
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, prop := range n.required {
+			if _, ok := v[prop]; !ok {
+				*violations = append(*violations, path+"."+prop+": required property missing")
+			}
+		}
+		props := make([]string, 0, len(v))
+		for prop := range v {
+			props = append(props, prop)
+		}
+		sort.Strings(props)
+		for _, prop := range props {
+			sub, ok := n.properties[prop]
+			switch {
+			case ok:
+				sub.validate(v[prop], path+"."+prop, violations)
+			case n.additionalProperties != nil && !*n.additionalProperties:
+				*violations = append(*violations, path+"."+prop+": additional property not allowed")
+			}
+		}
+	case []interface{}:
+		if n.minItems != nil && len(v) < *n.minItems {
+			fail("expected at least %d items, got %d", *n.minItems, len(v))
+		}
+		if n.maxItems != nil && len(v) > *n.maxItems {
+			fail("expected at most %d items, got %d", *n.maxItems, len(v))
+		}
+		if n.items != nil {
+			for i, item := range v {
+				n.items.validate(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			fail("expected at least %d characters, got %d", *n.minLength, length)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			fail("expected at most %d characters, got %d", *n.maxLength, length)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			fail("does not match pattern %q", n.pattern.String())
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if n.minimum != nil && f < *n.minimum {
+			fail("expected at least %v, got %v", *n.minimum, f)
+		}
+		if n.maximum != nil && f > *n.maximum {
+			fail("expected at most %v, got %v", *n.maximum, f)
+		}
+	}
+}
+
+func matchesAnyType(v interface{}, types []string) bool {
+	actual := jsonType(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON Schema type of a decoded value. Numbers without a
+// fractional part are integers.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func containsValue(allowed []interface{}, v interface{}) bool {
+	v = normalize(v)
+	for _, candidate := range allowed {
+		if reflect.DeepEqual(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize converts json.Number to float64, recursively, so decoded values
+// compare equal to schema literals decoded without UseNumber
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// checkSchema runs the configured schema validator over a serialized value
+func (p *Producer) checkSchema(key string, value []byte) error {
+	if p.schema == nil {
+		return nil
+	}
+
+	data := value
+	if encoder, ok := p.serializer.(ContentEncoder); ok {
+		decoded, err := DecodeValue(value, map[string]string{HeaderContentEncoding: encoder.ContentEncoding()})
+		if err != nil {
+			return &SchemaError{Key: key, Violations: []string{err.Error()}}
+		}
+		data = decoded
+	}
+
+	if violations := p.schema.Validate(data); len(violations) > 0 {
+		if p.logger != nil {
+			p.logger.Warn("Message does not match schema",
+				zap.String("topic", p.topic),
+				zap.String("key", key),
+				zap.Strings("violations", violations),
+			)
+		}
+		return &SchemaError{Key: key, Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notificationSchema describes the email and push payloads consumers expect
+const notificationSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "Notification",
+	"type": "object",
+	"required": ["notification_id", "notification_type", "user_id", "priority"],
+	"properties": {
+		"notification_id": {"type": "string", "minLength": 1},
+		"notification_type": {"enum": ["email", "push"]},
+		"user_id": {"type": "string", "pattern": "^usr_"},
+		"priority": {"type": "string", "enum": ["low", "normal", "high", "urgent"]},
+		"retry_count": {"type": "integer", "minimum": 0},
+		"device_tokens": {
+			"type": "object",
+			"properties": {
+				"ios": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+			}
+		}
+	}
+}`
+
+func newSchemaProducer(t *testing.T, serializer Serializer) (*Producer, *[]kafka.Message) {
+	schema, err := NewJSONSchema([]byte(notificationSchema))
+	require.NoError(t, err)
+
+	var written []kafka.Message
+	return &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = append(written, msgs...)
+				return nil
+			},
+		},
+		logger:     logger.Log,
+		serializer: serializer,
+		schema:     schema,
+	}, &written
+}
+
+func TestProducer_Publish_SchemaConforming(t *testing.T) {
+	producer, written := newSchemaProducer(t, nil)
+
+	err := producer.Publish(context.Background(), "notif-1", map[string]interface{}{
+		"notification_id":   "notif-1",
+		"notification_type": "email",
+		"user_id":           "usr_123",
+		"priority":          "high",
+		"retry_count":       2,
+		"device_tokens":     map[string]interface{}{"ios": []string{"tok-1"}},
+		"extra":             "allowed",
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, *written, 1)
+}
+
+func TestProducer_Publish_SchemaViolations(t *testing.T) {
+	producer, written := newSchemaProducer(t, nil)
+
+	err := producer.Publish(context.Background(), "notif-1", map[string]interface{}{
+		"notification_id":   "",
+		"notification_type": "sms",
+		"user_id":           "123",
+		"retry_count":       1.5,
+		"device_tokens":     map[string]interface{}{"ios": []interface{}{"tok-1", 7, "tok-3"}},
+	})
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrSchemaInvalid))
+	var schemaErr *SchemaError
+	require.True(t, errors.As(err, &schemaErr))
+	assert.Equal(t, "notif-1", schemaErr.Key)
+	assert.ElementsMatch(t, []string{
+		"$.priority: required property missing",
+		"$.notification_id: expected at least 1 characters, got 0",
+		"$.notification_type: value is not one of the allowed values",
+		`$.user_id: does not match pattern "^usr_"`,
+		"$.retry_count: expected integer, got number",
+		"$.device_tokens.ios: expected at most 2 items, got 3",
+		"$.device_tokens.ios[1]: expected string, got integer",
+	}, schemaErr.Violations)
+	assert.Empty(t, *written)
+}
+
+func TestProducer_PublishBatch_SchemaViolation(t *testing.T) {
+	producer, written := newSchemaProducer(t, nil)
+	valid := map[string]interface{}{"notification_id": "n1", "notification_type": "push", "user_id": "usr_1", "priority": "low"}
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "n1", Value: valid},
+		{Key: "n2", Value: []string{"not", "an", "object"}},
+	})
+
+	assert.True(t, errors.Is(err, ErrSchemaInvalid))
+	assert.Contains(t, err.Error(), "batch message at index 1")
+	assert.Contains(t, err.Error(), "$: expected object, got array")
+	assert.Empty(t, *written)
+}
+
+func TestProducer_Publish_SchemaDecodesContentEncoding(t *testing.T) {
+	producer, written := newSchemaProducer(t, JSONGzipSerializer{})
+
+	require.NoError(t, producer.Publish(context.Background(), "n1",
+		map[string]interface{}{"notification_id": "n1", "notification_type": "push", "user_id": "usr_1", "priority": "low"}))
+	assert.Len(t, *written, 1)
+
+	err := producer.Publish(context.Background(), "n2", map[string]interface{}{"notification_id": "n2"})
+	assert.True(t, errors.Is(err, ErrSchemaInvalid))
+}
+
+func TestProducer_Publish_NoSchemaValidator(t *testing.T) {
+	producer, written := newSchemaProducer(t, nil)
+	producer.schema = nil
+
+	require.NoError(t, producer.Publish(context.Background(), "n1", map[string]interface{}{"anything": true}))
+	assert.Len(t, *written, 1)
+}
+
+func TestNewJSONSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		expected string
+	}{
+		{name: "not an object", document: `"string"`, expected: "$: schema must be an object"},
+		{name: "unsupported keyword", document: `{"properties": {"a": {"oneOf": []}}}`, expected: `$.a: unsupported schema keyword "oneOf"`},
+		{name: "unknown type", document: `{"type": "date"}`, expected: `$: invalid "type": unknown type "date"`},
+		{name: "bad pattern", document: `{"pattern": "("}`, expected: `$: invalid "pattern"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJSONSchema([]byte(tt.document))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestJSONSchema_TypeListAndConst(t *testing.T) {
+	schema, err := NewJSONSchema([]byte(`{"type": "object", "additionalProperties": false, "properties": {
+		"version": {"const": 1},
+		"subject": {"type": ["string", "null"]}
+	}}`))
+	require.NoError(t, err)
+
+	assert.Empty(t, schema.Validate([]byte(`{"version": 1, "subject": null}`)))
+	assert.Equal(t, []string{
+		"$.subject: expected string or null, got integer",
+		"$.unknown: additional property not allowed",
+		"$.version: value does not equal the required constant",
+	}, schema.Validate([]byte(`{"version": 2, "subject": 3, "unknown": true}`)))
+	assert.Equal(t, []string{"$: invalid JSON: unexpected EOF"}, schema.Validate([]byte(`{"version"`)))
+}
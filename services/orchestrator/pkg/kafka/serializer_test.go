@@ -0,0 +1,182 @@
+package kafka
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSerializer is a Serializer returning fixed output for testing
+type stubSerializer struct {
+	data []byte
+	err  error
+}
+
+func (s stubSerializer) Marshal(v interface{}) ([]byte, error) {
+	return s.data, s.err
+}
+
+func TestJSONSerializer_Marshal(t *testing.T) {
+	data, err := JSONSerializer{}.Marshal(map[string]interface{}{"key": "value"})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key":"value"}`, string(data))
+}
+
+func TestProducer_Publish_CustomSerializer(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				require.Len(t, msgs, 1)
+				assert.Equal(t, []byte("custom"), msgs[0].Value)
+				return nil
+			},
+		},
+		logger:     logger.Log,
+		serializer: stubSerializer{data: []byte("custom")},
+	}
+
+	err := producer.Publish(context.Background(), "test-key", "ignored")
+	assert.NoError(t, err)
+}
+
+func TestProducer_PublishBatch_CustomSerializer(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				require.Len(t, msgs, 2)
+				for _, msg := range msgs {
+					assert.Equal(t, []byte("custom"), msg.Value)
+				}
+				return nil
+			},
+		},
+		logger:     logger.Log,
+		serializer: stubSerializer{data: []byte("custom")},
+	}
+
+	messages := []Message{
+		{Key: "key-1", Value: "one"},
+		{Key: "key-2", Value: "two"},
+	}
+
+	err := producer.PublishBatch(context.Background(), messages)
+	assert.NoError(t, err)
+}
+
+func TestProducer_Publish_SerializerError(t *testing.T) {
+	producer := &Producer{
+		writer:     &mockWriter{},
+		logger:     logger.Log,
+		serializer: stubSerializer{err: errors.New("boom")},
+	}
+
+	err := producer.Publish(context.Background(), "test-key", "value")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to marshal message")
+}
+
+func TestNewProducer_SerializerConfig(t *testing.T) {
+	serializer := stubSerializer{data: []byte("custom")}
+	producer := NewProducer(ProducerConfig{
+		Brokers:    []string{"localhost:9092"},
+		Topic:      "test-topic",
+		Logger:     logger.Log,
+		Serializer: serializer,
+	})
+
+	assert.Equal(t, serializer, producer.serializer)
+}
+
+func TestJSONGzipSerializer_RoundTrip(t *testing.T) {
+	var written kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				require.Len(t, msgs, 1)
+				written = msgs[0]
+				return nil
+			},
+		},
+		logger:     logger.Log,
+		serializer: JSONGzipSerializer{},
+	}
+	value := map[string]interface{}{"subject": "Welcome", "body": strings.Repeat("hello ", 200)}
+
+	require.NoError(t, producer.Publish(context.Background(), "test-key", value))
+
+	headers := map[string]string{}
+	for _, h := range written.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, "gzip", headers[HeaderContentEncoding])
+
+	plain, err := json.Marshal(value)
+	require.NoError(t, err)
+	assert.Less(t, len(written.Value), len(plain))
+
+	decoded, err := DecodeValue(written.Value, headers)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(plain), string(decoded))
+}
+
+func TestJSONGzipSerializer_StampsBatchHeaders(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				require.Len(t, msgs, 2)
+				assert.Contains(t, msgs[0].Headers, kafka.Header{Key: HeaderContentEncoding, Value: []byte("gzip")})
+				// A caller's own encoding header is left alone
+				assert.Equal(t, []kafka.Header{{Key: HeaderContentEncoding, Value: []byte("custom")}}, msgs[1].Headers)
+				return nil
+			},
+		},
+		logger:     logger.Log,
+		serializer: JSONGzipSerializer{Level: gzip.BestSpeed},
+	}
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "a", Value: "one"},
+		{Key: "b", Value: "two", Headers: map[string]string{HeaderContentEncoding: "custom"}},
+	})
+	assert.NoError(t, err)
+}
+
+func TestJSONSerializer_NoContentEncodingHeader(t *testing.T) {
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				assert.Empty(t, msgs[0].Headers)
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+
+	assert.NoError(t, producer.Publish(context.Background(), "test-key", "value"))
+}
+
+func TestJSONGzipSerializer_InvalidLevel(t *testing.T) {
+	_, err := JSONGzipSerializer{Level: 42}.Marshal("value")
+	assert.Error(t, err)
+}
+
+func TestDecodeValue(t *testing.T) {
+	value, err := DecodeValue([]byte(`{"a":1}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(value))
+
+	_, err = DecodeValue([]byte("not gzip"), map[string]string{HeaderContentEncoding: "gzip"})
+	assert.Error(t, err)
+
+	_, err = DecodeValue([]byte("x"), map[string]string{HeaderContentEncoding: "br"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package kafka
+
+import "fmt"
+
+// ErrMessageTooLarge is returned when a marshaled payload exceeds
+// ProducerConfig.MaxMessageBytes. Callers can match it with errors.As and
+// publish a smaller representation instead.
+type ErrMessageTooLarge struct {
+	Key   string
+	Size  int
+	Limit int
+}
+
+func (e *ErrMessageTooLarge) Error() string {
+	return fmt.Sprintf("message %q is %d bytes, exceeding the %d byte limit", e.Key, e.Size, e.Limit)
+}
+
+// checkSize rejects payloads larger than the configured limit
+func (p *Producer) checkSize(key string, value []byte) error {
+	if p.maxMessageBytes <= 0 || len(value) <= p.maxMessageBytes {
+		return nil
+	}
+	return &ErrMessageTooLarge{Key: key, Size: len(value), Limit: p.maxMessageBytes}
+}
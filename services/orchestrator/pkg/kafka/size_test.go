@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLimitedProducer(limit int, writes *int) *Producer {
+	return &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				*writes++
+				return nil
+			},
+		},
+		logger:          logger.Log,
+		topic:           "test-topic",
+		maxMessageBytes: limit,
+	}
+}
+
+func TestPublish_UnderSizeLimit(t *testing.T) {
+	var writes int
+	producer := newLimitedProducer(64, &writes)
+
+	err := producer.Publish(context.Background(), "user-1", "short")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, writes)
+}
+
+func TestPublish_OverSizeLimit(t *testing.T) {
+	var writes int
+	producer := newLimitedProducer(16, &writes)
+
+	err := producer.Publish(context.Background(), "user-1", strings.Repeat("x", 32))
+
+	var tooLarge *ErrMessageTooLarge
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, "user-1", tooLarge.Key)
+	assert.Equal(t, 34, tooLarge.Size) // quoted JSON string
+	assert.Equal(t, 16, tooLarge.Limit)
+	assert.Contains(t, err.Error(), "user-1")
+	assert.Equal(t, 0, writes)
+}
+
+func TestPublishBatch_UnderSizeLimit(t *testing.T) {
+	var writes int
+	producer := newLimitedProducer(64, &writes)
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "a", Value: "one"},
+		{Key: "b", Value: "two"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, writes)
+}
+
+func TestPublishBatch_OverSizeLimitRejectsWholeBatch(t *testing.T) {
+	var writes int
+	producer := newLimitedProducer(16, &writes)
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "a", Value: "one"},
+		{Key: "big", Value: strings.Repeat("x", 32)},
+	})
+
+	var tooLarge *ErrMessageTooLarge
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, "big", tooLarge.Key)
+	assert.Contains(t, err.Error(), "index 1")
+	assert.Equal(t, 0, writes)
+}
+
+func TestPublish_NoSizeLimitByDefault(t *testing.T) {
+	var writes int
+	producer := newLimitedProducer(0, &writes)
+
+	err := producer.Publish(context.Background(), "user-1", strings.Repeat("x", 4096))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, writes)
+}
+
+func TestNewProducerWithError_NegativeMaxMessageBytes(t *testing.T) {
+	_, err := NewProducerWithError(ProducerConfig{
+		Brokers:         []string{"localhost:9092"},
+		Topic:           "test-topic",
+		MaxMessageBytes: -1,
+	})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// customTLS reports whether the configuration overrides the default TLS
+// verification settings
+func (cfg ProducerConfig) customTLS() bool {
+	return len(cfg.CACertPEM) > 0 || cfg.InsecureSkipVerify
+}
+
+// tlsConfig builds the TLS configuration for broker connections. Without a
+// custom CA the system roots are used. The returned config is usable even when
+// the CA bundle fails to parse, falling back to the system roots.
+func (cfg ProducerConfig) tlsConfig() (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Only meant for development clusters with throwaway certificates
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if len(cfg.CACertPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CACertPEM) {
+			return tlsCfg, fmt.Errorf("failed to parse CA certificate PEM")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCACert generates a self-signed CA certificate in PEM format
+func testCACert(t *testing.T) ([]byte, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-kafka-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert
+}
+
+func TestNewProducer_CustomCACert(t *testing.T) {
+	caPEM, caCert := testCACert(t)
+
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     "test-topic",
+		Logger:    logger.Log,
+		UseTLS:    true,
+		CACertPEM: caPEM,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, producer.dialer.TLS)
+	require.NotNil(t, producer.dialer.TLS.RootCAs)
+
+	expected := x509.NewCertPool()
+	expected.AddCert(caCert)
+	assert.True(t, expected.Equal(producer.dialer.TLS.RootCAs))
+	assert.False(t, producer.dialer.TLS.InsecureSkipVerify)
+}
+
+func TestNewProducer_InsecureSkipVerify(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:            []string{"localhost:9092"},
+		Topic:              "test-topic",
+		Logger:             logger.Log,
+		UseTLS:             true,
+		InsecureSkipVerify: true,
+	})
+
+	require.NotNil(t, producer.dialer.TLS)
+	assert.True(t, producer.dialer.TLS.InsecureSkipVerify)
+	assert.Nil(t, producer.dialer.TLS.RootCAs)
+}
+
+func TestNewProducer_DefaultTLSUsesSystemRoots(t *testing.T) {
+	producer := NewProducer(ProducerConfig{
+		Brokers:  []string{"localhost:9092"},
+		Topic:    "test-topic",
+		Logger:   logger.Log,
+		UseTLS:   true,
+		Username: "user",
+		Password: "secret",
+	})
+
+	require.NotNil(t, producer.dialer.TLS)
+	assert.Nil(t, producer.dialer.TLS.RootCAs)
+	assert.False(t, producer.dialer.TLS.InsecureSkipVerify)
+}
+
+func TestNewProducerWithError_InvalidCACert(t *testing.T) {
+	producer, err := NewProducerWithError(ProducerConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     "test-topic",
+		Logger:    logger.Log,
+		UseTLS:    true,
+		CACertPEM: []byte("not a certificate"),
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, producer)
+	assert.Contains(t, err.Error(), "failed to parse CA certificate")
+}
@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishBatch_MixedTopics(t *testing.T) {
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+	}
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "user-1", Value: "email body", Topic: "notifications.email"},
+		{Key: "user-2", Value: "push body", Topic: "notifications.push"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, written, 2)
+	assert.Equal(t, "notifications.email", written[0].Topic)
+	assert.Equal(t, "notifications.push", written[1].Topic)
+}
+
+func TestPublishBatch_TopicOmittedUsesWriterTopic(t *testing.T) {
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "notifications.email",
+	}
+
+	err := producer.PublishBatch(context.Background(), []Message{{Key: "user-1", Value: "body"}})
+
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	assert.Empty(t, written[0].Topic)
+}
+
+func TestPublishBatch_TopicConflictsWithProducerTopic(t *testing.T) {
+	writes := 0
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				writes++
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "notifications.email",
+	}
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "user-1", Value: "body"},
+		{Key: "user-2", Value: "body", Topic: "notifications.push"},
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index 1")
+	assert.Contains(t, err.Error(), "requires a producer without a default topic")
+	assert.Equal(t, 0, writes)
+}
+
+func TestPublishBatch_MixedTopicsDLQKeepsSourceTopic(t *testing.T) {
+	var dlqMessages []kafka.Message
+	dlq := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				dlqMessages = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "notifications.dlq",
+	}
+	producer := &Producer{
+		writer: failingWriter(errors.New("broker down")),
+		logger: logger.Log,
+		dlq:    dlq,
+	}
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "user-1", Value: "body", Topic: "notifications.email"},
+		{Key: "user-2", Value: "body", Topic: "notifications.push"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, dlqMessages, 2)
+	source, _ := headerValue(dlqMessages[0].Headers, HeaderDLQSourceTopic)
+	assert.Equal(t, "notifications.email", source)
+	source, _ = headerValue(dlqMessages[1].Headers, HeaderDLQSourceTopic)
+	assert.Equal(t, "notifications.push", source)
+	assert.Empty(t, dlqMessages[0].Topic)
+}
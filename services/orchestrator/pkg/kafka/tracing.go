@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	tracerName      = "github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/kafka"
+	publishSpanName = "kafka.publish"
+)
+
+// traceContext propagates spans into message headers using the W3C
+// traceparent/tracestate format
+var traceContext = propagation.TraceContext{}
+
+// headerCarrier adapts kafka message headers to a propagation.TextMapCarrier
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+// tracerFor returns the tracer from the provider, or a no-op tracer
+func tracerFor(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// startPublishSpan starts a producer span for msgs and injects its context
+// into each message's headers
+func (p *Producer) startPublishSpan(ctx context.Context, msgs []kafka.Message) (context.Context, trace.Span) {
+	tracer := p.tracer
+	if tracer == nil {
+		tracer = tracerFor(nil)
+	}
+
+	var payloadSize int
+	for _, msg := range msgs {
+		payloadSize += len(msg.Value)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", p.topic),
+		attribute.Int("messaging.batch.message_count", len(msgs)),
+		attribute.Int("messaging.message.body.size", payloadSize),
+	}
+	if len(msgs) == 1 {
+		attrs = append(attrs, attribute.String("messaging.kafka.message.key", string(msgs[0].Key)))
+	}
+
+	ctx, span := tracer.Start(ctx, publishSpanName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...),
+	)
+
+	for i := range msgs {
+		traceContext.Inject(ctx, headerCarrier{headers: &msgs[i].Headers})
+	}
+
+	return ctx, span
+}
+
+// endPublishSpan records the outcome of a publish on its span
+func endPublishSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
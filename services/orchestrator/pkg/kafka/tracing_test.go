@@ -0,0 +1,149 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracedProducer(writer kafkaWriter) (*Producer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return &Producer{
+		writer: writer,
+		logger: logger.Log,
+		topic:  "test-topic",
+		tracer: tracerFor(provider),
+	}, recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestPublish_RecordsSpanAndInjectsTraceparent(t *testing.T) {
+	var written []kafka.Message
+	producer, recorder := newTracedProducer(&mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			written = msgs
+			return nil
+		},
+	})
+
+	err := producer.Publish(context.Background(), "user-1", map[string]string{"hello": "world"})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, publishSpanName, span.Name())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+
+	topic, ok := spanAttr(span, "messaging.destination.name")
+	require.True(t, ok)
+	assert.Equal(t, "test-topic", topic.AsString())
+
+	key, ok := spanAttr(span, "messaging.kafka.message.key")
+	require.True(t, ok)
+	assert.Equal(t, "user-1", key.AsString())
+
+	size, ok := spanAttr(span, "messaging.message.body.size")
+	require.True(t, ok)
+	assert.Equal(t, int64(len(written[0].Value)), size.AsInt64())
+
+	require.Len(t, written, 1)
+	traceparent, ok := headerValue(written[0].Headers, "traceparent")
+	require.True(t, ok)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
+
+func TestPublish_SpanRecordsError(t *testing.T) {
+	producer, recorder := newTracedProducer(failingWriter(errors.New("broker down")))
+
+	err := producer.Publish(context.Background(), "user-1", "payload")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	require.NotEmpty(t, spans[0].Events())
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+func TestPublishBatch_RecordsSingleSpan(t *testing.T) {
+	var written []kafka.Message
+	producer, recorder := newTracedProducer(&mockWriter{
+		writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+			written = msgs
+			return nil
+		},
+	})
+
+	err := producer.PublishBatch(context.Background(), []Message{
+		{Key: "a", Value: "one"},
+		{Key: "b", Value: "two", Headers: map[string]string{"source": "test"}},
+	})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	count, ok := spanAttr(spans[0], "messaging.batch.message_count")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), count.AsInt64())
+
+	require.Len(t, written, 2)
+	for _, msg := range written {
+		_, ok := headerValue(msg.Headers, "traceparent")
+		assert.True(t, ok)
+	}
+	source, ok := headerValue(written[1].Headers, "source")
+	require.True(t, ok)
+	assert.Equal(t, "test", source)
+}
+
+func TestPublish_NilTracerIsNoop(t *testing.T) {
+	var written []kafka.Message
+	producer := &Producer{
+		writer: &mockWriter{
+			writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				written = msgs
+				return nil
+			},
+		},
+		logger: logger.Log,
+		topic:  "test-topic",
+	}
+
+	require.NoError(t, producer.Publish(context.Background(), "key", "value"))
+	require.Len(t, written, 1)
+	_, ok := headerValue(written[0].Headers, "traceparent")
+	assert.False(t, ok)
+}
+
+func TestHeaderCarrier_SetReplacesExisting(t *testing.T) {
+	headers := []kafka.Header{{Key: "traceparent", Value: []byte("old")}}
+	carrier := headerCarrier{headers: &headers}
+
+	carrier.Set("traceparent", "new")
+	carrier.Set("tracestate", "vendor=1")
+
+	assert.Equal(t, "new", carrier.Get("traceparent"))
+	assert.Equal(t, "vendor=1", carrier.Get("tracestate"))
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate"}, carrier.Keys())
+}
@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// HeaderTransactionID names the transaction a message was committed in,
+// "<TransactionalID>-<sequence>", so consumers can drop a transaction they
+// already processed when a failed Commit is retried
+const HeaderTransactionID = "X-Transaction-ID"
+
+var (
+	// ErrNoTransaction is returned by Publish, Commit and Abort when BeginTx
+	// has not been called
+	ErrNoTransaction = errors.New("kafka: no transaction in progress")
+	// ErrTransactionInProgress is returned by BeginTx while the previous
+	// transaction is neither committed nor aborted
+	ErrTransactionInProgress = errors.New("kafka: transaction already in progress")
+)
+
+// TransactionalProducer is a buffered batch producer for notifications such
+// as payment receipts that belong together. Publish only buffers: nothing
+// reaches the broker before Commit, which hands the whole batch to the
+// writer in one call, and Abort drops it.
+//
+// It is not atomic. kafka-go's writer cannot write Kafka transactional
+// batches, so TransactionalID tags messages rather than fencing other
+// producers. The writer splits a commit by partition and by BatchSize and
+// BatchBytes, and retries failed writes, so a failed Commit can leave the
+// batch partly written and a retried one can write messages twice.
+// Consumers that must treat the batch as a unit should group and
+// deduplicate on HeaderTransactionID.
+//
+// The trade-off is latency and memory: messages wait in memory until
+// Commit and each Commit is a synchronous round trip, so keep high-volume
+// topics on a plain Producer.
+type TransactionalProducer struct {
+	producer        *Producer
+	transactionalID string
+
+	mu       sync.Mutex
+	open     bool
+	sequence int64
+	pending  []kafka.Message
+}
+
+// NewTransactionalProducer creates a producer for cfg, which must set
+// TransactionalID and must not be Async, since Commit has to know the
+// transaction was written
+func NewTransactionalProducer(cfg ProducerConfig) (*TransactionalProducer, error) {
+	if cfg.TransactionalID == "" {
+		return nil, fmt.Errorf("transactional id is required")
+	}
+	if cfg.Async {
+		return nil, fmt.Errorf("transactional producers cannot be async")
+	}
+	producer, err := NewProducerWithError(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionalProducer{producer: producer, transactionalID: cfg.TransactionalID}, nil
+}
+
+// BeginTx starts a transaction
+func (t *TransactionalProducer) BeginTx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.open {
+		return ErrTransactionInProgress
+	}
+	t.open = true
+	t.sequence++
+	t.pending = nil
+	return nil
+}
+
+// Publish adds a message to the current transaction. Keys, serialization
+// and size limits are checked here, so a message that could never be
+// written fails before Commit.
+func (t *TransactionalProducer) Publish(ctx context.Context, key string, value interface{}) error {
+	return t.PublishWithHeaders(ctx, key, value, nil)
+}
+
+// PublishWithHeaders adds a message with headers to the current transaction
+func (t *TransactionalProducer) PublishWithHeaders(ctx context.Context, key string, value interface{}, headers map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	msg, err := t.producer.buildMessage(key, value, headers)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.open {
+		return ErrNoTransaction
+	}
+	msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderTransactionID, Value: []byte(t.transactionIDLocked())})
+	t.pending = append(t.pending, msg)
+	return nil
+}
+
+// Commit writes every message of the transaction and ends the
+// transaction, whether or not the write succeeds; the next one can begin
+// while the write is in flight. It is not sent to the dead-letter queue on
+// failure; the caller decides whether to retry the whole transaction.
+func (t *TransactionalProducer) Commit(ctx context.Context) (err error) {
+	t.mu.Lock()
+	if !t.open {
+		t.mu.Unlock()
+		return ErrNoTransaction
+	}
+	msgs := t.pending
+	transactionID := t.transactionIDLocked()
+	t.open = false
+	t.pending = nil
+	t.mu.Unlock()
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	p := t.producer
+	stampCorrelationID(ctx, msgs)
+	stampPriority(ctx, msgs)
+	ctx, span := p.startPublishSpan(ctx, msgs)
+	defer func() { endPublishSpan(span, err) }()
+
+	if err := p.write(ctx, msgs...); err != nil {
+		if p.logger != nil {
+			p.logger.Error("Failed to commit transaction",
+				zap.String("topic", p.topic),
+				zap.String("transaction_id", transactionID),
+				zap.Int("count", len(msgs)),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to commit transaction %s: %w", transactionID, classifyWriteError(err))
+	}
+
+	if p.logger != nil {
+		p.logger.Debug("Transaction committed",
+			zap.String("topic", p.topic),
+			zap.String("transaction_id", transactionID),
+			zap.Int("count", len(msgs)),
+		)
+	}
+	return nil
+}
+
+// Abort discards the transaction's messages without writing them
+func (t *TransactionalProducer) Abort() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.open {
+		return ErrNoTransaction
+	}
+	t.open = false
+	t.pending = nil
+	return nil
+}
+
+// Close closes the underlying producer. An open transaction is discarded.
+func (t *TransactionalProducer) Close() error {
+	t.mu.Lock()
+	t.open = false
+	t.pending = nil
+	t.mu.Unlock()
+	return t.producer.Close()
+}
+
+// transactionIDLocked returns the current transaction's ID. t.mu must be
+// held.
+func (t *TransactionalProducer) transactionIDLocked() string {
+	return fmt.Sprintf("%s-%d", t.transactionalID, t.sequence)
+}
@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTransactionalProducer returns a transactional producer whose
+// writer records each write and fails with writeErr
+func newTestTransactionalProducer(writes *[][]kafka.Message, writeErr error) *TransactionalProducer {
+	return &TransactionalProducer{
+		producer: &Producer{
+			writer: &mockWriter{
+				writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+					*writes = append(*writes, msgs)
+					return writeErr
+				},
+			},
+			logger: logger.Log,
+			topic:  "receipts",
+		},
+		transactionalID: "receipts-tx",
+	}
+}
+
+// transactionID returns the HeaderTransactionID of msg
+func transactionID(msg kafka.Message) string {
+	id, _ := headerValue(msg.Headers, HeaderTransactionID)
+	return id
+}
+
+func TestTransactionalProducer_CommitFlushesBufferedMessages(t *testing.T) {
+	ctx := context.Background()
+	var writes [][]kafka.Message
+	tx := newTestTransactionalProducer(&writes, nil)
+
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Publish(ctx, "user-1", map[string]string{"receipt": "r-1"}))
+	require.NoError(t, tx.Publish(ctx, "user-1", map[string]string{"receipt": "r-2"}))
+	assert.Empty(t, writes, "nothing is written before Commit")
+
+	require.NoError(t, tx.Commit(ctx))
+
+	require.Len(t, writes, 1, "the transaction is handed to the writer in one call")
+	require.Len(t, writes[0], 2)
+	for _, msg := range writes[0] {
+		assert.Equal(t, "receipts-tx-1", transactionID(msg))
+	}
+	assert.ErrorIs(t, tx.Commit(ctx), ErrNoTransaction)
+
+	// The next transaction gets its own ID
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Publish(ctx, "user-2", "r-3"))
+	require.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, "receipts-tx-2", transactionID(writes[1][0]))
+}
+
+func TestTransactionalProducer_AbortDiscardsMessages(t *testing.T) {
+	ctx := context.Background()
+	var writes [][]kafka.Message
+	tx := newTestTransactionalProducer(&writes, nil)
+
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Publish(ctx, "user-1", "r-1"))
+	require.NoError(t, tx.Abort())
+
+	assert.Empty(t, writes)
+	assert.ErrorIs(t, tx.Abort(), ErrNoTransaction)
+	assert.ErrorIs(t, tx.Publish(ctx, "user-1", "r-2"), ErrNoTransaction)
+
+	// An empty commit after a new begin writes nothing either
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Commit(ctx))
+	assert.Empty(t, writes)
+}
+
+func TestTransactionalProducer_BeginWhileOpen(t *testing.T) {
+	ctx := context.Background()
+	var writes [][]kafka.Message
+	tx := newTestTransactionalProducer(&writes, nil)
+
+	require.NoError(t, tx.BeginTx(ctx))
+	assert.ErrorIs(t, tx.BeginTx(ctx), ErrTransactionInProgress)
+}
+
+func TestTransactionalProducer_CommitFailure(t *testing.T) {
+	ctx := context.Background()
+	var writes [][]kafka.Message
+	tx := newTestTransactionalProducer(&writes, kafka.LeaderNotAvailable)
+
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Publish(ctx, "user-1", "r-1"))
+	err := tx.Commit(ctx)
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBrokerUnavailable))
+	assert.Contains(t, err.Error(), "receipts-tx-1")
+	// The transaction is over either way
+	assert.NoError(t, tx.BeginTx(ctx))
+}
+
+func TestTransactionalProducer_CommitDoesNotBlockNextTransaction(t *testing.T) {
+	ctx := context.Background()
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	tx := &TransactionalProducer{
+		producer: &Producer{
+			writer: &mockWriter{
+				writeMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+					close(writing)
+					<-release
+					return nil
+				},
+			},
+			logger: logger.Log,
+			topic:  "receipts",
+		},
+		transactionalID: "receipts-tx",
+	}
+
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Publish(ctx, "user-1", "r-1"))
+	committed := make(chan error, 1)
+	go func() { committed <- tx.Commit(ctx) }()
+	<-writing
+
+	// The lock is not held across the write
+	require.NoError(t, tx.BeginTx(ctx))
+	require.NoError(t, tx.Publish(ctx, "user-2", "r-2"))
+	require.NoError(t, tx.Abort())
+
+	close(release)
+	require.NoError(t, <-committed)
+}
+
+func TestTransactionalProducer_PublishMarshalError(t *testing.T) {
+	ctx := context.Background()
+	var writes [][]kafka.Message
+	tx := newTestTransactionalProducer(&writes, nil)
+
+	require.NoError(t, tx.BeginTx(ctx))
+	assert.True(t, errors.Is(tx.Publish(ctx, "user-1", make(chan int)), ErrMarshal))
+	require.NoError(t, tx.Commit(ctx))
+	assert.Empty(t, writes)
+}
+
+func TestNewTransactionalProducer_Validation(t *testing.T) {
+	_, err := NewTransactionalProducer(ProducerConfig{Brokers: []string{"localhost:9092"}})
+	assert.ErrorContains(t, err, "transactional id is required")
+
+	_, err = NewTransactionalProducer(ProducerConfig{Brokers: []string{"localhost:9092"}, TransactionalID: "tx", Async: true})
+	assert.ErrorContains(t, err, "cannot be async")
+
+	tx, err := NewTransactionalProducer(ProducerConfig{Brokers: []string{"localhost:9092"}, TransactionalID: "tx"})
+	require.NoError(t, err)
+	assert.NoError(t, tx.Close())
+}
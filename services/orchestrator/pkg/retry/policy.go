@@ -0,0 +1,125 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BerylCAtieno/group24-notification-system/services/orchestrator/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Policy describes how Do retries an operation
+type Policy struct {
+	MaxAttempts       int           // Total attempts including the first (default: 1)
+	InitialDelay      time.Duration // Delay before the second attempt
+	MaxDelay          time.Duration // Cap on the delay between attempts
+	BackoffMultiplier float64       // Growth of the delay per attempt (default: 2.0)
+
+	// Retryable reports whether an error is worth another attempt. Defaults
+	// to IsRetryable. Errors wrapped with Permanent are never retried.
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy returns a policy of three attempts with exponential backoff
+// that retries network errors and 5xx-style failures
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:       3,
+		InitialDelay:      100 * time.Millisecond,
+		MaxDelay:          5 * time.Second,
+		BackoffMultiplier: 2.0,
+	}
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it without retrying, whatever the
+// policy's Retryable says
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetryable reports whether err looks transient: network errors,
+// timeouts and unavailable or failing upstreams
+func IsRetryable(err error) bool {
+	return isRetryableError(err)
+}
+
+// Do calls fn until it succeeds, returns an error the policy does not
+// retry, or runs out of attempts, waiting with exponential backoff in
+// between. The last error is returned, wrapped with the attempt count when
+// more than one attempt ran out.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	backoff := Config{
+		InitialDelay:      policy.InitialDelay,
+		MaxDelay:          policy.MaxDelay,
+		BackoffMultiplier: policy.BackoffMultiplier,
+	}
+	if backoff.BackoffMultiplier <= 0 {
+		backoff.BackoffMultiplier = 2.0
+	}
+	if backoff.MaxDelay <= 0 {
+		backoff.MaxDelay = backoff.InitialDelay
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("context cancelled: %w", ctxErr)
+		}
+
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if !retryable(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+
+		delay := calculateDelay(backoff, attempt)
+		logger.Log.Debug("Retrying operation",
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("context cancelled: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	if attempts == 1 {
+		return err
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", attempts, err)
+}
@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPolicy(attempts int) Policy {
+	return Policy{MaxAttempts: attempts, InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+}
+
+func TestDo_FailsThenSucceeds(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), testPolicy(5), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("service unavailable")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_AlwaysFails(t *testing.T) {
+	cause := errors.New("connection refused")
+	calls := 0
+	err := Do(context.Background(), testPolicy(3), func(ctx context.Context) error {
+		calls++
+		return cause
+	})
+
+	assert.Equal(t, 3, calls)
+	assert.True(t, errors.Is(err, cause))
+	assert.Contains(t, err.Error(), "gave up after 3 attempts")
+}
+
+func TestDo_StopsOnNonRetryableError(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), testPolicy(3), func(ctx context.Context) error {
+		calls++
+		return errors.New("invalid device token")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_PermanentOverridesRetryable(t *testing.T) {
+	policy := testPolicy(3)
+	policy.Retryable = func(err error) bool { return true }
+	cause := errors.New("recipient blocked")
+
+	calls := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return Permanent(cause)
+	})
+
+	assert.Equal(t, cause, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_CustomRetryable(t *testing.T) {
+	errBounce := errors.New("soft bounce")
+	policy := testPolicy(2)
+	policy.Retryable = func(err error) bool { return errors.Is(err, errBounce) }
+
+	calls := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return errBounce
+	})
+
+	assert.True(t, errors.Is(err, errBounce))
+	assert.Equal(t, 2, calls)
+}
+
+func TestDo_ContextCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{MaxAttempts: 3, InitialDelay: time.Hour}
+
+	calls := 0
+	err := Do(ctx, policy, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("timeout")
+	})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_ZeroPolicyTriesOnce(t *testing.T) {
+	cause := errors.New("timeout")
+	calls := 0
+	err := Do(context.Background(), Policy{}, func(ctx context.Context) error {
+		calls++
+		return cause
+	})
+
+	assert.Equal(t, cause, err)
+	assert.Equal(t, 1, calls)
+}
@@ -40,7 +40,7 @@ func TestKafkaConfig() kafka.ProducerConfig {
 
 // SetupTestKafkaProducer creates a test Kafka producer
 // Note: For integration tests, you may want to use a mock producer instead
-func SetupTestKafkaProducer(t *testing.T, topic string) (*kafka.Producer, func()) {
+func SetupTestKafkaProducer(t *testing.T, topic string) (kafka.Publisher, func()) {
 	cfg := TestKafkaConfig()
 	cfg.Topic = topic
 